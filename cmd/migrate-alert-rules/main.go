@@ -19,7 +19,7 @@ func main() {
 	}
 
 	// 连接数据库
-	db, err := database.New(&cfg.Database)
+	db, err := database.New(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -50,8 +50,8 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Initialize router
-	router := api.NewRouter(cfg, logger, db)
+	// Initialize router and background services
+	router, stopServices := api.NewRouter(cfg, logger, db)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -84,5 +84,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Stop collecting and alerting before the database connection is closed
+	stopServices()
+
 	logger.Info("Server exited")
 }
\ No newline at end of file
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"time"
+
 	"monitor-server/internal/config"
 	"monitor-server/internal/database"
 	"monitor-server/internal/handler"
@@ -11,8 +13,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// NewRouter creates and configures the main router
-func NewRouter(cfg *config.Config, logger *logger.Logger, db *database.DB) *gin.Engine {
+// NewRouter creates and configures the main router. The returned function stops the
+// background services started for it.
+func NewRouter(cfg *config.Config, logger *logger.Logger, db *database.DB) (*gin.Engine, func()) {
 	router := gin.New()
 
 	// Add recovery middleware
@@ -25,23 +28,89 @@ func NewRouter(cfg *config.Config, logger *logger.Logger, db *database.DB) *gin.
 	router.Use(middleware.CORS(cfg.CORS))
 
 	// Initialize services
-	monitorService := service.NewMonitorService()
+	monitorService := service.NewMonitorService(service.NewCollectFilterProvider(db.DB, cfg.Monitor, logger))
+
+	metricsRecorder := service.NewMetricsRecorder(monitorService, db.DB, cfg.Monitor, logger)
+
+	probeScheduler := service.NewProbeScheduler(db.DB, cfg.Probe, logger)
+	processWatcher := service.NewProcessWatcher(db.DB, cfg.Monitor, logger)
+	systemdMonitor := service.NewSystemdMonitor(db.DB, cfg.Monitor, service.NewExecRunner(), logger)
+	logWatcher := service.NewLogWatcher(db.DB, cfg.Monitor, logger)
+	nagiosExecutor := service.NewNagiosExecutor(db.DB, cfg.Nagios, logger)
+
+	// Run the collectors of the local machine, each on its own interval. The sample collectors,
+	// the system metrics, process watches, systemd units and log watches default to the record
+	// interval, the probes and Nagios checks to their tick interval.
+	collectorRegistry := service.NewCollectorRegistry()
+	collectors := monitorService.Collectors(time.Duration(cfg.Monitor.RecordInterval) * time.Second)
+	collectors = append(collectors, metricsRecorder.Collector(), probeScheduler.Collector(), processWatcher.Collector(),
+		systemdMonitor.Collector(), logWatcher.Collector())
+	if cfg.Nagios.Enabled {
+		collectors = append(collectors, nagiosExecutor.Collector())
+	}
+	for _, collector := range collectors {
+		if err := collectorRegistry.Register(collector); err != nil {
+			logger.Error("Failed to register collector", "collector", collector.Name(), "error", err)
+		}
+	}
+	collectorScheduler := service.NewCollectorScheduler(db.DB, collectorRegistry, cfg.Collector, cfg.Monitor.Hostname, logger)
+	collectorScheduler.Start()
+
+	// Start marking the local host as seen, evaluating alert rules and dispatching notifications
+	metricsRecorder.Start()
+	notificationDispatcher := service.NewNotificationDispatcher(db.DB, cfg.Notification, logger)
+	notificationDispatcher.Start()
+	alertEvaluator := service.NewAlertEvaluator(db.DB, cfg.Alert, notificationDispatcher, logger)
+	alertEvaluator.Start()
+	escalationManager := service.NewEscalationManager(db.DB, service.NewRealClock(), logger)
+	escalationManager.Start()
+	reachabilityChecker := service.NewReachabilityChecker(db.DB, cfg.Reachability, logger)
+	if cfg.Reachability.Enabled {
+		reachabilityChecker.Start()
+	}
 
 	// Initialize handlers
 	monitorHandler := handler.NewMonitorHandler(monitorService, logger)
 	hostHandler := handler.NewHostHandler(db.DB)
 	hostConfigHandler := handler.NewHostConfigHandler(db.DB)
 	hostGroupHandler := handler.NewHostGroupHandler(db.DB)
-	alertRuleHandler := handler.NewAlertRuleHandler(db.DB)
+	alertRuleHandler := handler.NewAlertRuleHandler(db.DB, time.Duration(cfg.Alert.StaleAfter)*time.Second)
+	forecastHandler := handler.NewForecastHandler(db.DB)
+	notificationRouteHandler := handler.NewNotificationRouteHandler(db.DB, notificationDispatcher)
+	alertHandler := handler.NewAlertHandler(db.DB)
+	escalationHandler := handler.NewEscalationHandler(db.DB)
+	inhibitionRuleHandler := handler.NewInhibitionRuleHandler(db.DB)
+	probeHandler := handler.NewProbeHandler(db.DB, probeScheduler, cfg.Monitor.Hostname)
+	processWatchHandler := handler.NewProcessWatchHandler(db.DB, processWatcher)
+	systemdHandler := handler.NewSystemdHandler(db.DB, systemdMonitor)
+	logEventHandler := handler.NewLogEventHandler(db.DB)
+	nagiosCheckHandler := handler.NewNagiosCheckHandler(db.DB, nagiosExecutor)
+	sensorHandler := handler.NewSensorHandler(db.DB)
+	filesystemHandler := handler.NewFilesystemHandler(db.DB)
+	collectorHandler := handler.NewCollectorHandler(collectorScheduler)
 
 	// Setup routes
-	setupRoutes(router, monitorHandler, hostHandler, hostConfigHandler, hostGroupHandler, alertRuleHandler)
+	setupRoutes(router, monitorHandler, hostHandler, hostConfigHandler, hostGroupHandler, alertRuleHandler, forecastHandler, notificationRouteHandler, alertHandler, escalationHandler, inhibitionRuleHandler, probeHandler, processWatchHandler, systemdHandler, logEventHandler, nagiosCheckHandler, sensorHandler, filesystemHandler, collectorHandler)
 
-	return router
+	// Stop the producers of samples and alerts before the dispatcher they notify. The running
+	// Nagios plugins are killed first so that their rounds finish, the tailed log files are
+	// closed once the collector scheduler has stopped.
+	stop := func() {
+		nagiosExecutor.Stop()
+		collectorScheduler.Stop()
+		logWatcher.Stop()
+		metricsRecorder.Stop()
+		reachabilityChecker.Stop()
+		alertEvaluator.Stop()
+		escalationManager.Stop()
+		notificationDispatcher.Stop()
+	}
+
+	return router, stop
 }
 
 // setupRoutes configures all API routes
-func setupRoutes(router *gin.Engine, monitorHandler *handler.MonitorHandler, hostHandler *handler.HostHandler, hostConfigHandler *handler.HostConfigHandler, hostGroupHandler *handler.HostGroupHandler, alertRuleHandler *handler.AlertRuleHandler) {
+func setupRoutes(router *gin.Engine, monitorHandler *handler.MonitorHandler, hostHandler *handler.HostHandler, hostConfigHandler *handler.HostConfigHandler, hostGroupHandler *handler.HostGroupHandler, alertRuleHandler *handler.AlertRuleHandler, forecastHandler *handler.ForecastHandler, notificationRouteHandler *handler.NotificationRouteHandler, alertHandler *handler.AlertHandler, escalationHandler *handler.EscalationHandler, inhibitionRuleHandler *handler.InhibitionRuleHandler, probeHandler *handler.ProbeHandler, processWatchHandler *handler.ProcessWatchHandler, systemdHandler *handler.SystemdHandler, logEventHandler *handler.LogEventHandler, nagiosCheckHandler *handler.NagiosCheckHandler, sensorHandler *handler.SensorHandler, filesystemHandler *handler.FilesystemHandler, collectorHandler *handler.CollectorHandler) {
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -60,6 +129,13 @@ func setupRoutes(router *gin.Engine, monitorHandler *handler.MonitorHandler, hos
 		v1.GET("/network", monitorHandler.GetNetwork)
 		v1.GET("/system", monitorHandler.GetSystem)
 		v1.GET("/processes", monitorHandler.GetProcesses)
+		v1.GET("/processes/tree", monitorHandler.GetProcessTree)
+		v1.GET("/connections", monitorHandler.GetConnections)
+		v1.GET("/sensors", monitorHandler.GetSensors)
+		v1.GET("/collect-filter/preview", monitorHandler.GetCollectFilterPreview)
+		v1.POST("/collect-filter/preview", monitorHandler.PreviewCollectFilter)
+		v1.GET("/collectors", collectorHandler.GetCollectors)
+		v1.GET("/processes/:pid", monitorHandler.GetProcess)
 
 		// Host management endpoints
 		hosts := v1.Group("/hosts")
@@ -79,6 +155,23 @@ func setupRoutes(router *gin.Engine, monitorHandler *handler.MonitorHandler, hos
 			
 			// Host group relationships
 			hosts.GET("/:id/groups", hostGroupHandler.GetHostGroupsForHost)
+
+			// Host alert rule resolution
+			hosts.GET("/:id/alert-rules", alertRuleHandler.GetEffectiveAlertRules)
+
+			// Host resource forecast
+			hosts.GET("/:id/forecast", forecastHandler.GetHostForecast)
+			// Host metric baseline for anomaly rules
+			hosts.GET("/:id/baseline", forecastHandler.GetHostBaseline)
+
+			// Host log watch events
+			hosts.GET("/:id/log-events", logEventHandler.GetLogEvents)
+
+			// Host hardware sensor history
+			hosts.GET("/:id/sensors/history", sensorHandler.GetSensorHistory)
+
+			// Host inode, read-only mount and file handle history
+			hosts.GET("/:id/filesystem/history", filesystemHandler.GetFilesystemHistory)
 		}
 
 		// Host configuration endpoints
@@ -111,9 +204,133 @@ func setupRoutes(router *gin.Engine, monitorHandler *handler.MonitorHandler, hos
 		alertRules := v1.Group("/alert-rules")
 		{
 			alertRules.GET("", alertRuleHandler.GetAlertRules)
+			alertRules.POST("", alertRuleHandler.CreateAlertRule)
+			alertRules.POST("/preview", alertRuleHandler.PreviewAlertRule)
 			alertRules.PUT("/:metric_type/:severity/threshold", alertRuleHandler.UpdateAlertRuleThreshold)
 			alertRules.POST("/host", alertRuleHandler.CreateHostAlertRule)
 		}
+
+		// Notification routing endpoints
+		notificationRoutes := v1.Group("/notification-routes")
+		{
+			notificationRoutes.GET("", notificationRouteHandler.GetNotificationRoutes)
+			notificationRoutes.POST("", notificationRouteHandler.CreateNotificationRoute)
+			notificationRoutes.GET("/:id", notificationRouteHandler.GetNotificationRoute)
+			notificationRoutes.PUT("/:id", notificationRouteHandler.UpdateNotificationRoute)
+			notificationRoutes.DELETE("/:id", notificationRouteHandler.DeleteNotificationRoute)
+		}
+		v1.GET("/notification-groups", notificationRouteHandler.GetNotificationGroups)
+
+		// Alert inhibition endpoints
+		inhibitionRules := v1.Group("/inhibition-rules")
+		{
+			inhibitionRules.GET("", inhibitionRuleHandler.GetInhibitionRules)
+			inhibitionRules.POST("", inhibitionRuleHandler.CreateInhibitionRule)
+			inhibitionRules.GET("/:id", inhibitionRuleHandler.GetInhibitionRule)
+			inhibitionRules.PUT("/:id", inhibitionRuleHandler.UpdateInhibitionRule)
+			inhibitionRules.DELETE("/:id", inhibitionRuleHandler.DeleteInhibitionRule)
+		}
+
+		// Alert endpoints
+		alerts := v1.Group("/alerts")
+		{
+			alerts.GET("", alertHandler.GetAlerts)
+			alerts.GET("/:id", alertHandler.GetAlert)
+			alerts.POST("/:id/ack", alertHandler.AcknowledgeAlert)
+		}
+
+		// Escalation policy and on-call schedule endpoints
+		escalationPolicies := v1.Group("/escalation-policies")
+		{
+			escalationPolicies.GET("", escalationHandler.GetEscalationPolicies)
+			escalationPolicies.POST("", escalationHandler.CreateEscalationPolicy)
+			escalationPolicies.GET("/:id", escalationHandler.GetEscalationPolicy)
+			escalationPolicies.PUT("/:id", escalationHandler.UpdateEscalationPolicy)
+			escalationPolicies.DELETE("/:id", escalationHandler.DeleteEscalationPolicy)
+		}
+		oncallSchedules := v1.Group("/oncall-schedules")
+		{
+			oncallSchedules.GET("", escalationHandler.GetOnCallSchedules)
+			oncallSchedules.POST("", escalationHandler.CreateOnCallSchedule)
+			oncallSchedules.GET("/:id", escalationHandler.GetOnCallSchedule)
+			oncallSchedules.PUT("/:id", escalationHandler.UpdateOnCallSchedule)
+			oncallSchedules.DELETE("/:id", escalationHandler.DeleteOnCallSchedule)
+			oncallSchedules.GET("/:id/current", escalationHandler.GetCurrentOnCall)
+			oncallSchedules.POST("/:id/overrides", escalationHandler.CreateOnCallOverride)
+			oncallSchedules.DELETE("/:id/overrides/:override_id", escalationHandler.DeleteOnCallOverride)
+		}
+
+		// Synthetic probe endpoints
+		httpChecks := v1.Group("/http-checks")
+		{
+			httpChecks.GET("", probeHandler.GetHTTPChecks)
+			httpChecks.POST("", probeHandler.CreateHTTPCheck)
+			httpChecks.GET("/:id", probeHandler.GetHTTPCheck)
+			httpChecks.PUT("/:id", probeHandler.UpdateHTTPCheck)
+			httpChecks.DELETE("/:id", probeHandler.DeleteHTTPCheck)
+			httpChecks.POST("/:id/run", probeHandler.RunHTTPCheck)
+		}
+		tcpChecks := v1.Group("/tcp-checks")
+		{
+			tcpChecks.GET("", probeHandler.GetTCPChecks)
+			tcpChecks.POST("", probeHandler.CreateTCPCheck)
+			tcpChecks.GET("/:id", probeHandler.GetTCPCheck)
+			tcpChecks.PUT("/:id", probeHandler.UpdateTCPCheck)
+			tcpChecks.DELETE("/:id", probeHandler.DeleteTCPCheck)
+			tcpChecks.POST("/:id/run", probeHandler.RunTCPCheck)
+		}
+		dnsChecks := v1.Group("/dns-checks")
+		{
+			dnsChecks.GET("", probeHandler.GetDNSChecks)
+			dnsChecks.POST("", probeHandler.CreateDNSCheck)
+			dnsChecks.GET("/:id", probeHandler.GetDNSCheck)
+			dnsChecks.PUT("/:id", probeHandler.UpdateDNSCheck)
+			dnsChecks.DELETE("/:id", probeHandler.DeleteDNSCheck)
+			dnsChecks.POST("/:id/run", probeHandler.RunDNSCheck)
+		}
+		certificateChecks := v1.Group("/certificate-checks")
+		{
+			certificateChecks.GET("", probeHandler.GetCertificateChecks)
+			certificateChecks.POST("", probeHandler.CreateCertificateCheck)
+			certificateChecks.GET("/:id", probeHandler.GetCertificateCheck)
+			certificateChecks.PUT("/:id", probeHandler.UpdateCertificateCheck)
+			certificateChecks.DELETE("/:id", probeHandler.DeleteCertificateCheck)
+			certificateChecks.POST("/:id/run", probeHandler.RunCertificateCheck)
+		}
+		v1.GET("/certificates", probeHandler.GetCertificates)
+
+		// Process watch endpoints
+		processWatches := v1.Group("/process-watches")
+		{
+			processWatches.GET("", processWatchHandler.GetProcessWatches)
+			processWatches.POST("", processWatchHandler.CreateProcessWatch)
+			processWatches.GET("/:id", processWatchHandler.GetProcessWatch)
+			processWatches.PUT("/:id", processWatchHandler.UpdateProcessWatch)
+			processWatches.DELETE("/:id", processWatchHandler.DeleteProcessWatch)
+			processWatches.GET("/:id/status", processWatchHandler.GetProcessWatchStatus)
+		}
+
+		// Systemd unit endpoints
+		systemdUnits := v1.Group("/systemd-units")
+		{
+			systemdUnits.GET("", systemdHandler.GetSystemdUnitWatches)
+			systemdUnits.POST("", systemdHandler.CreateSystemdUnitWatch)
+			systemdUnits.GET("/status", systemdHandler.GetSystemdStatus)
+			systemdUnits.GET("/:id", systemdHandler.GetSystemdUnitWatch)
+			systemdUnits.PUT("/:id", systemdHandler.UpdateSystemdUnitWatch)
+			systemdUnits.DELETE("/:id", systemdHandler.DeleteSystemdUnitWatch)
+		}
+
+		// Nagios plugin check endpoints
+		nagiosChecks := v1.Group("/nagios-checks")
+		{
+			nagiosChecks.GET("", nagiosCheckHandler.GetNagiosChecks)
+			nagiosChecks.POST("", nagiosCheckHandler.CreateNagiosCheck)
+			nagiosChecks.GET("/:id", nagiosCheckHandler.GetNagiosCheck)
+			nagiosChecks.PUT("/:id", nagiosCheckHandler.UpdateNagiosCheck)
+			nagiosChecks.DELETE("/:id", nagiosCheckHandler.DeleteNagiosCheck)
+			nagiosChecks.POST("/:id/run", nagiosCheckHandler.RunNagiosCheck)
+		}
 	}
 
 	// Legacy API routes (for backward compatibility)
@@ -9,11 +9,18 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Server   ServerConfig   `mapstructure:"server"`
-	Log      LogConfig      `mapstructure:"log"`
-	CORS     CORSConfig     `mapstructure:"cors"`
-	Database DatabaseConfig `mapstructure:"database"`
+	App          AppConfig          `mapstructure:"app"`
+	Server       ServerConfig       `mapstructure:"server"`
+	Log          LogConfig          `mapstructure:"log"`
+	CORS         CORSConfig         `mapstructure:"cors"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	Monitor      MonitorConfig      `mapstructure:"monitor"`
+	Alert        AlertConfig        `mapstructure:"alert"`
+	Notification NotificationConfig `mapstructure:"notification"`
+	Probe        ProbeConfig        `mapstructure:"probe"`
+	Reachability ReachabilityConfig `mapstructure:"reachability"`
+	Nagios       NagiosConfig       `mapstructure:"nagios"`
+	Collector    CollectorConfig    `mapstructure:"collector"`
 }
 
 // AppConfig holds application-specific configuration
@@ -47,6 +54,87 @@ type CORSConfig struct {
 	AllowedHeaders []string `mapstructure:"allowed_headers"`
 }
 
+// MonitorConfig holds local metrics collection configuration
+type MonitorConfig struct {
+	Hostname       string `mapstructure:"hostname"`        // hostname of the local machine in the hosts table
+	RecordInterval int    `mapstructure:"record_interval"` // seconds between persisted samples
+	RetentionDays  int    `mapstructure:"retention_days"`
+
+	Filters FilterConfig `mapstructure:"filters"` // mounts and interfaces reported by the local collectors
+}
+
+// FilterConfig holds include/exclude rules for disk and network collection.
+// An item must match one include rule when any are set and must not match an exclude rule.
+type FilterConfig struct {
+	IncludeFSTypes     []string `mapstructure:"include_fstypes"`
+	ExcludeFSTypes     []string `mapstructure:"exclude_fstypes"`
+	IncludeMountpoints []string `mapstructure:"include_mountpoints"` // globs, a trailing /** also matches everything below
+	ExcludeMountpoints []string `mapstructure:"exclude_mountpoints"`
+	IncludeDevices     []string `mapstructure:"include_devices"` // regular expressions
+	ExcludeDevices     []string `mapstructure:"exclude_devices"`
+	IncludeInterfaces  []string `mapstructure:"include_interfaces"` // regular expressions
+	ExcludeInterfaces  []string `mapstructure:"exclude_interfaces"`
+}
+
+// AlertConfig holds alert evaluation configuration
+type AlertConfig struct {
+	EvaluationInterval int `mapstructure:"evaluation_interval"` // seconds between rule evaluations
+	StaleAfter         int `mapstructure:"stale_after"`         // seconds after which the latest sample is considered stale
+	FlapWindow         int `mapstructure:"flap_window"`         // seconds within which state changes are counted for flap detection
+	FlapThreshold      int `mapstructure:"flap_threshold"`      // state changes within the window that mark an alert as flapping
+}
+
+// NotificationConfig holds alert notification defaults, used when a route does not override them
+type NotificationConfig struct {
+	GroupBy        string `mapstructure:"group_by"`        // comma separated alert labels used to group notifications
+	GroupWait      int    `mapstructure:"group_wait"`      // seconds to wait before the first notification of a new group
+	GroupInterval  int    `mapstructure:"group_interval"`  // seconds between notifications of a changed group
+	RepeatInterval int    `mapstructure:"repeat_interval"` // seconds before re-sending a group that still has firing alerts
+	FlushInterval  int    `mapstructure:"flush_interval"`  // seconds between checks for groups that are due
+}
+
+// ProbeConfig holds synthetic check scheduling configuration
+type ProbeConfig struct {
+	TickInterval  int `mapstructure:"tick_interval"`  // seconds between checks for probes that are due
+	MaxConcurrent int `mapstructure:"max_concurrent"` // maximum number of probes running at the same time
+}
+
+// ReachabilityConfig holds host reachability checking configuration
+type ReachabilityConfig struct {
+	Enabled          bool  `mapstructure:"enabled"`
+	Interval         int   `mapstructure:"interval"`           // seconds between reachability rounds
+	Ports            []int `mapstructure:"ports"`              // TCP ports tried in order before falling back to ping
+	Timeout          int   `mapstructure:"timeout"`            // seconds to wait for a connect or an echo reply
+	Attempts         int   `mapstructure:"attempts"`           // connects or echo requests per round, used for RTT and packet loss
+	FailureThreshold int   `mapstructure:"failure_threshold"`  // consecutive unreachable rounds before a host is marked offline
+	AgentSilentAfter int   `mapstructure:"agent_silent_after"` // seconds since last seen after which an agent counts as silent
+	MaxConcurrent    int   `mapstructure:"max_concurrent"`     // maximum number of hosts checked at the same time
+}
+
+// NagiosConfig holds Nagios plugin check execution configuration
+type NagiosConfig struct {
+	Enabled        bool     `mapstructure:"enabled"`
+	PluginDirs     []string `mapstructure:"plugin_dirs"`      // directories check commands must be located in
+	Environment    []string `mapstructure:"environment"`      // KEY=VALUE pairs added to the restricted plugin environment
+	TickInterval   int      `mapstructure:"tick_interval"`    // seconds between checks for checks that are due
+	MaxConcurrent  int      `mapstructure:"max_concurrent"`   // maximum number of plugins running at the same time
+	MaxOutputBytes int      `mapstructure:"max_output_bytes"` // plugin output beyond this size is discarded
+}
+
+// CollectorConfig holds local collector scheduling configuration
+type CollectorConfig struct {
+	TickInterval int                          `mapstructure:"tick_interval"` // seconds between checks for collectors that are due
+	Timeout      int                          `mapstructure:"timeout"`       // default seconds a single collection may take
+	Collectors   map[string]CollectorSettings `mapstructure:"collectors"`    // per collector settings keyed by collector name
+}
+
+// CollectorSettings holds the settings of a single collector
+type CollectorSettings struct {
+	Enabled  *bool `mapstructure:"enabled"`  // collectors are enabled unless set to false
+	Interval int   `mapstructure:"interval"` // seconds between collections, 0 uses the collector default
+	Timeout  int   `mapstructure:"timeout"`  // seconds a collection may take, 0 uses the default timeout
+}
+
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
 	Postgres PostgresConfig `mapstructure:"postgres"`
@@ -134,4 +222,56 @@ func setDefaults() {
 	viper.SetDefault("database.postgres.max_open_conns", 25)
 	viper.SetDefault("database.postgres.max_idle_conns", 5)
 	viper.SetDefault("database.postgres.conn_max_lifetime", 300)
-}
\ No newline at end of file
+
+	// Monitor defaults
+	viper.SetDefault("monitor.hostname", "localhost")
+	viper.SetDefault("monitor.record_interval", 60)
+	viper.SetDefault("monitor.retention_days", 30)
+	viper.SetDefault("monitor.filters.include_fstypes", []string{})
+	viper.SetDefault("monitor.filters.exclude_fstypes", []string{"autofs", "binfmt_misc", "bpf", "cgroup", "cgroup2", "configfs", "debugfs", "devpts", "devtmpfs", "fusectl", "hugetlbfs", "mqueue", "nsfs", "overlay", "proc", "pstore", "ramfs", "rootfs", "rpc_pipefs", "securityfs", "squashfs", "sysfs", "tmpfs", "tracefs", "none"})
+	viper.SetDefault("monitor.filters.include_mountpoints", []string{})
+	viper.SetDefault("monitor.filters.exclude_mountpoints", []string{"/dev/**", "/proc/**", "/sys/**", "/run/**", "/snap/**", "/var/lib/docker/**", "/var/lib/containers/**", "/var/lib/kubelet/**"})
+	viper.SetDefault("monitor.filters.include_devices", []string{})
+	viper.SetDefault("monitor.filters.exclude_devices", []string{"^/dev/loop[0-9]+$"})
+	viper.SetDefault("monitor.filters.include_interfaces", []string{})
+	viper.SetDefault("monitor.filters.exclude_interfaces", []string{"^veth", "^cali", "^cni", "^flannel", "^lxc", "^tap", "^vnet", "^kube-ipvs"})
+
+	// Alert defaults
+	viper.SetDefault("alert.evaluation_interval", 60)
+	viper.SetDefault("alert.stale_after", 300)
+	viper.SetDefault("alert.flap_window", 1800)
+	viper.SetDefault("alert.flap_threshold", 4)
+
+	// Notification defaults
+	viper.SetDefault("notification.group_by", "alertname")
+	viper.SetDefault("notification.group_wait", 30)
+	viper.SetDefault("notification.group_interval", 300)
+	viper.SetDefault("notification.repeat_interval", 14400)
+	viper.SetDefault("notification.flush_interval", 10)
+
+	// Probe defaults
+	viper.SetDefault("probe.tick_interval", 5)
+	viper.SetDefault("probe.max_concurrent", 10)
+
+	// Reachability defaults
+	viper.SetDefault("reachability.enabled", true)
+	viper.SetDefault("reachability.interval", 60)
+	viper.SetDefault("reachability.ports", []int{22, 80, 443})
+	viper.SetDefault("reachability.timeout", 2)
+	viper.SetDefault("reachability.attempts", 3)
+	viper.SetDefault("reachability.failure_threshold", 2)
+	viper.SetDefault("reachability.agent_silent_after", 300)
+	viper.SetDefault("reachability.max_concurrent", 10)
+
+	// Nagios plugin defaults
+	viper.SetDefault("nagios.enabled", true)
+	viper.SetDefault("nagios.plugin_dirs", []string{"/usr/lib/nagios/plugins", "/usr/lib64/nagios/plugins", "/usr/local/nagios/libexec"})
+	viper.SetDefault("nagios.environment", []string{})
+	viper.SetDefault("nagios.tick_interval", 5)
+	viper.SetDefault("nagios.max_concurrent", 5)
+	viper.SetDefault("nagios.max_output_bytes", 65536)
+
+	// Collector defaults
+	viper.SetDefault("collector.tick_interval", 1)
+	viper.SetDefault("collector.timeout", 30)
+}
@@ -2,6 +2,9 @@ package database
 
 import (
 	"fmt"
+
+	"gorm.io/gorm"
+
 	"monitor-server/internal/model"
 )
 
@@ -9,9 +12,26 @@ import (
 func (db *DB) AutoMigrate() error {
 	models := []interface{}{
 		&model.SystemMetrics{},
+		&model.MetricSample{},
+		&model.MetricBaseline{},
 		&model.SystemInfoDB{},
 		&model.AlertRule{},
 		&model.Alert{},
+		&model.NotificationRoute{},
+		&model.PendingNotificationGroup{},
+		&model.InhibitionRule{},
+		&model.EscalationPolicy{},
+		&model.EscalationStep{},
+		&model.OnCallSchedule{},
+		&model.OnCallOverride{},
+		&model.HTTPCheck{},
+		&model.TCPCheck{},
+		&model.DNSCheck{},
+		&model.CertificateCheck{},
+		&model.ProcessWatch{},
+		&model.SystemdUnitWatch{},
+		&model.LogEvent{},
+		&model.NagiosCheck{},
 		&model.MonitoringConfig{},
 		// 主机管理相关模型
 		&model.Host{},
@@ -26,6 +46,20 @@ func (db *DB) AutoMigrate() error {
 		}
 	}
 
+	// 日志监视配置键加上前缀，避免与采集器等其他主机配置键冲突
+	if err := db.DB.Model(&model.HostConfig{}).
+		Where("category = ? AND key NOT LIKE ?", model.HostConfigCategoryLogWatch, model.LogWatchKeyPrefix+"%").
+		Update("key", gorm.Expr("? || key", model.LogWatchKeyPrefix)).Error; err != nil {
+		return fmt.Errorf("failed to prefix log watch keys: %w", err)
+	}
+
+	// 通知路由名称的唯一索引改为只约束未删除的路由
+	if db.DB.Migrator().HasIndex(&model.NotificationRoute{}, "idx_notification_routes_name") {
+		if err := db.DB.Migrator().DropIndex(&model.NotificationRoute{}, "idx_notification_routes_name"); err != nil {
+			return fmt.Errorf("failed to drop notification route name index: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -156,6 +190,188 @@ func (db *DB) InitializeDefaultAlertRules() error {
 			Enabled:     true,
 			Description: "磁盘使用率持续超过98%达1分钟时触发严重告警",
 		},
+		{
+			Name:        "磁盘预计即将写满",
+			MetricType:  "disk",
+			RuleType:    model.RuleTypeForecast,
+			Operator:    "<",
+			Threshold:   24.0,  // 小时
+			Lookback:    21600, // 6小时
+			Severity:    "warning",
+			Enabled:     true,
+			Description: "根据最近6小时的增长趋势，磁盘预计在24小时内写满时触发告警",
+		},
+		{
+			Name:        "主机离线",
+			MetricType:  "up",
+			Operator:    "==",
+			Threshold:   0,
+			Duration:    0,
+			Severity:    "critical",
+			Enabled:     true,
+			Description: "主机状态为离线时触发告警，告警期间抑制该主机的其他告警",
+		},
+		{
+			Name:        "服务探测失败",
+			MetricType:  "probe_up",
+			Operator:    "==",
+			Threshold:   0,
+			Duration:    120, // 2分钟
+			Severity:    "critical",
+			Enabled:     true,
+			Description: "服务探测持续失败达2分钟时触发告警",
+		},
+		{
+			Name:        "证书即将过期",
+			MetricType:  "cert_days_remaining",
+			Operator:    "<",
+			Threshold:   14,
+			Duration:    0,
+			Severity:    "warning",
+			Enabled:     true,
+			Description: "证书距过期不足14天时触发告警",
+		},
+		{
+			Name:        "主机网络丢包",
+			MetricType:  "host_packet_loss_percent",
+			Operator:    ">",
+			Threshold:   50,
+			Duration:    300, // 5分钟
+			Severity:    "warning",
+			Enabled:     true,
+			Description: "主机可达性检测丢包率持续5分钟超过50%时触发告警",
+		},
+		{
+			Name:        "进程缺失",
+			MetricType:  "process_missing",
+			Operator:    "==",
+			Threshold:   1,
+			Duration:    60, // 1分钟
+			Severity:    "critical",
+			Enabled:     true,
+			Description: "进程监视匹配的进程数量持续1分钟少于最小数量时触发告警",
+		},
+		{
+			Name:        "进程超出限制",
+			MetricType:  "process_over_limit",
+			Operator:    "==",
+			Threshold:   1,
+			Duration:    300, // 5分钟
+			Severity:    "warning",
+			Enabled:     true,
+			Description: "进程监视匹配的进程数量、CPU、内存或打开文件数持续5分钟超出上限时触发告警",
+		},
+		{
+			Name:        "CLOSE_WAIT 连接堆积",
+			MetricType:  "tcp_close_wait",
+			Operator:    ">",
+			Threshold:   100,
+			Duration:    600, // 10分钟
+			Severity:    "warning",
+			Enabled:     true,
+			Description: "CLOSE_WAIT 状态的 TCP 连接持续10分钟超过100个时触发告警，通常是应用未关闭连接",
+		},
+		{
+			Name:        "systemd 服务失败",
+			MetricType:  "systemd_unit_failed",
+			Operator:    "==",
+			Threshold:   1,
+			Duration:    0,
+			Severity:    "critical",
+			Enabled:     true,
+			Description: "监视的 systemd 单元进入 failed 状态时立即触发告警",
+		},
+		{
+			Name:        "日志出现匹配行",
+			MetricType:  "log_matches",
+			Operator:    ">",
+			Threshold:   0,
+			Duration:    0,
+			Severity:    "warning",
+			Enabled:     true,
+			Description: "日志监视匹配到新的行时立即触发告警，按匹配频率告警可对 log_match_rate 配置规则",
+		},
+		{
+			Name:        "Nagios 检查警告",
+			MetricType:  "nagios_check_warning",
+			Operator:    "==",
+			Threshold:   1,
+			Duration:    0,
+			Severity:    "warning",
+			Enabled:     true,
+			Description: "Nagios 插件检查返回 WARNING 时触发告警",
+		},
+		{
+			Name:        "Nagios 检查严重",
+			MetricType:  "nagios_check_critical",
+			Operator:    "==",
+			Threshold:   1,
+			Duration:    0,
+			Severity:    "critical",
+			Enabled:     true,
+			Description: "Nagios 插件检查返回 CRITICAL 或执行超时时触发告警",
+		},
+		{
+			Name:        "硬件传感器超出上限",
+			MetricType:  "sensor_over_limit",
+			Operator:    "==",
+			Threshold:   1,
+			Duration:    300, // 5分钟
+			Severity:    "warning",
+			Enabled:     true,
+			Description: "温度、风扇转速、功率或电压持续5分钟超出芯片上限、低于下限或芯片报警时触发告警",
+		},
+		{
+			Name:        "硬件传感器达到临界值",
+			MetricType:  "sensor_over_critical",
+			Operator:    "==",
+			Threshold:   1,
+			Duration:    60, // 1分钟
+			Severity:    "critical",
+			Enabled:     true,
+			Description: "温度、功率或电压持续1分钟达到芯片临界值时触发告警",
+		},
+		{
+			Name:        "inode 使用率过高",
+			MetricType:  "disk_inode_usage_percent",
+			Operator:    ">",
+			Threshold:   90.0,
+			Duration:    300, // 5分钟
+			Severity:    "warning",
+			Enabled:     true,
+			Description: "挂载点 inode 使用率持续5分钟超过90%时触发告警，此时即使磁盘空间充足也无法创建文件",
+		},
+		{
+			Name:        "文件系统只读",
+			MetricType:  "disk_read_only",
+			Operator:    "==",
+			Threshold:   1,
+			Duration:    0,
+			Severity:    "critical",
+			Enabled:     true,
+			Description: "fstab 中为读写挂载的文件系统变为只读（如发生 I/O 错误后被内核重新挂载）时触发告警",
+		},
+		{
+			Name:        "系统文件句柄使用率过高",
+			MetricType:  "fd_usage_percent",
+			Operator:    ">",
+			Threshold:   90.0,
+			Duration:    300, // 5分钟
+			Severity:    "critical",
+			Enabled:     true,
+			Description: "系统已分配文件句柄持续5分钟超过 fs.file-max 的90%时触发告警",
+		},
+		{
+			Name:        "主机指标数据缺失",
+			MetricType:  "cpu",
+			RuleType:    model.RuleTypeAbsent,
+			Operator:    ">",
+			Threshold:   0,
+			Lookback:    600, // 10分钟
+			Severity:    "warning",
+			Enabled:     true,
+			Description: "已启用监控的在线主机连续10分钟没有上报指标数据时触发告警",
+		},
 	}
 
 	for _, rule := range defaultRules {
@@ -169,6 +385,30 @@ func (db *DB) InitializeDefaultAlertRules() error {
 	return nil
 }
 
+// InitializeDefaultInhibitionRules 初始化默认告警抑制规则
+func (db *DB) InitializeDefaultInhibitionRules() error {
+	defaultRules := []model.InhibitionRule{
+		{
+			Name:           "主机离线时抑制主机其他告警",
+			SourceMatchers: "metric_type=up",
+			TargetMatchers: "metric_type!=up",
+			Equal:          "hostname",
+			Enabled:        true,
+			Description:    "主机离线告警活动期间，抑制同一主机的磁盘、CPU、数据缺失等告警",
+		},
+	}
+
+	for _, rule := range defaultRules {
+		// 使用 FirstOrCreate 避免重复插入
+		var existingRule model.InhibitionRule
+		if err := db.Where("name = ?", rule.Name).FirstOrCreate(&existingRule, rule).Error; err != nil {
+			return fmt.Errorf("failed to create default inhibition rule %s: %w", rule.Name, err)
+		}
+	}
+
+	return nil
+}
+
 // InitializeDefaultHosts 初始化默认主机数据
 func (db *DB) InitializeDefaultHosts() error {
 	defaultHosts := []model.Host{
@@ -304,6 +544,11 @@ func (db *DB) Setup() error {
 		return fmt.Errorf("initialize default alert rules failed: %w", err)
 	}
 
+	// 初始化默认告警抑制规则
+	if err := db.InitializeDefaultInhibitionRules(); err != nil {
+		return fmt.Errorf("initialize default inhibition rules failed: %w", err)
+	}
+
 	// 初始化默认主机
 	if err := db.InitializeDefaultHosts(); err != nil {
 		return fmt.Errorf("initialize default hosts failed: %w", err)
@@ -320,4 +565,4 @@ func (db *DB) Setup() error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+)
+
+// AlertHandler 告警记录处理器
+type AlertHandler struct {
+	alertRepo repository.AlertRepository
+}
+
+// NewAlertHandler 创建告警记录处理器
+func NewAlertHandler(db *gorm.DB) *AlertHandler {
+	return &AlertHandler{
+		alertRepo: repository.NewAlertRepository(db),
+	}
+}
+
+// AlertListResponse 告警列表响应
+type AlertListResponse struct {
+	Alerts []model.Alert `json:"alerts"`
+	Total  int64         `json:"total"`
+	Page   int           `json:"page"`
+	Size   int           `json:"size"`
+}
+
+// AcknowledgeAlertRequest 确认告警请求
+type AcknowledgeAlertRequest struct {
+	By string `json:"by" binding:"required"` // 确认人
+}
+
+// GetAlerts 获取告警列表
+// @Summary 获取告警列表
+// @Description 获取告警记录，按开始时间倒序，支持分页和筛选
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页大小" default(20)
+// @Param status query string false "状态筛选" Enums(active, resolved, suppressed)
+// @Param hostname query string false "主机名筛选"
+// @Success 200 {object} AlertListResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alerts [get]
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	alerts, total, err := h.alertRepo.ListAlerts(c.Query("status"), c.Query("hostname"), (page-1)*size, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, AlertListResponse{
+		Alerts: alerts,
+		Total:  total,
+		Page:   page,
+		Size:   size,
+	})
+}
+
+// GetAlert 获取单个告警
+// @Summary 获取单个告警
+// @Description 根据ID获取告警详情，包括确认和升级状态
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path int true "告警ID"
+// @Success 200 {object} model.Alert
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alerts/{id} [get]
+func (h *AlertHandler) GetAlert(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	alert, err := h.alertRepo.GetAlertByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// AcknowledgeAlert 确认告警
+// @Summary 确认告警
+// @Description 确认活动告警，确认后停止升级通知
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path int true "告警ID"
+// @Param request body AcknowledgeAlertRequest true "确认信息"
+// @Success 200 {object} model.Alert
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alerts/{id}/ack [post]
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	var req AcknowledgeAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert, err := h.alertRepo.GetAlertByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if alert.Status != "active" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only active alerts can be acknowledged"})
+		return
+	}
+	if alert.AcknowledgedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Alert already acknowledged"})
+		return
+	}
+
+	now := time.Now()
+	acknowledged, err := h.alertRepo.AcknowledgeAlert(alert.ID, req.By, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !acknowledged {
+		// 告警在读取后已被恢复或被他人确认
+		c.JSON(http.StatusConflict, gin.H{"error": "Alert is no longer active or already acknowledged"})
+		return
+	}
+	alert.AcknowledgedAt = &now
+	alert.AcknowledgedBy = req.By
+
+	c.JSON(http.StatusOK, alert)
+}
@@ -4,23 +4,36 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"monitor-server/internal/model"
 	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
 )
 
+// maxPreviewRange 告警规则预览允许的最大时间范围
+const maxPreviewRange = 31 * 24 * time.Hour
+
 // AlertRuleHandler 告警规则管理处理器
 type AlertRuleHandler struct {
-	alertRepo repository.AlertRepository
+	alertRepo     repository.AlertRepository
+	metricsRepo   repository.MetricsRepository
+	hostRepo      repository.HostRepository
+	hostGroupRepo repository.HostGroupRepository
+	staleAfter    time.Duration // 预览时样本间隔超过该时间视为数据缺失，与告警评估一致
 }
 
 // NewAlertRuleHandler 创建告警规则管理处理器
-func NewAlertRuleHandler(db *gorm.DB) *AlertRuleHandler {
+func NewAlertRuleHandler(db *gorm.DB, staleAfter time.Duration) *AlertRuleHandler {
 	return &AlertRuleHandler{
-		alertRepo: repository.NewAlertRepository(db),
+		alertRepo:     repository.NewAlertRepository(db),
+		metricsRepo:   repository.NewMetricsRepository(db),
+		hostRepo:      repository.NewHostRepository(db),
+		hostGroupRepo: repository.NewHostGroupRepository(db),
+		staleAfter:    staleAfter,
 	}
 }
 
@@ -31,12 +44,45 @@ type UpdateAlertRuleThresholdRequest struct {
 
 // CreateHostAlertRuleRequest 创建主机告警规则请求
 type CreateHostAlertRuleRequest struct {
-	HostID      uint    `json:"host_id" binding:"required"`
-	MetricType  string  `json:"metric_type" binding:"required"`
-	Severity    string  `json:"severity" binding:"required"`
-	Threshold   float64 `json:"threshold" binding:"required"`
-	Duration    int     `json:"duration"`
-	Enabled     *bool   `json:"enabled"`
+	HostID     uint    `json:"host_id" binding:"required"`
+	MetricType string  `json:"metric_type" binding:"required"`
+	Severity   string  `json:"severity" binding:"required"`
+	Threshold  float64 `json:"threshold" binding:"required"`
+	Duration   int     `json:"duration"`
+	Enabled    *bool   `json:"enabled"`
+}
+
+// CreateAlertRuleRequest 创建告警规则请求
+type CreateAlertRuleRequest struct {
+	Name             string   `json:"name" binding:"required"`
+	MetricType       string   `json:"metric_type" binding:"required"`
+	Operator         string   `json:"operator" binding:"required"`
+	Threshold        float64  `json:"threshold"`
+	Duration         int      `json:"duration"`
+	Severity         string   `json:"severity" binding:"required"`
+	Enabled          *bool    `json:"enabled"`
+	Description      string   `json:"description"`
+	RuleType         string   `json:"rule_type"`         // threshold（默认）, forecast, anomaly, absent
+	Lookback         int      `json:"lookback"`          // 回看窗口（秒），用于预测规则、异常检测规则的基线学习和数据缺失规则的检测窗口
+	HostID           *uint    `json:"host_id"`           // 主机规则
+	HostGroupID      *uint    `json:"host_group_id"`     // 主机组规则
+	Selector         string   `json:"selector"`          // 标签选择器规则，如 env=prod,role=db
+	ResolveThreshold *float64 `json:"resolve_threshold"` // 恢复阈值，仅用于阈值和异常检测规则
+}
+
+// PreviewAlertRuleRequest 告警规则预览请求
+type PreviewAlertRuleRequest struct {
+	RuleID           *uint      `json:"rule_id"` // 以已有规则为模板，其余字段可覆盖
+	MetricType       string     `json:"metric_type"`
+	Operator         string     `json:"operator"`
+	Threshold        *float64   `json:"threshold"`
+	Duration         *int       `json:"duration"`
+	Severity         string     `json:"severity"`
+	ResolveThreshold *float64   `json:"resolve_threshold"` // 恢复阈值
+	HostID           *uint      `json:"host_id"`           // 仅预览指定主机
+	HostGroupID      *uint      `json:"host_group_id"`     // 仅预览指定主机组
+	StartTime        *time.Time `json:"start_time"`        // 默认为结束时间前24小时
+	EndTime          *time.Time `json:"end_time"`          // 默认为当前时间
 }
 
 // GetAlertRules 获取告警规则列表
@@ -51,10 +97,10 @@ type CreateHostAlertRuleRequest struct {
 // @Router /api/v1/alert-rules [get]
 func (h *AlertRuleHandler) GetAlertRules(c *gin.Context) {
 	hostIDStr := c.Query("host_id")
-	
+
 	var rules []model.AlertRule
 	var err error
-	
+
 	if hostIDStr != "" {
 		// 获取指定主机的规则（包括全局规则）
 		if hostID, parseErr := strconv.ParseUint(hostIDStr, 10, 32); parseErr == nil {
@@ -68,7 +114,7 @@ func (h *AlertRuleHandler) GetAlertRules(c *gin.Context) {
 		// 获取所有规则
 		rules, err = h.alertRepo.GetAllRules()
 	}
-	
+
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -110,7 +156,7 @@ func (h *AlertRuleHandler) UpdateAlertRuleThreshold(c *gin.Context) {
 
 	var targetRule *model.AlertRule
 	for _, rule := range rules {
-		if rule.MetricType == metricType && rule.Severity == severity {
+		if rule.Scope() == model.RuleScopeGlobal && rule.MetricType == metricType && rule.Severity == severity {
 			targetRule = &rule
 			break
 		}
@@ -160,8 +206,8 @@ func (h *AlertRuleHandler) CreateHostAlertRule(c *gin.Context) {
 	// 查找是否已存在相同的主机特定规则
 	var existingRule *model.AlertRule
 	for _, rule := range allRules {
-		if rule.HostID != nil && *rule.HostID == req.HostID && 
-		   rule.MetricType == req.MetricType && rule.Severity == req.Severity {
+		if rule.HostID != nil && *rule.HostID == req.HostID &&
+			rule.MetricType == req.MetricType && rule.Severity == req.Severity {
 			existingRule = &rule
 			break
 		}
@@ -189,7 +235,7 @@ func (h *AlertRuleHandler) CreateHostAlertRule(c *gin.Context) {
 	// 如果不存在，查找对应的全局规则作为模板
 	var templateRule *model.AlertRule
 	for _, rule := range allRules {
-		if rule.HostID == nil && rule.MetricType == req.MetricType && rule.Severity == req.Severity {
+		if rule.Scope() == model.RuleScopeGlobal && rule.MetricType == req.MetricType && rule.Severity == req.Severity {
 			templateRule = &rule
 			break
 		}
@@ -229,4 +275,376 @@ func (h *AlertRuleHandler) CreateHostAlertRule(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, newRule)
-}
\ No newline at end of file
+}
+
+// PreviewAlertRule 预览告警规则
+// @Summary 预览告警规则
+// @Description 使用历史指标数据回放告警规则，返回在该时间范围内将会触发的告警，不写入任何数据。与告警评估一致，触发后值满足恢复阈值时保持触发，样本间隔超过过期时间时结束超限
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param request body PreviewAlertRuleRequest true "规则定义、范围和时间区间"
+// @Success 200 {object} model.AlertPreviewResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alert-rules/preview [post]
+func (h *AlertRuleHandler) PreviewAlertRule(c *gin.Context) {
+	var req PreviewAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.HostID != nil && req.HostGroupID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "host_id and host_group_id cannot be used together"})
+		return
+	}
+
+	// 构造待预览的规则
+	rule := model.AlertRule{Operator: ">", Severity: "warning"}
+	if req.RuleID != nil {
+		existing, err := h.alertRepo.GetRuleByID(*req.RuleID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		rule = *existing
+	}
+	if req.MetricType != "" {
+		rule.MetricType = req.MetricType
+	}
+	if req.Operator != "" {
+		rule.Operator = req.Operator
+	}
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.Duration != nil {
+		rule.Duration = *req.Duration
+	}
+	if req.Severity != "" {
+		rule.Severity = req.Severity
+	}
+	if req.ResolveThreshold != nil {
+		rule.ResolveThreshold = req.ResolveThreshold
+	}
+
+	if rule.MetricType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric_type is required when rule_id is not provided"})
+		return
+	}
+	if req.RuleID == nil && req.Threshold == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "threshold is required when rule_id is not provided"})
+		return
+	}
+	if rule.Duration < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration cannot be negative"})
+		return
+	}
+	if rule.ResolveThreshold != nil {
+		if err := validateResolveThreshold(rule.Operator, rule.Threshold, *rule.ResolveThreshold); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// 时间范围
+	end := time.Now()
+	if req.EndTime != nil {
+		end = *req.EndTime
+	}
+	start := end.Add(-24 * time.Hour)
+	if req.StartTime != nil {
+		start = *req.StartTime
+	}
+	if !start.Before(end) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_time must be before end_time"})
+		return
+	}
+	if end.Sub(start) > maxPreviewRange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "time range cannot exceed 31 days"})
+		return
+	}
+
+	// 确定需要回放的主机，未指定范围时使用规则自身的作用范围
+	hostID, hostGroupID := req.HostID, req.HostGroupID
+	if hostID == nil && hostGroupID == nil {
+		hostID, hostGroupID = rule.HostID, rule.HostGroupID
+	}
+
+	var hostnames []string
+	switch {
+	case hostID != nil:
+		host, err := h.hostRepo.GetByID(*hostID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		hostnames = []string{host.Hostname}
+	case hostGroupID != nil:
+		if _, err := h.hostGroupRepo.GetByID(*hostGroupID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Host group not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		hosts, err := h.hostGroupRepo.GetHostsByGroupID(*hostGroupID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(hosts) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Host group has no hosts"})
+			return
+		}
+		for _, host := range hosts {
+			hostnames = append(hostnames, host.Hostname)
+		}
+	case rule.Selector != "":
+		selector, err := service.ParseSelector(rule.Selector)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		hosts, err := h.hostRepo.GetMonitoringEnabledHosts()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, host := range hosts {
+			if selector.Matches(service.HostLabels(host)) {
+				hostnames = append(hostnames, host.Hostname)
+			}
+		}
+		if len(hostnames) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No hosts match the rule selector"})
+			return
+		}
+	}
+
+	metrics, err := h.metricsRepo.GetRange(hostnames, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := service.BacktestRule(rule, metrics, start, end, h.staleAfter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// validateResolveThreshold 恢复阈值必须位于触发阈值不触发的一侧，例如 > 80 的规则恢复阈值应不大于 80
+func validateResolveThreshold(operator string, threshold, resolveThreshold float64) error {
+	beyond, _ := service.CompareValue(operator, threshold, resolveThreshold)
+	if operator == "==" || (resolveThreshold != threshold && !beyond) {
+		return fmt.Errorf("resolve_threshold must lie on the non-firing side of threshold")
+	}
+	return nil
+}
+
+// CreateAlertRule 创建告警规则
+// @Summary 创建告警规则
+// @Description 创建全局、主机、主机组或标签选择器范围的告警规则，同一规则只能指定一种范围。同一范围内已有类型、指标和严重级别相同的规则时返回 409
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param request body CreateAlertRuleRequest true "告警规则信息"
+// @Success 201 {object} model.AlertRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alert-rules [post]
+func (h *AlertRuleHandler) CreateAlertRule(c *gin.Context) {
+	var req CreateAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scopes := 0
+	if req.HostID != nil {
+		scopes++
+	}
+	if req.HostGroupID != nil {
+		scopes++
+	}
+	if req.Selector != "" {
+		scopes++
+	}
+	if scopes > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only one of host_id, host_group_id and selector can be set"})
+		return
+	}
+
+	if _, err := service.CompareValue(req.Operator, 0, req.Threshold); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ruleType := req.RuleType
+	if ruleType == "" {
+		ruleType = model.RuleTypeThreshold
+	}
+	switch ruleType {
+	case model.RuleTypeThreshold:
+	case model.RuleTypeForecast:
+		if req.MetricType != "disk" && req.MetricType != "memory" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Forecast rules only support disk and memory metrics"})
+			return
+		}
+	case model.RuleTypeAnomaly:
+		// 异常检测规则的阈值为偏离基线的标准差倍数
+		if req.Threshold <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Anomaly rules require a positive threshold in standard deviations"})
+			return
+		}
+	case model.RuleTypeAbsent:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule_type"})
+		return
+	}
+	if req.ResolveThreshold != nil {
+		if ruleType != model.RuleTypeThreshold && ruleType != model.RuleTypeAnomaly {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "resolve_threshold is only supported by threshold and anomaly rules"})
+			return
+		}
+		if err := validateResolveThreshold(req.Operator, req.Threshold, *req.ResolveThreshold); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Duration < 0 || req.Lookback < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration and lookback cannot be negative"})
+		return
+	}
+
+	if req.HostID != nil {
+		if _, err := h.hostRepo.GetByID(*req.HostID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+	}
+	if req.HostGroupID != nil {
+		if _, err := h.hostGroupRepo.GetByID(*req.HostGroupID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Host group not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+	}
+
+	selector := ""
+	if req.Selector != "" {
+		parsed, err := service.ParseSelector(req.Selector)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		selector = parsed.String()
+	}
+
+	rule := &model.AlertRule{
+		Name:             req.Name,
+		MetricType:       req.MetricType,
+		Operator:         req.Operator,
+		Threshold:        req.Threshold,
+		Duration:         req.Duration,
+		Severity:         req.Severity,
+		Enabled:          req.Enabled == nil || *req.Enabled,
+		Description:      req.Description,
+		RuleType:         ruleType,
+		Lookback:         req.Lookback,
+		HostID:           req.HostID,
+		HostGroupID:      req.HostGroupID,
+		Selector:         selector,
+		ResolveThreshold: req.ResolveThreshold,
+	}
+
+	// 同一范围内类型、指标和严重级别相同的规则只有一条能生效，其余会被静默覆盖
+	rules, err := h.alertRepo.GetAllRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if duplicate := service.DuplicateRule(*rule, rules); duplicate != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Alert rule %d (%s) already covers this rule type, metric type and severity in the same scope", duplicate.ID, duplicate.Name)})
+		return
+	}
+
+	if err := h.alertRepo.CreateRule(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetEffectiveAlertRules 获取主机实际生效的告警规则
+// @Summary 获取主机实际生效的告警规则
+// @Description 按 host > group > selector > global 的优先级解析每个指标类型和严重级别实际生效的规则，并说明原因
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param id path int true "主机ID"
+// @Success 200 {object} model.AlertRuleExplanation
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/hosts/{id}/alert-rules [get]
+func (h *AlertRuleHandler) GetEffectiveAlertRules(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host ID"})
+		return
+	}
+
+	host, err := h.hostRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	groups, err := h.hostGroupRepo.GetHostGroups(host.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	groupIDs := make([]uint, 0, len(groups))
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.ID)
+	}
+
+	rules, err := h.alertRepo.GetAllRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ResolveHostRules(*host, groupIDs, rules))
+}
@@ -0,0 +1,400 @@
+package handler
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+)
+
+// CertificateCheckRequest 创建或更新证书探测请求
+type CertificateCheckRequest struct {
+	Name        string `json:"name" binding:"required"`
+	HostID      uint   `json:"host_id" binding:"required"`
+	Source      string `json:"source"`      // tls（默认）, file
+	Address     string `json:"address"`     // tls 来源必填，host:port
+	ServerName  string `json:"server_name"` // SNI，默认取 address 中的主机名
+	FilePath    string `json:"file_path"`   // file 来源必填，服务端所在主机上的 PEM 文件绝对路径
+	Timeout     int    `json:"timeout"`     // 秒，默认 10
+	Interval    int    `json:"interval"`    // 秒，默认 60，应小于告警数据过期时间以便评估过期告警
+	Enabled     *bool  `json:"enabled"`
+	Description string `json:"description"`
+}
+
+// CertificateCheckListResponse 证书探测列表响应
+type CertificateCheckListResponse struct {
+	Checks []model.CertificateCheck `json:"checks"`
+	Total  int                      `json:"total"`
+}
+
+// CertificateListResponse 证书列表响应
+type CertificateListResponse struct {
+	Certificates []model.CertificateStatus `json:"certificates"`
+	Total        int                       `json:"total"`
+}
+
+// apply validates the request and copies it onto the check
+func (req CertificateCheckRequest) apply(check *model.CertificateCheck) error {
+	source := req.Source
+	if source == "" {
+		source = model.CertSourceTLS
+	}
+
+	address, filePath := "", ""
+	switch source {
+	case model.CertSourceTLS:
+		host, port, err := net.SplitHostPort(req.Address)
+		if err != nil || host == "" {
+			return fmt.Errorf("tls source requires address in host:port form")
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("invalid port: %s", port)
+		}
+		address = req.Address
+	case model.CertSourceFile:
+		if !filepath.IsAbs(req.FilePath) {
+			return fmt.Errorf("file source requires an absolute file_path")
+		}
+		filePath = filepath.Clean(req.FilePath)
+	default:
+		return fmt.Errorf("unsupported source: %s", req.Source)
+	}
+
+	timeout, interval, err := validateProbeSchedule(req.Timeout, req.Interval)
+	if err != nil {
+		return err
+	}
+
+	check.Name = req.Name
+	check.HostID = req.HostID
+	check.Source = source
+	check.Address = address
+	check.ServerName = strings.TrimSpace(req.ServerName)
+	check.FilePath = filePath
+	check.Timeout = timeout
+	check.Interval = interval
+	check.Enabled = req.Enabled == nil || *req.Enabled
+	check.Description = req.Description
+	return nil
+}
+
+// checkCertificateHost verifies the host of a certificate check. PEM files are read by the
+// server itself, so file checks must belong to the host the server runs on.
+func (h *ProbeHandler) checkCertificateHost(c *gin.Context, check *model.CertificateCheck) bool {
+	host, err := h.hostRepo.GetByID(check.HostID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Host not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	if check.Source == model.CertSourceFile && host.Hostname != h.localHostname {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("file certificates can only be read on the local host %s", h.localHostname)})
+		return false
+	}
+	return true
+}
+
+// GetCertificates 获取证书列表
+// @Summary 获取证书列表
+// @Description 获取所有证书探测的最新证书信息，按剩余天数升序排列，尚未获取到证书的排在最后
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param host_id query int false "主机ID，不提供则返回所有证书"
+// @Success 200 {object} CertificateListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/certificates [get]
+func (h *ProbeHandler) GetCertificates(c *gin.Context) {
+	var hostID uint
+	if hostIDStr := c.Query("host_id"); hostIDStr != "" {
+		id, err := strconv.ParseUint(hostIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host_id parameter"})
+			return
+		}
+		hostID = uint(id)
+	}
+
+	checks, err := h.certCheckRepo.List(hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	certificates := make([]model.CertificateStatus, 0, len(checks))
+	for _, check := range checks {
+		status := model.CertificateStatus{
+			CheckID:       check.ID,
+			Name:          check.Name,
+			HostID:        check.HostID,
+			Source:        check.Source,
+			Target:        check.Address,
+			Subject:       check.Subject,
+			Issuer:        check.Issuer,
+			NotAfter:      check.NotAfter,
+			ChainValid:    check.ChainValid,
+			ChainError:    check.ChainError,
+			LastCheckedAt: check.LastCheckedAt,
+			LastError:     check.LastError,
+		}
+		if check.Host != nil {
+			status.Hostname = check.Host.Hostname
+		}
+		if check.Source == model.CertSourceFile {
+			status.Target = check.FilePath
+		}
+		if check.SANs != "" {
+			status.SANs = strings.Split(check.SANs, ",")
+		}
+		if check.NotAfter != nil {
+			days := check.NotAfter.Sub(now).Hours() / 24
+			status.DaysRemaining = &days
+		}
+		certificates = append(certificates, status)
+	}
+	sort.SliceStable(certificates, func(i, j int) bool {
+		a, b := certificates[i].DaysRemaining, certificates[j].DaysRemaining
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+
+	c.JSON(http.StatusOK, CertificateListResponse{
+		Certificates: certificates,
+		Total:        len(certificates),
+	})
+}
+
+// GetCertificateChecks 获取证书探测列表
+// @Summary 获取证书探测列表
+// @Description 获取证书探测及其最近一次探测结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param host_id query int false "主机ID，不提供则返回所有探测"
+// @Success 200 {object} CertificateCheckListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/certificate-checks [get]
+func (h *ProbeHandler) GetCertificateChecks(c *gin.Context) {
+	var hostID uint
+	if hostIDStr := c.Query("host_id"); hostIDStr != "" {
+		id, err := strconv.ParseUint(hostIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host_id parameter"})
+			return
+		}
+		hostID = uint(id)
+	}
+
+	checks, err := h.certCheckRepo.List(hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, CertificateCheckListResponse{
+		Checks: checks,
+		Total:  len(checks),
+	})
+}
+
+// CreateCertificateCheck 创建证书探测
+// @Summary 创建证书探测
+// @Description 创建按间隔获取 TLS 服务或 PEM 文件证书的证书探测，结果记录为所属主机的 probe_* 和 cert_* 指标
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param check body CertificateCheckRequest true "证书探测"
+// @Success 201 {object} model.CertificateCheck
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/certificate-checks [post]
+func (h *ProbeHandler) CreateCertificateCheck(c *gin.Context) {
+	var req CertificateCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check := &model.CertificateCheck{}
+	if err := req.apply(check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkCertificateHost(c, check) {
+		return
+	}
+
+	if err := h.certCheckRepo.Create(check); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, check)
+}
+
+// GetCertificateCheck 获取单个证书探测
+// @Summary 获取单个证书探测
+// @Description 根据ID获取证书探测及其最近一次探测结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "证书探测ID"
+// @Success 200 {object} model.CertificateCheck
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/certificate-checks/{id} [get]
+func (h *ProbeHandler) GetCertificateCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.certCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Certificate check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// UpdateCertificateCheck 更新证书探测
+// @Summary 更新证书探测
+// @Description 使用请求内容整体替换证书探测配置
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "证书探测ID"
+// @Param check body CertificateCheckRequest true "证书探测"
+// @Success 200 {object} model.CertificateCheck
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/certificate-checks/{id} [put]
+func (h *ProbeHandler) UpdateCertificateCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	var req CertificateCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, err := h.certCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Certificate check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := req.apply(check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkCertificateHost(c, check) {
+		return
+	}
+
+	check.Host = nil
+	if err := h.certCheckRepo.Update(check); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// DeleteCertificateCheck 删除证书探测
+// @Summary 删除证书探测
+// @Description 删除证书探测，已记录的探测指标保留至过期清理
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "证书探测ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/certificate-checks/{id} [delete]
+func (h *ProbeHandler) DeleteCertificateCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	if _, err := h.certCheckRepo.GetByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Certificate check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.certCheckRepo.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RunCertificateCheck 立即执行证书探测
+// @Summary 立即执行证书探测
+// @Description 立即执行一次证书探测并记录结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "证书探测ID"
+// @Success 200 {object} model.ProbeResult
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/certificate-checks/{id}/run [post]
+func (h *ProbeHandler) RunCertificateCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.certCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Certificate check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, h.scheduler.RunCertificateCheck(check))
+}
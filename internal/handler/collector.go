@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/service"
+)
+
+// CollectorHandler 采集器状态处理器
+type CollectorHandler struct {
+	scheduler service.CollectorScheduler
+}
+
+// NewCollectorHandler 创建采集器状态处理器
+func NewCollectorHandler(scheduler service.CollectorScheduler) *CollectorHandler {
+	return &CollectorHandler{scheduler: scheduler}
+}
+
+// CollectorListResponse 采集器状态列表响应
+type CollectorListResponse struct {
+	Collectors []model.CollectorStatus `json:"collectors"`
+	Total      int                     `json:"total"`
+}
+
+// GetCollectors 获取采集器状态
+// @Summary 获取采集器状态
+// @Description 返回本机每个采集器生效的间隔、超时、是否启用，以及最近一次运行的时间、耗时和错误
+// @Tags collectors
+// @Accept json
+// @Produce json
+// @Success 200 {object} CollectorListResponse
+// @Router /api/v1/collectors [get]
+func (h *CollectorHandler) GetCollectors(c *gin.Context) {
+	collectors := h.scheduler.Status()
+	c.JSON(http.StatusOK, CollectorListResponse{
+		Collectors: collectors,
+		Total:      len(collectors),
+	})
+}
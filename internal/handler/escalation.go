@@ -0,0 +1,601 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
+)
+
+// EscalationHandler 升级策略和值班表处理器
+type EscalationHandler struct {
+	escalationRepo repository.EscalationRepository
+	hostGroupRepo  repository.HostGroupRepository
+}
+
+// NewEscalationHandler 创建升级策略和值班表处理器
+func NewEscalationHandler(db *gorm.DB) *EscalationHandler {
+	return &EscalationHandler{
+		escalationRepo: repository.NewEscalationRepository(db),
+		hostGroupRepo:  repository.NewHostGroupRepository(db),
+	}
+}
+
+// EscalationStepRequest 升级步骤
+type EscalationStepRequest struct {
+	DelayMinutes int    `json:"delay_minutes"` // 上一步骤（第一步为告警开始或重新打开）之后等待的分钟数
+	Receiver     string `json:"receiver"`      // log（默认）, webhook
+	WebhookURL   string `json:"webhook_url"`
+	ScheduleID   *uint  `json:"schedule_id"` // 通知该值班表当前的值班人员
+}
+
+// EscalationPolicyRequest 创建或更新升级策略请求
+type EscalationPolicyRequest struct {
+	Name        string                  `json:"name" binding:"required"`
+	Severity    string                  `json:"severity"`      // 为空匹配所有级别
+	HostGroupID *uint                   `json:"host_group_id"` // 为空匹配所有主机
+	Enabled     *bool                   `json:"enabled"`
+	Description string                  `json:"description"`
+	Steps       []EscalationStepRequest `json:"steps" binding:"required"`
+}
+
+// OnCallScheduleRequest 创建或更新值班表请求
+type OnCallScheduleRequest struct {
+	Name          string    `json:"name" binding:"required"`
+	Participants  []string  `json:"participants" binding:"required"` // 按轮换顺序排列
+	RotationHours int       `json:"rotation_hours"`                  // 默认168（每周轮换）
+	StartTime     time.Time `json:"start_time" binding:"required"`
+	Description   string    `json:"description"`
+}
+
+// OnCallOverrideRequest 创建值班替换请求
+type OnCallOverrideRequest struct {
+	User      string    `json:"user" binding:"required"`
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+}
+
+// EscalationPolicyListResponse 升级策略列表响应
+type EscalationPolicyListResponse struct {
+	Policies []model.EscalationPolicy `json:"policies"`
+	Total    int                      `json:"total"`
+}
+
+// OnCallScheduleListResponse 值班表列表响应
+type OnCallScheduleListResponse struct {
+	Schedules []model.OnCallSchedule `json:"schedules"`
+	Total     int                    `json:"total"`
+}
+
+// applyPolicy validates the request and copies it onto the policy
+func (h *EscalationHandler) applyPolicy(req EscalationPolicyRequest, policy *model.EscalationPolicy) error {
+	switch req.Severity {
+	case "", "info", "warning", "critical":
+	default:
+		return fmt.Errorf("invalid severity: %s", req.Severity)
+	}
+	if req.HostGroupID != nil {
+		if _, err := h.hostGroupRepo.GetByID(*req.HostGroupID); err != nil {
+			return fmt.Errorf("host group %d not found", *req.HostGroupID)
+		}
+	}
+	if len(req.Steps) == 0 {
+		return fmt.Errorf("at least one escalation step is required")
+	}
+
+	steps := make([]model.EscalationStep, 0, len(req.Steps))
+	for i, s := range req.Steps {
+		if s.DelayMinutes < 0 {
+			return fmt.Errorf("step %d: delay_minutes cannot be negative", i+1)
+		}
+		receiver, err := validateReceiver(s.Receiver, s.WebhookURL)
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if s.ScheduleID != nil {
+			if _, err := h.escalationRepo.GetScheduleByID(*s.ScheduleID); err != nil {
+				return fmt.Errorf("step %d: on-call schedule %d not found", i+1, *s.ScheduleID)
+			}
+		}
+		steps = append(steps, model.EscalationStep{
+			Position:     i,
+			DelayMinutes: s.DelayMinutes,
+			Receiver:     receiver,
+			WebhookURL:   s.WebhookURL,
+			ScheduleID:   s.ScheduleID,
+		})
+	}
+
+	policy.Name = req.Name
+	policy.Severity = req.Severity
+	policy.HostGroupID = req.HostGroupID
+	policy.Enabled = req.Enabled == nil || *req.Enabled
+	policy.Description = req.Description
+	policy.Steps = steps
+	policy.HostGroup = nil
+	return nil
+}
+
+// GetEscalationPolicies 获取升级策略列表
+// @Summary 获取升级策略列表
+// @Description 获取所有升级策略及其步骤
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Success 200 {object} EscalationPolicyListResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/escalation-policies [get]
+func (h *EscalationHandler) GetEscalationPolicies(c *gin.Context) {
+	policies, err := h.escalationRepo.ListPolicies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, EscalationPolicyListResponse{
+		Policies: policies,
+		Total:    len(policies),
+	})
+}
+
+// CreateEscalationPolicy 创建升级策略
+// @Summary 创建升级策略
+// @Description 创建按告警级别或主机组生效的升级策略，告警未确认时按步骤依次通知
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param policy body EscalationPolicyRequest true "升级策略"
+// @Success 201 {object} model.EscalationPolicy
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/escalation-policies [post]
+func (h *EscalationHandler) CreateEscalationPolicy(c *gin.Context) {
+	var req EscalationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := &model.EscalationPolicy{}
+	if err := h.applyPolicy(req, policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.escalationRepo.CreatePolicy(policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// GetEscalationPolicy 获取单个升级策略
+// @Summary 获取单个升级策略
+// @Description 根据ID获取升级策略及其步骤
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param id path int true "升级策略ID"
+// @Success 200 {object} model.EscalationPolicy
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/escalation-policies/{id} [get]
+func (h *EscalationHandler) GetEscalationPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid policy ID"})
+		return
+	}
+
+	policy, err := h.escalationRepo.GetPolicyByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Escalation policy not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateEscalationPolicy 更新升级策略
+// @Summary 更新升级策略
+// @Description 使用请求内容整体替换升级策略及其步骤
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param id path int true "升级策略ID"
+// @Param policy body EscalationPolicyRequest true "升级策略"
+// @Success 200 {object} model.EscalationPolicy
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/escalation-policies/{id} [put]
+func (h *EscalationHandler) UpdateEscalationPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid policy ID"})
+		return
+	}
+
+	var req EscalationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.escalationRepo.GetPolicyByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Escalation policy not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.applyPolicy(req, policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.escalationRepo.UpdatePolicy(policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteEscalationPolicy 删除升级策略
+// @Summary 删除升级策略
+// @Description 删除升级策略及其步骤
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param id path int true "升级策略ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/escalation-policies/{id} [delete]
+func (h *EscalationHandler) DeleteEscalationPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid policy ID"})
+		return
+	}
+
+	if _, err := h.escalationRepo.GetPolicyByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Escalation policy not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.escalationRepo.DeletePolicy(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// applySchedule validates the request and copies it onto the schedule
+func applySchedule(req OnCallScheduleRequest, schedule *model.OnCallSchedule) error {
+	var participants []string
+	for _, p := range req.Participants {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if strings.Contains(p, ",") {
+			return fmt.Errorf("participant names cannot contain commas: %q", p)
+		}
+		participants = append(participants, p)
+	}
+	if len(participants) == 0 {
+		return fmt.Errorf("at least one participant is required")
+	}
+	if req.RotationHours < 0 {
+		return fmt.Errorf("rotation_hours cannot be negative")
+	}
+
+	rotationHours := req.RotationHours
+	if rotationHours == 0 {
+		rotationHours = 168
+	}
+
+	schedule.Name = req.Name
+	schedule.Participants = strings.Join(participants, ",")
+	schedule.RotationHours = rotationHours
+	schedule.StartTime = req.StartTime
+	schedule.Description = req.Description
+	return nil
+}
+
+// GetOnCallSchedules 获取值班表列表
+// @Summary 获取值班表列表
+// @Description 获取所有值班表及其值班替换
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Success 200 {object} OnCallScheduleListResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/oncall-schedules [get]
+func (h *EscalationHandler) GetOnCallSchedules(c *gin.Context) {
+	schedules, err := h.escalationRepo.ListSchedules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, OnCallScheduleListResponse{
+		Schedules: schedules,
+		Total:     len(schedules),
+	})
+}
+
+// CreateOnCallSchedule 创建值班表
+// @Summary 创建值班表
+// @Description 创建参与人员按固定时长轮换的值班表
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param schedule body OnCallScheduleRequest true "值班表"
+// @Success 201 {object} model.OnCallSchedule
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/oncall-schedules [post]
+func (h *EscalationHandler) CreateOnCallSchedule(c *gin.Context) {
+	var req OnCallScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule := &model.OnCallSchedule{}
+	if err := applySchedule(req, schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.escalationRepo.CreateSchedule(schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// getSchedule loads a schedule by the id path parameter and writes the error response on failure
+func (h *EscalationHandler) getSchedule(c *gin.Context) (*model.OnCallSchedule, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return nil, false
+	}
+
+	schedule, err := h.escalationRepo.GetScheduleByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "On-call schedule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	return schedule, true
+}
+
+// GetOnCallSchedule 获取单个值班表
+// @Summary 获取单个值班表
+// @Description 根据ID获取值班表及其值班替换
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param id path int true "值班表ID"
+// @Success 200 {object} model.OnCallSchedule
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/oncall-schedules/{id} [get]
+func (h *EscalationHandler) GetOnCallSchedule(c *gin.Context) {
+	schedule, ok := h.getSchedule(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateOnCallSchedule 更新值班表
+// @Summary 更新值班表
+// @Description 更新值班表的参与人员和轮换设置，值班替换保持不变
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param id path int true "值班表ID"
+// @Param schedule body OnCallScheduleRequest true "值班表"
+// @Success 200 {object} model.OnCallSchedule
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/oncall-schedules/{id} [put]
+func (h *EscalationHandler) UpdateOnCallSchedule(c *gin.Context) {
+	var req OnCallScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, ok := h.getSchedule(c)
+	if !ok {
+		return
+	}
+
+	if err := applySchedule(req, schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.escalationRepo.UpdateSchedule(schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteOnCallSchedule 删除值班表
+// @Summary 删除值班表
+// @Description 删除值班表及其值班替换
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param id path int true "值班表ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/oncall-schedules/{id} [delete]
+func (h *EscalationHandler) DeleteOnCallSchedule(c *gin.Context) {
+	schedule, ok := h.getSchedule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.escalationRepo.DeleteSchedule(schedule.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetCurrentOnCall 获取当前值班人员
+// @Summary 获取当前值班人员
+// @Description 获取值班表在指定时间（默认当前时间）的值班人员，值班替换优先
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param id path int true "值班表ID"
+// @Param at query string false "查询时间（RFC3339）"
+// @Success 200 {object} model.OnCallStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/oncall-schedules/{id}/current [get]
+func (h *EscalationHandler) GetCurrentOnCall(c *gin.Context) {
+	at := time.Now()
+	if raw := c.Query("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at parameter, expected RFC3339"})
+			return
+		}
+		at = parsed
+	}
+
+	schedule, ok := h.getSchedule(c)
+	if !ok {
+		return
+	}
+
+	user, override := service.OnCallAt(*schedule, at)
+	c.JSON(http.StatusOK, model.OnCallStatus{
+		ScheduleID: schedule.ID,
+		Name:       schedule.Name,
+		User:       user,
+		Override:   override,
+		At:         at,
+	})
+}
+
+// CreateOnCallOverride 创建值班替换
+// @Summary 创建值班替换
+// @Description 在指定时间范围内由指定人员代替轮换值班
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param id path int true "值班表ID"
+// @Param override body OnCallOverrideRequest true "值班替换"
+// @Success 201 {object} model.OnCallOverride
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/oncall-schedules/{id}/overrides [post]
+func (h *EscalationHandler) CreateOnCallOverride(c *gin.Context) {
+	var req OnCallOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.EndTime.After(req.StartTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be after start_time"})
+		return
+	}
+
+	schedule, ok := h.getSchedule(c)
+	if !ok {
+		return
+	}
+
+	override := &model.OnCallOverride{
+		ScheduleID: schedule.ID,
+		User:       strings.TrimSpace(req.User),
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+	}
+	if err := h.escalationRepo.CreateOverride(override); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, override)
+}
+
+// DeleteOnCallOverride 删除值班替换
+// @Summary 删除值班替换
+// @Description 删除值班表中的值班替换
+// @Tags escalation
+// @Accept json
+// @Produce json
+// @Param id path int true "值班表ID"
+// @Param override_id path int true "值班替换ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/oncall-schedules/{id}/overrides/{override_id} [delete]
+func (h *EscalationHandler) DeleteOnCallOverride(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+	overrideID, err := strconv.ParseUint(c.Param("override_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid override ID"})
+		return
+	}
+
+	if err := h.escalationRepo.DeleteOverride(uint(id), uint(overrideID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "On-call override not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
+)
+
+// FilesystemHandler 文件系统健康历史处理器
+type FilesystemHandler struct {
+	hostRepo   repository.HostRepository
+	sampleRepo repository.SampleRepository
+}
+
+// NewFilesystemHandler 创建文件系统健康历史处理器
+func NewFilesystemHandler(db *gorm.DB) *FilesystemHandler {
+	return &FilesystemHandler{
+		hostRepo:   repository.NewHostRepository(db),
+		sampleRepo: repository.NewSampleRepository(db),
+	}
+}
+
+// GetFilesystemHistory 获取主机文件系统健康历史
+// @Summary 获取主机文件系统健康历史
+// @Description 返回回看窗口内记录的 inode 使用率、非预期只读状态（按挂载点分组）或系统文件句柄使用率
+// @Tags hosts
+// @Accept json
+// @Produce json
+// @Param id path int true "主机ID"
+// @Param metric query string false "指标：inode, read_only, fd" default(inode)
+// @Param mountpoint query string false "挂载点"
+// @Param lookback query int false "回看窗口（秒）" default(3600)
+// @Success 200 {object} model.FilesystemHistory
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/hosts/{id}/filesystem/history [get]
+func (h *FilesystemHandler) GetFilesystemHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host ID"})
+		return
+	}
+
+	metricName := c.DefaultQuery("metric", "inode")
+	metric := service.FilesystemSample(metricName)
+	if metric == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid metric"})
+		return
+	}
+
+	lookbackSeconds, err := strconv.Atoi(c.DefaultQuery("lookback", "3600"))
+	if err != nil || lookbackSeconds <= 0 || lookbackSeconds > int(maxPreviewRange.Seconds()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lookback parameter"})
+		return
+	}
+
+	host, err := h.hostRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	now := time.Now()
+	samples, err := h.sampleRepo.GetSeries(host.Hostname, metric, now.Add(-time.Duration(lookbackSeconds)*time.Second), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 样本按标签和时间排序，相同挂载点的样本连续出现
+	mountpoint := c.Query("mountpoint")
+	history := model.FilesystemHistory{Hostname: host.Hostname, Metric: metricName, Series: []model.FilesystemSeries{}}
+	lastLabels := ""
+	for _, sample := range samples {
+		labels := model.ParseLabels(sample.Labels)
+		if mountpoint != "" && labels["mountpoint"] != mountpoint {
+			continue
+		}
+		if len(history.Series) == 0 || sample.Labels != lastLabels {
+			history.Series = append(history.Series, model.FilesystemSeries{MountPoint: labels["mountpoint"]})
+			lastLabels = sample.Labels
+		}
+		series := &history.Series[len(history.Series)-1]
+		series.Points = append(series.Points, model.FilesystemPoint{Timestamp: sample.Timestamp, Value: sample.Value})
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
+)
+
+// ForecastHandler 资源趋势预测和指标基线处理器
+type ForecastHandler struct {
+	hostRepo   repository.HostRepository
+	sampleRepo repository.SampleRepository
+	baselines  service.BaselineLearner
+}
+
+// NewForecastHandler 创建资源趋势预测处理器
+func NewForecastHandler(db *gorm.DB) *ForecastHandler {
+	return &ForecastHandler{
+		hostRepo:   repository.NewHostRepository(db),
+		sampleRepo: repository.NewSampleRepository(db),
+		baselines:  service.NewBaselineLearner(db),
+	}
+}
+
+// GetHostForecast 获取主机资源耗尽预测
+// @Summary 获取主机资源耗尽预测
+// @Description 对回看窗口内各挂载点的磁盘使用率和内存使用率进行线性回归，预测写满时间
+// @Tags hosts
+// @Accept json
+// @Produce json
+// @Param id path int true "主机ID"
+// @Param lookback query int false "回看窗口（秒）" default(21600)
+// @Success 200 {object} model.HostForecast
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/hosts/{id}/forecast [get]
+func (h *ForecastHandler) GetHostForecast(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host ID"})
+		return
+	}
+
+	lookbackSeconds, err := strconv.Atoi(c.DefaultQuery("lookback", "21600"))
+	if err != nil || lookbackSeconds <= 0 || lookbackSeconds > int(maxPreviewRange.Seconds()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lookback parameter"})
+		return
+	}
+	lookback := time.Duration(lookbackSeconds) * time.Second
+
+	host, err := h.hostRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	now := time.Now()
+	diskSamples, err := h.sampleRepo.GetSeries(host.Hostname, service.SampleDiskUsagePercent, now.Add(-lookback), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	memorySamples, err := h.sampleRepo.GetSeries(host.Hostname, service.SampleMemoryUsagePercent, now.Add(-lookback), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, service.BuildHostForecast(*host, diskSamples, memorySamples, lookback, now))
+}
+
+// GetHostBaseline 获取主机指标基线
+// @Summary 获取主机指标基线
+// @Description 返回异常检测使用的基线：按一周中的小时分桶的均值和标准差，bucket为-1表示全部样本。基线由异常检测规则的评估学习，学习窗口需与规则的回看窗口一致
+// @Tags hosts
+// @Accept json
+// @Produce json
+// @Param id path int true "主机ID"
+// @Param metric_type query string true "指标类型"
+// @Param lookback query int false "学习窗口（秒）" default(2419200)
+// @Success 200 {object} model.HostBaseline
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/hosts/{id}/baseline [get]
+func (h *ForecastHandler) GetHostBaseline(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host ID"})
+		return
+	}
+
+	metricType := c.Query("metric_type")
+	if metricType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric_type is required"})
+		return
+	}
+
+	lookbackSeconds, err := strconv.Atoi(c.DefaultQuery("lookback", "2419200"))
+	if err != nil || lookbackSeconds <= 0 || lookbackSeconds > int(maxPreviewRange.Seconds()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lookback parameter"})
+		return
+	}
+
+	host, err := h.hostRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	baseline, err := h.baselines.GetBaseline(*host, metricType, time.Duration(lookbackSeconds)*time.Second)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Baseline not learned yet"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, baseline)
+}
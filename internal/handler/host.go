@@ -275,8 +275,9 @@ func (h *HostHandler) UpdateHost(c *gin.Context) {
 	if req.Description != "" {
 		host.Description = req.Description
 	}
-	if req.Status != "" {
+	if req.Status != "" && req.Status != host.Status {
 		host.Status = req.Status
+		host.StatusReason = ""
 	}
 	if req.MonitoringEnabled != nil {
 		host.MonitoringEnabled = *req.MonitoringEnabled
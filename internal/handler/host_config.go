@@ -9,6 +9,7 @@ import (
 
 	"monitor-server/internal/model"
 	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
 )
 
 // HostConfigHandler 主机配置管理处理器
@@ -67,6 +68,25 @@ type HostConfigListResponse struct {
 	Total   int                `json:"total"`
 }
 
+// validateHostConfig checks the keys and values of config categories consumed by the server
+func validateHostConfig(category, key, value string) error {
+	if err := service.ValidateHostConfigKey(category, key); err != nil {
+		return err
+	}
+	switch category {
+	case model.HostConfigCategoryLogWatch:
+		_, _, err := service.ParseLogWatchConfig(value)
+		return err
+	case model.HostConfigCategoryCollectFilter:
+		_, err := service.ParseCollectFilterConfig(value)
+		return err
+	case model.HostConfigCategoryCollector:
+		_, err := service.ParseCollectorConfig(value)
+		return err
+	}
+	return nil
+}
+
 // CreateHostConfig 创建主机配置
 // @Summary 创建主机配置
 // @Description 为指定主机创建新的配置项
@@ -77,6 +97,7 @@ type HostConfigListResponse struct {
 // @Success 201 {object} model.HostConfig
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/host-configs [post]
 func (h *HostConfigHandler) CreateHostConfig(c *gin.Context) {
@@ -86,6 +107,11 @@ func (h *HostConfigHandler) CreateHostConfig(c *gin.Context) {
 		return
 	}
 
+	if err := validateHostConfig(req.Category, req.Key, req.Value); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 验证主机是否存在
 	_, err := h.hostRepo.GetByID(req.HostID)
 	if err != nil {
@@ -97,6 +123,15 @@ func (h *HostConfigHandler) CreateHostConfig(c *gin.Context) {
 		return
 	}
 
+	// 配置键在主机内唯一，不同分类的配置也不能共用
+	if exists, err := h.keyExists(req.HostID, req.Key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "Config key already exists: " + req.Key})
+		return
+	}
+
 	config := &model.HostConfig{
 		HostID:      req.HostID,
 		Key:         req.Key,
@@ -115,6 +150,17 @@ func (h *HostConfigHandler) CreateHostConfig(c *gin.Context) {
 	c.JSON(http.StatusCreated, config)
 }
 
+// keyExists reports whether the host already has a config with the key
+func (h *HostConfigHandler) keyExists(hostID uint, key string) (bool, error) {
+	if _, err := h.hostConfigRepo.GetByHostIDAndKey(hostID, key); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 // GetHostConfigs 获取主机配置列表
 // @Summary 获取主机配置列表
 // @Description 获取指定主机的配置列表
@@ -250,6 +296,10 @@ func (h *HostConfigHandler) UpdateHostConfig(c *gin.Context) {
 	if req.Editable != nil {
 		config.Editable = *req.Editable
 	}
+	if err := validateHostConfig(config.Category, config.Key, config.Value); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := h.hostConfigRepo.Update(config); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -306,6 +356,7 @@ func (h *HostConfigHandler) DeleteHostConfig(c *gin.Context) {
 // @Success 201 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/host-configs/batch [post]
 func (h *HostConfigHandler) BatchCreateHostConfigs(c *gin.Context) {
@@ -332,7 +383,22 @@ func (h *HostConfigHandler) BatchCreateHostConfigs(c *gin.Context) {
 	}
 
 	var configs []model.HostConfig
+	keys := make(map[string]bool, len(req.Configs))
 	for _, configReq := range req.Configs {
+		if err := validateHostConfig(configReq.Category, configReq.Key, configReq.Value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": configReq.Key + ": " + err.Error()})
+			return
+		}
+		exists, err := h.keyExists(req.HostID, configReq.Key)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if exists || keys[configReq.Key] {
+			c.JSON(http.StatusConflict, gin.H{"error": "Config key already exists: " + configReq.Key})
+			return
+		}
+		keys[configReq.Key] = true
 		config := model.HostConfig{
 			HostID:      req.HostID,
 			Key:         configReq.Key,
@@ -425,7 +491,7 @@ func (h *HostConfigHandler) UpdateHostConfigValue(c *gin.Context) {
 	}
 
 	// 检查配置是否存在
-	_, err = h.hostConfigRepo.GetByHostIDAndKey(uint(hostID), key)
+	config, err := h.hostConfigRepo.GetByHostIDAndKey(uint(hostID), key)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Config not found"})
@@ -434,6 +500,10 @@ func (h *HostConfigHandler) UpdateHostConfigValue(c *gin.Context) {
 		}
 		return
 	}
+	if err := validateHostConfig(config.Category, config.Key, req.Value); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := h.hostConfigRepo.UpdateValue(uint(hostID), key, req.Value); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
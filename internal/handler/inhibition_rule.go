@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
+)
+
+// InhibitionRuleHandler 告警抑制规则管理处理器
+type InhibitionRuleHandler struct {
+	ruleRepo repository.InhibitionRuleRepository
+}
+
+// NewInhibitionRuleHandler 创建告警抑制规则管理处理器
+func NewInhibitionRuleHandler(db *gorm.DB) *InhibitionRuleHandler {
+	return &InhibitionRuleHandler{
+		ruleRepo: repository.NewInhibitionRuleRepository(db),
+	}
+}
+
+// InhibitionRuleRequest 创建或更新告警抑制规则请求
+type InhibitionRuleRequest struct {
+	Name           string `json:"name" binding:"required"`
+	SourceMatchers string `json:"source_matchers" binding:"required"` // 源告警标签选择器，如 metric_type=up
+	TargetMatchers string `json:"target_matchers" binding:"required"` // 目标告警标签选择器，如 metric_type!=up
+	Equal          string `json:"equal"`                              // 必须相同的标签，逗号分隔，默认 hostname
+	Enabled        *bool  `json:"enabled"`
+	Description    string `json:"description"`
+}
+
+// InhibitionRuleListResponse 告警抑制规则列表响应
+type InhibitionRuleListResponse struct {
+	Rules []model.InhibitionRule `json:"rules"`
+	Total int                    `json:"total"`
+}
+
+// apply validates the request and copies it onto the rule
+func (req InhibitionRuleRequest) apply(rule *model.InhibitionRule) error {
+	source, err := service.ParseSelector(req.SourceMatchers)
+	if err != nil {
+		return err
+	}
+	target, err := service.ParseSelector(req.TargetMatchers)
+	if err != nil {
+		return err
+	}
+
+	var equal []string
+	for _, key := range strings.Split(req.Equal, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			equal = append(equal, key)
+		}
+	}
+	if len(equal) == 0 {
+		equal = []string{"hostname"}
+	}
+
+	rule.Name = req.Name
+	rule.SourceMatchers = source.String()
+	rule.TargetMatchers = target.String()
+	rule.Equal = strings.Join(equal, ",")
+	rule.Enabled = req.Enabled == nil || *req.Enabled
+	rule.Description = req.Description
+	return nil
+}
+
+// GetInhibitionRules 获取告警抑制规则列表
+// @Summary 获取告警抑制规则列表
+// @Description 获取所有告警抑制规则
+// @Tags inhibition-rules
+// @Accept json
+// @Produce json
+// @Success 200 {object} InhibitionRuleListResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/inhibition-rules [get]
+func (h *InhibitionRuleHandler) GetInhibitionRules(c *gin.Context) {
+	rules, err := h.ruleRepo.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, InhibitionRuleListResponse{
+		Rules: rules,
+		Total: len(rules),
+	})
+}
+
+// CreateInhibitionRule 创建告警抑制规则
+// @Summary 创建告警抑制规则
+// @Description 创建告警抑制规则，源告警活动期间抑制标签相同的目标告警
+// @Tags inhibition-rules
+// @Accept json
+// @Produce json
+// @Param rule body InhibitionRuleRequest true "告警抑制规则"
+// @Success 201 {object} model.InhibitionRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/inhibition-rules [post]
+func (h *InhibitionRuleHandler) CreateInhibitionRule(c *gin.Context) {
+	var req InhibitionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := &model.InhibitionRule{}
+	if err := req.apply(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.ruleRepo.Create(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetInhibitionRule 获取单个告警抑制规则
+// @Summary 获取单个告警抑制规则
+// @Description 根据ID获取告警抑制规则
+// @Tags inhibition-rules
+// @Accept json
+// @Produce json
+// @Param id path int true "告警抑制规则ID"
+// @Success 200 {object} model.InhibitionRule
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/inhibition-rules/{id} [get]
+func (h *InhibitionRuleHandler) GetInhibitionRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	rule, err := h.ruleRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Inhibition rule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateInhibitionRule 更新告警抑制规则
+// @Summary 更新告警抑制规则
+// @Description 使用请求内容整体替换告警抑制规则，下一轮告警评估时生效
+// @Tags inhibition-rules
+// @Accept json
+// @Produce json
+// @Param id path int true "告警抑制规则ID"
+// @Param rule body InhibitionRuleRequest true "告警抑制规则"
+// @Success 200 {object} model.InhibitionRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/inhibition-rules/{id} [put]
+func (h *InhibitionRuleHandler) UpdateInhibitionRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	var req InhibitionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.ruleRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Inhibition rule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := req.apply(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.ruleRepo.Update(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteInhibitionRule 删除告警抑制规则
+// @Summary 删除告警抑制规则
+// @Description 删除告警抑制规则，被其抑制的告警在下一轮告警评估时恢复为活动状态
+// @Tags inhibition-rules
+// @Accept json
+// @Produce json
+// @Param id path int true "告警抑制规则ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/inhibition-rules/{id} [delete]
+func (h *InhibitionRuleHandler) DeleteInhibitionRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	if _, err := h.ruleRepo.GetByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Inhibition rule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.ruleRepo.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+)
+
+// LogEventHandler 日志匹配事件处理器
+type LogEventHandler struct {
+	eventRepo repository.LogEventRepository
+	hostRepo  repository.HostRepository
+}
+
+// NewLogEventHandler 创建日志匹配事件处理器
+func NewLogEventHandler(db *gorm.DB) *LogEventHandler {
+	return &LogEventHandler{
+		eventRepo: repository.NewLogEventRepository(db),
+		hostRepo:  repository.NewHostRepository(db),
+	}
+}
+
+// LogEventListResponse 日志匹配事件列表响应
+type LogEventListResponse struct {
+	Events []model.LogEvent `json:"events"`
+	Total  int              `json:"total"`
+}
+
+// GetLogEvents 获取主机日志匹配事件
+// @Summary 获取主机日志匹配事件
+// @Description 获取主机日志监视匹配到的样例行，按匹配时间倒序。日志监视在主机配置中以 log_watch 分类配置，配置键为 logwatch:<监视名称>
+// @Tags hosts
+// @Accept json
+// @Produce json
+// @Param id path int true "主机ID"
+// @Param watch query string false "监视名称"
+// @Param since query string false "开始时间，RFC3339 格式，默认24小时前"
+// @Param limit query int false "返回数量，默认100，最大1000"
+// @Success 200 {object} LogEventListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/hosts/{id}/log-events [get]
+func (h *LogEventHandler) GetLogEvents(c *gin.Context) {
+	hostID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host ID"})
+		return
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	if value := c.Query("since"); value != "" {
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since, expected RFC3339"})
+			return
+		}
+	}
+
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit, expected 1-1000"})
+			return
+		}
+	}
+
+	if _, err := h.hostRepo.GetByID(uint(hostID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	events, err := h.eventRepo.List(uint(hostID), c.Query("watch"), since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, LogEventListResponse{
+		Events: events,
+		Total:  len(events),
+	})
+}
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"strconv"
 
+	"monitor-server/internal/model"
 	"monitor-server/internal/service"
 	"monitor-server/pkg/logger"
 	"monitor-server/pkg/response"
@@ -88,7 +91,9 @@ func (h *MonitorHandler) GetSystem(c *gin.Context) {
 func (h *MonitorHandler) GetProcesses(c *gin.Context) {
 	// Parse query parameters
 	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
 	sortBy := c.DefaultQuery("sort", "cpu")
+	order := c.Query("order")
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit <= 0 {
@@ -96,12 +101,33 @@ func (h *MonitorHandler) GetProcesses(c *gin.Context) {
 		return
 	}
 
-	if sortBy != "cpu" && sortBy != "memory" {
-		response.BadRequest(c, "Invalid sort parameter. Must be 'cpu' or 'memory'")
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		response.BadRequest(c, "Invalid offset parameter")
 		return
 	}
 
-	data, err := h.monitorService.GetProcessData(c.Request.Context(), limit, sortBy)
+	if !service.ValidProcessSort(sortBy) {
+		response.BadRequest(c, "Invalid sort parameter. Must be a process field such as 'cpu', 'memory', 'pid', 'name' or 'create_time'")
+		return
+	}
+
+	if order != "" && order != "asc" && order != "desc" {
+		response.BadRequest(c, "Invalid order parameter. Must be 'asc' or 'desc'")
+		return
+	}
+
+	query := model.ProcessQuery{
+		Name:    c.Query("name"),
+		User:    c.Query("user"),
+		Status:  c.Query("status"),
+		Cmdline: c.Query("cmdline"),
+		SortBy:  sortBy,
+		Order:   order,
+		Offset:  offset,
+		Limit:   limit,
+	}
+	data, err := h.monitorService.GetProcessData(c.Request.Context(), query)
 	if err != nil {
 		h.logger.Error("Failed to get process data", "error", err)
 		response.InternalServerError(c, "Failed to retrieve process data")
@@ -109,4 +135,122 @@ func (h *MonitorHandler) GetProcesses(c *gin.Context) {
 	}
 
 	response.Success(c, data)
-}
\ No newline at end of file
+}
+
+// GetProcess handles GET /api/v1/processes/:pid requests
+func (h *MonitorHandler) GetProcess(c *gin.Context) {
+	pid, err := strconv.ParseInt(c.Param("pid"), 10, 32)
+	if err != nil || pid <= 0 {
+		response.BadRequest(c, "Invalid pid parameter")
+		return
+	}
+
+	data, err := h.monitorService.GetProcessDetail(c.Request.Context(), int32(pid))
+	if err != nil {
+		if errors.Is(err, service.ErrProcessNotFound) {
+			response.NotFound(c, "Process not found")
+			return
+		}
+		h.logger.Error("Failed to get process detail", "pid", pid, "error", err)
+		response.InternalServerError(c, "Failed to retrieve process detail")
+		return
+	}
+
+	response.Success(c, data)
+}
+
+// GetProcessTree handles GET /api/v1/processes/tree requests
+func (h *MonitorHandler) GetProcessTree(c *gin.Context) {
+	var rootPID int64
+	if rootStr := c.Query("root"); rootStr != "" {
+		var err error
+		rootPID, err = strconv.ParseInt(rootStr, 10, 32)
+		if err != nil || rootPID <= 0 {
+			response.BadRequest(c, "Invalid root parameter")
+			return
+		}
+	}
+
+	collapse, err := strconv.ParseBool(c.DefaultQuery("collapse", "false"))
+	if err != nil {
+		response.BadRequest(c, "Invalid collapse parameter")
+		return
+	}
+
+	data, err := h.monitorService.GetProcessTree(c.Request.Context(), int32(rootPID), collapse)
+	if err != nil {
+		if errors.Is(err, service.ErrProcessNotFound) {
+			response.NotFound(c, "Process not found")
+			return
+		}
+		h.logger.Error("Failed to get process tree", "error", err)
+		response.InternalServerError(c, "Failed to retrieve process tree")
+		return
+	}
+
+	response.Success(c, data)
+}
+
+// GetConnections handles GET /api/v1/connections requests
+func (h *MonitorHandler) GetConnections(c *gin.Context) {
+	top, err := strconv.Atoi(c.DefaultQuery("top", "20"))
+	if err != nil || top < 0 {
+		response.BadRequest(c, "Invalid top parameter")
+		return
+	}
+
+	data, err := h.monitorService.GetConnectionData(c.Request.Context(), top)
+	if err != nil {
+		h.logger.Error("Failed to get connection data", "error", err)
+		response.InternalServerError(c, "Failed to retrieve connection data")
+		return
+	}
+
+	response.Success(c, data)
+}
+
+// GetSensors handles GET /api/v1/sensors requests
+func (h *MonitorHandler) GetSensors(c *gin.Context) {
+	data, err := h.monitorService.GetSensorData(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get sensor data", "error", err)
+		response.InternalServerError(c, "Failed to retrieve sensor data")
+		return
+	}
+
+	response.Success(c, data)
+}
+
+// GetCollectFilterPreview handles GET /api/v1/collect-filter/preview requests
+func (h *MonitorHandler) GetCollectFilterPreview(c *gin.Context) {
+	h.previewCollectFilter(c, nil)
+}
+
+// PreviewCollectFilter handles POST /api/v1/collect-filter/preview requests.
+// The body holds host rules, as stored in a collect_filter host config, to try before saving them.
+func (h *MonitorHandler) PreviewCollectFilter(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		response.BadRequest(c, "Failed to read request body")
+		return
+	}
+	rules, err := service.ParseCollectFilterConfig(string(body))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	h.previewCollectFilter(c, rules)
+}
+
+// previewCollectFilter lists the local mounts and interfaces matched by the effective or given rules
+func (h *MonitorHandler) previewCollectFilter(c *gin.Context, rules *model.CollectFilter) {
+	data, err := h.monitorService.PreviewCollectFilter(c.Request.Context(), rules)
+	if err != nil {
+		h.logger.Error("Failed to preview collect filter", "error", err)
+		response.InternalServerError(c, "Failed to preview collect filter")
+		return
+	}
+
+	response.Success(c, data)
+}
@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
+)
+
+// NagiosCheckHandler Nagios 插件检查管理处理器
+type NagiosCheckHandler struct {
+	checkRepo repository.NagiosCheckRepository
+	hostRepo  repository.HostRepository
+	executor  service.NagiosExecutor
+}
+
+// NewNagiosCheckHandler 创建 Nagios 插件检查管理处理器
+func NewNagiosCheckHandler(db *gorm.DB, executor service.NagiosExecutor) *NagiosCheckHandler {
+	return &NagiosCheckHandler{
+		checkRepo: repository.NewNagiosCheckRepository(db),
+		hostRepo:  repository.NewHostRepository(db),
+		executor:  executor,
+	}
+}
+
+// NagiosCheckRequest 创建或更新 Nagios 插件检查请求
+type NagiosCheckRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	HostID      uint     `json:"host_id" binding:"required"` // 检查结果归属的主机
+	Command     string   `json:"command" binding:"required"` // 插件绝对路径，必须位于配置的插件目录中
+	Arguments   []string `json:"arguments"`                  // 不经过 shell，支持 $HOSTNAME$ 和 $HOSTADDRESS$
+	Timeout     int      `json:"timeout"`                    // 秒，默认 10
+	Interval    int      `json:"interval"`                   // 秒，默认 60
+	Enabled     *bool    `json:"enabled"`
+	Description string   `json:"description"`
+}
+
+// NagiosCheckListResponse Nagios 插件检查列表响应
+type NagiosCheckListResponse struct {
+	Checks []model.NagiosCheck `json:"checks"`
+	Total  int                 `json:"total"`
+}
+
+// apply validates the request and copies it onto the check
+func (req NagiosCheckRequest) apply(check *model.NagiosCheck, executor service.NagiosExecutor) error {
+	command, err := executor.ValidateCommand(req.Command)
+	if err != nil {
+		return err
+	}
+
+	timeout, interval, err := validateProbeSchedule(req.Timeout, req.Interval)
+	if err != nil {
+		return err
+	}
+
+	arguments := ""
+	if len(req.Arguments) > 0 {
+		encoded, err := json.Marshal(req.Arguments)
+		if err != nil {
+			return err
+		}
+		arguments = string(encoded)
+	}
+
+	check.Name = req.Name
+	check.HostID = req.HostID
+	check.Command = command
+	check.Arguments = arguments
+	check.Timeout = timeout
+	check.Interval = interval
+	check.Enabled = req.Enabled == nil || *req.Enabled
+	check.Description = req.Description
+	return nil
+}
+
+// checkHost verifies that the host of a check exists and writes the error response otherwise
+func (h *NagiosCheckHandler) checkHost(c *gin.Context, hostID uint) bool {
+	if _, err := h.hostRepo.GetByID(hostID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Host not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// GetNagiosChecks 获取 Nagios 插件检查列表
+// @Summary 获取 Nagios 插件检查列表
+// @Description 获取 Nagios 插件检查及其最近一次检查结果
+// @Tags nagios-checks
+// @Accept json
+// @Produce json
+// @Param host_id query int false "主机ID，不提供则返回所有检查"
+// @Success 200 {object} NagiosCheckListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nagios-checks [get]
+func (h *NagiosCheckHandler) GetNagiosChecks(c *gin.Context) {
+	var hostID uint
+	if hostIDStr := c.Query("host_id"); hostIDStr != "" {
+		id, err := strconv.ParseUint(hostIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host_id parameter"})
+			return
+		}
+		hostID = uint(id)
+	}
+
+	checks, err := h.checkRepo.List(hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, NagiosCheckListResponse{
+		Checks: checks,
+		Total:  len(checks),
+	})
+}
+
+// CreateNagiosCheck 创建 Nagios 插件检查
+// @Summary 创建 Nagios 插件检查
+// @Description 创建按间隔执行插件的检查，退出码记为检查状态，性能数据记为所属主机的 nagios_perf 指标
+// @Tags nagios-checks
+// @Accept json
+// @Produce json
+// @Param check body NagiosCheckRequest true "Nagios 插件检查"
+// @Success 201 {object} model.NagiosCheck
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nagios-checks [post]
+func (h *NagiosCheckHandler) CreateNagiosCheck(c *gin.Context) {
+	var req NagiosCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check := &model.NagiosCheck{LastState: model.NagiosStateUnknown}
+	if err := req.apply(check, h.executor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkHost(c, check.HostID) {
+		return
+	}
+
+	if err := h.checkRepo.Create(check); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, check)
+}
+
+// GetNagiosCheck 获取单个 Nagios 插件检查
+// @Summary 获取单个 Nagios 插件检查
+// @Description 根据ID获取 Nagios 插件检查及其最近一次检查结果
+// @Tags nagios-checks
+// @Accept json
+// @Produce json
+// @Param id path int true "Nagios 插件检查ID"
+// @Success 200 {object} model.NagiosCheck
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nagios-checks/{id} [get]
+func (h *NagiosCheckHandler) GetNagiosCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.checkRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nagios check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// UpdateNagiosCheck 更新 Nagios 插件检查
+// @Summary 更新 Nagios 插件检查
+// @Description 使用请求内容整体替换 Nagios 插件检查配置
+// @Tags nagios-checks
+// @Accept json
+// @Produce json
+// @Param id path int true "Nagios 插件检查ID"
+// @Param check body NagiosCheckRequest true "Nagios 插件检查"
+// @Success 200 {object} model.NagiosCheck
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nagios-checks/{id} [put]
+func (h *NagiosCheckHandler) UpdateNagiosCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	var req NagiosCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, err := h.checkRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nagios check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := req.apply(check, h.executor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkHost(c, check.HostID) {
+		return
+	}
+
+	check.Host = nil
+	if err := h.checkRepo.Update(check); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// DeleteNagiosCheck 删除 Nagios 插件检查
+// @Summary 删除 Nagios 插件检查
+// @Description 删除 Nagios 插件检查，已记录的指标保留至过期清理
+// @Tags nagios-checks
+// @Accept json
+// @Produce json
+// @Param id path int true "Nagios 插件检查ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nagios-checks/{id} [delete]
+func (h *NagiosCheckHandler) DeleteNagiosCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	if _, err := h.checkRepo.GetByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nagios check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.checkRepo.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RunNagiosCheck 立即执行 Nagios 插件检查
+// @Summary 立即执行 Nagios 插件检查
+// @Description 立即执行一次插件，记录并返回检查结果
+// @Tags nagios-checks
+// @Accept json
+// @Produce json
+// @Param id path int true "Nagios 插件检查ID"
+// @Success 200 {object} model.NagiosResult
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/nagios-checks/{id}/run [post]
+func (h *NagiosCheckHandler) RunNagiosCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.checkRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nagios check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, h.executor.RunCheck(check))
+}
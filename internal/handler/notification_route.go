@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
+)
+
+// NotificationRouteHandler 通知路由管理处理器
+type NotificationRouteHandler struct {
+	routeRepo  repository.NotificationRouteRepository
+	dispatcher service.NotificationDispatcher
+}
+
+// NewNotificationRouteHandler 创建通知路由管理处理器
+func NewNotificationRouteHandler(db *gorm.DB, dispatcher service.NotificationDispatcher) *NotificationRouteHandler {
+	return &NotificationRouteHandler{
+		routeRepo:  repository.NewNotificationRouteRepository(db),
+		dispatcher: dispatcher,
+	}
+}
+
+// NotificationRouteRequest 创建或更新通知路由请求
+type NotificationRouteRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Matchers       string `json:"matchers"`        // 告警标签选择器，如 severity=critical,env=prod
+	GroupBy        string `json:"group_by"`        // 分组标签，逗号分隔，如 host_group,env
+	GroupWait      int    `json:"group_wait"`      // 秒，0表示使用全局默认值
+	GroupInterval  int    `json:"group_interval"`  // 秒，0表示使用全局默认值
+	RepeatInterval int    `json:"repeat_interval"` // 秒，0表示使用全局默认值
+	Receiver       string `json:"receiver"`        // log（默认）, webhook
+	WebhookURL     string `json:"webhook_url"`
+	Priority       int    `json:"priority"`
+	Continue       bool   `json:"continue"`
+	Enabled        *bool  `json:"enabled"`
+	Description    string `json:"description"`
+}
+
+// NotificationRouteListResponse 通知路由列表响应
+type NotificationRouteListResponse struct {
+	Routes []model.NotificationRoute `json:"routes"`
+	Total  int                       `json:"total"`
+}
+
+// NotificationGroupListResponse 通知分组列表响应
+type NotificationGroupListResponse struct {
+	Groups []model.NotificationGroupStatus `json:"groups"`
+	Total  int                             `json:"total"`
+}
+
+// apply validates the request and copies it onto the route
+func (req NotificationRouteRequest) apply(route *model.NotificationRoute) error {
+	matchers := strings.TrimSpace(req.Matchers)
+	if matchers != "" {
+		selector, err := service.ParseSelector(matchers)
+		if err != nil {
+			return err
+		}
+		matchers = selector.String()
+	}
+
+	var groupBy []string
+	for _, key := range strings.Split(req.GroupBy, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			groupBy = append(groupBy, key)
+		}
+	}
+
+	if req.GroupWait < 0 || req.GroupInterval < 0 || req.RepeatInterval < 0 {
+		return fmt.Errorf("group_wait, group_interval and repeat_interval cannot be negative")
+	}
+
+	receiver, err := validateReceiver(req.Receiver, req.WebhookURL)
+	if err != nil {
+		return err
+	}
+
+	route.Name = req.Name
+	route.Matchers = matchers
+	route.GroupBy = strings.Join(groupBy, ",")
+	route.GroupWait = req.GroupWait
+	route.GroupInterval = req.GroupInterval
+	route.RepeatInterval = req.RepeatInterval
+	route.Receiver = receiver
+	route.WebhookURL = req.WebhookURL
+	route.Priority = req.Priority
+	route.Continue = req.Continue
+	route.Enabled = req.Enabled == nil || *req.Enabled
+	route.Description = req.Description
+	return nil
+}
+
+// validateReceiver checks a receiver and its webhook URL, defaulting to the log receiver
+func validateReceiver(receiver, webhookURL string) (string, error) {
+	if receiver == "" {
+		receiver = model.ReceiverLog
+	}
+	switch receiver {
+	case model.ReceiverLog:
+	case model.ReceiverWebhook:
+		parsed, err := url.Parse(webhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "", fmt.Errorf("webhook receiver requires a valid http(s) webhook_url")
+		}
+	default:
+		return "", fmt.Errorf("unsupported receiver: %s", receiver)
+	}
+	return receiver, nil
+}
+
+// GetNotificationRoutes 获取通知路由列表
+// @Summary 获取通知路由列表
+// @Description 按匹配优先级获取所有通知路由
+// @Tags notification-routes
+// @Accept json
+// @Produce json
+// @Success 200 {object} NotificationRouteListResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/notification-routes [get]
+func (h *NotificationRouteHandler) GetNotificationRoutes(c *gin.Context) {
+	routes, err := h.routeRepo.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, NotificationRouteListResponse{
+		Routes: routes,
+		Total:  len(routes),
+	})
+}
+
+// CreateNotificationRoute 创建通知路由
+// @Summary 创建通知路由
+// @Description 创建按标签匹配告警、按分组键聚合发送的通知路由
+// @Tags notification-routes
+// @Accept json
+// @Produce json
+// @Param route body NotificationRouteRequest true "通知路由"
+// @Success 201 {object} model.NotificationRoute
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/notification-routes [post]
+func (h *NotificationRouteHandler) CreateNotificationRoute(c *gin.Context) {
+	var req NotificationRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	route := &model.NotificationRoute{}
+	if err := req.apply(route); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.routeRepo.Create(route); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, route)
+}
+
+// GetNotificationRoute 获取单个通知路由
+// @Summary 获取单个通知路由
+// @Description 根据ID获取通知路由
+// @Tags notification-routes
+// @Accept json
+// @Produce json
+// @Param id path int true "通知路由ID"
+// @Success 200 {object} model.NotificationRoute
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/notification-routes/{id} [get]
+func (h *NotificationRouteHandler) GetNotificationRoute(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+		return
+	}
+
+	route, err := h.routeRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification route not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
+
+// UpdateNotificationRoute 更新通知路由
+// @Summary 更新通知路由
+// @Description 使用请求内容整体替换通知路由配置
+// @Tags notification-routes
+// @Accept json
+// @Produce json
+// @Param id path int true "通知路由ID"
+// @Param route body NotificationRouteRequest true "通知路由"
+// @Success 200 {object} model.NotificationRoute
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/notification-routes/{id} [put]
+func (h *NotificationRouteHandler) UpdateNotificationRoute(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+		return
+	}
+
+	var req NotificationRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	route, err := h.routeRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification route not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := req.apply(route); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.routeRepo.Update(route); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
+
+// DeleteNotificationRoute 删除通知路由
+// @Summary 删除通知路由
+// @Description 删除通知路由，已在分组中的告警按原路由完成发送
+// @Tags notification-routes
+// @Accept json
+// @Produce json
+// @Param id path int true "通知路由ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/notification-routes/{id} [delete]
+func (h *NotificationRouteHandler) DeleteNotificationRoute(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+		return
+	}
+
+	if _, err := h.routeRepo.GetByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification route not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.routeRepo.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetNotificationGroups 获取待发送的通知分组
+// @Summary 获取待发送的通知分组
+// @Description 查看当前聚合中的告警分组及其下次发送时间
+// @Tags notification-routes
+// @Accept json
+// @Produce json
+// @Success 200 {object} NotificationGroupListResponse
+// @Router /api/v1/notification-groups [get]
+func (h *NotificationRouteHandler) GetNotificationGroups(c *gin.Context) {
+	groups := h.dispatcher.Groups(time.Now())
+	c.JSON(http.StatusOK, NotificationGroupListResponse{
+		Groups: groups,
+		Total:  len(groups),
+	})
+}
@@ -0,0 +1,964 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
+)
+
+// ProbeHandler 服务探测管理处理器
+type ProbeHandler struct {
+	httpCheckRepo repository.HTTPCheckRepository
+	tcpCheckRepo  repository.TCPCheckRepository
+	dnsCheckRepo  repository.DNSCheckRepository
+	certCheckRepo repository.CertificateCheckRepository
+	hostRepo      repository.HostRepository
+	scheduler     service.ProbeScheduler
+	localHostname string // 运行服务端的主机，文件来源的证书探测只能归属于该主机
+}
+
+// NewProbeHandler 创建服务探测管理处理器
+func NewProbeHandler(db *gorm.DB, scheduler service.ProbeScheduler, localHostname string) *ProbeHandler {
+	return &ProbeHandler{
+		httpCheckRepo: repository.NewHTTPCheckRepository(db),
+		tcpCheckRepo:  repository.NewTCPCheckRepository(db),
+		dnsCheckRepo:  repository.NewDNSCheckRepository(db),
+		certCheckRepo: repository.NewCertificateCheckRepository(db),
+		hostRepo:      repository.NewHostRepository(db),
+		scheduler:     scheduler,
+		localHostname: localHostname,
+	}
+}
+
+// HTTPCheckRequest 创建或更新 HTTP 探测请求
+type HTTPCheckRequest struct {
+	Name           string            `json:"name" binding:"required"`
+	HostID         uint              `json:"host_id" binding:"required"` // 探测结果归属的主机
+	URL            string            `json:"url" binding:"required"`
+	Method         string            `json:"method"` // 默认 GET
+	Headers        map[string]string `json:"headers"`
+	ExpectedStatus int               `json:"expected_status"` // 默认 200
+	BodyRegex      string            `json:"body_regex"`
+	SkipTLSVerify  bool              `json:"skip_tls_verify"`
+	Timeout        int               `json:"timeout"`  // 秒，默认 10
+	Interval       int               `json:"interval"` // 秒，默认 60
+	Enabled        *bool             `json:"enabled"`
+	Description    string            `json:"description"`
+}
+
+// HTTPCheckListResponse HTTP 探测列表响应
+type HTTPCheckListResponse struct {
+	Checks []model.HTTPCheck `json:"checks"`
+	Total  int               `json:"total"`
+}
+
+// TCPCheckRequest 创建或更新 TCP 探测请求
+type TCPCheckRequest struct {
+	Name              string `json:"name" binding:"required"`
+	HostID            uint   `json:"host_id" binding:"required"`
+	Address           string `json:"address" binding:"required"` // host:port
+	Send              string `json:"send"`
+	BannerRegex       string `json:"banner_regex"`
+	Timeout           int    `json:"timeout"`  // 秒，默认 10
+	Interval          int    `json:"interval"` // 秒，默认 60
+	AffectsHostStatus bool   `json:"affects_host_status"`
+	FailureThreshold  int    `json:"failure_threshold"` // 默认 3
+	Enabled           *bool  `json:"enabled"`
+	Description       string `json:"description"`
+}
+
+// TCPCheckListResponse TCP 探测列表响应
+type TCPCheckListResponse struct {
+	Checks []model.TCPCheck `json:"checks"`
+	Total  int              `json:"total"`
+}
+
+// DNSCheckRequest 创建或更新 DNS 探测请求
+type DNSCheckRequest struct {
+	Name              string `json:"name" binding:"required"`
+	HostID            uint   `json:"host_id" binding:"required"`
+	QueryName         string `json:"query_name" binding:"required"`
+	RecordType        string `json:"record_type"` // A（默认）, AAAA, CNAME, MX, TXT, NS
+	ExpectedAnswer    string `json:"expected_answer"`
+	Resolver          string `json:"resolver"` // ip 或 ip:port，为空使用系统解析器
+	Timeout           int    `json:"timeout"`  // 秒，默认 10
+	Interval          int    `json:"interval"` // 秒，默认 60
+	AffectsHostStatus bool   `json:"affects_host_status"`
+	FailureThreshold  int    `json:"failure_threshold"` // 默认 3
+	Enabled           *bool  `json:"enabled"`
+	Description       string `json:"description"`
+}
+
+// DNSCheckListResponse DNS 探测列表响应
+type DNSCheckListResponse struct {
+	Checks []model.DNSCheck `json:"checks"`
+	Total  int              `json:"total"`
+}
+
+// probeMethods HTTP 探测支持的请求方法
+var probeMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodOptions: true,
+}
+
+// apply validates the request and copies it onto the check
+func (req HTTPCheckRequest) apply(check *model.HTTPCheck) error {
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url must be a valid http(s) URL")
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !probeMethods[method] {
+		return fmt.Errorf("unsupported method: %s", req.Method)
+	}
+
+	expectedStatus := req.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+	if expectedStatus < 100 || expectedStatus > 599 {
+		return fmt.Errorf("expected_status must be between 100 and 599")
+	}
+
+	if req.BodyRegex != "" {
+		if _, err := regexp.Compile(req.BodyRegex); err != nil {
+			return fmt.Errorf("invalid body_regex: %v", err)
+		}
+	}
+
+	timeout, interval, err := validateProbeSchedule(req.Timeout, req.Interval)
+	if err != nil {
+		return err
+	}
+
+	headers := ""
+	if len(req.Headers) > 0 {
+		encoded, err := json.Marshal(req.Headers)
+		if err != nil {
+			return err
+		}
+		headers = string(encoded)
+	}
+
+	check.Name = req.Name
+	check.HostID = req.HostID
+	check.URL = req.URL
+	check.Method = method
+	check.Headers = headers
+	check.ExpectedStatus = expectedStatus
+	check.BodyRegex = req.BodyRegex
+	check.SkipTLSVerify = req.SkipTLSVerify
+	check.Timeout = timeout
+	check.Interval = interval
+	check.Enabled = req.Enabled == nil || *req.Enabled
+	check.Description = req.Description
+	return nil
+}
+
+// apply validates the request and copies it onto the check
+func (req TCPCheckRequest) apply(check *model.TCPCheck) error {
+	host, port, err := net.SplitHostPort(req.Address)
+	if err != nil || host == "" {
+		return fmt.Errorf("address must be in host:port form")
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("invalid port: %s", port)
+	}
+
+	if req.BannerRegex != "" {
+		if _, err := regexp.Compile(req.BannerRegex); err != nil {
+			return fmt.Errorf("invalid banner_regex: %v", err)
+		}
+	}
+
+	timeout, interval, err := validateProbeSchedule(req.Timeout, req.Interval)
+	if err != nil {
+		return err
+	}
+	threshold, err := validateFailureThreshold(req.FailureThreshold)
+	if err != nil {
+		return err
+	}
+
+	check.Name = req.Name
+	check.HostID = req.HostID
+	check.Address = req.Address
+	check.Send = req.Send
+	check.BannerRegex = req.BannerRegex
+	check.Timeout = timeout
+	check.Interval = interval
+	check.AffectsHostStatus = req.AffectsHostStatus
+	check.FailureThreshold = threshold
+	check.Enabled = req.Enabled == nil || *req.Enabled
+	check.Description = req.Description
+	return nil
+}
+
+// apply validates the request and copies it onto the check
+func (req DNSCheckRequest) apply(check *model.DNSCheck) error {
+	recordType := strings.ToUpper(req.RecordType)
+	if recordType == "" {
+		recordType = "A"
+	}
+	if !service.ValidDNSRecordType(recordType) {
+		return fmt.Errorf("unsupported record_type: %s", req.RecordType)
+	}
+
+	// 解析器地址未指定端口时使用53端口
+	resolver := strings.TrimSpace(req.Resolver)
+	if resolver != "" {
+		if net.ParseIP(resolver) != nil {
+			resolver = net.JoinHostPort(resolver, "53")
+		}
+		host, port, err := net.SplitHostPort(resolver)
+		if err != nil || net.ParseIP(host) == nil {
+			return fmt.Errorf("resolver must be an IP address with an optional port")
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("invalid resolver port: %s", port)
+		}
+	}
+
+	timeout, interval, err := validateProbeSchedule(req.Timeout, req.Interval)
+	if err != nil {
+		return err
+	}
+	threshold, err := validateFailureThreshold(req.FailureThreshold)
+	if err != nil {
+		return err
+	}
+
+	check.Name = req.Name
+	check.HostID = req.HostID
+	check.QueryName = strings.TrimSpace(req.QueryName)
+	check.RecordType = recordType
+	check.ExpectedAnswer = strings.TrimSpace(req.ExpectedAnswer)
+	check.Resolver = resolver
+	check.Timeout = timeout
+	check.Interval = interval
+	check.AffectsHostStatus = req.AffectsHostStatus
+	check.FailureThreshold = threshold
+	check.Enabled = req.Enabled == nil || *req.Enabled
+	check.Description = req.Description
+	return nil
+}
+
+// validateProbeSchedule applies the default timeout and interval of a check and validates them
+func validateProbeSchedule(timeout, interval int) (int, int, error) {
+	if timeout == 0 {
+		timeout = 10
+	}
+	if interval == 0 {
+		interval = 60
+	}
+	if timeout < 1 || timeout > 60 {
+		return 0, 0, fmt.Errorf("timeout must be between 1 and 60 seconds")
+	}
+	if interval < 10 {
+		return 0, 0, fmt.Errorf("interval must be at least 10 seconds")
+	}
+	if timeout > interval {
+		return 0, 0, fmt.Errorf("timeout cannot be longer than interval")
+	}
+	return timeout, interval, nil
+}
+
+// validateFailureThreshold applies the default failure threshold of a status check and validates it
+func validateFailureThreshold(threshold int) (int, error) {
+	if threshold == 0 {
+		threshold = 3
+	}
+	if threshold < 1 {
+		return 0, fmt.Errorf("failure_threshold must be at least 1")
+	}
+	return threshold, nil
+}
+
+// checkHost verifies that the host of a check exists and writes the error response otherwise
+func (h *ProbeHandler) checkHost(c *gin.Context, hostID uint) bool {
+	if _, err := h.hostRepo.GetByID(hostID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Host not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// GetHTTPChecks 获取 HTTP 探测列表
+// @Summary 获取 HTTP 探测列表
+// @Description 获取 HTTP 探测及其最近一次探测结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param host_id query int false "主机ID，不提供则返回所有探测"
+// @Success 200 {object} HTTPCheckListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/http-checks [get]
+func (h *ProbeHandler) GetHTTPChecks(c *gin.Context) {
+	var hostID uint
+	if hostIDStr := c.Query("host_id"); hostIDStr != "" {
+		id, err := strconv.ParseUint(hostIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host_id parameter"})
+			return
+		}
+		hostID = uint(id)
+	}
+
+	checks, err := h.httpCheckRepo.List(hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, HTTPCheckListResponse{
+		Checks: checks,
+		Total:  len(checks),
+	})
+}
+
+// CreateHTTPCheck 创建 HTTP 探测
+// @Summary 创建 HTTP 探测
+// @Description 创建按间隔请求服务地址的 HTTP 探测，结果记录为所属主机的 probe_* 指标
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param check body HTTPCheckRequest true "HTTP 探测"
+// @Success 201 {object} model.HTTPCheck
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/http-checks [post]
+func (h *ProbeHandler) CreateHTTPCheck(c *gin.Context) {
+	var req HTTPCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check := &model.HTTPCheck{}
+	if err := req.apply(check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkHost(c, check.HostID) {
+		return
+	}
+
+	if err := h.httpCheckRepo.Create(check); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, check)
+}
+
+// GetHTTPCheck 获取单个 HTTP 探测
+// @Summary 获取单个 HTTP 探测
+// @Description 根据ID获取 HTTP 探测及其最近一次探测结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "HTTP 探测ID"
+// @Success 200 {object} model.HTTPCheck
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/http-checks/{id} [get]
+func (h *ProbeHandler) GetHTTPCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.httpCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "HTTP check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// UpdateHTTPCheck 更新 HTTP 探测
+// @Summary 更新 HTTP 探测
+// @Description 使用请求内容整体替换 HTTP 探测配置
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "HTTP 探测ID"
+// @Param check body HTTPCheckRequest true "HTTP 探测"
+// @Success 200 {object} model.HTTPCheck
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/http-checks/{id} [put]
+func (h *ProbeHandler) UpdateHTTPCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	var req HTTPCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, err := h.httpCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "HTTP check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := req.apply(check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkHost(c, check.HostID) {
+		return
+	}
+
+	check.Host = nil
+	if err := h.httpCheckRepo.Update(check); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// DeleteHTTPCheck 删除 HTTP 探测
+// @Summary 删除 HTTP 探测
+// @Description 删除 HTTP 探测，已记录的探测指标保留至过期清理
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "HTTP 探测ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/http-checks/{id} [delete]
+func (h *ProbeHandler) DeleteHTTPCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	if _, err := h.httpCheckRepo.GetByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "HTTP check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.httpCheckRepo.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RunHTTPCheck 立即执行 HTTP 探测
+// @Summary 立即执行 HTTP 探测
+// @Description 立即执行一次 HTTP 探测并记录结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "HTTP 探测ID"
+// @Success 200 {object} model.ProbeResult
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/http-checks/{id}/run [post]
+func (h *ProbeHandler) RunHTTPCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.httpCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "HTTP check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, h.scheduler.RunHTTPCheck(check))
+}
+
+// GetTCPChecks 获取 TCP 探测列表
+// @Summary 获取 TCP 探测列表
+// @Description 获取 TCP 探测及其最近一次探测结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param host_id query int false "主机ID，不提供则返回所有探测"
+// @Success 200 {object} TCPCheckListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/tcp-checks [get]
+func (h *ProbeHandler) GetTCPChecks(c *gin.Context) {
+	var hostID uint
+	if hostIDStr := c.Query("host_id"); hostIDStr != "" {
+		id, err := strconv.ParseUint(hostIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host_id parameter"})
+			return
+		}
+		hostID = uint(id)
+	}
+
+	checks, err := h.tcpCheckRepo.List(hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, TCPCheckListResponse{
+		Checks: checks,
+		Total:  len(checks),
+	})
+}
+
+// CreateTCPCheck 创建 TCP 探测
+// @Summary 创建 TCP 探测
+// @Description 创建按间隔连接服务端口的 TCP 探测，结果记录为所属主机的 probe_* 指标
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param check body TCPCheckRequest true "TCP 探测"
+// @Success 201 {object} model.TCPCheck
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/tcp-checks [post]
+func (h *ProbeHandler) CreateTCPCheck(c *gin.Context) {
+	var req TCPCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check := &model.TCPCheck{}
+	if err := req.apply(check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkHost(c, check.HostID) {
+		return
+	}
+
+	if err := h.tcpCheckRepo.Create(check); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, check)
+}
+
+// GetTCPCheck 获取单个 TCP 探测
+// @Summary 获取单个 TCP 探测
+// @Description 根据ID获取 TCP 探测及其最近一次探测结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "TCP 探测ID"
+// @Success 200 {object} model.TCPCheck
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/tcp-checks/{id} [get]
+func (h *ProbeHandler) GetTCPCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.tcpCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "TCP check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// UpdateTCPCheck 更新 TCP 探测
+// @Summary 更新 TCP 探测
+// @Description 使用请求内容整体替换 TCP 探测配置
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "TCP 探测ID"
+// @Param check body TCPCheckRequest true "TCP 探测"
+// @Success 200 {object} model.TCPCheck
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/tcp-checks/{id} [put]
+func (h *ProbeHandler) UpdateTCPCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	var req TCPCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, err := h.tcpCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "TCP check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := req.apply(check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkHost(c, check.HostID) {
+		return
+	}
+
+	check.Host = nil
+	if err := h.tcpCheckRepo.Update(check); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// DeleteTCPCheck 删除 TCP 探测
+// @Summary 删除 TCP 探测
+// @Description 删除 TCP 探测，已记录的探测指标保留至过期清理
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "TCP 探测ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/tcp-checks/{id} [delete]
+func (h *ProbeHandler) DeleteTCPCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	if _, err := h.tcpCheckRepo.GetByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "TCP check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.tcpCheckRepo.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RunTCPCheck 立即执行 TCP 探测
+// @Summary 立即执行 TCP 探测
+// @Description 立即执行一次 TCP 探测并记录结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "TCP 探测ID"
+// @Success 200 {object} model.ProbeResult
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/tcp-checks/{id}/run [post]
+func (h *ProbeHandler) RunTCPCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.tcpCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "TCP check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, h.scheduler.RunTCPCheck(check))
+}
+
+// GetDNSChecks 获取 DNS 探测列表
+// @Summary 获取 DNS 探测列表
+// @Description 获取 DNS 探测及其最近一次探测结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param host_id query int false "主机ID，不提供则返回所有探测"
+// @Success 200 {object} DNSCheckListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/dns-checks [get]
+func (h *ProbeHandler) GetDNSChecks(c *gin.Context) {
+	var hostID uint
+	if hostIDStr := c.Query("host_id"); hostIDStr != "" {
+		id, err := strconv.ParseUint(hostIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host_id parameter"})
+			return
+		}
+		hostID = uint(id)
+	}
+
+	checks, err := h.dnsCheckRepo.List(hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, DNSCheckListResponse{
+		Checks: checks,
+		Total:  len(checks),
+	})
+}
+
+// CreateDNSCheck 创建 DNS 探测
+// @Summary 创建 DNS 探测
+// @Description 创建按间隔解析域名的 DNS 探测，结果记录为所属主机的 probe_* 指标
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param check body DNSCheckRequest true "DNS 探测"
+// @Success 201 {object} model.DNSCheck
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/dns-checks [post]
+func (h *ProbeHandler) CreateDNSCheck(c *gin.Context) {
+	var req DNSCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check := &model.DNSCheck{}
+	if err := req.apply(check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkHost(c, check.HostID) {
+		return
+	}
+
+	if err := h.dnsCheckRepo.Create(check); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, check)
+}
+
+// GetDNSCheck 获取单个 DNS 探测
+// @Summary 获取单个 DNS 探测
+// @Description 根据ID获取 DNS 探测及其最近一次探测结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "DNS 探测ID"
+// @Success 200 {object} model.DNSCheck
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/dns-checks/{id} [get]
+func (h *ProbeHandler) GetDNSCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.dnsCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "DNS check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// UpdateDNSCheck 更新 DNS 探测
+// @Summary 更新 DNS 探测
+// @Description 使用请求内容整体替换 DNS 探测配置
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "DNS 探测ID"
+// @Param check body DNSCheckRequest true "DNS 探测"
+// @Success 200 {object} model.DNSCheck
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/dns-checks/{id} [put]
+func (h *ProbeHandler) UpdateDNSCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	var req DNSCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, err := h.dnsCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "DNS check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := req.apply(check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkHost(c, check.HostID) {
+		return
+	}
+
+	check.Host = nil
+	if err := h.dnsCheckRepo.Update(check); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// DeleteDNSCheck 删除 DNS 探测
+// @Summary 删除 DNS 探测
+// @Description 删除 DNS 探测，已记录的探测指标保留至过期清理
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "DNS 探测ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/dns-checks/{id} [delete]
+func (h *ProbeHandler) DeleteDNSCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	if _, err := h.dnsCheckRepo.GetByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "DNS check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.dnsCheckRepo.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RunDNSCheck 立即执行 DNS 探测
+// @Summary 立即执行 DNS 探测
+// @Description 立即执行一次 DNS 探测并记录结果
+// @Tags probes
+// @Accept json
+// @Produce json
+// @Param id path int true "DNS 探测ID"
+// @Success 200 {object} model.ProbeResult
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/dns-checks/{id}/run [post]
+func (h *ProbeHandler) RunDNSCheck(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.dnsCheckRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "DNS check not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, h.scheduler.RunDNSCheck(check))
+}
@@ -0,0 +1,341 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
+)
+
+// ProcessWatchHandler 进程监视管理处理器
+type ProcessWatchHandler struct {
+	watchRepo     repository.ProcessWatchRepository
+	hostRepo      repository.HostRepository
+	hostGroupRepo repository.HostGroupRepository
+	watcher       service.ProcessWatcher
+}
+
+// NewProcessWatchHandler 创建进程监视管理处理器
+func NewProcessWatchHandler(db *gorm.DB, watcher service.ProcessWatcher) *ProcessWatchHandler {
+	return &ProcessWatchHandler{
+		watchRepo:     repository.NewProcessWatchRepository(db),
+		hostRepo:      repository.NewHostRepository(db),
+		hostGroupRepo: repository.NewHostGroupRepository(db),
+		watcher:       watcher,
+	}
+}
+
+// ProcessWatchRequest 创建或更新进程监视请求
+type ProcessWatchRequest struct {
+	Name          string  `json:"name" binding:"required"`
+	HostID        *uint   `json:"host_id"`       // 与 host_group_id 二选一
+	HostGroupID   *uint   `json:"host_group_id"` // 与 host_id 二选一
+	ProcessName   string  `json:"process_name"`  // process_name、cmdline_regex、user 至少提供一个
+	CmdlineRegex  string  `json:"cmdline_regex"`
+	User          string  `json:"user"`
+	MinCount      *int    `json:"min_count"` // 默认 1，为 0 时只检查上限
+	MaxCount      int     `json:"max_count"`
+	MaxCPUPercent float64 `json:"max_cpu_percent"`
+	MaxRSSMB      float64 `json:"max_rss_mb"`
+	MaxOpenFDs    int     `json:"max_open_fds"`
+	Enabled       *bool   `json:"enabled"`
+	Description   string  `json:"description"`
+}
+
+// ProcessWatchListResponse 进程监视列表响应
+type ProcessWatchListResponse struct {
+	Watches []model.ProcessWatch `json:"watches"`
+	Total   int                  `json:"total"`
+}
+
+// apply validates the request and copies it onto the watch
+func (req ProcessWatchRequest) apply(watch *model.ProcessWatch) error {
+	if (req.HostID == nil) == (req.HostGroupID == nil) {
+		return fmt.Errorf("exactly one of host_id and host_group_id is required")
+	}
+
+	processName := strings.TrimSpace(req.ProcessName)
+	user := strings.TrimSpace(req.User)
+	if processName == "" && req.CmdlineRegex == "" && user == "" {
+		return fmt.Errorf("at least one of process_name, cmdline_regex and user is required")
+	}
+	if req.CmdlineRegex != "" {
+		if _, err := regexp.Compile(req.CmdlineRegex); err != nil {
+			return fmt.Errorf("invalid cmdline_regex: %v", err)
+		}
+	}
+
+	minCount := 1
+	if req.MinCount != nil {
+		minCount = *req.MinCount
+	}
+	if minCount < 0 || req.MaxCount < 0 || req.MaxCPUPercent < 0 || req.MaxRSSMB < 0 || req.MaxOpenFDs < 0 {
+		return fmt.Errorf("counts and limits must not be negative")
+	}
+	if req.MaxCount > 0 && req.MaxCount < minCount {
+		return fmt.Errorf("max_count must not be less than min_count")
+	}
+
+	watch.Name = req.Name
+	watch.HostID = req.HostID
+	watch.HostGroupID = req.HostGroupID
+	watch.ProcessName = processName
+	watch.CmdlineRegex = req.CmdlineRegex
+	watch.User = user
+	watch.MinCount = minCount
+	watch.MaxCount = req.MaxCount
+	watch.MaxCPUPercent = req.MaxCPUPercent
+	watch.MaxRSSMB = req.MaxRSSMB
+	watch.MaxOpenFDs = req.MaxOpenFDs
+	watch.Enabled = req.Enabled == nil || *req.Enabled
+	watch.Description = req.Description
+	return nil
+}
+
+// checkTarget verifies that the host or host group of a watch exists
+func (h *ProcessWatchHandler) checkTarget(c *gin.Context, watch *model.ProcessWatch) bool {
+	var err error
+	notFound := "Host not found"
+	if watch.HostID != nil {
+		_, err = h.hostRepo.GetByID(*watch.HostID)
+	} else {
+		_, err = h.hostGroupRepo.GetByID(*watch.HostGroupID)
+		notFound = "Host group not found"
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": notFound})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// GetProcessWatches 获取进程监视列表
+// @Summary 获取进程监视列表
+// @Description 获取所有进程监视定义
+// @Tags process-watches
+// @Accept json
+// @Produce json
+// @Success 200 {object} ProcessWatchListResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/process-watches [get]
+func (h *ProcessWatchHandler) GetProcessWatches(c *gin.Context) {
+	watches, err := h.watchRepo.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ProcessWatchListResponse{
+		Watches: watches,
+		Total:   len(watches),
+	})
+}
+
+// CreateProcessWatch 创建进程监视
+// @Summary 创建进程监视
+// @Description 创建进程监视，按进程名、命令行正则或用户匹配进程，记录为所属主机的 process_* 指标
+// @Tags process-watches
+// @Accept json
+// @Produce json
+// @Param watch body ProcessWatchRequest true "进程监视"
+// @Success 201 {object} model.ProcessWatch
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/process-watches [post]
+func (h *ProcessWatchHandler) CreateProcessWatch(c *gin.Context) {
+	var req ProcessWatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	watch := &model.ProcessWatch{}
+	if err := req.apply(watch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkTarget(c, watch) {
+		return
+	}
+
+	if err := h.watchRepo.Create(watch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, watch)
+}
+
+// GetProcessWatch 获取单个进程监视
+// @Summary 获取单个进程监视
+// @Description 根据ID获取进程监视定义
+// @Tags process-watches
+// @Accept json
+// @Produce json
+// @Param id path int true "进程监视ID"
+// @Success 200 {object} model.ProcessWatch
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/process-watches/{id} [get]
+func (h *ProcessWatchHandler) GetProcessWatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watch ID"})
+		return
+	}
+
+	watch, err := h.watchRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Process watch not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, watch)
+}
+
+// UpdateProcessWatch 更新进程监视
+// @Summary 更新进程监视
+// @Description 使用请求内容整体替换进程监视定义，下一轮采集时生效
+// @Tags process-watches
+// @Accept json
+// @Produce json
+// @Param id path int true "进程监视ID"
+// @Param watch body ProcessWatchRequest true "进程监视"
+// @Success 200 {object} model.ProcessWatch
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/process-watches/{id} [put]
+func (h *ProcessWatchHandler) UpdateProcessWatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watch ID"})
+		return
+	}
+
+	var req ProcessWatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	watch, err := h.watchRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Process watch not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := req.apply(watch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkTarget(c, watch) {
+		return
+	}
+
+	watch.Host = nil
+	watch.HostGroup = nil
+	if err := h.watchRepo.Update(watch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, watch)
+}
+
+// DeleteProcessWatch 删除进程监视
+// @Summary 删除进程监视
+// @Description 删除进程监视，已记录的指标保留至过期清理
+// @Tags process-watches
+// @Accept json
+// @Produce json
+// @Param id path int true "进程监视ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/process-watches/{id} [delete]
+func (h *ProcessWatchHandler) DeleteProcessWatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watch ID"})
+		return
+	}
+
+	if _, err := h.watchRepo.GetByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Process watch not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.watchRepo.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetProcessWatchStatus 获取进程监视当前状态
+// @Summary 获取进程监视当前状态
+// @Description 立即在本机评估进程监视并返回匹配的进程和违反的限制，不记录指标
+// @Tags process-watches
+// @Accept json
+// @Produce json
+// @Param id path int true "进程监视ID"
+// @Success 200 {object} model.ProcessWatchResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/process-watches/{id}/status [get]
+func (h *ProcessWatchHandler) GetProcessWatchStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watch ID"})
+		return
+	}
+
+	watch, err := h.watchRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Process watch not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	result, err := h.watcher.Evaluate(watch)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if result == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Process watch does not apply to the local host"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
+)
+
+// SensorHandler 硬件传感器历史处理器
+type SensorHandler struct {
+	hostRepo   repository.HostRepository
+	sampleRepo repository.SampleRepository
+}
+
+// NewSensorHandler 创建硬件传感器历史处理器
+func NewSensorHandler(db *gorm.DB) *SensorHandler {
+	return &SensorHandler{
+		hostRepo:   repository.NewHostRepository(db),
+		sampleRepo: repository.NewSampleRepository(db),
+	}
+}
+
+// GetSensorHistory 获取主机硬件传感器历史
+// @Summary 获取主机硬件传感器历史
+// @Description 返回回看窗口内记录的某一类传感器读数，按芯片和传感器分组，可按芯片和传感器过滤
+// @Tags hosts
+// @Accept json
+// @Produce json
+// @Param id path int true "主机ID"
+// @Param type query string false "传感器类型：temperature, fan, power, voltage, current" default(temperature)
+// @Param chip query string false "芯片ID"
+// @Param sensor query string false "传感器，如 temp1"
+// @Param lookback query int false "回看窗口（秒）" default(3600)
+// @Success 200 {object} model.SensorHistory
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/hosts/{id}/sensors/history [get]
+func (h *SensorHandler) GetSensorHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host ID"})
+		return
+	}
+
+	sensorType := c.DefaultQuery("type", model.SensorTypeTemperature)
+	metric := service.SensorSample(sensorType)
+	if metric == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sensor type"})
+		return
+	}
+
+	lookbackSeconds, err := strconv.Atoi(c.DefaultQuery("lookback", "3600"))
+	if err != nil || lookbackSeconds <= 0 || lookbackSeconds > int(maxPreviewRange.Seconds()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lookback parameter"})
+		return
+	}
+
+	host, err := h.hostRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	now := time.Now()
+	samples, err := h.sampleRepo.GetSeries(host.Hostname, metric, now.Add(-time.Duration(lookbackSeconds)*time.Second), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 样本按标签和时间排序，相同标签的样本连续出现
+	chip, sensor := c.Query("chip"), c.Query("sensor")
+	history := model.SensorHistory{Hostname: host.Hostname, Type: sensorType, Series: []model.SensorSeries{}}
+	lastLabels := ""
+	for _, sample := range samples {
+		labels := model.ParseLabels(sample.Labels)
+		if (chip != "" && labels["chip"] != chip) || (sensor != "" && labels["sensor"] != sensor) {
+			continue
+		}
+		if len(history.Series) == 0 || sample.Labels != lastLabels {
+			history.Series = append(history.Series, model.SensorSeries{Chip: labels["chip"], Sensor: labels["sensor"]})
+			lastLabels = sample.Labels
+		}
+		series := &history.Series[len(history.Series)-1]
+		series.Points = append(series.Points, model.SensorPoint{Timestamp: sample.Timestamp, Value: sample.Value})
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/internal/service"
+)
+
+// SystemdHandler systemd 单元监视管理处理器
+type SystemdHandler struct {
+	watchRepo repository.SystemdUnitWatchRepository
+	hostRepo  repository.HostRepository
+	monitor   service.SystemdMonitor
+}
+
+// NewSystemdHandler 创建 systemd 单元监视管理处理器
+func NewSystemdHandler(db *gorm.DB, monitor service.SystemdMonitor) *SystemdHandler {
+	return &SystemdHandler{
+		watchRepo: repository.NewSystemdUnitWatchRepository(db),
+		hostRepo:  repository.NewHostRepository(db),
+		monitor:   monitor,
+	}
+}
+
+// SystemdUnitWatchRequest 创建或更新 systemd 单元监视请求
+type SystemdUnitWatchRequest struct {
+	HostID      uint   `json:"host_id" binding:"required"`
+	Unit        string `json:"unit" binding:"required"` // 未指定单元类型时补全为 .service
+	Enabled     *bool  `json:"enabled"`
+	Description string `json:"description"`
+}
+
+// SystemdUnitWatchListResponse systemd 单元监视列表响应
+type SystemdUnitWatchListResponse struct {
+	Watches []model.SystemdUnitWatch `json:"watches"`
+	Total   int                      `json:"total"`
+}
+
+// apply validates the request and copies it onto the watch
+func (req SystemdUnitWatchRequest) apply(watch *model.SystemdUnitWatch) error {
+	unit, err := service.NormalizeSystemdUnit(req.Unit)
+	if err != nil {
+		return err
+	}
+
+	watch.HostID = req.HostID
+	watch.Unit = unit
+	watch.Enabled = req.Enabled == nil || *req.Enabled
+	watch.Description = req.Description
+	return nil
+}
+
+// checkHost verifies that the host of a watch exists
+func (h *SystemdHandler) checkHost(c *gin.Context, hostID uint) bool {
+	if _, err := h.hostRepo.GetByID(hostID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Host not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// GetSystemdUnitWatches 获取 systemd 单元监视列表
+// @Summary 获取 systemd 单元监视列表
+// @Description 获取 systemd 单元监视及最近一次查询结果，可按主机过滤
+// @Tags systemd-units
+// @Accept json
+// @Produce json
+// @Param host_id query int false "主机ID"
+// @Success 200 {object} SystemdUnitWatchListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/systemd-units [get]
+func (h *SystemdHandler) GetSystemdUnitWatches(c *gin.Context) {
+	var hostID uint64
+	if value := c.Query("host_id"); value != "" {
+		var err error
+		if hostID, err = strconv.ParseUint(value, 10, 32); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host ID"})
+			return
+		}
+	}
+
+	watches, err := h.watchRepo.List(uint(hostID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SystemdUnitWatchListResponse{
+		Watches: watches,
+		Total:   len(watches),
+	})
+}
+
+// CreateSystemdUnitWatch 创建 systemd 单元监视
+// @Summary 创建 systemd 单元监视
+// @Description 创建 systemd 单元监视，记录为所属主机的 systemd_unit_* 指标，单元失败时触发告警
+// @Tags systemd-units
+// @Accept json
+// @Produce json
+// @Param watch body SystemdUnitWatchRequest true "systemd 单元监视"
+// @Success 201 {object} model.SystemdUnitWatch
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/systemd-units [post]
+func (h *SystemdHandler) CreateSystemdUnitWatch(c *gin.Context) {
+	var req SystemdUnitWatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	watch := &model.SystemdUnitWatch{}
+	if err := req.apply(watch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkHost(c, watch.HostID) {
+		return
+	}
+
+	if err := h.watchRepo.Create(watch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, watch)
+}
+
+// GetSystemdUnitWatch 获取单个 systemd 单元监视
+// @Summary 获取单个 systemd 单元监视
+// @Description 根据ID获取 systemd 单元监视及最近一次查询结果
+// @Tags systemd-units
+// @Accept json
+// @Produce json
+// @Param id path int true "systemd 单元监视ID"
+// @Success 200 {object} model.SystemdUnitWatch
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/systemd-units/{id} [get]
+func (h *SystemdHandler) GetSystemdUnitWatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watch ID"})
+		return
+	}
+
+	watch, err := h.watchRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Systemd unit watch not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, watch)
+}
+
+// UpdateSystemdUnitWatch 更新 systemd 单元监视
+// @Summary 更新 systemd 单元监视
+// @Description 使用请求内容整体替换 systemd 单元监视定义，下一轮查询时生效
+// @Tags systemd-units
+// @Accept json
+// @Produce json
+// @Param id path int true "systemd 单元监视ID"
+// @Param watch body SystemdUnitWatchRequest true "systemd 单元监视"
+// @Success 200 {object} model.SystemdUnitWatch
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/systemd-units/{id} [put]
+func (h *SystemdHandler) UpdateSystemdUnitWatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watch ID"})
+		return
+	}
+
+	var req SystemdUnitWatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	watch, err := h.watchRepo.GetByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Systemd unit watch not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := req.apply(watch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkHost(c, watch.HostID) {
+		return
+	}
+
+	watch.Host = nil
+	if err := h.watchRepo.Update(watch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, watch)
+}
+
+// DeleteSystemdUnitWatch 删除 systemd 单元监视
+// @Summary 删除 systemd 单元监视
+// @Description 删除 systemd 单元监视，已记录的指标保留至过期清理
+// @Tags systemd-units
+// @Accept json
+// @Produce json
+// @Param id path int true "systemd 单元监视ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/systemd-units/{id} [delete]
+func (h *SystemdHandler) DeleteSystemdUnitWatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watch ID"})
+		return
+	}
+
+	if _, err := h.watchRepo.GetByID(uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Systemd unit watch not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := h.watchRepo.Delete(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetSystemdStatus 获取本机 systemd 状态
+// @Summary 获取本机 systemd 状态
+// @Description 立即查询本机监视的单元和所有失败的单元，不记录指标
+// @Tags systemd-units
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.SystemdStatus
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/systemd-units/status [get]
+func (h *SystemdHandler) GetSystemdStatus(c *gin.Context) {
+	status, err := h.monitor.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,97 @@
+package model
+
+import "time"
+
+// AlertPreviewResult represents the outcome of replaying an alert rule against stored metrics
+type AlertPreviewResult struct {
+	Rule             AlertRule          `json:"rule"`
+	StartTime        time.Time          `json:"start_time"`
+	EndTime          time.Time          `json:"end_time"`
+	HostsEvaluated   int                `json:"hosts_evaluated"`
+	SamplesEvaluated int                `json:"samples_evaluated"`
+	TotalAlerts      int                `json:"total_alerts"`
+	TotalFiringTime  int                `json:"total_firing_time"` // 告警持续总时长（秒）
+	Hosts            []AlertPreviewHost `json:"hosts"`
+	Alerts           []AlertPreview     `json:"alerts"`
+}
+
+// AlertPreviewHost summarizes the would-be alerts of a single host
+type AlertPreviewHost struct {
+	Hostname   string `json:"hostname"`
+	Samples    int    `json:"samples"`
+	AlertCount int    `json:"alert_count"`
+	FiringTime int    `json:"firing_time"` // 告警持续总时长（秒）
+}
+
+// AlertPreview represents a single alert that would have fired
+type AlertPreview struct {
+	Hostname  string    `json:"hostname"`
+	StartTime time.Time `json:"start_time"` // 满足持续时间后告警触发的时间
+	EndTime   time.Time `json:"end_time"`
+	Duration  int       `json:"duration"` // 告警持续时间（秒）
+	PeakValue float64   `json:"peak_value"`
+	Resolved  bool      `json:"resolved"` // false 表示在时间范围结束时仍处于告警状态
+}
+
+// AlertRuleExplanation explains which alert rules apply to a host and why
+type AlertRuleExplanation struct {
+	HostID   uint                 `json:"host_id"`
+	Hostname string               `json:"hostname"`
+	Labels   map[string]string    `json:"labels"`
+	GroupIDs []uint               `json:"group_ids"`
+	Rules    []EffectiveAlertRule `json:"rules"`
+}
+
+// EffectiveAlertRule represents the rule applied to a host for a rule type, metric type and severity
+type EffectiveAlertRule struct {
+	RuleType   string               `json:"rule_type"`
+	MetricType string               `json:"metric_type"`
+	Severity   string               `json:"severity"`
+	Rule       *AlertRule           `json:"rule"`  // 为空表示没有适用的规则
+	Scope      string               `json:"scope"` // host, group, selector, global
+	Reason     string               `json:"reason"`
+	Candidates []AlertRuleCandidate `json:"candidates"` // 按优先级排序的所有候选规则
+}
+
+// AlertRuleCandidate represents a rule considered while resolving the effective rule
+type AlertRuleCandidate struct {
+	RuleID  uint   `json:"rule_id"`
+	Name    string `json:"name"`
+	Scope   string `json:"scope"`
+	Applied bool   `json:"applied"`
+	// 与生效规则的范围相同，只因规则ID较大而未生效；新建时会拒绝这种重复规则
+	Shadowed bool   `json:"shadowed"`
+	Reason   string `json:"reason"`
+}
+
+// HostForecast represents predicted resource exhaustion of a host
+type HostForecast struct {
+	HostID      uint               `json:"host_id"`
+	Hostname    string             `json:"hostname"`
+	Lookback    int                `json:"lookback"` // 回看窗口（秒）
+	GeneratedAt time.Time          `json:"generated_at"`
+	Disks       []ResourceForecast `json:"disks"`
+	Memory      *ResourceForecast  `json:"memory"` // 为空表示没有内存样本
+}
+
+// ResourceForecast represents the linear trend of a resource usage series
+type ResourceForecast struct {
+	Resource        string     `json:"resource"` // disk, memory
+	MountPoint      string     `json:"mount_point,omitempty"`
+	Samples         int        `json:"samples"`
+	CurrentUsage    float64    `json:"current_usage"`   // 最新使用率（%）
+	GrowthPerHour   float64    `json:"growth_per_hour"` // 每小时增长的百分点
+	RSquared        float64    `json:"r_squared"`       // 拟合优度
+	HoursToFull     *float64   `json:"hours_to_full"`   // 为空表示未增长或数据不足
+	PredictedFullAt *time.Time `json:"predicted_full_at"`
+}
+
+// HostBaseline represents the learned baseline of a metric on a host
+type HostBaseline struct {
+	HostID     uint             `json:"host_id"`
+	Hostname   string           `json:"hostname"`
+	MetricType string           `json:"metric_type"`
+	Lookback   int              `json:"lookback"` // 学习窗口（秒）
+	LearnedAt  time.Time        `json:"learned_at"`
+	Baselines  []MetricBaseline `json:"baselines"` // 按标签和分桶排序
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// HostConfigCategoryCollector 采集器配置分类，配置键为采集器名称，值为 CollectorSettings 的 JSON
+const HostConfigCategoryCollector = "collector"
+
+// CollectorSettings 采集器设置，作为主机配置时覆盖全局配置中设置了的字段
+type CollectorSettings struct {
+	Enabled  *bool `json:"enabled,omitempty"`  // 为空时沿用全局配置，默认启用
+	Interval int   `json:"interval,omitempty"` // 采集间隔（秒），0 表示沿用全局配置或采集器默认间隔
+	Timeout  int   `json:"timeout,omitempty"`  // 单次采集超时（秒），0 表示沿用全局配置
+}
+
+// CollectorStatus 采集器运行状态
+type CollectorStatus struct {
+	Name           string     `json:"name"`
+	Enabled        bool       `json:"enabled"`
+	Interval       float64    `json:"interval"` // 生效的采集间隔（秒）
+	Timeout        float64    `json:"timeout"`  // 生效的单次采集超时（秒）
+	Running        bool       `json:"running"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	LastDurationMs float64    `json:"last_duration_ms"`
+	LastSamples    int        `json:"last_samples"` // 最近一次采集产生的样本数
+	LastError      string     `json:"last_error"`   // 最近一次采集的错误，成功时为空
+	LastErrorAt    *time.Time `json:"last_error_at"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+}
@@ -0,0 +1,84 @@
+package model
+
+import "time"
+
+// EscalationPolicy 升级策略模型，告警未被确认时按步骤依次通知
+type EscalationPolicy struct {
+	BaseModel
+	Name        string           `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	Severity    string           `gorm:"type:varchar(50);not null;default:''" json:"severity"` // 为空匹配所有级别
+	HostGroupID *uint            `gorm:"index" json:"host_group_id"`                           // 为空匹配所有主机
+	Enabled     bool             `gorm:"not null" json:"enabled"`
+	Description string           `gorm:"type:text" json:"description"`
+	Steps       []EscalationStep `gorm:"foreignKey:PolicyID" json:"steps"`
+
+	// 关联关系
+	HostGroup *HostGroup `gorm:"foreignKey:HostGroupID" json:"host_group,omitempty"`
+}
+
+// EscalationStep 升级步骤模型
+type EscalationStep struct {
+	BaseModel
+	PolicyID     uint   `gorm:"not null;index" json:"policy_id"`
+	Position     int    `gorm:"not null" json:"position"`                                // 步骤顺序，从0开始
+	DelayMinutes int    `gorm:"not null" json:"delay_minutes"`                           // 上一步骤（第一步为告警开始或重新打开）之后仍未确认时等待的分钟数
+	Receiver     string `gorm:"type:varchar(50);not null;default:'log'" json:"receiver"` // log, webhook
+	WebhookURL   string `gorm:"type:varchar(500);not null;default:''" json:"webhook_url"`
+	ScheduleID   *uint  `gorm:"index" json:"schedule_id"` // 有值时通知该值班表当前的值班人员
+}
+
+// OnCallSchedule 值班表模型，参与人员按固定时长轮换
+type OnCallSchedule struct {
+	BaseModel
+	Name          string           `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	Participants  string           `gorm:"type:text;not null" json:"participants"` // 按轮换顺序排列，逗号分隔
+	RotationHours int              `gorm:"not null" json:"rotation_hours"`
+	StartTime     time.Time        `gorm:"not null" json:"start_time"` // 第一位参与人员开始值班的时间
+	Description   string           `gorm:"type:text" json:"description"`
+	Overrides     []OnCallOverride `gorm:"foreignKey:ScheduleID" json:"overrides"`
+}
+
+// OnCallOverride 值班替换模型，在时间范围内由指定人员代替轮换值班
+type OnCallOverride struct {
+	BaseModel
+	ScheduleID uint      `gorm:"not null;index" json:"schedule_id"`
+	User       string    `gorm:"type:varchar(255);not null" json:"user"`
+	StartTime  time.Time `gorm:"not null" json:"start_time"`
+	EndTime    time.Time `gorm:"not null" json:"end_time"`
+}
+
+func (EscalationPolicy) TableName() string {
+	return "escalation_policies"
+}
+
+func (EscalationStep) TableName() string {
+	return "escalation_steps"
+}
+
+func (OnCallSchedule) TableName() string {
+	return "oncall_schedules"
+}
+
+func (OnCallOverride) TableName() string {
+	return "oncall_overrides"
+}
+
+// EscalationNotification represents a notification sent by an escalation step
+type EscalationNotification struct {
+	PolicyID   uint      `json:"policy_id"`
+	PolicyName string    `json:"policy_name"`
+	Step       int       `json:"step"`
+	OnCall     string    `json:"on_call,omitempty"` // 值班表当前的值班人员
+	Alert      Alert     `json:"alert"`
+	Summary    string    `json:"summary"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// OnCallStatus represents who is on call for a schedule at a given time
+type OnCallStatus struct {
+	ScheduleID uint      `json:"schedule_id"`
+	Name       string    `json:"name"`
+	User       string    `json:"user"`
+	Override   bool      `json:"override"` // 是否来自值班替换
+	At         time.Time `json:"at"`
+}
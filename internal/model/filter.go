@@ -0,0 +1,34 @@
+package model
+
+// HostConfigCategoryCollectFilter 采集过滤配置分类，值为 CollectFilter 的 JSON，多个配置按键名顺序叠加
+const HostConfigCategoryCollectFilter = "collect_filter"
+
+// CollectFilter 磁盘和网卡采集的包含/排除规则。
+// 设置了包含规则时必须匹配其中一条，且不能匹配任何排除规则。
+// 作为主机配置时，未出现的字段沿用全局配置，空数组清空全局配置
+type CollectFilter struct {
+	IncludeFSTypes     []string `json:"include_fstypes,omitempty"`     // 文件系统类型
+	ExcludeFSTypes     []string `json:"exclude_fstypes,omitempty"`     // 文件系统类型
+	IncludeMountpoints []string `json:"include_mountpoints,omitempty"` // 挂载点 glob，结尾的 /** 同时匹配其下所有路径
+	ExcludeMountpoints []string `json:"exclude_mountpoints,omitempty"` // 挂载点 glob
+	IncludeDevices     []string `json:"include_devices,omitempty"`     // 设备正则表达式
+	ExcludeDevices     []string `json:"exclude_devices,omitempty"`     // 设备正则表达式
+	IncludeInterfaces  []string `json:"include_interfaces,omitempty"`  // 网卡名正则表达式
+	ExcludeInterfaces  []string `json:"exclude_interfaces,omitempty"`  // 网卡名正则表达式
+}
+
+// CollectFilterPreview 采集过滤预览，列出本机所有挂载点和网卡及其是否被采集
+type CollectFilterPreview struct {
+	Filter     CollectFilter `json:"filter"` // 生效的过滤规则
+	Mounts     []FilterMatch `json:"mounts"`
+	Interfaces []FilterMatch `json:"interfaces"`
+}
+
+// FilterMatch 单个挂载点或网卡的过滤结果
+type FilterMatch struct {
+	Name     string `json:"name"`             // 挂载点或网卡名
+	Device   string `json:"device,omitempty"` // 挂载点的设备
+	FSType   string `json:"fstype,omitempty"` // 挂载点的文件系统类型
+	Included bool   `json:"included"`
+	Reason   string `json:"reason,omitempty"` // 被排除的原因，如 exclude_fstypes: tmpfs
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// labelEscaper escapes the separators inside label keys and values
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `,`, `\,`, `=`, `\=`)
+
+// FormatLabels encodes labels as a comma separated key=value list sorted by key. Backslashes,
+// commas and equal signs inside keys and values are escaped with a backslash.
+func FormatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, labelEscaper.Replace(key)+"="+labelEscaper.Replace(labels[key]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseLabels decodes labels produced by FormatLabels. A backslash not followed by an escaped
+// character is kept as it is.
+func ParseLabels(labels string) map[string]string {
+	result := make(map[string]string)
+	var key, current strings.Builder
+	inValue := false
+	flush := func() {
+		if !inValue && current.Len() > 0 {
+			// 没有等号的部分作为值为空的键
+			result[current.String()] = ""
+		} else if inValue {
+			result[key.String()] = current.String()
+		}
+		key.Reset()
+		current.Reset()
+		inValue = false
+	}
+	for i := 0; i < len(labels); i++ {
+		c := labels[i]
+		switch {
+		case c == '\\' && i+1 < len(labels) && strings.IndexByte(`\,=`, labels[i+1]) >= 0:
+			i++
+			current.WriteByte(labels[i])
+		case c == ',':
+			flush()
+		case c == '=' && !inValue:
+			key.WriteString(current.String())
+			current.Reset()
+			inValue = true
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return result
+}
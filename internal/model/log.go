@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// HostConfigCategoryLogWatch 日志监视配置分类，配置键为 LogWatchKeyPrefix 加监视名称，值为 LogWatchConfig 的 JSON
+const HostConfigCategoryLogWatch = "log_watch"
+
+// LogWatchKeyPrefix 日志监视配置键前缀，避免监视名称与采集器等其他主机配置键冲突
+const LogWatchKeyPrefix = "logwatch:"
+
+// LogWatchConfig 日志监视配置，保存在主机配置中
+type LogWatchConfig struct {
+	Path      string `json:"path"`       // 日志文件绝对路径
+	Pattern   string `json:"pattern"`    // 匹配行的正则表达式
+	FromStart bool   `json:"from_start"` // 首次打开时从文件开头读取，默认只读取新写入的行
+}
+
+// LogEvent 日志匹配事件，每个监视每轮最多保存若干条样例行
+type LogEvent struct {
+	BaseModel
+	HostID    uint      `gorm:"not null;index:idx_log_events_host_time,priority:1" json:"host_id"`
+	Watch     string    `gorm:"type:varchar(255);not null" json:"watch"` // 监视名称，即去掉前缀的主机配置键
+	Path      string    `gorm:"type:varchar(1024);not null" json:"path"`
+	Line      string    `gorm:"type:text;not null" json:"line"`
+	MatchedAt time.Time `gorm:"not null;index:idx_log_events_host_time,priority:2" json:"matched_at"`
+}
+
+func (LogEvent) TableName() string {
+	return "log_events"
+}
@@ -50,35 +50,106 @@ type SystemInfoDB struct {
 // AlertRule 告警规则模型
 type AlertRule struct {
 	BaseModel
-	Name        string    `gorm:"type:varchar(255);not null" json:"name"`
-	MetricType  string    `gorm:"type:varchar(100);not null;index" json:"metric_type"` // cpu, memory, disk, network
-	Operator    string    `gorm:"type:varchar(10);not null" json:"operator"`           // >, <, >=, <=, ==
-	Threshold   float64   `gorm:"type:decimal(10,2);not null" json:"threshold"`
-	Duration    int       `gorm:"not null" json:"duration"`     // 持续时间（秒）
-	Severity    string    `gorm:"type:varchar(50);not null" json:"severity"` // info, warning, critical
-	Enabled     bool      `gorm:"not null;default:true" json:"enabled"`
-	Description string    `gorm:"type:text" json:"description"`
-	HostID      *uint     `gorm:"index" json:"host_id"` // null表示全局规则，有值表示主机特定规则
-	
+	Name             string   `gorm:"type:varchar(255);not null" json:"name"`
+	MetricType       string   `gorm:"type:varchar(100);not null;index" json:"metric_type"` // cpu, memory, disk, network
+	Operator         string   `gorm:"type:varchar(10);not null" json:"operator"`           // >, <, >=, <=, ==
+	Threshold        float64  `gorm:"type:decimal(10,2);not null" json:"threshold"`
+	Duration         int      `gorm:"not null" json:"duration"`                  // 持续时间（秒）
+	Severity         string   `gorm:"type:varchar(50);not null" json:"severity"` // info, warning, critical
+	Enabled          bool     `gorm:"not null;default:true" json:"enabled"`
+	Description      string   `gorm:"type:text" json:"description"`
+	RuleType         string   `gorm:"type:varchar(50);not null;default:'threshold'" json:"rule_type"` // threshold, forecast, anomaly, absent
+	Lookback         int      `gorm:"not null;default:0" json:"lookback"`                             // 回看窗口（秒），用于预测等规则类型
+	HostID           *uint    `gorm:"index" json:"host_id"`                                           // null表示全局规则，有值表示主机特定规则
+	HostGroupID      *uint    `gorm:"index" json:"host_group_id"`                                     // 有值表示主机组规则
+	Selector         string   `gorm:"type:varchar(500);not null;default:''" json:"selector"`          // 标签选择器，如 env=prod,role=db
+	ResolveThreshold *float64 `gorm:"type:decimal(10,2)" json:"resolve_threshold"`                    // 恢复阈值，为空时与触发阈值相同
+
 	// 关联关系
-	Host        *Host     `gorm:"foreignKey:HostID" json:"host,omitempty"`
+	Host      *Host      `gorm:"foreignKey:HostID" json:"host,omitempty"`
+	HostGroup *HostGroup `gorm:"foreignKey:HostGroupID" json:"host_group,omitempty"`
+}
+
+// 告警规则类型
+const (
+	RuleTypeThreshold = "threshold" // 静态阈值
+	RuleTypeForecast  = "forecast"  // 趋势预测，阈值为预计写满前的小时数
+	RuleTypeAnomaly   = "anomaly"   // 基线异常检测，阈值为偏离基线的标准差倍数
+	RuleTypeAbsent    = "absent"    // 数据缺失检测，回看窗口内没有样本时触发
+)
+
+// 告警规则作用范围，优先级从高到低为 host > group > selector > global
+const (
+	RuleScopeHost     = "host"
+	RuleScopeGroup    = "group"
+	RuleScopeSelector = "selector"
+	RuleScopeGlobal   = "global"
+)
+
+// Scope 返回告警规则的作用范围
+func (r AlertRule) Scope() string {
+	switch {
+	case r.HostID != nil:
+		return RuleScopeHost
+	case r.HostGroupID != nil:
+		return RuleScopeGroup
+	case r.Selector != "":
+		return RuleScopeSelector
+	default:
+		return RuleScopeGlobal
+	}
 }
 
 // Alert 告警记录模型
 type Alert struct {
 	BaseModel
-	RuleID      uint      `gorm:"not null;index" json:"rule_id"`
-	Rule        AlertRule `gorm:"foreignKey:RuleID" json:"rule"`
-	Hostname    string    `gorm:"type:varchar(255);not null;index" json:"hostname"`
-	MetricType  string    `gorm:"type:varchar(100);not null" json:"metric_type"`
-	Value       float64   `gorm:"type:decimal(10,2);not null" json:"value"`
-	Threshold   float64   `gorm:"type:decimal(10,2);not null" json:"threshold"`
-	Severity    string    `gorm:"type:varchar(50);not null" json:"severity"`
-	Message     string    `gorm:"type:text;not null" json:"message"`
-	Status      string    `gorm:"type:varchar(50);not null;default:'active'" json:"status"` // active, resolved, suppressed
-	StartTime   time.Time `gorm:"not null" json:"start_time"`
-	EndTime     *time.Time `json:"end_time"`
-	Duration    *int      `json:"duration"` // 持续时间（秒）
+	RuleID             uint       `gorm:"not null;index" json:"rule_id"`
+	Rule               AlertRule  `gorm:"foreignKey:RuleID" json:"rule"`
+	Hostname           string     `gorm:"type:varchar(255);not null;index" json:"hostname"`
+	MetricType         string     `gorm:"type:varchar(100);not null" json:"metric_type"`
+	Labels             string     `gorm:"type:varchar(500);not null;default:''" json:"labels"` // 序列标签，如 mountpoint=/
+	Value              float64    `gorm:"type:decimal(10,2);not null" json:"value"`
+	Threshold          float64    `gorm:"type:decimal(10,2);not null" json:"threshold"`
+	Severity           string     `gorm:"type:varchar(50);not null" json:"severity"`
+	Message            string     `gorm:"type:text;not null" json:"message"`
+	Status             string     `gorm:"type:varchar(50);not null;default:'active'" json:"status"` // active, resolved, suppressed
+	StartTime          time.Time  `gorm:"not null" json:"start_time"`
+	EndTime            *time.Time `json:"end_time"`
+	Duration           *int       `json:"duration"`                                                      // 持续时间（秒）
+	Fingerprint        string     `gorm:"type:varchar(64);not null;default:'';index" json:"fingerprint"` // 规则+主机+标签的去重指纹
+	Flapping           bool       `gorm:"not null;default:false" json:"flapping"`                        // 状态抖动中，暂停通知
+	StateChanges       int        `gorm:"not null;default:0" json:"state_changes"`                       // 抖动窗口内的状态变化次数
+	LastStateChangeAt  *time.Time `json:"last_state_change_at"`
+	NotifiedStatus     string     `gorm:"type:varchar(50);not null;default:''" json:"notified_status"` // 最近一次通知的状态：firing, resolved
+	AcknowledgedAt     *time.Time `json:"acknowledged_at"`
+	AcknowledgedBy     string     `gorm:"type:varchar(255);not null;default:''" json:"acknowledged_by"`
+	EscalationPolicyID *uint      `json:"escalation_policy_id"`
+	EscalationStep     int        `gorm:"not null;default:0" json:"escalation_step"` // 已执行的升级步骤数
+	LastEscalatedAt    *time.Time `json:"last_escalated_at"`
+	InhibitedByID      *uint      `gorm:"index" json:"inhibited_by_id"` // 抑制该告警的源告警ID，状态为 suppressed 时有效
+}
+
+// MetricSample 通用指标样本模型，用于按标签区分的时间序列（如单个挂载点的磁盘使用率）
+type MetricSample struct {
+	BaseModel
+	Hostname  string    `gorm:"type:varchar(255);not null;index:idx_metric_samples_series,priority:1" json:"hostname"`
+	Metric    string    `gorm:"type:varchar(100);not null;index:idx_metric_samples_series,priority:2" json:"metric"`
+	Labels    string    `gorm:"type:varchar(500);not null;default:''" json:"labels"` // 按键排序的 key=value 列表，逗号分隔
+	Value     float64   `gorm:"not null" json:"value"`
+	Timestamp time.Time `gorm:"not null;index:idx_metric_samples_series,priority:3" json:"timestamp"`
+}
+
+// MetricBaseline 指标基线模型，按主机、指标和一周中的小时分桶统计均值和标准差
+type MetricBaseline struct {
+	BaseModel
+	Hostname   string  `gorm:"type:varchar(255);not null;index:idx_metric_baselines_series,priority:1" json:"hostname"`
+	MetricType string  `gorm:"type:varchar(100);not null;index:idx_metric_baselines_series,priority:2" json:"metric_type"`
+	Labels     string  `gorm:"type:varchar(500);not null;default:''" json:"labels"`
+	Bucket     int     `gorm:"not null" json:"bucket"`   // 一周中的小时（周日0点为0，共168个），-1表示全部样本
+	Mean       float64 `gorm:"not null" json:"mean"`
+	StdDev     float64 `gorm:"not null" json:"std_dev"`
+	Samples    int     `gorm:"not null" json:"samples"`
+	Lookback   int     `gorm:"not null;index:idx_metric_baselines_series,priority:3" json:"lookback"` // 学习窗口（秒），不同窗口的基线分别保存
 }
 
 // MonitoringConfig 监控配置模型
@@ -103,6 +174,7 @@ type Host struct {
 	Tags            string     `gorm:"type:text" json:"tags"` // JSON 格式存储标签
 	Description     string     `gorm:"type:text" json:"description"`
 	Status          string     `gorm:"type:varchar(20);not null;default:'unknown';index" json:"status"` // online, offline, maintenance, unknown
+	StatusReason    string     `gorm:"type:varchar(255);not null;default:''" json:"status_reason"` // 自动检测得出当前状态的原因，手动修改状态时清空
 	MonitoringEnabled bool     `gorm:"not null;default:true" json:"monitoring_enabled"`
 	LastSeen        *time.Time `json:"last_seen"`
 	OS              string     `gorm:"type:varchar(100)" json:"os"`
@@ -170,6 +242,14 @@ func (Alert) TableName() string {
 	return "alerts"
 }
 
+func (MetricSample) TableName() string {
+	return "metric_samples"
+}
+
+func (MetricBaseline) TableName() string {
+	return "metric_baselines"
+}
+
 func (MonitoringConfig) TableName() string {
 	return "monitoring_configs"
 }
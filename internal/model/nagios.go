@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// Nagios 插件检查状态，即插件退出码
+const (
+	NagiosStateOK       = 0
+	NagiosStateWarning  = 1
+	NagiosStateCritical = 2
+	NagiosStateUnknown  = 3
+)
+
+// NagiosStateNames 检查状态名称
+var NagiosStateNames = map[int]string{
+	NagiosStateOK:       "OK",
+	NagiosStateWarning:  "WARNING",
+	NagiosStateCritical: "CRITICAL",
+	NagiosStateUnknown:  "UNKNOWN",
+}
+
+// NagiosCheck Nagios 插件检查模型，按间隔在服务端执行兼容 Nagios 的插件
+type NagiosCheck struct {
+	BaseModel
+	Name        string `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	HostID      uint   `gorm:"not null;index" json:"host_id"`              // 检查结果归属的主机
+	Command     string `gorm:"type:varchar(1000);not null" json:"command"` // 插件绝对路径，必须位于配置的插件目录中
+	Arguments   string `gorm:"type:text" json:"arguments"`                 // JSON 数组格式存储参数，支持 $HOSTNAME$ 和 $HOSTADDRESS$
+	Timeout     int    `gorm:"not null" json:"timeout"`                    // 秒，超时后终止插件进程组并记为 CRITICAL
+	Interval    int    `gorm:"not null" json:"interval"`                   // 秒
+	Enabled     bool   `gorm:"not null" json:"enabled"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// 最近一次检查结果
+	LastCheckedAt  *time.Time `json:"last_checked_at"`
+	LastState      int        `gorm:"not null" json:"last_state"`      // 0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN
+	LastOutput     string     `gorm:"type:text" json:"last_output"`    // 插件输出的第一行
+	LastPerfData   string     `gorm:"type:text" json:"last_perf_data"` // 原始性能数据
+	LastDurationMs float64    `gorm:"not null;default:0" json:"last_duration_ms"`
+	StateChangedAt *time.Time `json:"state_changed_at"`
+
+	// 关联关系
+	Host *Host `gorm:"foreignKey:HostID" json:"host,omitempty"`
+}
+
+func (NagiosCheck) TableName() string {
+	return "nagios_checks"
+}
+
+// NagiosPerfData 插件性能数据项，时间和字节单位已换算为秒和字节
+type NagiosPerfData struct {
+	Label string   `json:"label"`
+	Value float64  `json:"value"`
+	UOM   string   `json:"uom"`            // s, %, B, c 或插件自定义单位
+	Warn  string   `json:"warn,omitempty"` // 阈值范围原文
+	Crit  string   `json:"crit,omitempty"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// NagiosResult 单次插件执行结果
+type NagiosResult struct {
+	State      int              `json:"state"`
+	StateName  string           `json:"state_name"`
+	ExitCode   int              `json:"exit_code"` // 超时或无法执行时为 -1
+	Output     string           `json:"output"`
+	LongOutput string           `json:"long_output,omitempty"`
+	PerfData   []NagiosPerfData `json:"perf_data"`
+	RawPerf    string           `json:"raw_perf_data,omitempty"`
+	DurationMs float64          `json:"duration_ms"`
+	TimedOut   bool             `json:"timed_out"`
+	CheckedAt  time.Time        `json:"checked_at"`
+}
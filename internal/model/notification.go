@@ -0,0 +1,102 @@
+package model
+
+import "time"
+
+// 通知接收方式
+const (
+	ReceiverLog     = "log"
+	ReceiverWebhook = "webhook"
+)
+
+// NotificationRoute 通知路由模型，按标签匹配告警并按分组键聚合发送
+type NotificationRoute struct {
+	BaseModel
+	Name           string `gorm:"type:varchar(255);not null;uniqueIndex:idx_notification_routes_active_name,where:deleted_at IS NULL" json:"name"`
+	Matchers       string `gorm:"type:varchar(500);not null;default:''" json:"matchers"`   // 告警标签选择器，为空匹配所有告警
+	GroupBy        string `gorm:"type:varchar(500);not null;default:''" json:"group_by"`   // 分组标签，逗号分隔，如 host_group,env,alertname；为空使用全局默认值
+	GroupWait      int    `gorm:"not null;default:0" json:"group_wait"`                    // 新分组首次发送前的等待时间（秒），0表示使用全局默认值
+	GroupInterval  int    `gorm:"not null;default:0" json:"group_interval"`                // 分组内有变化时的发送间隔（秒）
+	RepeatInterval int    `gorm:"not null;default:0" json:"repeat_interval"`               // 未恢复告警的重复提醒间隔（秒）
+	Receiver       string `gorm:"type:varchar(50);not null;default:'log'" json:"receiver"` // log, webhook
+	WebhookURL     string `gorm:"type:varchar(500);not null;default:''" json:"webhook_url"`
+	Priority       int    `gorm:"not null;default:0;index" json:"priority"` // 数值越小越先匹配
+	Continue       bool   `gorm:"not null;default:false" json:"continue"`   // 匹配后是否继续匹配后续路由
+	Enabled        bool   `gorm:"not null" json:"enabled"`
+	Description    string `gorm:"type:text" json:"description"`
+}
+
+func (NotificationRoute) TableName() string {
+	return "notification_routes"
+}
+
+// PendingNotificationGroup 尚未发送完的通知分组，服务重启后据此恢复待发送的通知
+type PendingNotificationGroup struct {
+	BaseModel
+	GroupKey   string     `gorm:"type:varchar(700);not null;uniqueIndex" json:"group_key"` // 删除时物理删除
+	RouteID    uint       `gorm:"not null" json:"route_id"`                                // 0 表示默认路由
+	Labels     string     `gorm:"type:varchar(500);not null;default:''" json:"labels"`     // 分组标签
+	Alerts     string     `gorm:"type:text;not null" json:"alerts"`                        // JSON 格式的 NotificationAlert 列表
+	Changed    bool       `gorm:"not null;default:false" json:"changed"`
+	LastSentAt *time.Time `json:"last_sent_at"`
+}
+
+func (PendingNotificationGroup) TableName() string {
+	return "pending_notification_groups"
+}
+
+// InhibitionRule 告警抑制规则模型，源告警活动期间抑制标签相同的目标告警
+type InhibitionRule struct {
+	BaseModel
+	Name           string `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	SourceMatchers string `gorm:"type:varchar(500);not null" json:"source_matchers"`          // 源告警标签选择器，如 metric_type=up
+	TargetMatchers string `gorm:"type:varchar(500);not null" json:"target_matchers"`          // 目标告警标签选择器，如 metric_type!=up
+	Equal          string `gorm:"type:varchar(500);not null;default:'hostname'" json:"equal"` // 源告警和目标告警必须相同的标签，逗号分隔
+	Enabled        bool   `gorm:"not null" json:"enabled"`
+	Description    string `gorm:"type:text" json:"description"`
+}
+
+func (InhibitionRule) TableName() string {
+	return "inhibition_rules"
+}
+
+// NotificationAlert represents a single alert inside an aggregated notification
+type NotificationAlert struct {
+	AlertID   uint              `json:"alert_id"`
+	Status    string            `json:"status"` // firing, resolved
+	Hostname  string            `json:"hostname"`
+	Severity  string            `json:"severity"`
+	Labels    map[string]string `json:"labels"`
+	Value     float64           `json:"value"`
+	Message   string            `json:"message"`
+	StartTime time.Time         `json:"start_time"`
+	EndTime   *time.Time        `json:"end_time,omitempty"`
+}
+
+// NotificationMessage represents an aggregated notification of an alert group
+type NotificationMessage struct {
+	RouteID     uint                `json:"route_id"` // 0 表示默认路由
+	RouteName   string              `json:"route_name"`
+	GroupKey    string              `json:"group_key"`
+	GroupLabels map[string]string   `json:"group_labels"`
+	Status      string              `json:"status"` // 分组内存在未恢复告警时为 firing，否则为 resolved
+	Repeat      bool                `json:"repeat"` // 是否为重复提醒
+	Firing      int                 `json:"firing"`
+	Resolved    int                 `json:"resolved"`
+	Summary     string              `json:"summary"`
+	Alerts      []NotificationAlert `json:"alerts"`
+	SentAt      time.Time           `json:"sent_at"`
+}
+
+// NotificationGroupStatus represents the pending state of an alert group in the dispatcher
+type NotificationGroupStatus struct {
+	RouteID     uint              `json:"route_id"`
+	RouteName   string            `json:"route_name"`
+	GroupKey    string            `json:"group_key"`
+	GroupLabels map[string]string `json:"group_labels"`
+	Alerts      int               `json:"alerts"`
+	Firing      int               `json:"firing"`
+	Changed     bool              `json:"changed"` // 上次发送后是否有变化
+	CreatedAt   time.Time         `json:"created_at"`
+	LastSentAt  *time.Time        `json:"last_sent_at"`
+	NextFlushAt time.Time         `json:"next_flush_at"`
+}
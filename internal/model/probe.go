@@ -0,0 +1,216 @@
+package model
+
+import "time"
+
+// 探测类型
+const (
+	ProbeTypeHTTP = "http"
+	ProbeTypeTCP  = "tcp"
+	ProbeTypeDNS  = "dns"
+	ProbeTypeCert = "cert"
+)
+
+// 证书来源
+const (
+	CertSourceTLS  = "tls"  // 连接 host:port 获取服务端证书
+	CertSourceFile = "file" // 读取本机 PEM 文件
+)
+
+// 可达性检测方式
+const (
+	ReachMethodTCP  = "tcp"  // TCP 连接，端口拒绝连接也说明主机可达
+	ReachMethodICMP = "icmp" // 非特权 UDP ping 套接字
+)
+
+// 自动检测得出的主机状态原因
+const (
+	HostReasonAgentSilent = "agent silent but host reachable"
+	HostReasonUnreachable = "host unreachable"
+	HostReasonProbeFailed = "status probe failed"
+)
+
+// HTTPCheck HTTP 探测模型，按间隔请求服务地址并校验响应
+type HTTPCheck struct {
+	BaseModel
+	Name           string `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	HostID         uint   `gorm:"not null;index" json:"host_id"` // 探测结果归属的主机
+	URL            string `gorm:"type:varchar(1000);not null" json:"url"`
+	Method         string `gorm:"type:varchar(10);not null;default:'GET'" json:"method"`
+	Headers        string `gorm:"type:text" json:"headers"` // JSON 对象格式存储请求头
+	ExpectedStatus int    `gorm:"not null" json:"expected_status"`
+	BodyRegex      string `gorm:"type:varchar(500);not null;default:''" json:"body_regex"` // 响应体需要匹配的正则，为空不校验
+	SkipTLSVerify  bool   `gorm:"not null;default:false" json:"skip_tls_verify"`
+	Timeout        int    `gorm:"not null" json:"timeout"`  // 秒
+	Interval       int    `gorm:"not null" json:"interval"` // 秒
+	Enabled        bool   `gorm:"not null" json:"enabled"`
+	Description    string `gorm:"type:text" json:"description"`
+
+	// 最近一次探测结果
+	LastCheckedAt  *time.Time `json:"last_checked_at"`
+	LastSuccess    bool       `gorm:"not null;default:false" json:"last_success"`
+	LastStatusCode int        `gorm:"not null;default:0" json:"last_status_code"`
+	LastLatencyMs  float64    `gorm:"not null;default:0" json:"last_latency_ms"`
+	LastError      string     `gorm:"type:text" json:"last_error"`
+
+	// 关联关系
+	Host *Host `gorm:"foreignKey:HostID" json:"host,omitempty"`
+}
+
+func (HTTPCheck) TableName() string {
+	return "http_checks"
+}
+
+// TCPCheck TCP 端口探测模型，建立连接并可选校验服务横幅
+type TCPCheck struct {
+	BaseModel
+	Name              string `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	HostID            uint   `gorm:"not null;index" json:"host_id"`
+	Address           string `gorm:"type:varchar(500);not null" json:"address"`                 // host:port
+	Send              string `gorm:"type:text" json:"send"`                                     // 连接后发送的内容，为空不发送
+	BannerRegex       string `gorm:"type:varchar(500);not null;default:''" json:"banner_regex"` // 服务返回内容需要匹配的正则，为空不校验
+	Timeout           int    `gorm:"not null" json:"timeout"`                                   // 秒
+	Interval          int    `gorm:"not null" json:"interval"`                                  // 秒
+	AffectsHostStatus bool   `gorm:"not null;default:false" json:"affects_host_status"`         // 连续失败达到阈值时将主机置为离线
+	FailureThreshold  int    `gorm:"not null" json:"failure_threshold"`
+	Enabled           bool   `gorm:"not null" json:"enabled"`
+	Description       string `gorm:"type:text" json:"description"`
+
+	// 最近一次探测结果
+	LastCheckedAt       *time.Time `json:"last_checked_at"`
+	LastSuccess         bool       `gorm:"not null;default:false" json:"last_success"`
+	LastLatencyMs       float64    `gorm:"not null;default:0" json:"last_latency_ms"`
+	LastError           string     `gorm:"type:text" json:"last_error"`
+	ConsecutiveFailures int        `gorm:"not null;default:0" json:"consecutive_failures"`
+
+	// 关联关系
+	Host *Host `gorm:"foreignKey:HostID" json:"host,omitempty"`
+}
+
+func (TCPCheck) TableName() string {
+	return "tcp_checks"
+}
+
+// DNSCheck DNS 解析探测模型，查询指定记录并可选校验解析结果
+type DNSCheck struct {
+	BaseModel
+	Name              string `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	HostID            uint   `gorm:"not null;index" json:"host_id"`
+	QueryName         string `gorm:"type:varchar(255);not null" json:"query_name"`
+	RecordType        string `gorm:"type:varchar(10);not null;default:'A'" json:"record_type"`     // A, AAAA, CNAME, MX, TXT, NS
+	ExpectedAnswer    string `gorm:"type:varchar(500);not null;default:''" json:"expected_answer"` // 解析结果需包含的记录，为空只要求有结果
+	Resolver          string `gorm:"type:varchar(255);not null;default:''" json:"resolver"`        // ip:port，为空使用系统解析器
+	Timeout           int    `gorm:"not null" json:"timeout"`                                      // 秒
+	Interval          int    `gorm:"not null" json:"interval"`                                     // 秒
+	AffectsHostStatus bool   `gorm:"not null;default:false" json:"affects_host_status"`            // 连续失败达到阈值时将主机置为离线
+	FailureThreshold  int    `gorm:"not null" json:"failure_threshold"`
+	Enabled           bool   `gorm:"not null" json:"enabled"`
+	Description       string `gorm:"type:text" json:"description"`
+
+	// 最近一次探测结果
+	LastCheckedAt       *time.Time `json:"last_checked_at"`
+	LastSuccess         bool       `gorm:"not null;default:false" json:"last_success"`
+	LastLatencyMs       float64    `gorm:"not null;default:0" json:"last_latency_ms"`
+	LastAnswers         string     `gorm:"type:text" json:"last_answers"` // 逗号分隔
+	LastError           string     `gorm:"type:text" json:"last_error"`
+	ConsecutiveFailures int        `gorm:"not null;default:0" json:"consecutive_failures"`
+
+	// 关联关系
+	Host *Host `gorm:"foreignKey:HostID" json:"host,omitempty"`
+}
+
+func (DNSCheck) TableName() string {
+	return "dns_checks"
+}
+
+// CertificateCheck 证书探测模型，定期获取证书并记录有效期和证书链校验结果
+type CertificateCheck struct {
+	BaseModel
+	Name        string `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	HostID      uint   `gorm:"not null;index" json:"host_id"`
+	Source      string `gorm:"type:varchar(10);not null;default:'tls'" json:"source"`    // tls, file
+	Address     string `gorm:"type:varchar(500);not null;default:''" json:"address"`     // tls 来源的 host:port
+	ServerName  string `gorm:"type:varchar(255);not null;default:''" json:"server_name"` // SNI 及证书校验使用的域名，默认取 address 中的主机名
+	FilePath    string `gorm:"type:varchar(1000);not null;default:''" json:"file_path"`  // file 来源的 PEM 文件路径
+	Timeout     int    `gorm:"not null" json:"timeout"`                                  // 秒
+	Interval    int    `gorm:"not null" json:"interval"`                                 // 秒
+	Enabled     bool   `gorm:"not null" json:"enabled"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// 最近一次探测结果
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	LastSuccess   bool       `gorm:"not null;default:false" json:"last_success"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+	NotBefore     *time.Time `json:"not_before"`
+	NotAfter      *time.Time `gorm:"index" json:"not_after"`
+	Subject       string     `gorm:"type:varchar(500);not null;default:''" json:"subject"`
+	Issuer        string     `gorm:"type:varchar(500);not null;default:''" json:"issuer"`
+	SANs          string     `gorm:"type:text" json:"sans"` // 逗号分隔
+	ChainValid    bool       `gorm:"not null;default:false" json:"chain_valid"`
+	ChainError    string     `gorm:"type:text" json:"chain_error"`
+
+	// 关联关系
+	Host *Host `gorm:"foreignKey:HostID" json:"host,omitempty"`
+}
+
+func (CertificateCheck) TableName() string {
+	return "certificate_checks"
+}
+
+// CertificateInfo 证书探测获取的证书信息
+type CertificateInfo struct {
+	Subject       string    `json:"subject"`
+	Issuer        string    `json:"issuer"`
+	SANs          []string  `json:"sans"`
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `json:"not_after"`
+	DaysRemaining float64   `json:"days_remaining"`
+	ChainValid    bool      `json:"chain_valid"`
+	ChainError    string    `json:"chain_error,omitempty"`
+}
+
+// CertificateStatus 证书列表项
+type CertificateStatus struct {
+	CheckID       uint       `json:"check_id"`
+	Name          string     `json:"name"`
+	HostID        uint       `json:"host_id"`
+	Hostname      string     `json:"hostname"`
+	Source        string     `json:"source"`
+	Target        string     `json:"target"` // 地址或文件路径
+	Subject       string     `json:"subject"`
+	Issuer        string     `json:"issuer"`
+	SANs          []string   `json:"sans"`
+	NotAfter      *time.Time `json:"not_after"`
+	DaysRemaining *float64   `json:"days_remaining"` // 尚未获取到证书时为空
+	ChainValid    bool       `json:"chain_valid"`
+	ChainError    string     `json:"chain_error,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// ProbeResult 单次探测结果
+type ProbeResult struct {
+	Success        bool             `json:"success"`
+	StatusCode     int              `json:"status_code,omitempty"`
+	LatencyMs      float64          `json:"latency_ms"`
+	TLSHandshakeMs float64          `json:"tls_handshake_ms,omitempty"` // 仅 HTTPS 请求
+	Answers        []string         `json:"answers,omitempty"`          // 仅 DNS 探测
+	Certificate    *CertificateInfo `json:"certificate,omitempty"`      // 仅证书探测
+	Error          string           `json:"error,omitempty"`
+	CheckedAt      time.Time        `json:"checked_at"`
+}
+
+// ReachabilityResult 主机可达性检测结果
+type ReachabilityResult struct {
+	HostID     uint      `json:"host_id"`
+	Hostname   string    `json:"hostname"`
+	IPAddress  string    `json:"ip_address"`
+	Reachable  bool      `json:"reachable"`
+	Method     string    `json:"method"`         // tcp, icmp，未能检测时为空
+	Port       int       `json:"port,omitempty"` // 应答的 TCP 端口
+	Sent       int       `json:"sent"`
+	Received   int       `json:"received"`
+	PacketLoss float64   `json:"packet_loss"` // 百分比
+	RTTMs      float64   `json:"rtt_ms"`      // 应答的平均往返时间
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
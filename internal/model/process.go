@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// ProcessWatch 进程监视定义，匹配主机上的进程并检查数量和资源使用上限
+type ProcessWatch struct {
+	BaseModel
+	Name          string  `gorm:"type:varchar(255);not null;uniqueIndex" json:"name"`
+	HostID        *uint   `gorm:"index" json:"host_id"`                                         // 有值表示主机监视
+	HostGroupID   *uint   `gorm:"index" json:"host_group_id"`                                   // 有值表示主机组监视，组内每台主机分别评估
+	ProcessName   string  `gorm:"type:varchar(255);not null;default:''" json:"process_name"`    // 进程名完全匹配，为空不校验
+	CmdlineRegex  string  `gorm:"type:varchar(500);not null;default:''" json:"cmdline_regex"`   // 命令行需要匹配的正则，为空不校验
+	User          string  `gorm:"type:varchar(100);not null;default:''" json:"user"`            // 进程所属用户，为空不校验
+	MinCount      int     `gorm:"not null" json:"min_count"`                                    // 匹配进程少于该数量视为缺失，0 只检查上限，默认值由接口设置
+	MaxCount      int     `gorm:"not null;default:0" json:"max_count"`                          // 匹配进程数量上限，0 不限制
+	MaxCPUPercent float64 `gorm:"type:decimal(10,2);not null;default:0" json:"max_cpu_percent"` // 匹配进程 CPU 使用率之和上限，0 不限制
+	MaxRSSMB      float64 `gorm:"type:decimal(12,2);not null;default:0" json:"max_rss_mb"`      // 匹配进程常驻内存之和上限（MB），0 不限制
+	MaxOpenFDs    int     `gorm:"not null;default:0" json:"max_open_fds"`                       // 匹配进程打开文件数之和上限，0 不限制
+	Enabled       bool    `gorm:"not null" json:"enabled"`                                      // 默认值由接口设置
+	Description   string  `gorm:"type:text" json:"description"`
+
+	// 关联关系
+	Host      *Host      `gorm:"foreignKey:HostID" json:"host,omitempty"`
+	HostGroup *HostGroup `gorm:"foreignKey:HostGroupID" json:"host_group,omitempty"`
+}
+
+func (ProcessWatch) TableName() string {
+	return "process_watches"
+}
+
+// ProcessWatchResult 进程监视在一台主机上的评估结果
+type ProcessWatchResult struct {
+	WatchID    uint      `json:"watch_id"`
+	Name       string    `json:"name"`
+	Hostname   string    `json:"hostname"`
+	Count      int       `json:"count"`
+	PIDs       []int32   `json:"pids"`
+	CPUPercent *float64  `json:"cpu_percent"` // 任一匹配进程尚无两次采样时为空，不检查 CPU 上限
+	RSSMB      float64   `json:"rss_mb"`
+	OpenFDs    int       `json:"open_fds"`
+	Restarts   int       `json:"restarts"`   // 与上次评估相比新出现的进程数
+	Missing    bool      `json:"missing"`    // 匹配进程少于最小数量
+	OverLimit  bool      `json:"over_limit"` // 超出任一上限
+	Violations []string  `json:"violations,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
@@ -43,6 +43,15 @@ type DiskData struct {
 	TotalCapacity float64    `json:"total_capacity"`
 	TotalUsed     float64    `json:"total_used"`
 	TotalFree     float64    `json:"total_free"`
+
+	FileDescriptors *FileDescriptorUsage `json:"file_descriptors,omitempty"` // nil when file-nr is unavailable
+}
+
+// FileDescriptorUsage represents the system-wide file handle usage from /proc/sys/fs/file-nr
+type FileDescriptorUsage struct {
+	Used         uint64  `json:"used"` // allocated minus allocated but unused handles
+	Max          uint64  `json:"max"`
+	UsagePercent float64 `json:"usage_percent"`
 }
 
 // DiskInfo represents individual disk information
@@ -54,6 +63,33 @@ type DiskInfo struct {
 	Used         float64 `json:"used"`
 	Free         float64 `json:"free"`
 	UsagePercent float64 `json:"usage_percent"`
+
+	InodesTotal       uint64  `json:"inodes_total"`
+	InodesUsed        uint64  `json:"inodes_used"`
+	InodesFree        uint64  `json:"inodes_free"`
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
+
+	ReadOnly           bool `json:"read_only"`            // mounted with the ro option
+	UnexpectedReadOnly bool `json:"unexpected_read_only"` // read-only although fstab mounts it read-write, e.g. remounted after I/O errors
+}
+
+// FilesystemHistory represents the recorded filesystem health samples of one metric
+type FilesystemHistory struct {
+	Hostname string             `json:"hostname"`
+	Metric   string             `json:"metric"`
+	Series   []FilesystemSeries `json:"series"`
+}
+
+// FilesystemSeries represents the recorded samples of one mount point, empty for system-wide metrics
+type FilesystemSeries struct {
+	MountPoint string            `json:"mount_point,omitempty"`
+	Points     []FilesystemPoint `json:"points"`
+}
+
+// FilesystemPoint represents a recorded filesystem health sample
+type FilesystemPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
 }
 
 // NetworkData represents network monitoring data
@@ -100,16 +136,186 @@ type ProcessData struct {
 	TotalProcesses    int           `json:"total_processes"`
 	RunningProcesses  int           `json:"running_processes"`
 	SleepingProcesses int           `json:"sleeping_processes"`
+	Matched           int           `json:"matched"` // processes matching the filters, before pagination
+	Offset            int           `json:"offset"`
+	Limit             int           `json:"limit"`
 }
 
 // ProcessInfo represents individual process information
 type ProcessInfo struct {
 	PID           int32     `json:"pid"`
+	PPID          int32     `json:"ppid"`
 	Name          string    `json:"name"`
-	CPUPercent    float64   `json:"cpu_percent"`
+	User          string    `json:"user"`
+	CPUPercent    float64   `json:"cpu_percent"` // sampled over an interval, not a lifetime average
 	MemoryPercent float32   `json:"memory_percent"`
 	MemoryMB      float64   `json:"memory_mb"`
+	NumThreads    int32     `json:"num_threads"`
 	Status        string    `json:"status"`
 	CreateTime    time.Time `json:"create_time"`
 	Cmdline       string    `json:"cmdline"`
-}
\ No newline at end of file
+}
+
+// ProcessQuery holds process list filters, sorting and pagination
+type ProcessQuery struct {
+	Name    string // name substring, case insensitive
+	User    string // exact user name
+	Status  string // exact status, such as running, sleep, zombie
+	Cmdline string // cmdline substring
+	SortBy  string // any ProcessInfo field by its JSON name, cpu and memory are accepted as shorthands
+	Order   string // asc or desc, defaults to desc for resource usage fields and asc otherwise
+	Offset  int
+	Limit   int // 0 means no limit
+}
+
+// ProcessDetail represents detailed information of a single process
+type ProcessDetail struct {
+	ProcessInfo
+	Exe         string              `json:"exe"`
+	Cwd         string              `json:"cwd"`
+	Parent      *ProcessRef         `json:"parent,omitempty"`
+	Children    []ProcessRef        `json:"children"`
+	OpenFiles   int32               `json:"open_files"`
+	Connections []ProcessConnection `json:"connections"`
+	Environment map[string]string   `json:"environment"` // only values of common harmless variables are shown, others are redacted
+	IOCounters  *ProcessIOCounters  `json:"io_counters,omitempty"`
+}
+
+// ProcessRef identifies a related process
+type ProcessRef struct {
+	PID  int32  `json:"pid"`
+	Name string `json:"name"`
+}
+
+// ProcessConnection represents a network connection of a process
+type ProcessConnection struct {
+	Protocol      string `json:"protocol"` // tcp, tcp6, udp, udp6, unix
+	LocalAddress  string `json:"local_address"`
+	RemoteAddress string `json:"remote_address"`
+	Status        string `json:"status"`
+}
+
+// ProcessIOCounters represents the I/O counters of a process
+type ProcessIOCounters struct {
+	ReadCount  uint64 `json:"read_count"`
+	WriteCount uint64 `json:"write_count"`
+	ReadBytes  uint64 `json:"read_bytes"`
+	WriteBytes uint64 `json:"write_bytes"`
+}
+
+// ProcessTree represents the process hierarchy built from parent PIDs
+type ProcessTree struct {
+	Roots          []*ProcessTreeNode `json:"roots"`
+	TotalProcesses int                `json:"total_processes"`
+	TotalZombies   int                `json:"total_zombies"`
+}
+
+// ProcessTreeNode represents a process, or sibling processes of the same name when collapsed,
+// with the aggregated usage of its subtree
+type ProcessTreeNode struct {
+	PID                  int32              `json:"pid"`
+	PPID                 int32              `json:"ppid"`
+	PIDs                 []int32            `json:"pids,omitempty"` // processes merged into the node when collapsed
+	Name                 string             `json:"name"`
+	User                 string             `json:"user"`
+	Status               string             `json:"status"`
+	Count                int                `json:"count"` // processes represented by the node
+	CPUPercent           float64            `json:"cpu_percent"`
+	MemoryPercent        float32            `json:"memory_percent"`
+	MemoryMB             float64            `json:"memory_mb"`
+	Zombies              int                `json:"zombies"` // zombie children not yet reaped by the node
+	SubtreeProcesses     int                `json:"subtree_processes"`
+	SubtreeCPUPercent    float64            `json:"subtree_cpu_percent"`
+	SubtreeMemoryPercent float32            `json:"subtree_memory_percent"`
+	SubtreeMemoryMB      float64            `json:"subtree_memory_mb"`
+	Children             []*ProcessTreeNode `json:"children"`
+}
+
+// ConnectionData represents socket monitoring data
+type ConnectionData struct {
+	Total           int                  `json:"total"`
+	TCPStates       map[string]int       `json:"tcp_states"` // every TCP state, zero when no socket is in it
+	UDPSockets      int                  `json:"udp_sockets"`
+	Listening       []ListeningPort      `json:"listening"`
+	RemoteAddresses []RemoteAddressCount `json:"remote_addresses"` // sorted by connection count, descending
+}
+
+// ListeningPort represents a listening socket and its owning process
+type ListeningPort struct {
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"`
+	Port     uint32 `json:"port"`
+	PID      int32  `json:"pid"`     // 0 when the owner cannot be read
+	Process  string `json:"process"` // empty when the owner cannot be read
+}
+
+// RemoteAddressCount represents the TCP connections to a remote address
+type RemoteAddressCount struct {
+	Address string         `json:"address"`
+	Count   int            `json:"count"`
+	States  map[string]int `json:"states"`
+}
+
+// Hardware sensor types
+const (
+	SensorTypeTemperature = "temperature"
+	SensorTypeFan         = "fan"
+	SensorTypePower       = "power"
+	SensorTypeVoltage     = "voltage"
+	SensorTypeCurrent     = "current"
+)
+
+// Hardware sensor states
+const (
+	SensorStatusNormal   = "normal"
+	SensorStatusAlarm    = "alarm"    // beyond the high or below the min value, or flagged by the chip
+	SensorStatusCritical = "critical" // at or beyond the critical value
+)
+
+// SensorData represents all hardware sensors of the machine grouped by chip
+type SensorData struct {
+	Chips     []SensorChip `json:"chips"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// SensorChip represents a hwmon chip and its sensors
+type SensorChip struct {
+	ID      string          `json:"id"`     // name and device, unique per machine
+	Name    string          `json:"name"`   // driver name, e.g. coretemp, nct6775
+	Device  string          `json:"device"` // underlying device, e.g. coretemp.0, 0000:00:18.3
+	Sensors []SensorReading `json:"sensors"`
+}
+
+// SensorReading represents a single sensor reading in °C, RPM, W, V or A
+type SensorReading struct {
+	Key      string   `json:"key"` // attribute prefix, e.g. temp1, fan2
+	Label    string   `json:"label"`
+	Type     string   `json:"type"`
+	Unit     string   `json:"unit"`
+	Value    float64  `json:"value"`
+	Min      *float64 `json:"min,omitempty"`
+	High     *float64 `json:"high,omitempty"`
+	Critical *float64 `json:"critical,omitempty"`
+	Alarm    bool     `json:"alarm"`
+	Status   string   `json:"status"`
+}
+
+// SensorHistory represents the recorded readings of hardware sensors of one type
+type SensorHistory struct {
+	Hostname string         `json:"hostname"`
+	Type     string         `json:"type"`
+	Series   []SensorSeries `json:"series"`
+}
+
+// SensorSeries represents the recorded readings of one sensor
+type SensorSeries struct {
+	Chip   string        `json:"chip"`
+	Sensor string        `json:"sensor"`
+	Points []SensorPoint `json:"points"`
+}
+
+// SensorPoint represents a recorded sensor reading
+type SensorPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
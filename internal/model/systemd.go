@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// SystemdUnitWatch systemd 单元监视，按主机配置需要保持运行的单元
+type SystemdUnitWatch struct {
+	BaseModel
+	HostID      uint   `gorm:"not null;uniqueIndex:idx_systemd_unit_watch_host_unit" json:"host_id"`
+	Unit        string `gorm:"type:varchar(255);not null;uniqueIndex:idx_systemd_unit_watch_host_unit" json:"unit"` // 单元全名，如 nginx.service
+	Enabled     bool   `gorm:"not null" json:"enabled"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// 最近一次查询结果
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	LoadState     string     `gorm:"type:varchar(50);not null;default:''" json:"load_state"`   // loaded, not-found, masked
+	ActiveState   string     `gorm:"type:varchar(50);not null;default:''" json:"active_state"` // active, inactive, failed, activating, deactivating
+	SubState      string     `gorm:"type:varchar(50);not null;default:''" json:"sub_state"`    // running, exited, dead, auto-restart 等
+	Result        string     `gorm:"type:varchar(50);not null;default:''" json:"result"`       // success, exit-code, signal, timeout 等
+	NRestarts     int        `gorm:"not null;default:0" json:"n_restarts"`                     // systemd 自动重启次数
+	MainPID       int32      `gorm:"not null;default:0" json:"main_pid"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+
+	// 关联关系
+	Host *Host `gorm:"foreignKey:HostID" json:"host,omitempty"`
+}
+
+func (SystemdUnitWatch) TableName() string {
+	return "systemd_unit_watches"
+}
+
+// SystemdUnitStatus systemd 单元状态，来自 systemctl show
+type SystemdUnitStatus struct {
+	Unit        string     `json:"unit"`
+	Description string     `json:"description"`
+	LoadState   string     `json:"load_state"`
+	ActiveState string     `json:"active_state"`
+	SubState    string     `json:"sub_state"`
+	Result      string     `json:"result"`
+	NRestarts   int        `json:"n_restarts"`
+	MainPID     int32      `json:"main_pid"`
+	ActiveSince *time.Time `json:"active_since,omitempty"`
+}
+
+// SystemdStatus 本机 systemd 状态，包括监视的单元和所有失败的单元
+type SystemdStatus struct {
+	Hostname    string              `json:"hostname"`
+	Units       []SystemdUnitStatus `json:"units"`
+	FailedUnits []string            `json:"failed_units"`
+	CheckedAt   time.Time           `json:"checked_at"`
+}
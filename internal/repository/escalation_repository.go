@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+)
+
+// EscalationRepository 升级策略和值班表仓库接口
+type EscalationRepository interface {
+	// 升级策略，步骤按顺序加载
+	CreatePolicy(policy *model.EscalationPolicy) error
+	GetPolicyByID(id uint) (*model.EscalationPolicy, error)
+	ListPolicies() ([]model.EscalationPolicy, error)
+	GetEnabledPolicies() ([]model.EscalationPolicy, error)
+	UpdatePolicy(policy *model.EscalationPolicy) error // 整体替换升级步骤
+	DeletePolicy(id uint) error
+
+	// 值班表
+	CreateSchedule(schedule *model.OnCallSchedule) error
+	GetScheduleByID(id uint) (*model.OnCallSchedule, error)
+	ListSchedules() ([]model.OnCallSchedule, error)
+	UpdateSchedule(schedule *model.OnCallSchedule) error
+	DeleteSchedule(id uint) error
+	CreateOverride(override *model.OnCallOverride) error
+	DeleteOverride(scheduleID, overrideID uint) error
+}
+
+// escalationRepository GORM实现
+type escalationRepository struct {
+	db *gorm.DB
+}
+
+// NewEscalationRepository 创建升级策略和值班表仓库
+func NewEscalationRepository(db *gorm.DB) EscalationRepository {
+	return &escalationRepository{db: db}
+}
+
+// preloadSteps 按顺序预加载升级步骤
+func preloadSteps(db *gorm.DB) *gorm.DB {
+	return db.Order("position asc")
+}
+
+func (r *escalationRepository) CreatePolicy(policy *model.EscalationPolicy) error {
+	return r.db.Create(policy).Error
+}
+
+func (r *escalationRepository) GetPolicyByID(id uint) (*model.EscalationPolicy, error) {
+	var policy model.EscalationPolicy
+	err := r.db.Preload("Steps", preloadSteps).Preload("HostGroup").First(&policy, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *escalationRepository) ListPolicies() ([]model.EscalationPolicy, error) {
+	var policies []model.EscalationPolicy
+	err := r.db.Preload("Steps", preloadSteps).Preload("HostGroup").Order("id asc").Find(&policies).Error
+	return policies, err
+}
+
+func (r *escalationRepository) GetEnabledPolicies() ([]model.EscalationPolicy, error) {
+	var policies []model.EscalationPolicy
+	err := r.db.Preload("Steps", preloadSteps).Where("enabled = ?", true).Order("id asc").Find(&policies).Error
+	return policies, err
+}
+
+func (r *escalationRepository) UpdatePolicy(policy *model.EscalationPolicy) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Steps", "HostGroup").Save(policy).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("policy_id = ?", policy.ID).Delete(&model.EscalationStep{}).Error; err != nil {
+			return err
+		}
+		for i := range policy.Steps {
+			policy.Steps[i].ID = 0
+			policy.Steps[i].PolicyID = policy.ID
+		}
+		if len(policy.Steps) == 0 {
+			return nil
+		}
+		return tx.Create(&policy.Steps).Error
+	})
+}
+
+func (r *escalationRepository) DeletePolicy(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("policy_id = ?", id).Delete(&model.EscalationStep{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.EscalationPolicy{}, id).Error
+	})
+}
+
+func (r *escalationRepository) CreateSchedule(schedule *model.OnCallSchedule) error {
+	return r.db.Create(schedule).Error
+}
+
+func (r *escalationRepository) GetScheduleByID(id uint) (*model.OnCallSchedule, error) {
+	var schedule model.OnCallSchedule
+	err := r.db.Preload("Overrides", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_time asc")
+	}).First(&schedule, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *escalationRepository) ListSchedules() ([]model.OnCallSchedule, error) {
+	var schedules []model.OnCallSchedule
+	err := r.db.Preload("Overrides", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_time asc")
+	}).Order("id asc").Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *escalationRepository) UpdateSchedule(schedule *model.OnCallSchedule) error {
+	return r.db.Omit("Overrides").Save(schedule).Error
+}
+
+func (r *escalationRepository) DeleteSchedule(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", id).Delete(&model.OnCallOverride{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.OnCallSchedule{}, id).Error
+	})
+}
+
+func (r *escalationRepository) CreateOverride(override *model.OnCallOverride) error {
+	return r.db.Create(override).Error
+}
+
+func (r *escalationRepository) DeleteOverride(scheduleID, overrideID uint) error {
+	result := r.db.Where("id = ? AND schedule_id = ?", overrideID, scheduleID).Delete(&model.OnCallOverride{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"testing"
+
+	"monitor-server/internal/model"
+)
+
+func TestEscalationPolicyCreateDisabled(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewEscalationRepository(db)
+
+	policy := &model.EscalationPolicy{Name: "critical", Severity: "critical", Enabled: false}
+	if err := repo.CreatePolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "enabled")
+	if policy.Enabled {
+		t.Error("disabled policy was created enabled")
+	}
+}
+
+func TestOnCallScheduleCreateWritesRotation(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewEscalationRepository(db)
+
+	schedule := &model.OnCallSchedule{Name: "ops", Participants: "alice,bob", RotationHours: 12}
+	if err := repo.CreateSchedule(schedule); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "rotation_hours")
+}
@@ -31,6 +31,7 @@ type HostRepository interface {
 	
 	// 批量操作
 	BatchUpdateStatus(hostIDs []uint, status string) error
+	UpdateStatusWithReason(hostID uint, status, reason string) error
 	BatchToggleMonitoring(hostIDs []uint, enabled bool) error
 	
 	// 统计查询
@@ -204,7 +205,17 @@ func (r *hostRepository) GetWithAll(id uint) (*model.Host, error) {
 }
 
 func (r *hostRepository) BatchUpdateStatus(hostIDs []uint, status string) error {
-	return r.db.Model(&model.Host{}).Where("id IN ?", hostIDs).Update("status", status).Error
+	return r.db.Model(&model.Host{}).Where("id IN ?", hostIDs).Updates(map[string]interface{}{
+		"status":        status,
+		"status_reason": "",
+	}).Error
+}
+
+func (r *hostRepository) UpdateStatusWithReason(hostID uint, status, reason string) error {
+	return r.db.Model(&model.Host{}).Where("id = ?", hostID).Updates(map[string]interface{}{
+		"status":        status,
+		"status_reason": reason,
+	}).Error
 }
 
 func (r *hostRepository) BatchToggleMonitoring(hostIDs []uint, enabled bool) error {
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+)
+
+// LogEventRepository 日志匹配事件仓库接口
+type LogEventRepository interface {
+	CreateBatch(events []model.LogEvent) error
+	List(hostID uint, watch string, since time.Time, limit int) ([]model.LogEvent, error) // 按匹配时间倒序，watch 为空时不过滤
+	DeleteOldEvents(days int) error
+}
+
+// logEventRepository GORM实现
+type logEventRepository struct {
+	db *gorm.DB
+}
+
+// NewLogEventRepository 创建日志匹配事件仓库
+func NewLogEventRepository(db *gorm.DB) LogEventRepository {
+	return &logEventRepository{db: db}
+}
+
+func (r *logEventRepository) CreateBatch(events []model.LogEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(events, 100).Error
+}
+
+func (r *logEventRepository) List(hostID uint, watch string, since time.Time, limit int) ([]model.LogEvent, error) {
+	var events []model.LogEvent
+	query := r.db.Where("host_id = ? AND matched_at >= ?", hostID, since)
+	if watch != "" {
+		query = query.Where("watch = ?", watch)
+	}
+	err := query.Order("matched_at desc, id desc").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *logEventRepository) DeleteOldEvents(days int) error {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	return r.db.Unscoped().Where("matched_at < ?", cutoff).Delete(&model.LogEvent{}).Error
+}
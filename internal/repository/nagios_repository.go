@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+)
+
+// NagiosCheckRepository Nagios 插件检查仓库接口
+type NagiosCheckRepository interface {
+	Create(check *model.NagiosCheck) error
+	GetByID(id uint) (*model.NagiosCheck, error)
+	Update(check *model.NagiosCheck) error
+	Delete(id uint) error
+	List(hostID uint) ([]model.NagiosCheck, error) // hostID 为0时返回全部
+	GetEnabled() ([]model.NagiosCheck, error)
+	UpdateResult(check *model.NagiosCheck) error // 仅更新最近一次检查结果
+}
+
+// nagiosCheckRepository GORM实现
+type nagiosCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewNagiosCheckRepository 创建 Nagios 插件检查仓库
+func NewNagiosCheckRepository(db *gorm.DB) NagiosCheckRepository {
+	return &nagiosCheckRepository{db: db}
+}
+
+func (r *nagiosCheckRepository) Create(check *model.NagiosCheck) error {
+	return r.db.Create(check).Error
+}
+
+func (r *nagiosCheckRepository) GetByID(id uint) (*model.NagiosCheck, error) {
+	var check model.NagiosCheck
+	err := r.db.Preload("Host").First(&check, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+func (r *nagiosCheckRepository) Update(check *model.NagiosCheck) error {
+	return r.db.Omit("Host").Save(check).Error
+}
+
+func (r *nagiosCheckRepository) Delete(id uint) error {
+	return r.db.Delete(&model.NagiosCheck{}, id).Error
+}
+
+func (r *nagiosCheckRepository) List(hostID uint) ([]model.NagiosCheck, error) {
+	var checks []model.NagiosCheck
+	query := r.db.Preload("Host").Order("id asc")
+	if hostID != 0 {
+		query = query.Where("host_id = ?", hostID)
+	}
+	err := query.Find(&checks).Error
+	return checks, err
+}
+
+func (r *nagiosCheckRepository) GetEnabled() ([]model.NagiosCheck, error) {
+	var checks []model.NagiosCheck
+	err := r.db.Preload("Host").Where("enabled = ?", true).Order("id asc").Find(&checks).Error
+	return checks, err
+}
+
+func (r *nagiosCheckRepository) UpdateResult(check *model.NagiosCheck) error {
+	return r.db.Model(check).
+		Select("LastCheckedAt", "LastState", "LastOutput", "LastPerfData", "LastDurationMs", "StateChangedAt").
+		Updates(check).Error
+}
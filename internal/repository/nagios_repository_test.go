@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"testing"
+
+	"monitor-server/internal/model"
+)
+
+func TestNagiosCheckCreateKeepsZeroValues(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewNagiosCheckRepository(db)
+
+	// 禁用的检查和 OK 状态不能被数据库默认值替换
+	check := &model.NagiosCheck{Name: "load", HostID: 1, Command: "/usr/lib/nagios/plugins/check_load", Timeout: 10, Interval: 60, LastState: model.NagiosStateOK}
+	if err := repo.Create(check); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "timeout", "interval", "enabled", "last_state")
+	if check.Enabled || check.LastState != model.NagiosStateOK {
+		t.Errorf("check was created with enabled %v and state %d", check.Enabled, check.LastState)
+	}
+}
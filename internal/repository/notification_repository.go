@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+)
+
+// NotificationRouteRepository 通知路由仓库接口
+type NotificationRouteRepository interface {
+	Create(route *model.NotificationRoute) error
+	GetByID(id uint) (*model.NotificationRoute, error)
+	Update(route *model.NotificationRoute) error
+	Delete(id uint) error
+	List() ([]model.NotificationRoute, error)       // 按优先级和ID排序
+	GetEnabled() ([]model.NotificationRoute, error) // 按优先级和ID排序
+}
+
+// notificationRouteRepository GORM实现
+type notificationRouteRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRouteRepository 创建通知路由仓库
+func NewNotificationRouteRepository(db *gorm.DB) NotificationRouteRepository {
+	return &notificationRouteRepository{db: db}
+}
+
+func (r *notificationRouteRepository) Create(route *model.NotificationRoute) error {
+	return r.db.Create(route).Error
+}
+
+func (r *notificationRouteRepository) GetByID(id uint) (*model.NotificationRoute, error) {
+	var route model.NotificationRoute
+	err := r.db.First(&route, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+func (r *notificationRouteRepository) Update(route *model.NotificationRoute) error {
+	return r.db.Save(route).Error
+}
+
+func (r *notificationRouteRepository) Delete(id uint) error {
+	return r.db.Delete(&model.NotificationRoute{}, id).Error
+}
+
+func (r *notificationRouteRepository) List() ([]model.NotificationRoute, error) {
+	var routes []model.NotificationRoute
+	err := r.db.Order("priority asc, id asc").Find(&routes).Error
+	return routes, err
+}
+
+func (r *notificationRouteRepository) GetEnabled() ([]model.NotificationRoute, error) {
+	var routes []model.NotificationRoute
+	err := r.db.Where("enabled = ?", true).Order("priority asc, id asc").Find(&routes).Error
+	return routes, err
+}
+
+// PendingNotificationGroupRepository 待发送通知分组仓库接口
+type PendingNotificationGroupRepository interface {
+	List() ([]model.PendingNotificationGroup, error)
+	Save(group *model.PendingNotificationGroup) error // 按分组键替换
+	Delete(groupKey string) error
+}
+
+// pendingNotificationGroupRepository GORM实现
+type pendingNotificationGroupRepository struct {
+	db *gorm.DB
+}
+
+// NewPendingNotificationGroupRepository 创建待发送通知分组仓库
+func NewPendingNotificationGroupRepository(db *gorm.DB) PendingNotificationGroupRepository {
+	return &pendingNotificationGroupRepository{db: db}
+}
+
+func (r *pendingNotificationGroupRepository) List() ([]model.PendingNotificationGroup, error) {
+	var groups []model.PendingNotificationGroup
+	err := r.db.Order("id asc").Find(&groups).Error
+	return groups, err
+}
+
+func (r *pendingNotificationGroupRepository) Save(group *model.PendingNotificationGroup) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("group_key = ?", group.GroupKey).
+			Delete(&model.PendingNotificationGroup{}).Error; err != nil {
+			return err
+		}
+		group.ID = 0
+		return tx.Create(group).Error
+	})
+}
+
+func (r *pendingNotificationGroupRepository) Delete(groupKey string) error {
+	return r.db.Unscoped().Where("group_key = ?", groupKey).Delete(&model.PendingNotificationGroup{}).Error
+}
+
+// InhibitionRuleRepository 告警抑制规则仓库接口
+type InhibitionRuleRepository interface {
+	Create(rule *model.InhibitionRule) error
+	GetByID(id uint) (*model.InhibitionRule, error)
+	Update(rule *model.InhibitionRule) error
+	Delete(id uint) error
+	List() ([]model.InhibitionRule, error)
+	GetEnabled() ([]model.InhibitionRule, error)
+}
+
+// inhibitionRuleRepository GORM实现
+type inhibitionRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewInhibitionRuleRepository 创建告警抑制规则仓库
+func NewInhibitionRuleRepository(db *gorm.DB) InhibitionRuleRepository {
+	return &inhibitionRuleRepository{db: db}
+}
+
+func (r *inhibitionRuleRepository) Create(rule *model.InhibitionRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *inhibitionRuleRepository) GetByID(id uint) (*model.InhibitionRule, error) {
+	var rule model.InhibitionRule
+	err := r.db.First(&rule, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *inhibitionRuleRepository) Update(rule *model.InhibitionRule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r *inhibitionRuleRepository) Delete(id uint) error {
+	return r.db.Delete(&model.InhibitionRule{}, id).Error
+}
+
+func (r *inhibitionRuleRepository) List() ([]model.InhibitionRule, error) {
+	var rules []model.InhibitionRule
+	err := r.db.Order("id asc").Find(&rules).Error
+	return rules, err
+}
+
+func (r *inhibitionRuleRepository) GetEnabled() ([]model.InhibitionRule, error) {
+	var rules []model.InhibitionRule
+	err := r.db.Where("enabled = ?", true).Order("id asc").Find(&rules).Error
+	return rules, err
+}
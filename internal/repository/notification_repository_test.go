@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+
+	"monitor-server/internal/model"
+)
+
+func TestNotificationRouteCreateDisabled(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewNotificationRouteRepository(db)
+
+	route := &model.NotificationRoute{Name: "ops", Receiver: model.ReceiverLog, Enabled: false}
+	if err := repo.Create(route); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "enabled")
+	if route.Enabled {
+		t.Error("disabled route was created enabled")
+	}
+}
+
+func TestInhibitionRuleCreateDisabled(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewInhibitionRuleRepository(db)
+
+	rule := &model.InhibitionRule{Name: "host down", SourceMatchers: "metric_type=up", TargetMatchers: "metric_type!=up", Equal: "hostname", Enabled: false}
+	if err := repo.Create(rule); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "enabled")
+	if rule.Enabled {
+		t.Error("disabled rule was created enabled")
+	}
+}
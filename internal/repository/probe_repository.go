@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+)
+
+// HTTPCheckRepository HTTP 探测仓库接口
+type HTTPCheckRepository interface {
+	Create(check *model.HTTPCheck) error
+	GetByID(id uint) (*model.HTTPCheck, error)
+	Update(check *model.HTTPCheck) error
+	Delete(id uint) error
+	List(hostID uint) ([]model.HTTPCheck, error) // hostID 为0时返回全部
+	GetEnabled() ([]model.HTTPCheck, error)
+	UpdateResult(check *model.HTTPCheck) error // 仅更新最近一次探测结果
+}
+
+// httpCheckRepository GORM实现
+type httpCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewHTTPCheckRepository 创建 HTTP 探测仓库
+func NewHTTPCheckRepository(db *gorm.DB) HTTPCheckRepository {
+	return &httpCheckRepository{db: db}
+}
+
+func (r *httpCheckRepository) Create(check *model.HTTPCheck) error {
+	return r.db.Create(check).Error
+}
+
+func (r *httpCheckRepository) GetByID(id uint) (*model.HTTPCheck, error) {
+	var check model.HTTPCheck
+	err := r.db.Preload("Host").First(&check, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+func (r *httpCheckRepository) Update(check *model.HTTPCheck) error {
+	return r.db.Omit("Host").Save(check).Error
+}
+
+func (r *httpCheckRepository) Delete(id uint) error {
+	return r.db.Delete(&model.HTTPCheck{}, id).Error
+}
+
+func (r *httpCheckRepository) List(hostID uint) ([]model.HTTPCheck, error) {
+	var checks []model.HTTPCheck
+	query := r.db.Preload("Host").Order("id asc")
+	if hostID != 0 {
+		query = query.Where("host_id = ?", hostID)
+	}
+	err := query.Find(&checks).Error
+	return checks, err
+}
+
+func (r *httpCheckRepository) GetEnabled() ([]model.HTTPCheck, error) {
+	var checks []model.HTTPCheck
+	err := r.db.Preload("Host").Where("enabled = ?", true).Order("id asc").Find(&checks).Error
+	return checks, err
+}
+
+func (r *httpCheckRepository) UpdateResult(check *model.HTTPCheck) error {
+	return r.db.Model(check).
+		Select("LastCheckedAt", "LastSuccess", "LastStatusCode", "LastLatencyMs", "LastError").
+		Updates(check).Error
+}
+
+// TCPCheckRepository TCP 探测仓库接口
+type TCPCheckRepository interface {
+	Create(check *model.TCPCheck) error
+	GetByID(id uint) (*model.TCPCheck, error)
+	Update(check *model.TCPCheck) error
+	Delete(id uint) error
+	List(hostID uint) ([]model.TCPCheck, error) // hostID 为0时返回全部
+	GetEnabled() ([]model.TCPCheck, error)
+	UpdateResult(check *model.TCPCheck) error // 仅更新最近一次探测结果
+}
+
+// tcpCheckRepository GORM实现
+type tcpCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewTCPCheckRepository 创建 TCP 探测仓库
+func NewTCPCheckRepository(db *gorm.DB) TCPCheckRepository {
+	return &tcpCheckRepository{db: db}
+}
+
+func (r *tcpCheckRepository) Create(check *model.TCPCheck) error {
+	return r.db.Create(check).Error
+}
+
+func (r *tcpCheckRepository) GetByID(id uint) (*model.TCPCheck, error) {
+	var check model.TCPCheck
+	err := r.db.Preload("Host").First(&check, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+func (r *tcpCheckRepository) Update(check *model.TCPCheck) error {
+	return r.db.Omit("Host").Save(check).Error
+}
+
+func (r *tcpCheckRepository) Delete(id uint) error {
+	return r.db.Delete(&model.TCPCheck{}, id).Error
+}
+
+func (r *tcpCheckRepository) List(hostID uint) ([]model.TCPCheck, error) {
+	var checks []model.TCPCheck
+	query := r.db.Preload("Host").Order("id asc")
+	if hostID != 0 {
+		query = query.Where("host_id = ?", hostID)
+	}
+	err := query.Find(&checks).Error
+	return checks, err
+}
+
+func (r *tcpCheckRepository) GetEnabled() ([]model.TCPCheck, error) {
+	var checks []model.TCPCheck
+	err := r.db.Preload("Host").Where("enabled = ?", true).Order("id asc").Find(&checks).Error
+	return checks, err
+}
+
+func (r *tcpCheckRepository) UpdateResult(check *model.TCPCheck) error {
+	return r.db.Model(check).
+		Select("LastCheckedAt", "LastSuccess", "LastLatencyMs", "LastError", "ConsecutiveFailures").
+		Updates(check).Error
+}
+
+// DNSCheckRepository DNS 探测仓库接口
+type DNSCheckRepository interface {
+	Create(check *model.DNSCheck) error
+	GetByID(id uint) (*model.DNSCheck, error)
+	Update(check *model.DNSCheck) error
+	Delete(id uint) error
+	List(hostID uint) ([]model.DNSCheck, error) // hostID 为0时返回全部
+	GetEnabled() ([]model.DNSCheck, error)
+	UpdateResult(check *model.DNSCheck) error // 仅更新最近一次探测结果
+}
+
+// dnsCheckRepository GORM实现
+type dnsCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewDNSCheckRepository 创建 DNS 探测仓库
+func NewDNSCheckRepository(db *gorm.DB) DNSCheckRepository {
+	return &dnsCheckRepository{db: db}
+}
+
+func (r *dnsCheckRepository) Create(check *model.DNSCheck) error {
+	return r.db.Create(check).Error
+}
+
+func (r *dnsCheckRepository) GetByID(id uint) (*model.DNSCheck, error) {
+	var check model.DNSCheck
+	err := r.db.Preload("Host").First(&check, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+func (r *dnsCheckRepository) Update(check *model.DNSCheck) error {
+	return r.db.Omit("Host").Save(check).Error
+}
+
+func (r *dnsCheckRepository) Delete(id uint) error {
+	return r.db.Delete(&model.DNSCheck{}, id).Error
+}
+
+func (r *dnsCheckRepository) List(hostID uint) ([]model.DNSCheck, error) {
+	var checks []model.DNSCheck
+	query := r.db.Preload("Host").Order("id asc")
+	if hostID != 0 {
+		query = query.Where("host_id = ?", hostID)
+	}
+	err := query.Find(&checks).Error
+	return checks, err
+}
+
+func (r *dnsCheckRepository) GetEnabled() ([]model.DNSCheck, error) {
+	var checks []model.DNSCheck
+	err := r.db.Preload("Host").Where("enabled = ?", true).Order("id asc").Find(&checks).Error
+	return checks, err
+}
+
+func (r *dnsCheckRepository) UpdateResult(check *model.DNSCheck) error {
+	return r.db.Model(check).
+		Select("LastCheckedAt", "LastSuccess", "LastLatencyMs", "LastAnswers", "LastError", "ConsecutiveFailures").
+		Updates(check).Error
+}
+
+// CertificateCheckRepository 证书探测仓库接口
+type CertificateCheckRepository interface {
+	Create(check *model.CertificateCheck) error
+	GetByID(id uint) (*model.CertificateCheck, error)
+	Update(check *model.CertificateCheck) error
+	Delete(id uint) error
+	List(hostID uint) ([]model.CertificateCheck, error) // hostID 为0时返回全部
+	GetEnabled() ([]model.CertificateCheck, error)
+	UpdateResult(check *model.CertificateCheck) error // 仅更新最近一次探测结果
+}
+
+// certificateCheckRepository GORM实现
+type certificateCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewCertificateCheckRepository 创建证书探测仓库
+func NewCertificateCheckRepository(db *gorm.DB) CertificateCheckRepository {
+	return &certificateCheckRepository{db: db}
+}
+
+func (r *certificateCheckRepository) Create(check *model.CertificateCheck) error {
+	return r.db.Create(check).Error
+}
+
+func (r *certificateCheckRepository) GetByID(id uint) (*model.CertificateCheck, error) {
+	var check model.CertificateCheck
+	err := r.db.Preload("Host").First(&check, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+func (r *certificateCheckRepository) Update(check *model.CertificateCheck) error {
+	return r.db.Omit("Host").Save(check).Error
+}
+
+func (r *certificateCheckRepository) Delete(id uint) error {
+	return r.db.Delete(&model.CertificateCheck{}, id).Error
+}
+
+func (r *certificateCheckRepository) List(hostID uint) ([]model.CertificateCheck, error) {
+	var checks []model.CertificateCheck
+	query := r.db.Preload("Host").Order("id asc")
+	if hostID != 0 {
+		query = query.Where("host_id = ?", hostID)
+	}
+	err := query.Find(&checks).Error
+	return checks, err
+}
+
+func (r *certificateCheckRepository) GetEnabled() ([]model.CertificateCheck, error) {
+	var checks []model.CertificateCheck
+	err := r.db.Preload("Host").Where("enabled = ?", true).Order("id asc").Find(&checks).Error
+	return checks, err
+}
+
+func (r *certificateCheckRepository) UpdateResult(check *model.CertificateCheck) error {
+	return r.db.Model(check).
+		Select("LastCheckedAt", "LastSuccess", "LastError", "NotBefore", "NotAfter", "Subject", "Issuer", "SANs", "ChainValid", "ChainError").
+		Updates(check).Error
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"testing"
+
+	"monitor-server/internal/model"
+)
+
+func TestHTTPCheckCreateDisabled(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewHTTPCheckRepository(db)
+
+	check := &model.HTTPCheck{Name: "api", HostID: 1, URL: "https://example.com", Method: "GET", ExpectedStatus: 200, Timeout: 10, Interval: 60}
+	if err := repo.Create(check); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "expected_status", "timeout", "interval", "enabled")
+	if check.Enabled {
+		t.Error("disabled check was created enabled")
+	}
+}
+
+func TestTCPCheckCreateDisabled(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewTCPCheckRepository(db)
+
+	check := &model.TCPCheck{Name: "ssh", HostID: 1, Address: "127.0.0.1:22", Timeout: 10, Interval: 60, FailureThreshold: 3}
+	if err := repo.Create(check); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "timeout", "interval", "failure_threshold", "enabled")
+	if check.Enabled {
+		t.Error("disabled check was created enabled")
+	}
+}
+
+func TestDNSCheckCreateDisabled(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewDNSCheckRepository(db)
+
+	check := &model.DNSCheck{Name: "resolver", HostID: 1, QueryName: "example.com", RecordType: "A", Timeout: 10, Interval: 60, FailureThreshold: 3}
+	if err := repo.Create(check); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "timeout", "interval", "failure_threshold", "enabled")
+	if check.Enabled {
+		t.Error("disabled check was created enabled")
+	}
+}
+
+func TestCertificateCheckCreateDisabled(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewCertificateCheckRepository(db)
+
+	check := &model.CertificateCheck{Name: "site", HostID: 1, Source: "tls", Address: "example.com:443", Timeout: 10, Interval: 60}
+	if err := repo.Create(check); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "timeout", "interval", "enabled")
+	if check.Enabled {
+		t.Error("disabled check was created enabled")
+	}
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+)
+
+// ProcessWatchRepository 进程监视仓库接口
+type ProcessWatchRepository interface {
+	Create(watch *model.ProcessWatch) error
+	GetByID(id uint) (*model.ProcessWatch, error)
+	Update(watch *model.ProcessWatch) error
+	Delete(id uint) error
+	List() ([]model.ProcessWatch, error)
+	GetEnabled() ([]model.ProcessWatch, error)
+}
+
+// processWatchRepository GORM实现
+type processWatchRepository struct {
+	db *gorm.DB
+}
+
+// NewProcessWatchRepository 创建进程监视仓库
+func NewProcessWatchRepository(db *gorm.DB) ProcessWatchRepository {
+	return &processWatchRepository{db: db}
+}
+
+func (r *processWatchRepository) Create(watch *model.ProcessWatch) error {
+	return r.db.Create(watch).Error
+}
+
+func (r *processWatchRepository) GetByID(id uint) (*model.ProcessWatch, error) {
+	var watch model.ProcessWatch
+	err := r.db.Preload("Host").Preload("HostGroup").First(&watch, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &watch, nil
+}
+
+func (r *processWatchRepository) Update(watch *model.ProcessWatch) error {
+	return r.db.Omit("Host", "HostGroup").Save(watch).Error
+}
+
+func (r *processWatchRepository) Delete(id uint) error {
+	return r.db.Delete(&model.ProcessWatch{}, id).Error
+}
+
+func (r *processWatchRepository) List() ([]model.ProcessWatch, error) {
+	var watches []model.ProcessWatch
+	err := r.db.Preload("Host").Preload("HostGroup").Order("id asc").Find(&watches).Error
+	return watches, err
+}
+
+func (r *processWatchRepository) GetEnabled() ([]model.ProcessWatch, error) {
+	var watches []model.ProcessWatch
+	err := r.db.Where("enabled = ?", true).Order("id asc").Find(&watches).Error
+	return watches, err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+)
+
+// dryRunDB 不连接数据库，只生成 SQL
+func dryRunDB(t *testing.T) (*gorm.DB, *[]string) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statements []string
+	if err := db.Callback().Create().After("gorm:create").Register("test:record", func(tx *gorm.DB) {
+		statements = append(statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return db, &statements
+}
+
+// assertInsertWrites 检查插入语句写入了指定列的值，而不是读回数据库默认值
+func assertInsertWrites(t *testing.T, statements []string, columns ...string) {
+	t.Helper()
+	if len(statements) != 1 {
+		t.Fatalf("got %d statements, want 1", len(statements))
+	}
+	sql := statements[0]
+	returning := ""
+	if i := strings.Index(sql, "RETURNING"); i >= 0 {
+		returning = sql[i:]
+	}
+	for _, column := range columns {
+		if !strings.Contains(sql, `"`+column+`"`) {
+			t.Errorf("insert %s does not write %s", sql, column)
+		}
+		if strings.Contains(returning, column) {
+			t.Errorf("insert %s reads %s back from the database default", sql, column)
+		}
+	}
+}
+
+func TestProcessWatchCreateKeepsZeroMinCount(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewProcessWatchRepository(db)
+
+	// 最少进程数为 0 表示只检查上限，不能被数据库默认值替换
+	watch := &model.ProcessWatch{Name: "workers", ProcessName: "worker", MinCount: 0, MaxCount: 8, Enabled: true}
+	if err := repo.Create(watch); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "min_count")
+	if watch.MinCount != 0 {
+		t.Errorf("MinCount = %d, want 0", watch.MinCount)
+	}
+}
+
+func TestProcessWatchCreateDisabled(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewProcessWatchRepository(db)
+
+	watch := &model.ProcessWatch{Name: "workers", ProcessName: "worker", MinCount: 1, Enabled: false}
+	if err := repo.Create(watch); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "enabled")
+	if watch.Enabled {
+		t.Error("disabled watch was created enabled")
+	}
+}
@@ -14,6 +14,7 @@ type MetricsRepository interface {
 	CreateBatch(metrics []model.SystemMetrics) error
 	GetLatestByHostname(hostname string) (*model.SystemMetrics, error)
 	GetHistoryByHostname(hostname string, hours int) ([]model.SystemMetrics, error)
+	GetRange(hostnames []string, start, end time.Time) ([]model.SystemMetrics, error) // hostnames 为空表示所有主机
 	GetAverageCPUUsage(hostname string, hours int) (float64, error)
 	GetHostStats() ([]HostStats, error)
 	DeleteOldRecords(days int) error
@@ -41,23 +42,32 @@ type AlertRepository interface {
 	GetActiveRules() ([]model.AlertRule, error)
 	GetAllRules() ([]model.AlertRule, error)
 	GetRulesByHostID(hostID *uint) ([]model.AlertRule, error) // 获取指定主机的规则（包括全局规则）
-	GetGlobalRules() ([]model.AlertRule, error) // 获取全局规则
+	GetGlobalRules() ([]model.AlertRule, error)               // 获取全局规则
 	GetRuleByID(id uint) (*model.AlertRule, error)
 	UpdateRule(rule *model.AlertRule) error
 	DeleteRule(id uint) error
 	CreateAlert(alert *model.Alert) error
+	GetAlertByID(id uint) (*model.Alert, error)
+	ListAlerts(status, hostname string, offset, limit int) ([]model.Alert, int64, error) // 按开始时间倒序
+	UpdateAlert(alert *model.Alert) error                                                // 不修改确认和升级信息
+	ReopenAlert(alert *model.Alert) error                                                // 重新打开已恢复的告警，清除确认和升级信息
+	AcknowledgeAlert(id uint, by string, at time.Time) (bool, error)                     // 仅确认未确认的活动告警
+	UpdateEscalationState(alert *model.Alert) error
 	GetActiveAlerts() ([]model.Alert, error)
+	GetFiringAlerts() ([]model.Alert, error)                                            // 获取未恢复的告警，包括被抑制的告警
+	GetRecentlyResolvedAlert(fingerprint string, since time.Time) (*model.Alert, error) // 获取指定时间后恢复的最近一条告警
+	GetFlappingAlerts() ([]model.Alert, error)
 	ResolveAlert(id uint) error
 }
 
 // HostStats 主机统计信息
 type HostStats struct {
-	Hostname string  `json:"hostname"`
-	Count    int64   `json:"count"`
-	AvgCPU   float64 `json:"avg_cpu"`
-	MaxCPU   float64 `json:"max_cpu"`
-	AvgMem   float64 `json:"avg_memory"`
-	MaxMem   float64 `json:"max_memory"`
+	Hostname string    `json:"hostname"`
+	Count    int64     `json:"count"`
+	AvgCPU   float64   `json:"avg_cpu"`
+	MaxCPU   float64   `json:"max_cpu"`
+	AvgMem   float64   `json:"avg_memory"`
+	MaxMem   float64   `json:"max_memory"`
 	LastSeen time.Time `json:"last_seen"`
 }
 
@@ -84,7 +94,7 @@ func (r *metricsRepository) GetLatestByHostname(hostname string) (*model.SystemM
 	err := r.db.Where("hostname = ?", hostname).
 		Order("timestamp desc").
 		First(&metric).Error
-	
+
 	if err != nil {
 		return nil, err
 	}
@@ -94,29 +104,40 @@ func (r *metricsRepository) GetLatestByHostname(hostname string) (*model.SystemM
 func (r *metricsRepository) GetHistoryByHostname(hostname string, hours int) ([]model.SystemMetrics, error) {
 	var metrics []model.SystemMetrics
 	since := time.Now().Add(-time.Duration(hours) * time.Hour)
-	
+
 	err := r.db.Where("hostname = ? AND timestamp > ?", hostname, since).
 		Order("timestamp desc").
 		Find(&metrics).Error
-	
+
+	return metrics, err
+}
+
+func (r *metricsRepository) GetRange(hostnames []string, start, end time.Time) ([]model.SystemMetrics, error) {
+	var metrics []model.SystemMetrics
+	query := r.db.Where("timestamp >= ? AND timestamp <= ?", start, end)
+	if len(hostnames) > 0 {
+		query = query.Where("hostname IN ?", hostnames)
+	}
+
+	err := query.Order("hostname asc, timestamp asc").Find(&metrics).Error
 	return metrics, err
 }
 
 func (r *metricsRepository) GetAverageCPUUsage(hostname string, hours int) (float64, error) {
 	var avgCPU float64
 	since := time.Now().Add(-time.Duration(hours) * time.Hour)
-	
+
 	err := r.db.Model(&model.SystemMetrics{}).
 		Where("hostname = ? AND timestamp > ?", hostname, since).
 		Select("AVG(cpu_usage)").
 		Scan(&avgCPU).Error
-	
+
 	return avgCPU, err
 }
 
 func (r *metricsRepository) GetHostStats() ([]HostStats, error) {
 	var stats []HostStats
-	
+
 	err := r.db.Model(&model.SystemMetrics{}).
 		Select(`
 			hostname,
@@ -129,7 +150,7 @@ func (r *metricsRepository) GetHostStats() ([]HostStats, error) {
 		`).
 		Group("hostname").
 		Scan(&stats).Error
-	
+
 	return stats, err
 }
 
@@ -231,20 +252,22 @@ func (r *alertRepository) GetActiveRules() ([]model.AlertRule, error) {
 
 func (r *alertRepository) GetAllRules() ([]model.AlertRule, error) {
 	var rules []model.AlertRule
-	err := r.db.Preload("Host").Find(&rules).Error
+	err := r.db.Preload("Host").Preload("HostGroup").Find(&rules).Error
 	return rules, err
 }
 
 func (r *alertRepository) GetRulesByHostID(hostID *uint) ([]model.AlertRule, error) {
 	var rules []model.AlertRule
-	// 获取全局规则（host_id 为 null）和指定主机的规则
-	err := r.db.Preload("Host").Where("host_id IS NULL OR host_id = ?", hostID).Find(&rules).Error
+	// 获取全局规则（未指定主机、主机组和选择器）和指定主机的规则
+	err := r.db.Preload("Host").
+		Where("(host_id IS NULL AND host_group_id IS NULL AND selector = '') OR host_id = ?", hostID).
+		Find(&rules).Error
 	return rules, err
 }
 
 func (r *alertRepository) GetGlobalRules() ([]model.AlertRule, error) {
 	var rules []model.AlertRule
-	err := r.db.Where("host_id IS NULL").Find(&rules).Error
+	err := r.db.Where("host_id IS NULL AND host_group_id IS NULL AND selector = ''").Find(&rules).Error
 	return rules, err
 }
 
@@ -269,12 +292,114 @@ func (r *alertRepository) CreateAlert(alert *model.Alert) error {
 	return r.db.Create(alert).Error
 }
 
+func (r *alertRepository) GetAlertByID(id uint) (*model.Alert, error) {
+	var alert model.Alert
+	err := r.db.Preload("Rule").First(&alert, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *alertRepository) ListAlerts(status, hostname string, offset, limit int) ([]model.Alert, int64, error) {
+	var alerts []model.Alert
+	var total int64
+
+	query := r.db.Model(&model.Alert{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if hostname != "" {
+		query = query.Where("hostname = ?", hostname)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Rule").
+		Order("start_time desc").
+		Offset(offset).
+		Limit(limit).
+		Find(&alerts).Error
+	return alerts, total, err
+}
+
+// alertHandlingFields 确认和升级信息，由确认操作和升级过程单独修改
+var alertHandlingFields = []string{"AcknowledgedAt", "AcknowledgedBy", "EscalationPolicyID", "EscalationStep", "LastEscalatedAt"}
+
+func (r *alertRepository) UpdateAlert(alert *model.Alert) error {
+	// 避免评估过程覆盖并发的确认操作和升级进度
+	return r.db.Omit(append([]string{"Rule"}, alertHandlingFields...)...).Save(alert).Error
+}
+
+func (r *alertRepository) ReopenAlert(alert *model.Alert) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(append([]string{"Rule"}, alertHandlingFields...)...).Save(alert).Error; err != nil {
+			return err
+		}
+		// 重新打开的告警需要重新确认和升级
+		alert.AcknowledgedAt = nil
+		alert.AcknowledgedBy = ""
+		alert.EscalationPolicyID = nil
+		alert.EscalationStep = 0
+		alert.LastEscalatedAt = nil
+		return tx.Model(alert).Updates(map[string]interface{}{
+			"acknowledged_at":      nil,
+			"acknowledged_by":      "",
+			"escalation_policy_id": nil,
+			"escalation_step":      0,
+			"last_escalated_at":    nil,
+		}).Error
+	})
+}
+
+func (r *alertRepository) AcknowledgeAlert(id uint, by string, at time.Time) (bool, error) {
+	result := r.db.Model(&model.Alert{}).
+		Where("id = ? AND status = ? AND acknowledged_at IS NULL", id, "active").
+		Updates(map[string]interface{}{
+			"acknowledged_at": at,
+			"acknowledged_by": by,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *alertRepository) UpdateEscalationState(alert *model.Alert) error {
+	return r.db.Model(alert).
+		Select("EscalationPolicyID", "EscalationStep", "LastEscalatedAt").
+		Updates(alert).Error
+}
+
 func (r *alertRepository) GetActiveAlerts() ([]model.Alert, error) {
 	var alerts []model.Alert
 	err := r.db.Preload("Rule").Where("status = ?", "active").Find(&alerts).Error
 	return alerts, err
 }
 
+func (r *alertRepository) GetFiringAlerts() ([]model.Alert, error) {
+	var alerts []model.Alert
+	err := r.db.Preload("Rule").Where("status IN ?", []string{"active", "suppressed"}).Find(&alerts).Error
+	return alerts, err
+}
+
+func (r *alertRepository) GetRecentlyResolvedAlert(fingerprint string, since time.Time) (*model.Alert, error) {
+	var alert model.Alert
+	err := r.db.Preload("Rule").
+		Where("fingerprint = ? AND status = ? AND end_time >= ?", fingerprint, "resolved", since).
+		Order("end_time desc").
+		First(&alert).Error
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *alertRepository) GetFlappingAlerts() ([]model.Alert, error) {
+	var alerts []model.Alert
+	err := r.db.Preload("Rule").Where("flapping = ?", true).Find(&alerts).Error
+	return alerts, err
+}
+
 func (r *alertRepository) ResolveAlert(id uint) error {
 	now := time.Now()
 	return r.db.Model(&model.Alert{}).
@@ -283,4 +408,4 @@ func (r *alertRepository) ResolveAlert(id uint) error {
 			"status":   "resolved",
 			"end_time": &now,
 		}).Error
-}
\ No newline at end of file
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+)
+
+// SampleRepository 通用指标样本仓库接口
+type SampleRepository interface {
+	CreateBatch(samples []model.MetricSample) error
+	GetSeries(hostname, metric string, start, end time.Time) ([]model.MetricSample, error) // 按标签和时间升序排列
+	GetLastSeen(hostname, metric string, since time.Time) (map[string]time.Time, error)    // 各标签序列的最新样本时间
+	DeleteOldSamples(days int) error
+}
+
+// sampleRepository GORM实现
+type sampleRepository struct {
+	db *gorm.DB
+}
+
+// NewSampleRepository 创建通用指标样本仓库
+func NewSampleRepository(db *gorm.DB) SampleRepository {
+	return &sampleRepository{db: db}
+}
+
+func (r *sampleRepository) CreateBatch(samples []model.MetricSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(samples, 100).Error
+}
+
+func (r *sampleRepository) GetSeries(hostname, metric string, start, end time.Time) ([]model.MetricSample, error) {
+	var samples []model.MetricSample
+	err := r.db.Where("hostname = ? AND metric = ? AND timestamp >= ? AND timestamp <= ?", hostname, metric, start, end).
+		Order("labels asc, timestamp asc").
+		Find(&samples).Error
+	return samples, err
+}
+
+func (r *sampleRepository) GetLastSeen(hostname, metric string, since time.Time) (map[string]time.Time, error) {
+	var rows []struct {
+		Labels   string
+		LastSeen time.Time
+	}
+	err := r.db.Model(&model.MetricSample{}).
+		Select("labels, MAX(timestamp) AS last_seen").
+		Where("hostname = ? AND metric = ? AND timestamp >= ?", hostname, metric, since).
+		Group("labels").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lastSeen := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		lastSeen[row.Labels] = row.LastSeen
+	}
+	return lastSeen, nil
+}
+
+func (r *sampleRepository) DeleteOldSamples(days int) error {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	// 样本数据量大，直接物理删除
+	return r.db.Unscoped().Where("timestamp < ?", cutoff).Delete(&model.MetricSample{}).Error
+}
+
+// BaselineRepository 指标基线仓库接口
+type BaselineRepository interface {
+	GetBaselines(hostname, metricType string, lookback int) ([]model.MetricBaseline, error) // 按标签和分桶排序
+	ReplaceBaselines(hostname, metricType string, lookback int, baselines []model.MetricBaseline) error
+}
+
+// baselineRepository GORM实现
+type baselineRepository struct {
+	db *gorm.DB
+}
+
+// NewBaselineRepository 创建指标基线仓库
+func NewBaselineRepository(db *gorm.DB) BaselineRepository {
+	return &baselineRepository{db: db}
+}
+
+func (r *baselineRepository) GetBaselines(hostname, metricType string, lookback int) ([]model.MetricBaseline, error) {
+	var baselines []model.MetricBaseline
+	err := r.db.Where("hostname = ? AND metric_type = ? AND lookback = ?", hostname, metricType, lookback).
+		Order("labels asc, bucket asc").
+		Find(&baselines).Error
+	return baselines, err
+}
+
+func (r *baselineRepository) ReplaceBaselines(hostname, metricType string, lookback int, baselines []model.MetricBaseline) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Where("hostname = ? AND metric_type = ? AND lookback = ?", hostname, metricType, lookback).
+			Delete(&model.MetricBaseline{}).Error; err != nil {
+			return err
+		}
+		if len(baselines) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(baselines, 100).Error
+	})
+}
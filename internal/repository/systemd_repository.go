@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+)
+
+// SystemdUnitWatchRepository systemd 单元监视仓库接口
+type SystemdUnitWatchRepository interface {
+	Create(watch *model.SystemdUnitWatch) error
+	GetByID(id uint) (*model.SystemdUnitWatch, error)
+	Update(watch *model.SystemdUnitWatch) error
+	Delete(id uint) error
+	List(hostID uint) ([]model.SystemdUnitWatch, error) // hostID 为0时返回全部
+	GetEnabledByHost(hostID uint) ([]model.SystemdUnitWatch, error)
+	UpdateResult(watch *model.SystemdUnitWatch) error // 仅更新最近一次查询结果
+}
+
+// systemdUnitWatchRepository GORM实现
+type systemdUnitWatchRepository struct {
+	db *gorm.DB
+}
+
+// NewSystemdUnitWatchRepository 创建 systemd 单元监视仓库
+func NewSystemdUnitWatchRepository(db *gorm.DB) SystemdUnitWatchRepository {
+	return &systemdUnitWatchRepository{db: db}
+}
+
+func (r *systemdUnitWatchRepository) Create(watch *model.SystemdUnitWatch) error {
+	return r.db.Create(watch).Error
+}
+
+func (r *systemdUnitWatchRepository) GetByID(id uint) (*model.SystemdUnitWatch, error) {
+	var watch model.SystemdUnitWatch
+	err := r.db.Preload("Host").First(&watch, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &watch, nil
+}
+
+func (r *systemdUnitWatchRepository) Update(watch *model.SystemdUnitWatch) error {
+	return r.db.Omit("Host").Save(watch).Error
+}
+
+func (r *systemdUnitWatchRepository) Delete(id uint) error {
+	return r.db.Delete(&model.SystemdUnitWatch{}, id).Error
+}
+
+func (r *systemdUnitWatchRepository) List(hostID uint) ([]model.SystemdUnitWatch, error) {
+	var watches []model.SystemdUnitWatch
+	query := r.db.Preload("Host").Order("id asc")
+	if hostID != 0 {
+		query = query.Where("host_id = ?", hostID)
+	}
+	err := query.Find(&watches).Error
+	return watches, err
+}
+
+func (r *systemdUnitWatchRepository) GetEnabledByHost(hostID uint) ([]model.SystemdUnitWatch, error) {
+	var watches []model.SystemdUnitWatch
+	err := r.db.Where("host_id = ? AND enabled = ?", hostID, true).Order("id asc").Find(&watches).Error
+	return watches, err
+}
+
+func (r *systemdUnitWatchRepository) UpdateResult(watch *model.SystemdUnitWatch) error {
+	return r.db.Model(watch).
+		Select("LastCheckedAt", "LoadState", "ActiveState", "SubState", "Result", "NRestarts", "MainPID", "LastError").
+		Updates(watch).Error
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"testing"
+
+	"monitor-server/internal/model"
+)
+
+func TestSystemdUnitWatchCreateDisabled(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := NewSystemdUnitWatchRepository(db)
+
+	watch := &model.SystemdUnitWatch{HostID: 1, Unit: "nginx.service", Enabled: false}
+	if err := repo.Create(watch); err != nil {
+		t.Fatal(err)
+	}
+	assertInsertWrites(t, *statements, "enabled")
+	if watch.Enabled {
+		t.Error("disabled watch was created enabled")
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+// MetricPoint represents a single value of a metric series
+type MetricPoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// firingEpisode represents a continuous period during which a rule condition held
+type firingEpisode struct {
+	BreachStart time.Time // 首次满足条件的时间
+	FireTime    time.Time // 满足持续时间后触发告警的时间
+	EndTime     time.Time
+	Peak        float64
+	Resolved    bool
+}
+
+// CompareValue checks a metric value against a threshold using the rule operator
+func CompareValue(operator string, value, threshold float64) (bool, error) {
+	switch operator {
+	case ">":
+		return value > threshold, nil
+	case ">=":
+		return value >= threshold, nil
+	case "<":
+		return value < threshold, nil
+	case "<=":
+		return value <= threshold, nil
+	case "==":
+		return value == threshold, nil
+	default:
+		return false, fmt.Errorf("unsupported operator: %s", operator)
+	}
+}
+
+// MetricSeries extracts the series of a metric type from system metrics of a single host.
+// The metrics must be ordered by timestamp ascending.
+func MetricSeries(metricType string, metrics []model.SystemMetrics) ([]MetricPoint, error) {
+	points := make([]MetricPoint, 0, len(metrics))
+
+	switch metricType {
+	case "cpu":
+		for _, m := range metrics {
+			points = append(points, MetricPoint{Timestamp: m.Timestamp, Value: m.CPUUsage})
+		}
+	case "memory":
+		for _, m := range metrics {
+			points = append(points, MetricPoint{Timestamp: m.Timestamp, Value: m.MemoryUsage})
+		}
+	case "disk":
+		for _, m := range metrics {
+			points = append(points, MetricPoint{Timestamp: m.Timestamp, Value: m.DiskUsage})
+		}
+	case "network":
+		// 网络指标为累计字节数，换算为相邻两次采样之间的收发速率（KB/s）
+		for i := 1; i < len(metrics); i++ {
+			prev, cur := metrics[i-1], metrics[i]
+			elapsed := cur.Timestamp.Sub(prev.Timestamp).Seconds()
+			prevTotal := prev.NetworkSent + prev.NetworkRecv
+			curTotal := cur.NetworkSent + cur.NetworkRecv
+			if elapsed <= 0 || curTotal < prevTotal {
+				continue // 时间异常或计数器重置
+			}
+			rate := float64(curTotal-prevTotal) / elapsed / 1024
+			points = append(points, MetricPoint{Timestamp: cur.Timestamp, Value: rate})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported metric type: %s", metricType)
+	}
+
+	return points, nil
+}
+
+// episodeCondition describes when a replayed rule fires and resolves
+type episodeCondition struct {
+	Operator         string
+	Threshold        float64
+	ResolveThreshold *float64 // 触发后值仍满足恢复阈值时保持触发，为空时与触发阈值相同
+	Duration         time.Duration
+	MaxGap           time.Duration // 相邻样本间隔超过该值时结束当前超限，0 不限制
+}
+
+// findFiringEpisodes replays a rule condition over a series and returns the periods
+// during which an alert would have been firing
+func findFiringEpisodes(points []MetricPoint, operator string, threshold float64, duration time.Duration) ([]firingEpisode, error) {
+	return replayEpisodes(points, episodeCondition{Operator: operator, Threshold: threshold, Duration: duration})
+}
+
+// replayEpisodes replays a rule condition over a series like the alert evaluator: a breach
+// fires once it has held for the duration and then keeps firing while the value holds the
+// resolve threshold. A gap longer than MaxGap ends the breach without resolving it.
+func replayEpisodes(points []MetricPoint, cond episodeCondition) ([]firingEpisode, error) {
+	var episodes []firingEpisode
+	var breaching, fired bool
+	var breachStart, lastBreach time.Time
+	var peak float64
+
+	closeEpisode := func(end time.Time, resolved bool) {
+		if fired {
+			episodes = append(episodes, firingEpisode{
+				BreachStart: breachStart,
+				FireTime:    breachStart.Add(cond.Duration),
+				EndTime:     end,
+				Peak:        peak,
+				Resolved:    resolved,
+			})
+		}
+		breaching = false
+		fired = false
+	}
+
+	for _, p := range points {
+		matched, err := CompareValue(cond.Operator, p.Value, cond.Threshold)
+		if err != nil {
+			return nil, err
+		}
+
+		// 数据缺失期间无法判断条件是否持续满足
+		if breaching && cond.MaxGap > 0 && p.Timestamp.Sub(lastBreach) > cond.MaxGap {
+			closeEpisode(lastBreach, false)
+		}
+
+		if !matched && fired && cond.ResolveThreshold != nil {
+			matched, _ = CompareValue(cond.Operator, p.Value, *cond.ResolveThreshold)
+		}
+		if !matched {
+			if breaching {
+				closeEpisode(p.Timestamp, true)
+			}
+			continue
+		}
+
+		if !breaching {
+			breaching = true
+			breachStart = p.Timestamp
+			peak = p.Value
+		} else if moreExtreme(cond.Operator, p.Value, peak) {
+			peak = p.Value
+		}
+		lastBreach = p.Timestamp
+		if lastBreach.Sub(breachStart) >= cond.Duration {
+			fired = true
+		}
+	}
+
+	// 时间范围结束时仍处于告警状态
+	if breaching {
+		closeEpisode(lastBreach, false)
+	}
+
+	return episodes, nil
+}
+
+// moreExtreme reports whether value is further past the threshold than current
+func moreExtreme(operator string, value, current float64) bool {
+	switch operator {
+	case "<", "<=":
+		return value < current
+	default:
+		return value > current
+	}
+}
+
+// BacktestRule replays an alert rule against historical system metrics without writing anything.
+// The metrics may contain several hosts and must be ordered by timestamp ascending per host.
+// A gap between samples longer than staleAfter ends a breach, as stale data does for the
+// alert evaluator.
+func BacktestRule(rule model.AlertRule, metrics []model.SystemMetrics, start, end time.Time, staleAfter time.Duration) (*model.AlertPreviewResult, error) {
+	if staleAfter <= 0 {
+		staleAfter = defaultStaleAfter
+	}
+	if ruleTypeOf(rule) != model.RuleTypeThreshold {
+		return nil, fmt.Errorf("preview only supports threshold rules, got %s", rule.RuleType)
+	}
+	if _, err := CompareValue(rule.Operator, 0, rule.Threshold); err != nil {
+		return nil, err
+	}
+
+	// 按主机分组
+	byHost := make(map[string][]model.SystemMetrics)
+	for _, m := range metrics {
+		byHost[m.Hostname] = append(byHost[m.Hostname], m)
+	}
+
+	hostnames := make([]string, 0, len(byHost))
+	for hostname := range byHost {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+
+	result := &model.AlertPreviewResult{
+		Rule:      rule,
+		StartTime: start,
+		EndTime:   end,
+		Hosts:     []model.AlertPreviewHost{},
+		Alerts:    []model.AlertPreview{},
+	}
+
+	cond := episodeCondition{
+		Operator:         rule.Operator,
+		Threshold:        rule.Threshold,
+		ResolveThreshold: rule.ResolveThreshold,
+		Duration:         time.Duration(rule.Duration) * time.Second,
+		MaxGap:           staleAfter,
+	}
+	for _, hostname := range hostnames {
+		points, err := MetricSeries(rule.MetricType, byHost[hostname])
+		if err != nil {
+			return nil, err
+		}
+
+		episodes, err := replayEpisodes(points, cond)
+		if err != nil {
+			return nil, err
+		}
+
+		hostSummary := model.AlertPreviewHost{
+			Hostname:   hostname,
+			Samples:    len(points),
+			AlertCount: len(episodes),
+		}
+
+		for _, episode := range episodes {
+			firing := int(episode.EndTime.Sub(episode.FireTime).Seconds())
+			hostSummary.FiringTime += firing
+			result.Alerts = append(result.Alerts, model.AlertPreview{
+				Hostname:  hostname,
+				StartTime: episode.FireTime,
+				EndTime:   episode.EndTime,
+				Duration:  firing,
+				PeakValue: episode.Peak,
+				Resolved:  episode.Resolved,
+			})
+		}
+
+		result.Hosts = append(result.Hosts, hostSummary)
+		result.SamplesEvaluated += len(points)
+		result.TotalAlerts += hostSummary.AlertCount
+		result.TotalFiringTime += hostSummary.FiringTime
+	}
+	result.HostsEvaluated = len(result.Hosts)
+
+	return result, nil
+}
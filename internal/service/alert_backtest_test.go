@@ -0,0 +1,229 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+// testSeries builds a series with one point per minute
+func testSeries(start time.Time, values ...float64) []MetricPoint {
+	points := make([]MetricPoint, len(values))
+	for i, value := range values {
+		points[i] = MetricPoint{Timestamp: start.Add(time.Duration(i) * time.Minute), Value: value}
+	}
+	return points
+}
+
+func TestFindFiringEpisodes(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	tests := []struct {
+		name      string
+		values    []float64
+		operator  string
+		threshold float64
+		duration  time.Duration
+		want      []firingEpisode
+	}{
+		{
+			name:      "never breached",
+			values:    []float64{10, 20, 30},
+			operator:  ">",
+			threshold: 80,
+		},
+		{
+			name:      "breach shorter than duration",
+			values:    []float64{10, 90, 95, 10},
+			operator:  ">",
+			threshold: 80,
+			duration:  5 * time.Minute,
+		},
+		{
+			name:      "resolved episode",
+			values:    []float64{10, 85, 95, 90, 10},
+			operator:  ">",
+			threshold: 80,
+			duration:  2 * time.Minute,
+			want:      []firingEpisode{{BreachStart: at(1), FireTime: at(3), EndTime: at(4), Peak: 95, Resolved: true}},
+		},
+		{
+			name:      "still firing at the end",
+			values:    []float64{10, 85, 90},
+			operator:  ">=",
+			threshold: 85,
+			duration:  time.Minute,
+			want:      []firingEpisode{{BreachStart: at(1), FireTime: at(2), EndTime: at(2), Peak: 90}},
+		},
+		{
+			name:      "lower bound peak is the minimum",
+			values:    []float64{50, 8, 3, 6, 50},
+			operator:  "<",
+			threshold: 10,
+			want:      []firingEpisode{{BreachStart: at(1), FireTime: at(1), EndTime: at(4), Peak: 3, Resolved: true}},
+		},
+		{
+			name:      "separate episodes",
+			values:    []float64{90, 10, 90, 90},
+			operator:  ">",
+			threshold: 80,
+			want: []firingEpisode{
+				{BreachStart: at(0), FireTime: at(0), EndTime: at(1), Peak: 90, Resolved: true},
+				{BreachStart: at(2), FireTime: at(2), EndTime: at(3), Peak: 90},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findFiringEpisodes(testSeries(start, tt.values...), tt.operator, tt.threshold, tt.duration)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("episodes = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("episode %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := findFiringEpisodes(testSeries(start, 1), "!=", 0, 0); err == nil {
+		t.Error("expected error for unsupported operator")
+	}
+}
+
+func TestReplayEpisodesResolveThreshold(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	resolve := 70.0
+	cond := episodeCondition{Operator: ">", Threshold: 80, ResolveThreshold: &resolve, Duration: time.Minute}
+
+	// 触发后降到 75 仍保持触发，降到 60 才恢复；触发前降到 75 则结束超限
+	got, err := replayEpisodes(testSeries(start, 90, 90, 75, 95, 75, 60, 90, 75, 90), cond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []firingEpisode{{BreachStart: at(0), FireTime: at(1), EndTime: at(5), Peak: 95, Resolved: true}}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("episodes = %+v, want %+v", got, want)
+	}
+}
+
+func TestReplayEpisodesEndsOnGaps(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	points := []MetricPoint{
+		{Timestamp: at(0), Value: 90},
+		{Timestamp: at(1), Value: 90},
+		{Timestamp: at(30), Value: 90},
+		{Timestamp: at(31), Value: 90},
+		{Timestamp: at(32), Value: 10},
+	}
+	cond := episodeCondition{Operator: ">", Threshold: 80, Duration: time.Minute, MaxGap: 5 * time.Minute}
+
+	got, err := replayEpisodes(points, cond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []firingEpisode{
+		{BreachStart: at(0), FireTime: at(1), EndTime: at(1), Peak: 90},
+		{BreachStart: at(30), FireTime: at(31), EndTime: at(32), Peak: 90, Resolved: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("episodes = %+v, want %+v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("episode %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// 间隔前后都不满足持续时间时不会触发
+	cond.Duration = 2 * time.Minute
+	if got, _ := replayEpisodes(points[:4], cond); len(got) != 0 {
+		t.Errorf("breach across a gap fired: %+v", got)
+	}
+}
+
+func TestBacktestRule(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	var metrics []model.SystemMetrics
+	for i, usage := range []float64{50, 92, 95, 97, 60} {
+		ts := start.Add(time.Duration(i) * time.Minute)
+		metrics = append(metrics,
+			model.SystemMetrics{Hostname: "web-2", Timestamp: ts, CPUUsage: usage},
+			model.SystemMetrics{Hostname: "web-1", Timestamp: ts, CPUUsage: 40},
+		)
+	}
+	rule := model.AlertRule{MetricType: "cpu", Operator: ">", Threshold: 90, Duration: 60}
+
+	result, err := BacktestRule(rule, metrics, start, start.Add(5*time.Minute), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.HostsEvaluated != 2 || result.SamplesEvaluated != 10 || result.TotalAlerts != 1 {
+		t.Fatalf("result = %+v, want 2 hosts, 10 samples and 1 alert", result)
+	}
+	// 主机按名称排序
+	if result.Hosts[0].Hostname != "web-1" || result.Hosts[0].AlertCount != 0 {
+		t.Errorf("first host = %+v, want web-1 without alerts", result.Hosts[0])
+	}
+	// 1分钟时开始超限，持续1分钟后触发，4分钟时恢复
+	alert := result.Alerts[0]
+	if alert.Hostname != "web-2" || !alert.StartTime.Equal(start.Add(2*time.Minute)) || alert.Duration != 120 || alert.PeakValue != 97 || !alert.Resolved {
+		t.Errorf("alert = %+v", alert)
+	}
+	if result.TotalFiringTime != 120 || result.Hosts[1].FiringTime != 120 {
+		t.Errorf("firing time = %d, want 120", result.TotalFiringTime)
+	}
+
+	// 4 分钟时的 60 仍满足恢复阈值，时间范围结束时告警仍在触发
+	resolve := 50.0
+	rule.ResolveThreshold = &resolve
+	result, err = BacktestRule(rule, metrics, start, start.Add(5*time.Minute), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alert := result.Alerts[0]; alert.Resolved || alert.Duration != 120 || result.TotalAlerts != 1 {
+		t.Errorf("alert with resolve threshold = %+v", alert)
+	}
+}
+
+func TestBacktestRuleNetworkRate(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	metrics := []model.SystemMetrics{
+		{Hostname: "web-1", Timestamp: start, NetworkSent: 0, NetworkRecv: 0},
+		{Hostname: "web-1", Timestamp: start.Add(10 * time.Second), NetworkSent: 10240, NetworkRecv: 10240},
+		// 计数器重置的样本被跳过
+		{Hostname: "web-1", Timestamp: start.Add(20 * time.Second), NetworkSent: 0, NetworkRecv: 0},
+	}
+	rule := model.AlertRule{MetricType: "network", Operator: ">=", Threshold: 2}
+
+	result, err := BacktestRule(rule, metrics, start, start.Add(time.Minute), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SamplesEvaluated != 1 || result.TotalAlerts != 1 || result.Alerts[0].PeakValue != 2 {
+		t.Errorf("result = %+v, want a single 2 KB/s sample firing", result)
+	}
+}
+
+func TestBacktestRuleRejectsInvalidRules(t *testing.T) {
+	invalid := []model.AlertRule{
+		{MetricType: "cpu", RuleType: model.RuleTypeForecast, Operator: ">", Threshold: 24},
+		{MetricType: "cpu", Operator: "!=", Threshold: 90},
+		{MetricType: "gpu", Operator: ">", Threshold: 90},
+	}
+	metrics := []model.SystemMetrics{{Hostname: "web-1", Timestamp: time.Now()}}
+	for _, rule := range invalid {
+		if _, err := BacktestRule(rule, metrics, time.Time{}, time.Now(), 0); err == nil {
+			t.Errorf("expected error for rule %+v", rule)
+		}
+	}
+}
@@ -0,0 +1,906 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+const (
+	// defaultForecastLookback 预测规则未配置回看窗口时使用的默认值
+	defaultForecastLookback = 6 * time.Hour
+	// absentSeriesHorizon 数据缺失检测认定序列存在的时间范围，超过该时间没有样本的序列视为已下线
+	absentSeriesHorizon = 24 * time.Hour
+	// defaultStaleAfter 未配置 stale_after 时最新样本视为过期的时间
+	defaultStaleAfter = 5 * time.Minute
+	// MetricHostUp 由主机状态合成的在线指标，在线为1，离线为0
+	MetricHostUp = "up"
+)
+
+// AlertEvaluator periodically evaluates the effective alert rules of every monitored
+// host against stored metrics and maintains the alerts table
+type AlertEvaluator interface {
+	Start()
+	Stop()
+	EvaluateOnce(now time.Time) error
+}
+
+// ruleResult represents the evaluation result of a rule for a single series
+type ruleResult struct {
+	Labels  string
+	Firing  bool
+	Unknown bool // 数据缺失或过期，保持告警当前状态
+	Value   float64
+	Message string
+}
+
+// alertEvaluator implements AlertEvaluator interface
+type alertEvaluator struct {
+	alertRepo     repository.AlertRepository
+	hostRepo      repository.HostRepository
+	hostGroupRepo repository.HostGroupRepository
+	sampleRepo    repository.SampleRepository
+	series        seriesLoader
+	baselines     BaselineLearner
+	notifier      AlertNotifier
+	interval      time.Duration
+	staleAfter    time.Duration
+	flapWindow    time.Duration
+	flapThreshold int
+	logger        *logger.Logger
+	stop          chan struct{}
+	running       sync.WaitGroup // the background loop, waited for by Stop
+
+	// 抑制规则匹配使用告警标签
+	inhibitionRepo repository.InhibitionRuleRepository
+	labeler        alertLabeler
+}
+
+// NewAlertEvaluator creates a new alert evaluator instance
+func NewAlertEvaluator(db *gorm.DB, cfg config.AlertConfig, notifier AlertNotifier, logger *logger.Logger) AlertEvaluator {
+	interval := time.Duration(cfg.EvaluationInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	staleAfter := time.Duration(cfg.StaleAfter) * time.Second
+	if staleAfter <= 0 {
+		staleAfter = defaultStaleAfter
+	}
+	flapWindow := time.Duration(cfg.FlapWindow) * time.Second
+	if flapWindow <= 0 {
+		flapWindow = 30 * time.Minute
+	}
+	flapThreshold := cfg.FlapThreshold
+	if flapThreshold <= 0 {
+		flapThreshold = 4
+	}
+
+	return &alertEvaluator{
+		alertRepo:     repository.NewAlertRepository(db),
+		hostRepo:      repository.NewHostRepository(db),
+		hostGroupRepo: repository.NewHostGroupRepository(db),
+		sampleRepo:    repository.NewSampleRepository(db),
+		series:        newSeriesLoader(db),
+		baselines:     NewBaselineLearner(db),
+		notifier:      notifier,
+		interval:      interval,
+		staleAfter:    staleAfter,
+		flapWindow:    flapWindow,
+		flapThreshold: flapThreshold,
+		logger:        logger,
+		stop:          make(chan struct{}),
+
+		inhibitionRepo: repository.NewInhibitionRuleRepository(db),
+		labeler:        newAlertLabeler(db),
+	}
+}
+
+// Start starts evaluating alert rules in background
+func (e *alertEvaluator) Start() {
+	e.running.Add(1)
+	go func() {
+		defer e.running.Done()
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				if err := e.EvaluateOnce(now); err != nil {
+					e.logger.Error("Failed to evaluate alert rules", "error", err)
+				}
+			case <-e.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops evaluating alert rules and waits for the running round to finish
+func (e *alertEvaluator) Stop() {
+	close(e.stop)
+	e.running.Wait()
+}
+
+// alertFingerprint identifies an alert by rule, host and series labels
+func alertFingerprint(ruleID uint, hostname, labels string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%s|%s", ruleID, hostname, labels)))
+	return hex.EncodeToString(sum[:])
+}
+
+// EvaluateOnce evaluates all effective rules of all monitored hosts once
+func (e *alertEvaluator) EvaluateOnce(now time.Time) error {
+	hosts, err := e.hostRepo.GetMonitoringEnabledHosts()
+	if err != nil {
+		return fmt.Errorf("failed to get monitored hosts: %w", err)
+	}
+	rules, err := e.alertRepo.GetActiveRules()
+	if err != nil {
+		return fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	// 被抑制的告警同样参与评估，抑制状态在评估后重新计算
+	activeAlerts, err := e.alertRepo.GetFiringAlerts()
+	if err != nil {
+		return fmt.Errorf("failed to get active alerts: %w", err)
+	}
+
+	active := make(map[string]*model.Alert, len(activeAlerts))
+	for i := range activeAlerts {
+		alert := &activeAlerts[i]
+		if alert.Fingerprint == "" {
+			// 兼容去重指纹引入之前创建的告警
+			alert.Fingerprint = alertFingerprint(alert.RuleID, alert.Hostname, alert.Labels)
+		}
+		active[alert.Fingerprint] = alert
+	}
+
+	// 记录本轮评估过的告警，未评估到的活动告警将被恢复
+	keep := make(map[string]bool)
+	var fired []*model.Alert
+	for _, host := range hosts {
+		groups, err := e.hostGroupRepo.GetHostGroups(host.ID)
+		if err != nil {
+			e.logger.Warn("Failed to get host groups", "hostname", host.Hostname, "error", err)
+			e.keepHostAlerts(host.Hostname, activeAlerts, keep)
+			continue
+		}
+		groupIDs := make([]uint, 0, len(groups))
+		for _, group := range groups {
+			groupIDs = append(groupIDs, group.ID)
+		}
+
+		for _, rule := range EffectiveRules(host, groupIDs, rules) {
+			// 离线和维护中的主机由主机状态体现，数据缺失告警保持当前状态
+			if ruleTypeOf(rule) == model.RuleTypeAbsent && (host.Status == "offline" || host.Status == "maintenance") {
+				e.keepRuleAlerts(rule.ID, host.Hostname, activeAlerts, keep)
+				continue
+			}
+
+			results, err := e.evaluateRule(rule, host, active, now)
+			if err != nil {
+				e.logger.Warn("Failed to evaluate alert rule", "rule", rule.Name, "hostname", host.Hostname, "error", err)
+				e.keepRuleAlerts(rule.ID, host.Hostname, activeAlerts, keep)
+				continue
+			}
+
+			for _, result := range results {
+				fingerprint := alertFingerprint(rule.ID, host.Hostname, result.Labels)
+				if result.Unknown {
+					keep[fingerprint] = true
+					continue
+				}
+
+				alert, ok := active[fingerprint]
+				firing := result.Firing
+				if !firing && ok {
+					firing = e.holdsResolveThreshold(rule, result.Value)
+				}
+				if !firing {
+					continue
+				}
+				keep[fingerprint] = true
+
+				if ok {
+					alert.Value = result.Value
+					alert.Message = result.Message
+					if err := e.alertRepo.UpdateAlert(alert); err != nil {
+						e.logger.Warn("Failed to update alert", "alert_id", alert.ID, "error", err)
+					}
+					continue
+				}
+				if alert := e.fireAlert(rule, host.Hostname, fingerprint, result, now); alert != nil {
+					fired = append(fired, alert)
+				}
+			}
+		}
+	}
+
+	// 恢复不再触发的告警
+	var firing, resolved []*model.Alert
+	for fingerprint, alert := range active {
+		if !keep[fingerprint] {
+			if e.resolveAlert(alert, now) {
+				resolved = append(resolved, alert)
+			}
+			continue
+		}
+		firing = append(firing, alert)
+	}
+	firing = append(firing, fired...)
+
+	// 先计算抑制状态再发送通知，避免被抑制的告警先发出通知
+	e.applyInhibitions(firing)
+	for _, alert := range append(firing, resolved...) {
+		e.notify(alert)
+	}
+
+	e.settleFlappingAlerts(now)
+
+	return nil
+}
+
+// holdsResolveThreshold reports whether an active alert should keep firing because the value
+// has not yet crossed the rule's resolve threshold (hysteresis). Only threshold and anomaly rules
+// support a resolve threshold.
+func (e *alertEvaluator) holdsResolveThreshold(rule model.AlertRule, value float64) bool {
+	if rule.ResolveThreshold == nil {
+		return false
+	}
+	switch ruleTypeOf(rule) {
+	case model.RuleTypeThreshold, model.RuleTypeAnomaly:
+	default:
+		return false
+	}
+	holds, err := CompareValue(rule.Operator, value, *rule.ResolveThreshold)
+	return err == nil && holds
+}
+
+// fireAlert reopens an alert with the same fingerprint that resolved within the flap window,
+// clearing its acknowledgement and escalation progress, or creates a new alert. It returns nil when the alert could not be stored.
+func (e *alertEvaluator) fireAlert(rule model.AlertRule, hostname, fingerprint string, result ruleResult, now time.Time) *model.Alert {
+	alert, err := e.alertRepo.GetRecentlyResolvedAlert(fingerprint, now.Add(-e.flapWindow))
+	if err == nil {
+		alert.Status = "active"
+		alert.EndTime = nil
+		alert.Duration = nil
+		alert.Value = result.Value
+		alert.Message = result.Message
+		e.recordStateChange(alert, now)
+		if err := e.alertRepo.ReopenAlert(alert); err != nil {
+			e.logger.Warn("Failed to reopen alert", "alert_id", alert.ID, "error", err)
+			return nil
+		}
+		e.logger.Info("Alert reopened", "alert_id", alert.ID, "hostname", hostname, "labels", result.Labels, "state_changes", alert.StateChanges)
+		return alert
+	}
+	if err != gorm.ErrRecordNotFound {
+		e.logger.Warn("Failed to look up resolved alert", "fingerprint", fingerprint, "error", err)
+	}
+
+	alert = &model.Alert{
+		RuleID:      rule.ID,
+		Hostname:    hostname,
+		MetricType:  rule.MetricType,
+		Labels:      result.Labels,
+		Value:       result.Value,
+		Threshold:   rule.Threshold,
+		Severity:    rule.Severity,
+		Message:     result.Message,
+		Status:      "active",
+		StartTime:   now,
+		Fingerprint: fingerprint,
+	}
+	e.recordStateChange(alert, now)
+	if err := e.alertRepo.CreateAlert(alert); err != nil {
+		e.logger.Warn("Failed to create alert", "rule", rule.Name, "hostname", hostname, "error", err)
+		return nil
+	}
+	e.logger.Info("Alert fired", "rule", rule.Name, "hostname", hostname, "labels", result.Labels, "value", result.Value)
+	return alert
+}
+
+// recordStateChange counts a firing/resolved transition and marks the alert as flapping when
+// it changed state too often within the flap window. The count restarts once the alert has
+// been stable for a whole window.
+func (e *alertEvaluator) recordStateChange(alert *model.Alert, now time.Time) {
+	if alert.LastStateChangeAt == nil || now.Sub(*alert.LastStateChangeAt) > e.flapWindow {
+		alert.StateChanges = 0
+	}
+	alert.StateChanges++
+	alert.LastStateChangeAt = &now
+
+	if !alert.Flapping && alert.StateChanges >= e.flapThreshold {
+		alert.Flapping = true
+		e.logger.Warn("Alert is flapping, holding notifications", "alert_id", alert.ID, "hostname", alert.Hostname, "state_changes", alert.StateChanges)
+	}
+}
+
+// settleFlappingAlerts clears the flapping flag of alerts that have been stable for the whole
+// flap window and sends the notification for their current state
+func (e *alertEvaluator) settleFlappingAlerts(now time.Time) {
+	alerts, err := e.alertRepo.GetFlappingAlerts()
+	if err != nil {
+		e.logger.Warn("Failed to get flapping alerts", "error", err)
+		return
+	}
+
+	for i := range alerts {
+		alert := &alerts[i]
+		if alert.LastStateChangeAt != nil && now.Sub(*alert.LastStateChangeAt) <= e.flapWindow {
+			continue
+		}
+		alert.Flapping = false
+		alert.StateChanges = 0
+		if err := e.alertRepo.UpdateAlert(alert); err != nil {
+			e.logger.Warn("Failed to update flapping alert", "alert_id", alert.ID, "error", err)
+			continue
+		}
+		e.logger.Info("Alert stabilized", "alert_id", alert.ID, "hostname", alert.Hostname, "status", alert.Status)
+		e.notify(alert)
+	}
+}
+
+// notify sends a notification for the current alert state unless the alert is flapping
+// or the state has already been notified. Suppression and recovery are only sent for alerts
+// that have been notified before.
+func (e *alertEvaluator) notify(alert *model.Alert) {
+	event := alertEvent(*alert)
+	if alert.Flapping || alert.NotifiedStatus == event {
+		return
+	}
+	if alert.NotifiedStatus == "" && event != AlertEventFiring {
+		return
+	}
+	if err := e.notifier.Notify(*alert, event); err != nil {
+		e.logger.Warn("Failed to send alert notification", "alert_id", alert.ID, "event", event, "error", err)
+		return
+	}
+	alert.NotifiedStatus = event
+	if err := e.alertRepo.UpdateAlert(alert); err != nil {
+		e.logger.Warn("Failed to update alert", "alert_id", alert.ID, "error", err)
+	}
+}
+
+// keepHostAlerts keeps all active alerts of a host when it cannot be evaluated
+func (e *alertEvaluator) keepHostAlerts(hostname string, alerts []model.Alert, keep map[string]bool) {
+	for _, alert := range alerts {
+		if alert.Hostname == hostname {
+			keep[alert.Fingerprint] = true
+		}
+	}
+}
+
+// keepRuleAlerts keeps the active alerts of a rule on a host when the rule cannot be evaluated
+func (e *alertEvaluator) keepRuleAlerts(ruleID uint, hostname string, alerts []model.Alert, keep map[string]bool) {
+	for _, alert := range alerts {
+		if alert.RuleID == ruleID && alert.Hostname == hostname {
+			keep[alert.Fingerprint] = true
+		}
+	}
+}
+
+// resolveAlert marks an alert as resolved and reports whether it was stored
+func (e *alertEvaluator) resolveAlert(alert *model.Alert, now time.Time) bool {
+	duration := int(now.Sub(alert.StartTime).Seconds())
+	alert.Status = "resolved"
+	alert.EndTime = &now
+	alert.Duration = &duration
+	alert.InhibitedByID = nil
+	e.recordStateChange(alert, now)
+	if err := e.alertRepo.UpdateAlert(alert); err != nil {
+		e.logger.Warn("Failed to resolve alert", "alert_id", alert.ID, "error", err)
+		return false
+	}
+	e.logger.Info("Alert resolved", "alert_id", alert.ID, "hostname", alert.Hostname, "labels", alert.Labels)
+	return true
+}
+
+// inhibitionMatcher is an enabled inhibition rule with parsed matchers
+type inhibitionMatcher struct {
+	rule   model.InhibitionRule
+	source LabelSelector
+	target LabelSelector
+	equal  []string
+}
+
+// inhibitingAlert returns the alert that inhibits the target, or nil. A target is inhibited when
+// another firing alert matches the source matchers of a rule whose target matchers match the target
+// and both alerts have the same values for all equal labels. An alert that also matches the target
+// matchers of a rule cannot be a source of that rule, so alerts never inhibit each other.
+func inhibitingAlert(matchers []inhibitionMatcher, target *model.Alert, alerts []*model.Alert, labels map[uint]map[string]string) *model.Alert {
+	for _, m := range matchers {
+		if !m.target.Matches(labels[target.ID]) {
+			continue
+		}
+		for _, source := range alerts {
+			sourceLabels := labels[source.ID]
+			if source.ID == target.ID || !m.source.Matches(sourceLabels) || m.target.Matches(sourceLabels) {
+				continue
+			}
+			equal := true
+			for _, key := range m.equal {
+				if sourceLabels[key] != labels[target.ID][key] {
+					equal = false
+					break
+				}
+			}
+			if equal {
+				return source
+			}
+		}
+	}
+	return nil
+}
+
+// applyInhibitions recomputes the suppressed state of the firing alerts from the enabled
+// inhibition rules and stores the alerts whose state changed
+func (e *alertEvaluator) applyInhibitions(alerts []*model.Alert) {
+	rules, err := e.inhibitionRepo.GetEnabled()
+	if err != nil {
+		e.logger.Warn("Failed to get inhibition rules", "error", err)
+		return
+	}
+
+	var matchers []inhibitionMatcher
+	for _, rule := range rules {
+		source, err := ParseSelector(rule.SourceMatchers)
+		if err != nil {
+			e.logger.Warn("Invalid inhibition source matchers", "rule", rule.Name, "error", err)
+			continue
+		}
+		target, err := ParseSelector(rule.TargetMatchers)
+		if err != nil {
+			e.logger.Warn("Invalid inhibition target matchers", "rule", rule.Name, "error", err)
+			continue
+		}
+		var equal []string
+		for _, key := range strings.Split(rule.Equal, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				equal = append(equal, key)
+			}
+		}
+		matchers = append(matchers, inhibitionMatcher{rule: rule, source: source, target: target, equal: equal})
+	}
+
+	labels := make(map[uint]map[string]string, len(alerts))
+	if len(matchers) > 0 {
+		// 同一主机的多个告警只查询一次主机和分组
+		labeler := e.labeler.cached()
+		for _, alert := range alerts {
+			labels[alert.ID] = labeler.labels(*alert)
+		}
+	}
+
+	for _, alert := range alerts {
+		var source *model.Alert
+		if len(matchers) > 0 {
+			source = inhibitingAlert(matchers, alert, alerts, labels)
+		}
+
+		status := "active"
+		var inhibitedBy *uint
+		if source != nil {
+			status = "suppressed"
+			id := source.ID
+			inhibitedBy = &id
+		}
+		if alert.Status == status && equalIDs(alert.InhibitedByID, inhibitedBy) {
+			continue
+		}
+
+		alert.Status = status
+		alert.InhibitedByID = inhibitedBy
+		if err := e.alertRepo.UpdateAlert(alert); err != nil {
+			e.logger.Warn("Failed to update alert inhibition", "alert_id", alert.ID, "error", err)
+			continue
+		}
+		if source != nil {
+			e.logger.Info("Alert suppressed", "alert_id", alert.ID, "hostname", alert.Hostname, "inhibited_by", source.ID)
+		} else {
+			e.logger.Info("Alert no longer suppressed", "alert_id", alert.ID, "hostname", alert.Hostname)
+		}
+	}
+}
+
+// equalIDs reports whether two optional IDs are equal
+func equalIDs(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// evaluateRule evaluates a rule for a host according to its rule type
+func (e *alertEvaluator) evaluateRule(rule model.AlertRule, host model.Host, active map[string]*model.Alert, now time.Time) ([]ruleResult, error) {
+	switch ruleTypeOf(rule) {
+	case model.RuleTypeThreshold:
+		return e.evaluateThreshold(rule, host, now)
+	case model.RuleTypeForecast:
+		return e.evaluateForecast(rule, host, now)
+	case model.RuleTypeAnomaly:
+		return e.evaluateAnomaly(rule, host, now)
+	case model.RuleTypeAbsent:
+		return e.evaluateAbsent(rule, host, active, now)
+	default:
+		return nil, fmt.Errorf("unsupported rule type: %s", rule.RuleType)
+	}
+}
+
+// isSystemMetric reports whether a metric type is stored in the system_metrics table
+func isSystemMetric(metricType string) bool {
+	switch metricType {
+	case "cpu", "memory", "disk", "network":
+		return true
+	}
+	return false
+}
+
+// seriesLoader loads metric series from stored metrics
+type seriesLoader struct {
+	metricsRepo repository.MetricsRepository
+	sampleRepo  repository.SampleRepository
+}
+
+// newSeriesLoader creates a new series loader
+func newSeriesLoader(db *gorm.DB) seriesLoader {
+	return seriesLoader{
+		metricsRepo: repository.NewMetricsRepository(db),
+		sampleRepo:  repository.NewSampleRepository(db),
+	}
+}
+
+// load loads the series of a metric type for a host, keyed by labels.
+// System metric types come from system_metrics, all other types from metric_samples.
+func (l seriesLoader) load(metricType, hostname string, start, end time.Time) (map[string][]MetricPoint, []string, error) {
+	if isSystemMetric(metricType) {
+		metrics, err := l.metricsRepo.GetRange([]string{hostname}, start, end)
+		if err != nil {
+			return nil, nil, err
+		}
+		points, err := MetricSeries(metricType, metrics)
+		if err != nil {
+			return nil, nil, err
+		}
+		return map[string][]MetricPoint{"": points}, []string{""}, nil
+	}
+
+	samples, err := l.sampleRepo.GetSeries(hostname, metricType, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	series, order := groupSamplesByLabels(samples)
+	return series, order, nil
+}
+
+// lastSeen returns the latest sample time of every series of a metric type since the given time
+func (l seriesLoader) lastSeen(metricType, hostname string, since time.Time) (map[string]time.Time, error) {
+	if isSystemMetric(metricType) {
+		latest, err := l.metricsRepo.GetLatestByHostname(hostname)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return map[string]time.Time{}, nil
+			}
+			return nil, err
+		}
+		if latest.Timestamp.Before(since) {
+			return map[string]time.Time{}, nil
+		}
+		return map[string]time.Time{"": latest.Timestamp}, nil
+	}
+
+	return l.sampleRepo.GetLastSeen(hostname, metricType, since)
+}
+
+// evaluateThreshold fires when the condition has held for the rule duration up to the latest sample
+func (e *alertEvaluator) evaluateThreshold(rule model.AlertRule, host model.Host, now time.Time) ([]ruleResult, error) {
+	if rule.MetricType == MetricHostUp {
+		return hostUpResult(rule, host)
+	}
+
+	duration := time.Duration(rule.Duration) * time.Second
+	start := now.Add(-duration - 2*e.staleAfter)
+
+	series, order, err := e.series.load(rule.MetricType, host.Hostname, start, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ruleResult
+	for _, labels := range order {
+		points := series[labels]
+		if len(points) == 0 {
+			continue
+		}
+
+		latest := points[len(points)-1]
+		if now.Sub(latest.Timestamp) > e.staleAfter {
+			results = append(results, ruleResult{Labels: labels, Unknown: true})
+			continue
+		}
+
+		episodes, err := findFiringEpisodes(points, rule.Operator, rule.Threshold, duration)
+		if err != nil {
+			return nil, err
+		}
+		firing := len(episodes) > 0 && !episodes[len(episodes)-1].Resolved
+
+		results = append(results, ruleResult{
+			Labels:  labels,
+			Firing:  firing,
+			Value:   latest.Value,
+			Message: thresholdMessage(rule, host.Hostname, labels, latest.Value),
+		})
+	}
+
+	return results, nil
+}
+
+// hostUpResult evaluates a threshold rule against the up metric synthesized from the host status.
+// Hosts in maintenance are reported as up.
+func hostUpResult(rule model.AlertRule, host model.Host) ([]ruleResult, error) {
+	value := 1.0
+	if host.Status == "offline" {
+		value = 0
+	}
+	firing, err := CompareValue(rule.Operator, value, rule.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("%s: 主机 %s 状态为 %s", rule.Name, host.Hostname, host.Status)
+	if host.StatusReason != "" {
+		message += fmt.Sprintf(" (%s)", host.StatusReason)
+	}
+	return []ruleResult{{
+		Firing:  firing,
+		Value:   value,
+		Message: message,
+	}}, nil
+}
+
+// forecastSampleMetric maps a forecast rule metric type to the stored usage series
+func forecastSampleMetric(metricType string) (string, error) {
+	switch metricType {
+	case "disk":
+		return SampleDiskUsagePercent, nil
+	case "memory":
+		return SampleMemoryUsagePercent, nil
+	default:
+		return "", fmt.Errorf("forecast rules only support disk and memory, got %s", metricType)
+	}
+}
+
+// evaluateForecast fires when the projected time to full compares to the threshold in hours
+func (e *alertEvaluator) evaluateForecast(rule model.AlertRule, host model.Host, now time.Time) ([]ruleResult, error) {
+	metric, err := forecastSampleMetric(rule.MetricType)
+	if err != nil {
+		return nil, err
+	}
+
+	lookback := time.Duration(rule.Lookback) * time.Second
+	if lookback <= 0 {
+		lookback = defaultForecastLookback
+	}
+
+	samples, err := e.sampleRepo.GetSeries(host.Hostname, metric, now.Add(-lookback), now)
+	if err != nil {
+		return nil, err
+	}
+	series, order := groupSamplesByLabels(samples)
+
+	var results []ruleResult
+	for _, labels := range order {
+		points := series[labels]
+		if now.Sub(points[len(points)-1].Timestamp) > e.staleAfter {
+			results = append(results, ruleResult{Labels: labels, Unknown: true})
+			continue
+		}
+
+		forecast := ForecastUsage(rule.MetricType, points, 100, now)
+		if forecast.HoursToFull == nil {
+			results = append(results, ruleResult{Labels: labels})
+			continue
+		}
+
+		firing, err := CompareValue(rule.Operator, *forecast.HoursToFull, rule.Threshold)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, ruleResult{
+			Labels:  labels,
+			Firing:  firing,
+			Value:   *forecast.HoursToFull,
+			Message: forecastMessage(rule, host.Hostname, labels, forecast),
+		})
+	}
+
+	return results, nil
+}
+
+// thresholdMessage builds the alert message of a threshold rule
+func thresholdMessage(rule model.AlertRule, hostname, labels string, value float64) string {
+	series := ""
+	if labels != "" {
+		series = fmt.Sprintf(" [%s]", labels)
+	}
+	return fmt.Sprintf("%s: 主机 %s%s 的 %s 当前值 %.2f %s 阈值 %.2f",
+		rule.Name, hostname, series, rule.MetricType, value, rule.Operator, rule.Threshold)
+}
+
+// forecastMessage builds the alert message of a forecast rule
+func forecastMessage(rule model.AlertRule, hostname, labels string, forecast model.ResourceForecast) string {
+	target := rule.MetricType
+	if mountPoint := model.ParseLabels(labels)["mountpoint"]; mountPoint != "" {
+		target = fmt.Sprintf("挂载点 %s", mountPoint)
+	}
+	return fmt.Sprintf("%s: 主机 %s 的 %s 当前使用率 %.2f%%，每小时增长 %.2f%%，预计 %.1f 小时后写满（阈值 %.0f 小时）",
+		rule.Name, hostname, target, forecast.CurrentUsage, forecast.GrowthPerHour, *forecast.HoursToFull, rule.Threshold)
+}
+
+// evaluateAnomaly fires when the deviation from the learned baseline, in standard
+// deviations, has matched the rule for the rule duration up to the latest sample
+func (e *alertEvaluator) evaluateAnomaly(rule model.AlertRule, host model.Host, now time.Time) ([]ruleResult, error) {
+	lookback := time.Duration(rule.Lookback) * time.Second
+	if lookback <= 0 {
+		lookback = defaultBaselineLookback
+	}
+
+	baseline, err := e.baselines.LearnBaseline(host, rule.MetricType, lookback, now)
+	if err != nil {
+		return nil, err
+	}
+	index := indexBaselines(baseline.Baselines)
+
+	duration := time.Duration(rule.Duration) * time.Second
+	series, order, err := e.series.load(rule.MetricType, host.Hostname, now.Add(-duration-2*e.staleAfter), now)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ruleResult
+	for _, labels := range order {
+		points := series[labels]
+		if len(points) == 0 {
+			continue
+		}
+		latest := points[len(points)-1]
+		if now.Sub(latest.Timestamp) > e.staleAfter {
+			results = append(results, ruleResult{Labels: labels, Unknown: true})
+			continue
+		}
+
+		// 将原始值换算为偏离基线的标准差倍数
+		deviations := make([]MetricPoint, 0, len(points))
+		var latestBaseline model.MetricBaseline
+		for _, p := range points {
+			b, ok := index.lookup(labels, p.Timestamp)
+			if !ok {
+				continue
+			}
+			deviations = append(deviations, MetricPoint{Timestamp: p.Timestamp, Value: deviation(p.Value, b)})
+			latestBaseline = b
+		}
+		if len(deviations) == 0 {
+			// 尚未学习到基线
+			results = append(results, ruleResult{Labels: labels, Unknown: true})
+			continue
+		}
+
+		episodes, err := findFiringEpisodes(deviations, rule.Operator, rule.Threshold, duration)
+		if err != nil {
+			return nil, err
+		}
+		firing := len(episodes) > 0 && !episodes[len(episodes)-1].Resolved
+		current := deviations[len(deviations)-1].Value
+
+		results = append(results, ruleResult{
+			Labels:  labels,
+			Firing:  firing,
+			Value:   current,
+			Message: anomalyMessage(rule, host.Hostname, labels, latest.Value, latestBaseline, current),
+		})
+	}
+
+	return results, nil
+}
+
+// anomalyMessage builds the alert message of an anomaly rule
+func anomalyMessage(rule model.AlertRule, hostname, labels string, value float64, baseline model.MetricBaseline, sigma float64) string {
+	series := ""
+	if labels != "" {
+		series = fmt.Sprintf(" [%s]", labels)
+	}
+	return fmt.Sprintf("%s: 主机 %s%s 的 %s 当前值 %.2f 偏离基线 %.2f±%.2f 达 %.1f 个标准差（阈值 %.1f）",
+		rule.Name, hostname, series, rule.MetricType, value, baseline.Mean, baseline.StdDev, sigma, rule.Threshold)
+}
+
+// evaluateAbsent fires when a series of the host has no samples within the rule lookback window.
+// Series seen within absentSeriesHorizon are checked individually; a host without any samples in
+// that horizon fires a single host-level alert. Hosts that never reported are skipped. An absent
+// alert resolves only once samples resume, also after the series has left the horizon.
+func (e *alertEvaluator) evaluateAbsent(rule model.AlertRule, host model.Host, active map[string]*model.Alert, now time.Time) ([]ruleResult, error) {
+	// 从未上报过数据的主机（例如只登记未安装代理的主机）没有可缺失的数据
+	if host.LastSeen == nil {
+		return nil, nil
+	}
+
+	window := time.Duration(rule.Lookback) * time.Second
+	if window <= 0 {
+		window = e.staleAfter
+	}
+
+	lastSeen, err := e.series.lastSeen(rule.MetricType, host.Hostname, now.Add(-absentSeriesHorizon))
+	if err != nil {
+		return nil, err
+	}
+
+	labelsList := make([]string, 0, len(lastSeen))
+	for labels := range lastSeen {
+		labelsList = append(labelsList, labels)
+	}
+	sort.Strings(labelsList)
+
+	results := make([]ruleResult, 0, len(labelsList))
+	for _, labels := range labelsList {
+		seen := lastSeen[labels]
+		silence := now.Sub(seen)
+		results = append(results, ruleResult{
+			Labels:  labels,
+			Firing:  silence > window,
+			Value:   silence.Seconds(),
+			Message: absentMessage(rule, host.Hostname, labels, &seen, window),
+		})
+	}
+
+	// 已超出检测范围的序列仍在缺失，其告警保持触发，消息保留最后一次上报的时间。
+	// 无标签的告警是主机级告警，只在主机没有任何样本时触发。
+	var gone []*model.Alert
+	for _, alert := range active {
+		if _, ok := lastSeen[alert.Labels]; !ok && alert.Labels != "" && alert.RuleID == rule.ID && alert.Hostname == host.Hostname {
+			gone = append(gone, alert)
+		}
+	}
+	sort.Slice(gone, func(i, j int) bool { return gone[i].Labels < gone[j].Labels })
+	for _, alert := range gone {
+		results = append(results, ruleResult{
+			Labels:  alert.Labels,
+			Firing:  true,
+			Value:   alert.Value + now.Sub(alert.UpdatedAt).Seconds(),
+			Message: alert.Message,
+		})
+	}
+
+	if len(lastSeen) == 0 && len(results) == 0 {
+		results = append(results, ruleResult{
+			Firing:  true,
+			Value:   absentSeriesHorizon.Seconds(),
+			Message: absentMessage(rule, host.Hostname, "", nil, window),
+		})
+	}
+
+	return results, nil
+}
+
+// absentMessage builds the alert message of an absent rule
+func absentMessage(rule model.AlertRule, hostname, labels string, lastSeen *time.Time, window time.Duration) string {
+	series := ""
+	if labels != "" {
+		series = fmt.Sprintf(" [%s]", labels)
+	}
+	if lastSeen == nil {
+		return fmt.Sprintf("%s: 主机 %s 在最近 %s 内没有上报 %s 数据", rule.Name, hostname, absentSeriesHorizon, rule.MetricType)
+	}
+	return fmt.Sprintf("%s: 主机 %s%s 的 %s 已超过 %s 没有上报数据，最后一次上报于 %s",
+		rule.Name, hostname, series, rule.MetricType, window, lastSeen.Format(time.RFC3339))
+}
@@ -0,0 +1,736 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+func testInhibitionMatcher(t *testing.T, source, target, equal string) inhibitionMatcher {
+	t.Helper()
+	m := inhibitionMatcher{source: mustParseSelector(t, source), target: mustParseSelector(t, target)}
+	if equal != "" {
+		m.equal = []string{equal}
+	}
+	return m
+}
+
+func mustParseSelector(t *testing.T, selector string) LabelSelector {
+	t.Helper()
+	parsed, err := ParseSelector(selector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestInhibitingAlert(t *testing.T) {
+	down := &model.Alert{BaseModel: model.BaseModel{ID: 1}}
+	cpu := &model.Alert{BaseModel: model.BaseModel{ID: 2}}
+	otherDown := &model.Alert{BaseModel: model.BaseModel{ID: 3}}
+	critical := &model.Alert{BaseModel: model.BaseModel{ID: 4}}
+	labels := map[uint]map[string]string{
+		1: {"alertname": "HostDown", "hostname": "web-1", "severity": "critical"},
+		2: {"alertname": "HighCPU", "hostname": "web-1", "severity": "warning"},
+		3: {"alertname": "HostDown", "hostname": "web-2", "severity": "critical"},
+		4: {"alertname": "HighCPU", "hostname": "web-1", "severity": "critical"},
+	}
+
+	tests := []struct {
+		name     string
+		matchers []inhibitionMatcher
+		target   *model.Alert
+		alerts   []*model.Alert
+		want     *model.Alert
+	}{
+		{
+			name:   "no rules",
+			target: cpu,
+			alerts: []*model.Alert{down, cpu},
+		},
+		{
+			name:     "source inhibits target on the same host",
+			matchers: []inhibitionMatcher{testInhibitionMatcher(t, "alertname=HostDown", "alertname=HighCPU", "hostname")},
+			target:   cpu,
+			alerts:   []*model.Alert{down, cpu},
+			want:     down,
+		},
+		{
+			name:     "equal labels differ",
+			matchers: []inhibitionMatcher{testInhibitionMatcher(t, "alertname=HostDown", "alertname=HighCPU", "hostname")},
+			target:   cpu,
+			alerts:   []*model.Alert{otherDown, cpu},
+		},
+		{
+			name:     "without equal labels any source inhibits",
+			matchers: []inhibitionMatcher{testInhibitionMatcher(t, "alertname=HostDown", "alertname=HighCPU", "")},
+			target:   cpu,
+			alerts:   []*model.Alert{otherDown, cpu},
+			want:     otherDown,
+		},
+		{
+			name:     "target matchers do not match",
+			matchers: []inhibitionMatcher{testInhibitionMatcher(t, "alertname=HostDown", "alertname=HighCPU", "hostname")},
+			target:   down,
+			alerts:   []*model.Alert{down, cpu},
+		},
+		{
+			name:     "no firing source",
+			matchers: []inhibitionMatcher{testInhibitionMatcher(t, "alertname=HostDown", "alertname=HighCPU", "hostname")},
+			target:   cpu,
+			alerts:   []*model.Alert{cpu},
+		},
+		{
+			// 两个告警同时匹配源和目标时互不抑制
+			name:     "alerts matching both sides never inhibit each other",
+			matchers: []inhibitionMatcher{testInhibitionMatcher(t, "hostname=web-1", "hostname=web-1", "")},
+			target:   cpu,
+			alerts:   []*model.Alert{down, cpu, critical},
+		},
+		{
+			name:     "critical inhibits warning of the same alert",
+			matchers: []inhibitionMatcher{testInhibitionMatcher(t, "severity=critical", "severity=warning", "alertname")},
+			target:   cpu,
+			alerts:   []*model.Alert{down, cpu, critical},
+			want:     critical,
+		},
+		{
+			name: "second rule matches",
+			matchers: []inhibitionMatcher{
+				testInhibitionMatcher(t, "alertname=Maintenance", "alertname=HighCPU", ""),
+				testInhibitionMatcher(t, "alertname=HostDown", "alertname=HighCPU", "hostname"),
+			},
+			target: cpu,
+			alerts: []*model.Alert{down, cpu},
+			want:   down,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := inhibitingAlert(tt.matchers, tt.target, tt.alerts, labels)
+			if got != tt.want {
+				t.Errorf("inhibitingAlert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// labelLookups counts the lookups done by an alert labeler
+type labelLookups struct {
+	hosts, groups, rules int
+}
+
+type countingHostRepo struct {
+	repository.HostRepository
+	lookups *labelLookups
+}
+
+func (r countingHostRepo) GetByHostname(hostname string) (*model.Host, error) {
+	r.lookups.hosts++
+	if hostname != "web-1" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &model.Host{BaseModel: model.BaseModel{ID: 1}, Hostname: hostname, Environment: "prod"}, nil
+}
+
+type countingHostGroupRepo struct {
+	repository.HostGroupRepository
+	lookups *labelLookups
+}
+
+func (r countingHostGroupRepo) GetHostGroups(hostID uint) ([]model.HostGroup, error) {
+	r.lookups.groups++
+	return []model.HostGroup{{Name: "web"}, {Name: "frontend"}}, nil
+}
+
+type countingAlertRepo struct {
+	repository.AlertRepository
+	lookups *labelLookups
+}
+
+func (r countingAlertRepo) GetRuleByID(id uint) (*model.AlertRule, error) {
+	r.lookups.rules++
+	return &model.AlertRule{BaseModel: model.BaseModel{ID: id}, Name: "HighCPU"}, nil
+}
+
+func TestAlertLabelerCache(t *testing.T) {
+	lookups := &labelLookups{}
+	labeler := alertLabeler{
+		alertRepo:     countingAlertRepo{lookups: lookups},
+		hostRepo:      countingHostRepo{lookups: lookups},
+		hostGroupRepo: countingHostGroupRepo{lookups: lookups},
+	}
+	alerts := []model.Alert{
+		{RuleID: 7, Hostname: "web-1", Severity: "warning", Labels: "cpu=0"},
+		{RuleID: 7, Hostname: "web-1", Severity: "warning", Labels: "cpu=1"},
+		{RuleID: 7, Hostname: "db-1", Severity: "warning"},
+		{RuleID: 7, Hostname: "db-1", Severity: "warning"},
+	}
+
+	cached := labeler.cached()
+	for _, alert := range alerts {
+		cached.labels(alert)
+	}
+	if *lookups != (labelLookups{hosts: 2, groups: 1, rules: 1}) {
+		t.Errorf("lookups = %+v, want 2 hosts, 1 group and 1 rule", *lookups)
+	}
+
+	// 缓存的标签不会被单个告警的标签污染
+	labels := cached.labels(alerts[0])
+	want := map[string]string{
+		"hostname": "web-1", "env": "prod", "environment": "prod", "host_group": "frontend,web",
+		"alertname": "HighCPU", "rule_id": "7", "rule_type": model.RuleTypeThreshold,
+		"severity": "warning", "metric_type": "", "cpu": "0",
+	}
+	if len(labels) != len(want) {
+		t.Errorf("labels = %v, want %v", labels, want)
+	}
+	for key, value := range want {
+		if labels[key] != value {
+			t.Errorf("labels[%q] = %q, want %q", key, labels[key], value)
+		}
+	}
+	if _, ok := cached.labels(alerts[2])["cpu"]; ok {
+		t.Error("series labels of another alert leaked into the cache")
+	}
+
+	// 未缓存的标签器每次都查询
+	lookups.hosts = 0
+	for _, alert := range alerts {
+		labeler.labels(alert)
+	}
+	if lookups.hosts != len(alerts) {
+		t.Errorf("uncached host lookups = %d, want %d", lookups.hosts, len(alerts))
+	}
+}
+
+// lastSeenSampleRepo returns fixed last sample times per series
+type lastSeenSampleRepo struct {
+	repository.SampleRepository
+	lastSeen map[string]time.Time
+}
+
+func (r lastSeenSampleRepo) GetLastSeen(hostname, metric string, since time.Time) (map[string]time.Time, error) {
+	lastSeen := make(map[string]time.Time)
+	for labels, seen := range r.lastSeen {
+		if !seen.Before(since) {
+			lastSeen[labels] = seen
+		}
+	}
+	return lastSeen, nil
+}
+
+func TestEvaluateAbsent(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	reported := now.Add(-time.Minute)
+	rule := model.AlertRule{BaseModel: model.BaseModel{ID: 5}, Name: "NoData", MetricType: "process_count", RuleType: model.RuleTypeAbsent, Lookback: 600}
+	host := model.Host{Hostname: "web-1", Status: "online", LastSeen: &reported}
+	goneAlert := func(ruleID uint, hostname, labels string) *model.Alert {
+		return &model.Alert{
+			BaseModel: model.BaseModel{UpdatedAt: now.Add(-time.Minute)},
+			RuleID:    ruleID, Hostname: hostname, Labels: labels,
+			Value: 90000, Message: "last seen yesterday",
+		}
+	}
+
+	type result struct {
+		labels string
+		firing bool
+	}
+	tests := []struct {
+		name     string
+		host     model.Host
+		lastSeen map[string]time.Time
+		active   []*model.Alert
+		want     []result
+	}{
+		{
+			name: "host never reported",
+			host: model.Host{Hostname: "db-1", Status: "unknown"},
+		},
+		{
+			name:     "fresh series",
+			host:     host,
+			lastSeen: map[string]time.Time{"name=nginx": now.Add(-time.Minute)},
+			want:     []result{{"name=nginx", false}},
+		},
+		{
+			name:     "series silent longer than the window",
+			host:     host,
+			lastSeen: map[string]time.Time{"name=nginx": now.Add(-time.Minute), "name=redis": now.Add(-time.Hour)},
+			want:     []result{{"name=nginx", false}, {"name=redis", true}},
+		},
+		{
+			name: "no samples within the horizon",
+			host: host,
+			want: []result{{"", true}},
+		},
+		{
+			name:     "series left the horizon while firing",
+			host:     host,
+			lastSeen: map[string]time.Time{"name=nginx": now.Add(-time.Minute), "name=redis": now.Add(-2 * absentSeriesHorizon)},
+			active:   []*model.Alert{goneAlert(5, "web-1", "name=redis")},
+			want:     []result{{"name=nginx", false}, {"name=redis", true}},
+		},
+		{
+			// 所有序列都已超出检测范围时不重复触发主机级告警
+			name:   "all series left the horizon while firing",
+			host:   host,
+			active: []*model.Alert{goneAlert(5, "web-1", "name=redis")},
+			want:   []result{{"name=redis", true}},
+		},
+		{
+			name:     "host-level alert resolves once samples resume",
+			host:     host,
+			lastSeen: map[string]time.Time{"name=nginx": now.Add(-time.Minute)},
+			active:   []*model.Alert{goneAlert(5, "web-1", "")},
+			want:     []result{{"name=nginx", false}},
+		},
+		{
+			name:     "alerts of other rules and hosts are ignored",
+			host:     host,
+			lastSeen: map[string]time.Time{"name=nginx": now.Add(-time.Minute)},
+			active:   []*model.Alert{goneAlert(6, "web-1", "name=redis"), goneAlert(5, "web-2", "name=redis")},
+			want:     []result{{"name=nginx", false}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &alertEvaluator{series: seriesLoader{sampleRepo: lastSeenSampleRepo{lastSeen: tt.lastSeen}}, staleAfter: 5 * time.Minute}
+			active := make(map[string]*model.Alert)
+			for _, alert := range tt.active {
+				active[alertFingerprint(alert.RuleID, alert.Hostname, alert.Labels)] = alert
+			}
+
+			results, err := e.evaluateAbsent(rule, tt.host, active, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []result
+			for _, r := range results {
+				got = append(got, result{r.Labels, r.Firing})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("results = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// 超出检测范围的告警保留原消息，缺失时长继续累加
+	e := &alertEvaluator{series: seriesLoader{sampleRepo: lastSeenSampleRepo{}}, staleAfter: 5 * time.Minute}
+	alert := goneAlert(5, "web-1", "name=redis")
+	results, err := e.evaluateAbsent(rule, host, map[string]*model.Alert{"redis": alert}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Message != alert.Message || results[0].Value != 90060 {
+		t.Errorf("results = %+v, want the kept message with a value of 90060", results)
+	}
+}
+
+// memoryAlertRepo stores rules and alerts in memory
+type memoryAlertRepo struct {
+	repository.AlertRepository
+	rules  []model.AlertRule
+	alerts []model.Alert
+}
+
+func (r *memoryAlertRepo) GetActiveRules() ([]model.AlertRule, error) {
+	return r.rules, nil
+}
+
+func (r *memoryAlertRepo) GetRuleByID(id uint) (*model.AlertRule, error) {
+	for _, rule := range r.rules {
+		if rule.ID == id {
+			return &rule, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryAlertRepo) CreateAlert(alert *model.Alert) error {
+	alert.ID = uint(len(r.alerts) + 1)
+	r.alerts = append(r.alerts, *alert)
+	return nil
+}
+
+// UpdateAlert keeps the stored acknowledgement and escalation progress like the database repository
+func (r *memoryAlertRepo) UpdateAlert(alert *model.Alert) error {
+	stored := r.alerts[alert.ID-1]
+	updated := *alert
+	updated.AcknowledgedAt, updated.AcknowledgedBy = stored.AcknowledgedAt, stored.AcknowledgedBy
+	updated.EscalationPolicyID, updated.EscalationStep, updated.LastEscalatedAt = stored.EscalationPolicyID, stored.EscalationStep, stored.LastEscalatedAt
+	r.alerts[alert.ID-1] = updated
+	return nil
+}
+
+func (r *memoryAlertRepo) ReopenAlert(alert *model.Alert) error {
+	alert.AcknowledgedAt, alert.AcknowledgedBy = nil, ""
+	alert.EscalationPolicyID, alert.EscalationStep, alert.LastEscalatedAt = nil, 0, nil
+	r.alerts[alert.ID-1] = *alert
+	return nil
+}
+
+func (r *memoryAlertRepo) UpdateEscalationState(alert *model.Alert) error {
+	stored := &r.alerts[alert.ID-1]
+	stored.EscalationPolicyID, stored.EscalationStep, stored.LastEscalatedAt = alert.EscalationPolicyID, alert.EscalationStep, alert.LastEscalatedAt
+	return nil
+}
+
+func (r *memoryAlertRepo) GetActiveAlerts() ([]model.Alert, error) {
+	return r.find(func(a model.Alert) bool { return a.Status == "active" }), nil
+}
+
+func (r *memoryAlertRepo) find(match func(model.Alert) bool) []model.Alert {
+	var alerts []model.Alert
+	for _, alert := range r.alerts {
+		if match(alert) {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+func (r *memoryAlertRepo) GetFiringAlerts() ([]model.Alert, error) {
+	return r.find(func(a model.Alert) bool { return a.Status != "resolved" }), nil
+}
+
+func (r *memoryAlertRepo) GetFlappingAlerts() ([]model.Alert, error) {
+	return r.find(func(a model.Alert) bool { return a.Flapping }), nil
+}
+
+func (r *memoryAlertRepo) GetRecentlyResolvedAlert(fingerprint string, since time.Time) (*model.Alert, error) {
+	alerts := r.find(func(a model.Alert) bool {
+		return a.Fingerprint == fingerprint && a.Status == "resolved" && !a.EndTime.Before(since)
+	})
+	if len(alerts) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &alerts[len(alerts)-1], nil
+}
+
+type evaluatorHostRepo struct {
+	repository.HostRepository
+	hosts []model.Host
+}
+
+func (r evaluatorHostRepo) GetMonitoringEnabledHosts() ([]model.Host, error) {
+	return r.hosts, nil
+}
+
+type noHostGroupRepo struct {
+	repository.HostGroupRepository
+}
+
+func (noHostGroupRepo) GetHostGroups(hostID uint) ([]model.HostGroup, error) {
+	return nil, nil
+}
+
+type staticEscalationRepo struct {
+	repository.EscalationRepository
+	policies []model.EscalationPolicy
+}
+
+func (r staticEscalationRepo) GetEnabledPolicies() ([]model.EscalationPolicy, error) {
+	return r.policies, nil
+}
+
+type noInhibitionRepo struct {
+	repository.InhibitionRuleRepository
+}
+
+func (noInhibitionRepo) GetEnabled() ([]model.InhibitionRule, error) {
+	return nil, nil
+}
+
+// recordingNotifier records the notification events it was asked to send
+type recordingNotifier struct {
+	events []string
+}
+
+func (n *recordingNotifier) Notify(alert model.Alert, event string) error {
+	n.events = append(n.events, event)
+	return nil
+}
+
+// testEvaluation drives an alert evaluator over samples of a single process_count series
+type testEvaluation struct {
+	t         *testing.T
+	evaluator *alertEvaluator
+	alerts    *memoryAlertRepo
+	samples   *windowSampleRepo
+	notifier  *recordingNotifier
+	now       time.Time
+}
+
+func newTestEvaluation(t *testing.T, rules ...model.AlertRule) *testEvaluation {
+	seen := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	host := model.Host{BaseModel: model.BaseModel{ID: 1}, Hostname: "web-1", Status: "online", LastSeen: &seen}
+	alerts := &memoryAlertRepo{rules: rules}
+	samples := &windowSampleRepo{}
+	notifier := &recordingNotifier{}
+	evaluator := &alertEvaluator{
+		alertRepo:      alerts,
+		hostRepo:       evaluatorHostRepo{hosts: []model.Host{host}},
+		hostGroupRepo:  noHostGroupRepo{},
+		series:         seriesLoader{sampleRepo: samples},
+		notifier:       notifier,
+		staleAfter:     5 * time.Minute,
+		flapWindow:     30 * time.Minute,
+		flapThreshold:  4,
+		logger:         logger.New(config.LogConfig{Level: "error", Format: "text"}),
+		inhibitionRepo: noInhibitionRepo{},
+		labeler:        alertLabeler{alertRepo: alerts, hostRepo: &staticHostRepo{host: &host}, hostGroupRepo: noHostGroupRepo{}},
+	}
+	return &testEvaluation{t: t, evaluator: evaluator, alerts: alerts, samples: samples, notifier: notifier, now: seen}
+}
+
+// step records a sample one minute after the previous evaluation and evaluates again
+func (te *testEvaluation) step(value float64) {
+	te.t.Helper()
+	te.now = te.now.Add(time.Minute)
+	te.samples.samples = append(te.samples.samples, model.MetricSample{
+		Hostname: "web-1", Metric: "process_count", Timestamp: te.now, Value: value,
+	})
+	if err := te.evaluator.EvaluateOnce(te.now); err != nil {
+		te.t.Fatal(err)
+	}
+}
+
+func testThresholdRule(resolveThreshold *float64) model.AlertRule {
+	rule := model.AlertRule{
+		Name: "TooManyProcesses", MetricType: "process_count", Operator: ">", Threshold: 80,
+		Severity: "warning", Enabled: true, ResolveThreshold: resolveThreshold,
+	}
+	rule.ID = 1
+	return rule
+}
+
+func TestAlertDeduplication(t *testing.T) {
+	te := newTestEvaluation(t, testThresholdRule(nil))
+	for _, value := range []float64{90, 95, 92} {
+		te.step(value)
+	}
+
+	// 同一指纹只有一条告警，值随每轮评估更新，只通知一次
+	if len(te.alerts.alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(te.alerts.alerts))
+	}
+	alert := te.alerts.alerts[0]
+	if alert.Status != "active" || alert.Value != 92 || alert.Fingerprint != alertFingerprint(1, "web-1", "") {
+		t.Errorf("alert = %+v", alert)
+	}
+	if len(te.notifier.events) != 1 || te.notifier.events[0] != AlertEventFiring {
+		t.Errorf("events = %v, want a single firing notification", te.notifier.events)
+	}
+
+	// 指纹为空的旧告警按规则、主机和标签匹配，不会重复创建
+	te.alerts.alerts[0].Fingerprint = ""
+	te.step(93)
+	if len(te.alerts.alerts) != 1 || te.alerts.alerts[0].Value != 93 {
+		t.Errorf("alerts = %+v, want the legacy alert updated", te.alerts.alerts)
+	}
+}
+
+func TestResolveThreshold(t *testing.T) {
+	seventy := 70.0
+	tests := []struct {
+		name             string
+		resolveThreshold *float64
+		values           []float64
+		want             []string // 每轮评估后的告警状态，空表示没有告警
+	}{
+		{"no resolve threshold", nil, []float64{85, 75, 65}, []string{"active", "resolved", "resolved"}},
+		{"held until the resolve threshold", &seventy, []float64{85, 75, 65}, []string{"active", "active", "resolved"}},
+		{"resolve threshold does not fire", &seventy, []float64{75, 85}, []string{"", "active"}},
+		{"crossing exactly resolves", &seventy, []float64{85, 70}, []string{"active", "resolved"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := newTestEvaluation(t, testThresholdRule(tt.resolveThreshold))
+			for i, value := range tt.values {
+				te.step(value)
+				got := ""
+				if len(te.alerts.alerts) > 0 {
+					got = te.alerts.alerts[0].Status
+				}
+				if got != tt.want[i] {
+					t.Errorf("after value %v: status = %q, want %q", value, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHoldsResolveThreshold(t *testing.T) {
+	seventy := 70.0
+	rule := func(ruleType, operator string) model.AlertRule {
+		return model.AlertRule{RuleType: ruleType, Operator: operator, Threshold: 80, ResolveThreshold: &seventy}
+	}
+	tests := []struct {
+		rule  model.AlertRule
+		value float64
+		want  bool
+	}{
+		{rule(model.RuleTypeThreshold, ">"), 75, true},
+		{rule(model.RuleTypeThreshold, ">"), 70, false},
+		{rule(model.RuleTypeThreshold, ">="), 70, true},
+		{rule(model.RuleTypeAnomaly, ">"), 75, true},
+		{rule(model.RuleTypeForecast, ">"), 75, false}, // 预测规则不支持恢复阈值
+		{rule(model.RuleTypeAbsent, ">"), 75, false},
+		{model.AlertRule{Operator: ">", Threshold: 80}, 75, false},
+	}
+	e := &alertEvaluator{}
+	for _, tt := range tests {
+		if got := e.holdsResolveThreshold(tt.rule, tt.value); got != tt.want {
+			t.Errorf("holdsResolveThreshold(%s %s 80 resolve 70, %v) = %v, want %v", tt.rule.RuleType, tt.rule.Operator, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRecordStateChange(t *testing.T) {
+	now := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name         string
+		alert        model.Alert
+		wantChanges  int
+		wantFlapping bool
+	}{
+		{"first change", model.Alert{}, 1, false},
+		{"counts changes within the window", model.Alert{StateChanges: 2, LastStateChangeAt: ago(time.Minute)}, 3, false},
+		{"starts flapping at the threshold", model.Alert{StateChanges: 3, LastStateChangeAt: ago(time.Minute)}, 4, true},
+		{"count restarts after a stable window", model.Alert{StateChanges: 3, LastStateChangeAt: ago(31 * time.Minute)}, 1, false},
+		{"change at the window edge still counts", model.Alert{StateChanges: 3, LastStateChangeAt: ago(30 * time.Minute)}, 4, true},
+		{"flapping is only cleared by settling", model.Alert{StateChanges: 9, Flapping: true, LastStateChangeAt: ago(time.Hour)}, 1, true},
+	}
+
+	e := &alertEvaluator{flapWindow: 30 * time.Minute, flapThreshold: 4, logger: logger.New(config.LogConfig{Level: "error", Format: "text"})}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := tt.alert
+			e.recordStateChange(&alert, now)
+			if alert.StateChanges != tt.wantChanges || alert.Flapping != tt.wantFlapping {
+				t.Errorf("state changes = %d, flapping = %v; want %d, %v", alert.StateChanges, alert.Flapping, tt.wantChanges, tt.wantFlapping)
+			}
+			if alert.LastStateChangeAt == nil || !alert.LastStateChangeAt.Equal(now) {
+				t.Errorf("LastStateChangeAt = %v, want %v", alert.LastStateChangeAt, now)
+			}
+		})
+	}
+}
+
+func TestFlapDetection(t *testing.T) {
+	te := newTestEvaluation(t, testThresholdRule(nil))
+
+	// 触发、恢复、重新打开、恢复：第4次状态变化时进入抖动状态
+	for _, value := range []float64{90, 10, 90, 10} {
+		te.step(value)
+	}
+	if len(te.alerts.alerts) != 1 {
+		t.Fatalf("got %d alerts, want the alert to be reopened", len(te.alerts.alerts))
+	}
+	alert := te.alerts.alerts[0]
+	if !alert.Flapping || alert.StateChanges != 4 || alert.Status != "resolved" {
+		t.Fatalf("alert = %+v, want a resolved flapping alert after 4 state changes", alert)
+	}
+	want := []string{AlertEventFiring, AlertEventResolved, AlertEventFiring}
+	if !reflect.DeepEqual(te.notifier.events, want) {
+		t.Fatalf("events = %v, want %v", te.notifier.events, want)
+	}
+
+	// 抖动期间的状态变化不通知
+	te.step(90)
+	if len(te.notifier.events) != 3 {
+		t.Fatalf("events = %v, want notifications held while flapping", te.notifier.events)
+	}
+
+	// 稳定超过一个完整的抖动窗口后发送当前状态的通知
+	te.step(10)
+	resolvedAt := te.now
+	for te.now.Sub(resolvedAt) < 30*time.Minute {
+		te.step(10)
+		if !te.alerts.alerts[0].Flapping {
+			t.Fatalf("alert settled %v after the last state change, want more than 30m", te.now.Sub(resolvedAt))
+		}
+	}
+	te.step(10)
+	alert = te.alerts.alerts[0]
+	if alert.Flapping || alert.StateChanges != 0 {
+		t.Errorf("alert = %+v, want settled", alert)
+	}
+	want = append(want, AlertEventResolved)
+	if !reflect.DeepEqual(te.notifier.events, want) {
+		t.Errorf("events = %v, want %v", te.notifier.events, want)
+	}
+}
+
+func TestEscalationBetweenEvaluations(t *testing.T) {
+	te := newTestEvaluation(t, testThresholdRule(nil))
+	clock := &fakeClock{}
+	manager := &escalationManager{
+		alertRepo:      te.alerts,
+		hostRepo:       &staticHostRepo{},
+		hostGroupRepo:  noHostGroupRepo{},
+		escalationRepo: staticEscalationRepo{policies: []model.EscalationPolicy{testPolicy()}},
+		logger:         te.evaluator.logger,
+	}
+	var sent int
+	manager.engine = &escalationEngine{
+		clock: clock,
+		send: func(step model.EscalationStep, n model.EscalationNotification) error {
+			sent++
+			return nil
+		},
+	}
+	escalate := func() {
+		t.Helper()
+		clock.now = te.now
+		if err := manager.EscalateOnce(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	te.step(90)
+	escalate()
+	// 升级之后的评估不会覆盖升级进度
+	te.step(95)
+	if alert := te.alerts.alerts[0]; alert.EscalationStep != 1 || alert.EscalationPolicyID == nil || alert.LastEscalatedAt == nil {
+		t.Fatalf("alert = %+v, want escalation step 1 kept", alert)
+	}
+	escalate()
+	if sent != 1 {
+		t.Errorf("sent %d escalations, want the first step only once", sent)
+	}
+
+	// 在抖动窗口内重新触发的告警清除确认信息并重新升级
+	acknowledgedAt := te.now
+	te.alerts.alerts[0].AcknowledgedAt, te.alerts.alerts[0].AcknowledgedBy = &acknowledgedAt, "alice"
+	te.step(10)
+	te.step(90)
+	alert := te.alerts.alerts[0]
+	if len(te.alerts.alerts) != 1 || alert.Status != "active" {
+		t.Fatalf("alerts = %+v, want the alert reopened", te.alerts.alerts)
+	}
+	if alert.AcknowledgedAt != nil || alert.AcknowledgedBy != "" || alert.EscalationPolicyID != nil || alert.EscalationStep != 0 || alert.LastEscalatedAt != nil {
+		t.Fatalf("reopened alert = %+v, want acknowledgement and escalation cleared", alert)
+	}
+	escalate()
+	if sent != 2 || te.alerts.alerts[0].EscalationStep != 1 {
+		t.Errorf("sent %d escalations, step %d, want the reopened alert escalated again", sent, te.alerts.alerts[0].EscalationStep)
+	}
+}
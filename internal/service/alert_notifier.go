@@ -0,0 +1,52 @@
+package service
+
+import (
+	"monitor-server/internal/model"
+	"monitor-server/pkg/logger"
+)
+
+// Alert notification events
+const (
+	AlertEventFiring   = "firing"
+	AlertEventResolved = "resolved"
+	// AlertEventSuppressed 告警被抑制，撤回尚未发送的通知
+	AlertEventSuppressed = "suppressed"
+)
+
+// AlertNotifier delivers alert state changes to the outside world
+type AlertNotifier interface {
+	Notify(alert model.Alert, event string) error
+}
+
+// logNotifier writes alert notifications to the application log
+type logNotifier struct {
+	logger *logger.Logger
+}
+
+// NewLogNotifier creates a notifier that only logs alert notifications
+func NewLogNotifier(logger *logger.Logger) AlertNotifier {
+	return &logNotifier{logger: logger}
+}
+
+// Notify logs the alert notification
+func (n *logNotifier) Notify(alert model.Alert, event string) error {
+	n.logger.Info("Alert notification",
+		"event", event,
+		"alert_id", alert.ID,
+		"hostname", alert.Hostname,
+		"severity", alert.Severity,
+		"message", alert.Message,
+	)
+	return nil
+}
+
+// alertEvent returns the notification event matching the alert status
+func alertEvent(alert model.Alert) string {
+	switch alert.Status {
+	case "resolved":
+		return AlertEventResolved
+	case "suppressed":
+		return AlertEventSuppressed
+	}
+	return AlertEventFiring
+}
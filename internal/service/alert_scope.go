@@ -0,0 +1,351 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"monitor-server/internal/model"
+)
+
+// scopePriority 作用范围优先级，数值越小优先级越高
+var scopePriority = map[string]int{
+	model.RuleScopeHost:     0,
+	model.RuleScopeGroup:    1,
+	model.RuleScopeSelector: 2,
+	model.RuleScopeGlobal:   3,
+}
+
+// selectorTerm represents a single term of a label selector
+type selectorTerm struct {
+	Key    string
+	Value  string
+	Negate bool // key!=value 或 !key
+	Exists bool // 仅判断标签是否存在
+}
+
+// LabelSelector represents a parsed label selector such as "env=prod,role=db"
+type LabelSelector []selectorTerm
+
+// ParseSelector parses a comma separated label selector.
+// Supported terms: key=value, key!=value, key (exists) and !key (not exists).
+func ParseSelector(selector string) (LabelSelector, error) {
+	var terms LabelSelector
+	for _, raw := range strings.Split(selector, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		var term selectorTerm
+		switch {
+		case strings.Contains(raw, "!="):
+			parts := strings.SplitN(raw, "!=", 2)
+			term = selectorTerm{Key: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1]), Negate: true}
+		case strings.Contains(raw, "="):
+			parts := strings.SplitN(raw, "=", 2)
+			term = selectorTerm{Key: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])}
+		case strings.HasPrefix(raw, "!"):
+			term = selectorTerm{Key: strings.TrimSpace(raw[1:]), Negate: true, Exists: true}
+		default:
+			term = selectorTerm{Key: raw, Exists: true}
+		}
+
+		if term.Key == "" {
+			return nil, fmt.Errorf("invalid selector term: %q", raw)
+		}
+		terms = append(terms, term)
+	}
+
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("selector cannot be empty")
+	}
+	return terms, nil
+}
+
+// Matches reports whether the labels satisfy every term of the selector
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, term := range s {
+		value, ok := labels[term.Key]
+		var matched bool
+		if term.Exists {
+			matched = ok
+		} else {
+			matched = ok && value == term.Value
+		}
+		if matched == term.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the canonical form of the selector
+func (s LabelSelector) String() string {
+	parts := make([]string, 0, len(s))
+	for _, term := range s {
+		switch {
+		case term.Exists && term.Negate:
+			parts = append(parts, "!"+term.Key)
+		case term.Exists:
+			parts = append(parts, term.Key)
+		case term.Negate:
+			parts = append(parts, term.Key+"!="+term.Value)
+		default:
+			parts = append(parts, term.Key+"="+term.Value)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// HostLabels builds the label set of a host from its attributes and tags.
+// Tags may be a JSON object ({"role":"db"}) or a JSON array of "key=value" or bare tags.
+func HostLabels(host model.Host) map[string]string {
+	labels := map[string]string{
+		"hostname": host.Hostname,
+	}
+	if host.Environment != "" {
+		labels["env"] = host.Environment
+		labels["environment"] = host.Environment
+	}
+	if host.Location != "" {
+		labels["location"] = host.Location
+	}
+	if host.OS != "" {
+		labels["os"] = host.OS
+	}
+	if host.Platform != "" {
+		labels["platform"] = host.Platform
+	}
+
+	for key, value := range parseTags(host.Tags) {
+		labels[key] = value
+	}
+	return labels
+}
+
+// parseTags parses the JSON tags column into labels
+func parseTags(tags string) map[string]string {
+	labels := make(map[string]string)
+	tags = strings.TrimSpace(tags)
+	if tags == "" {
+		return labels
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(tags), &object); err == nil {
+		for key, value := range object {
+			labels[key] = fmt.Sprint(value)
+		}
+		return labels
+	}
+
+	var list []string
+	if err := json.Unmarshal([]byte(tags), &list); err != nil {
+		// 兼容逗号分隔的纯文本标签
+		list = strings.Split(tags, ",")
+	}
+	for _, tag := range list {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if key, value, ok := strings.Cut(tag, "="); ok {
+			labels[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		} else if key, value, ok := strings.Cut(tag, ":"); ok {
+			labels[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		} else {
+			labels[tag] = ""
+		}
+	}
+	return labels
+}
+
+// ruleMatchesHost reports whether a rule's scope covers the host and explains why
+func ruleMatchesHost(rule model.AlertRule, host model.Host, groupIDs map[uint]bool, labels map[string]string) (bool, string) {
+	switch rule.Scope() {
+	case model.RuleScopeHost:
+		if *rule.HostID == host.ID {
+			return true, "规则指定了该主机"
+		}
+		return false, fmt.Sprintf("规则属于其他主机 (ID: %d)", *rule.HostID)
+	case model.RuleScopeGroup:
+		if groupIDs[*rule.HostGroupID] {
+			return true, fmt.Sprintf("主机属于主机组 (ID: %d)", *rule.HostGroupID)
+		}
+		return false, fmt.Sprintf("主机不属于主机组 (ID: %d)", *rule.HostGroupID)
+	case model.RuleScopeSelector:
+		selector, err := ParseSelector(rule.Selector)
+		if err != nil {
+			return false, fmt.Sprintf("标签选择器无效: %v", err)
+		}
+		if selector.Matches(labels) {
+			return true, fmt.Sprintf("主机标签匹配选择器 %s", selector)
+		}
+		return false, fmt.Sprintf("主机标签不匹配选择器 %s", selector)
+	default:
+		return true, "全局规则适用于所有主机"
+	}
+}
+
+// ruleTypeOf returns the rule type, treating an empty type as a threshold rule
+func ruleTypeOf(rule model.AlertRule) string {
+	if rule.RuleType == "" {
+		return model.RuleTypeThreshold
+	}
+	return rule.RuleType
+}
+
+// ResolveHostRules determines which rules apply to a host. For every rule type, metric type
+// and severity the rule with the most specific scope wins (host > group > selector > global);
+// ties are broken by selector specificity and then by the lowest rule ID. Candidates losing
+// only by ID to a rule of the same scope are marked as shadowed duplicates.
+func ResolveHostRules(host model.Host, groupIDs []uint, rules []model.AlertRule) *model.AlertRuleExplanation {
+	groups := make(map[uint]bool, len(groupIDs))
+	for _, id := range groupIDs {
+		groups[id] = true
+	}
+	labels := HostLabels(host)
+
+	// 确定性排序
+	sorted := make([]model.AlertRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := scopePriority[sorted[i].Scope()], scopePriority[sorted[j].Scope()]
+		if pi != pj {
+			return pi < pj
+		}
+		si, sj := selectorSpecificity(sorted[i]), selectorSpecificity(sorted[j])
+		if si != sj {
+			return si > sj
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	byKey := make(map[string]*model.EffectiveAlertRule)
+	var keys []string
+	for _, rule := range sorted {
+		ruleType := ruleTypeOf(rule)
+		key := ruleType + "/" + rule.MetricType + "/" + rule.Severity
+		effective, ok := byKey[key]
+		if !ok {
+			effective = &model.EffectiveAlertRule{
+				RuleType:   ruleType,
+				MetricType: rule.MetricType,
+				Severity:   rule.Severity,
+				Candidates: []model.AlertRuleCandidate{},
+			}
+			byKey[key] = effective
+			keys = append(keys, key)
+		}
+
+		candidate := model.AlertRuleCandidate{
+			RuleID: rule.ID,
+			Name:   rule.Name,
+			Scope:  rule.Scope(),
+		}
+
+		matched, reason := ruleMatchesHost(rule, host, groups, labels)
+		switch {
+		case !matched:
+			candidate.Reason = reason
+		case !rule.Enabled:
+			candidate.Reason = "规则已禁用"
+		case effective.Rule != nil && sameRuleScope(rule, *effective.Rule):
+			candidate.Shadowed = true
+			candidate.Reason = fmt.Sprintf("%s，但与范围相同的规则 %d 重复，被其覆盖", reason, effective.Rule.ID)
+		case effective.Rule != nil:
+			candidate.Reason = fmt.Sprintf("%s，但被优先级更高的规则 %d (%s) 覆盖", reason, effective.Rule.ID, effective.Scope)
+		default:
+			applied := rule
+			effective.Rule = &applied
+			effective.Scope = rule.Scope()
+			effective.Reason = reason
+			candidate.Applied = true
+			candidate.Reason = reason
+		}
+		effective.Candidates = append(effective.Candidates, candidate)
+	}
+
+	sort.Strings(keys)
+	explanation := &model.AlertRuleExplanation{
+		HostID:   host.ID,
+		Hostname: host.Hostname,
+		Labels:   labels,
+		GroupIDs: groupIDs,
+		Rules:    make([]model.EffectiveAlertRule, 0, len(keys)),
+	}
+	for _, key := range keys {
+		explanation.Rules = append(explanation.Rules, *byKey[key])
+	}
+	return explanation
+}
+
+// EffectiveRules returns only the rules that actually apply to a host
+func EffectiveRules(host model.Host, groupIDs []uint, rules []model.AlertRule) []model.AlertRule {
+	explanation := ResolveHostRules(host, groupIDs, rules)
+	var effective []model.AlertRule
+	for _, rule := range explanation.Rules {
+		if rule.Rule != nil {
+			effective = append(effective, *rule.Rule)
+		}
+	}
+	return effective
+}
+
+// DuplicateRule returns the first rule that has the same rule type, metric type, severity and
+// scope as the given rule. Only one of such rules can apply to a host, so the others would be
+// silently shadowed.
+func DuplicateRule(rule model.AlertRule, rules []model.AlertRule) *model.AlertRule {
+	for i := range rules {
+		other := rules[i]
+		if other.ID == rule.ID || ruleTypeOf(other) != ruleTypeOf(rule) || other.MetricType != rule.MetricType || other.Severity != rule.Severity {
+			continue
+		}
+		if sameRuleScope(rule, other) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// sameRuleScope reports whether two rules cover exactly the same hosts by the same scope
+func sameRuleScope(a, b model.AlertRule) bool {
+	if a.Scope() != b.Scope() {
+		return false
+	}
+	switch a.Scope() {
+	case model.RuleScopeHost:
+		return *a.HostID == *b.HostID
+	case model.RuleScopeGroup:
+		return *a.HostGroupID == *b.HostGroupID
+	case model.RuleScopeSelector:
+		return canonicalSelector(a.Selector) == canonicalSelector(b.Selector)
+	default:
+		return true
+	}
+}
+
+// canonicalSelector returns the sorted canonical terms of a selector, or the selector itself if it is invalid
+func canonicalSelector(selector string) string {
+	parsed, err := ParseSelector(selector)
+	if err != nil {
+		return selector
+	}
+	terms := strings.Split(parsed.String(), ",")
+	sort.Strings(terms)
+	return strings.Join(terms, ",")
+}
+
+// selectorSpecificity returns the number of selector terms, used to break ties between selector rules
+func selectorSpecificity(rule model.AlertRule) int {
+	if rule.Scope() != model.RuleScopeSelector {
+		return 0
+	}
+	selector, err := ParseSelector(rule.Selector)
+	if err != nil {
+		return 0
+	}
+	return len(selector)
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"monitor-server/internal/model"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     LabelSelector
+		str      string
+	}{
+		{"env=prod", LabelSelector{{Key: "env", Value: "prod"}}, "env=prod"},
+		{" env = prod , role!=db ", LabelSelector{{Key: "env", Value: "prod"}, {Key: "role", Value: "db", Negate: true}}, "env=prod,role!=db"},
+		{"gpu,!canary", LabelSelector{{Key: "gpu", Exists: true}, {Key: "canary", Negate: true, Exists: true}}, "gpu,!canary"},
+		{"url=http://a?b=c", LabelSelector{{Key: "url", Value: "http://a?b=c"}}, "url=http://a?b=c"},
+		{"env=,", LabelSelector{{Key: "env", Value: ""}}, "env="},
+	}
+	for _, tt := range tests {
+		got, err := ParseSelector(tt.selector)
+		if err != nil {
+			t.Errorf("ParseSelector(%q) error: %v", tt.selector, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSelector(%q) = %+v, want %+v", tt.selector, got, tt.want)
+		}
+		if got.String() != tt.str {
+			t.Errorf("ParseSelector(%q).String() = %q, want %q", tt.selector, got.String(), tt.str)
+		}
+	}
+
+	for _, selector := range []string{"", " , ", "=prod", "!=prod", "!"} {
+		if _, err := ParseSelector(selector); err == nil {
+			t.Errorf("ParseSelector(%q) expected error", selector)
+		}
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "role": "db", "gpu": ""}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"env=prod", true},
+		{"env=prod,role=db", true},
+		{"env=prod,role=web", false},
+		{"env!=staging", true},
+		{"role!=db", false},
+		{"zone!=eu", true}, // 缺少的标签不等于任何值
+		{"gpu", true},
+		{"!gpu", false},
+		{"!canary", true},
+		{"zone", false},
+	}
+	for _, tt := range tests {
+		selector, err := ParseSelector(tt.selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := selector.Matches(labels); got != tt.want {
+			t.Errorf("%q.Matches(%v) = %v, want %v", tt.selector, labels, got, tt.want)
+		}
+	}
+}
+
+func TestHostLabels(t *testing.T) {
+	tests := []struct {
+		tags string
+		want map[string]string
+	}{
+		{`{"role":"db","tier":2}`, map[string]string{"role": "db", "tier": "2"}},
+		{`["role=db","gpu","zone:eu"]`, map[string]string{"role": "db", "gpu": "", "zone": "eu"}},
+		{`role=db, gpu`, map[string]string{"role": "db", "gpu": ""}},
+		{``, map[string]string{}},
+	}
+	for _, tt := range tests {
+		labels := HostLabels(model.Host{Hostname: "db-1", Environment: "prod", Tags: tt.tags})
+		want := map[string]string{"hostname": "db-1", "env": "prod", "environment": "prod"}
+		for key, value := range tt.want {
+			want[key] = value
+		}
+		if !reflect.DeepEqual(labels, want) {
+			t.Errorf("HostLabels(tags %q) = %v, want %v", tt.tags, labels, want)
+		}
+	}
+}
+
+func TestResolveHostRulesPrecedence(t *testing.T) {
+	hostID, otherHostID, groupID, otherGroupID := uint(1), uint(2), uint(10), uint(11)
+	host := model.Host{BaseModel: model.BaseModel{ID: hostID}, Hostname: "db-1", Environment: "prod", Tags: `{"role":"db"}`}
+	rule := func(id uint, threshold float64, scope func(r *model.AlertRule)) model.AlertRule {
+		r := model.AlertRule{MetricType: "cpu", Severity: "warning", Operator: ">", Threshold: threshold, Enabled: true}
+		r.ID = id
+		if scope != nil {
+			scope(&r)
+		}
+		return r
+	}
+	global := func(id uint) model.AlertRule { return rule(id, 90, nil) }
+	selector := func(id uint, s string) model.AlertRule {
+		return rule(id, 85, func(r *model.AlertRule) { r.Selector = s })
+	}
+	group := func(id uint, g uint) model.AlertRule {
+		return rule(id, 80, func(r *model.AlertRule) { r.HostGroupID = &g })
+	}
+	forHost := func(id uint, h uint) model.AlertRule {
+		return rule(id, 70, func(r *model.AlertRule) { r.HostID = &h })
+	}
+	disabled := func(r model.AlertRule) model.AlertRule {
+		r.Enabled = false
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		rules []model.AlertRule
+		want  uint // 生效规则ID，0 表示没有规则生效
+	}{
+		{"global only", []model.AlertRule{global(1)}, 1},
+		{"selector beats global", []model.AlertRule{global(1), selector(2, "env=prod")}, 2},
+		{"group beats selector", []model.AlertRule{global(1), selector(2, "env=prod"), group(3, groupID)}, 3},
+		{"host beats group", []model.AlertRule{forHost(4, hostID), global(1), group(3, groupID)}, 4},
+		{"other host ignored", []model.AlertRule{global(1), forHost(4, otherHostID)}, 1},
+		{"other group ignored", []model.AlertRule{global(1), group(3, otherGroupID)}, 1},
+		{"non-matching selector ignored", []model.AlertRule{global(1), selector(2, "role=web")}, 1},
+		{"more specific selector wins", []model.AlertRule{selector(2, "env=prod"), selector(5, "env=prod,role=db")}, 5},
+		{"lowest ID breaks ties", []model.AlertRule{global(7), global(3)}, 3},
+		{"disabled rule falls through", []model.AlertRule{global(1), disabled(forHost(4, hostID))}, 1},
+		{"only disabled rules", []model.AlertRule{disabled(global(1))}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explanation := ResolveHostRules(host, []uint{groupID}, tt.rules)
+			if len(explanation.Rules) != 1 {
+				t.Fatalf("got %d rule keys, want 1", len(explanation.Rules))
+			}
+			effective := explanation.Rules[0]
+			var got uint
+			if effective.Rule != nil {
+				got = effective.Rule.ID
+			}
+			if got != tt.want {
+				t.Errorf("effective rule = %d, want %d (candidates %+v)", got, tt.want, effective.Candidates)
+			}
+			if len(effective.Candidates) != len(tt.rules) {
+				t.Errorf("got %d candidates, want %d", len(effective.Candidates), len(tt.rules))
+			}
+		})
+	}
+}
+
+func TestEffectiveRulesPerKey(t *testing.T) {
+	host := model.Host{BaseModel: model.BaseModel{ID: 1}, Hostname: "web-1"}
+	rules := []model.AlertRule{
+		{MetricType: "cpu", Severity: "warning", Enabled: true},
+		{MetricType: "cpu", Severity: "critical", Enabled: true},
+		{MetricType: "memory", Severity: "warning", Enabled: true},
+		{MetricType: "cpu", Severity: "warning", RuleType: model.RuleTypeForecast, Enabled: true},
+	}
+	for i := range rules {
+		rules[i].ID = uint(i + 1)
+	}
+	// 规则类型、指标类型和严重级别各不相同时互不覆盖
+	if got := EffectiveRules(host, nil, rules); len(got) != 4 {
+		t.Errorf("got %d effective rules, want 4", len(got))
+	}
+}
+
+func TestDuplicateRules(t *testing.T) {
+	host := model.Host{BaseModel: model.BaseModel{ID: 1}, Hostname: "web-1", Environment: "prod", Tags: `{"role":"web"}`}
+	hostID, groupID := uint(1), uint(10)
+	rule := func(id uint, operator string, threshold float64) model.AlertRule {
+		r := model.AlertRule{MetricType: "cpu", Severity: "warning", Operator: operator, Threshold: threshold, Enabled: true}
+		r.ID = id
+		return r
+	}
+
+	// 两条全局 cpu warning 规则，ID 较大的被覆盖并在说明中标出
+	rules := []model.AlertRule{rule(1, ">", 90), rule(2, "<", 5)}
+	candidates := ResolveHostRules(host, nil, rules).Rules[0].Candidates
+	if !candidates[0].Applied || candidates[0].Shadowed || candidates[1].Applied || !candidates[1].Shadowed {
+		t.Errorf("expected rule 2 to be shadowed by rule 1, got %+v", candidates)
+	}
+	if duplicate := DuplicateRule(rule(0, ">", 80), rules); duplicate == nil || duplicate.ID != 1 {
+		t.Errorf("expected rule 1 as duplicate, got %+v", duplicate)
+	}
+
+	forHost := rule(3, ">", 70)
+	forHost.HostID = &hostID
+	forGroup := rule(4, ">", 70)
+	forGroup.HostGroupID = &groupID
+	bySelector := rule(5, ">", 70)
+	bySelector.Selector = "env=prod,role=web"
+	scoped := []model.AlertRule{forHost, forGroup, bySelector}
+
+	// 范围更具体的规则按优先级覆盖，不算重复
+	candidates = ResolveHostRules(host, []uint{groupID}, append(scoped, rules[0])).Rules[0].Candidates
+	for _, candidate := range candidates {
+		if candidate.Shadowed {
+			t.Errorf("rule %d of another scope marked as shadowed", candidate.RuleID)
+		}
+	}
+
+	sameSelector := rule(0, ">", 60)
+	sameSelector.Selector = "role=web, env=prod"
+	if duplicate := DuplicateRule(sameSelector, scoped); duplicate == nil || duplicate.ID != 5 {
+		t.Errorf("expected the selector rule as duplicate, got %+v", duplicate)
+	}
+	otherSeverity := rule(0, ">", 95)
+	otherSeverity.Severity = "critical"
+	otherType := rule(0, ">", 3)
+	otherType.RuleType = model.RuleTypeAnomaly
+	for _, r := range []model.AlertRule{otherSeverity, otherType} {
+		if duplicate := DuplicateRule(r, rules); duplicate != nil {
+			t.Errorf("rule %+v reported as duplicate of %d", r, duplicate.ID)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+)
+
+const (
+	defaultBaselineLookback = 28 * 24 * time.Hour
+	baselineRefreshInterval = time.Hour // 基线重新学习的间隔
+	minBucketSamples        = 10        // 分桶样本不足时退回到整体基线
+	overallBucket           = -1
+)
+
+// BaselineLearner learns and caches per-host metric baselines. Baselines learned with
+// different lookback windows are stored separately.
+type BaselineLearner interface {
+	// LearnBaseline returns the stored baseline, relearning it when it is missing or outdated
+	LearnBaseline(host model.Host, metricType string, lookback time.Duration, now time.Time) (*model.HostBaseline, error)
+	// GetBaseline returns the stored baseline without learning, or gorm.ErrRecordNotFound
+	GetBaseline(host model.Host, metricType string, lookback time.Duration) (*model.HostBaseline, error)
+}
+
+// baselineLearner stores learned baselines in metric_baselines and relearns them periodically
+type baselineLearner struct {
+	series       seriesLoader
+	baselineRepo repository.BaselineRepository
+}
+
+// NewBaselineLearner creates a new baseline learner
+func NewBaselineLearner(db *gorm.DB) BaselineLearner {
+	return &baselineLearner{
+		series:       newSeriesLoader(db),
+		baselineRepo: repository.NewBaselineRepository(db),
+	}
+}
+
+// LearnBaseline returns the stored baseline of the lookback window, relearning it from
+// the samples of that window when it is missing or older than baselineRefreshInterval
+func (l *baselineLearner) LearnBaseline(host model.Host, metricType string, lookback time.Duration, now time.Time) (*model.HostBaseline, error) {
+	lookbackSeconds := baselineLookbackSeconds(lookback)
+
+	baselines, err := l.baselineRepo.GetBaselines(host.Hostname, metricType, lookbackSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(baselines) == 0 || now.Sub(baselines[0].UpdatedAt) >= baselineRefreshInterval {
+		lookback = time.Duration(lookbackSeconds) * time.Second
+		series, order, err := l.series.load(metricType, host.Hostname, now.Add(-lookback), now)
+		if err != nil {
+			return nil, err
+		}
+		baselines = learnBaselines(host.Hostname, metricType, series, order, lookbackSeconds)
+		if err := l.baselineRepo.ReplaceBaselines(host.Hostname, metricType, lookbackSeconds, baselines); err != nil {
+			return nil, err
+		}
+	}
+
+	learnedAt := now
+	if len(baselines) > 0 {
+		learnedAt = baselines[0].UpdatedAt
+	}
+	return newHostBaseline(host, metricType, lookbackSeconds, learnedAt, baselines), nil
+}
+
+// GetBaseline returns the baseline learned for the lookback window. Reading never
+// relearns or stores anything; baselines are learned while evaluating anomaly rules.
+func (l *baselineLearner) GetBaseline(host model.Host, metricType string, lookback time.Duration) (*model.HostBaseline, error) {
+	lookbackSeconds := baselineLookbackSeconds(lookback)
+
+	baselines, err := l.baselineRepo.GetBaselines(host.Hostname, metricType, lookbackSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if len(baselines) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return newHostBaseline(host, metricType, lookbackSeconds, baselines[0].UpdatedAt, baselines), nil
+}
+
+// baselineLookbackSeconds returns the lookback window in seconds, applying the default
+func baselineLookbackSeconds(lookback time.Duration) int {
+	if lookback <= 0 {
+		lookback = defaultBaselineLookback
+	}
+	return int(lookback.Seconds())
+}
+
+// newHostBaseline builds the baseline response of a host
+func newHostBaseline(host model.Host, metricType string, lookback int, learnedAt time.Time, baselines []model.MetricBaseline) *model.HostBaseline {
+	return &model.HostBaseline{
+		HostID:     host.ID,
+		Hostname:   host.Hostname,
+		MetricType: metricType,
+		Lookback:   lookback,
+		LearnedAt:  learnedAt,
+		Baselines:  baselines,
+	}
+}
+
+// hourOfWeek returns the hour-of-week bucket of a timestamp, with Sunday 00:00 as 0
+func hourOfWeek(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// learnBaselines computes the mean and standard deviation of every series per
+// hour-of-week bucket, plus an overall bucket covering all samples
+func learnBaselines(hostname, metricType string, series map[string][]MetricPoint, order []string, lookback int) []model.MetricBaseline {
+	var baselines []model.MetricBaseline
+	for _, labels := range order {
+		points := series[labels]
+		if len(points) == 0 {
+			continue
+		}
+
+		buckets := make(map[int][]float64)
+		all := make([]float64, 0, len(points))
+		for _, p := range points {
+			bucket := hourOfWeek(p.Timestamp)
+			buckets[bucket] = append(buckets[bucket], p.Value)
+			all = append(all, p.Value)
+		}
+
+		newBaseline := func(bucket int, values []float64) model.MetricBaseline {
+			mean, stdDev := meanStdDev(values)
+			return model.MetricBaseline{
+				Hostname:   hostname,
+				MetricType: metricType,
+				Labels:     labels,
+				Bucket:     bucket,
+				Mean:       mean,
+				StdDev:     stdDev,
+				Samples:    len(values),
+				Lookback:   lookback,
+			}
+		}
+
+		baselines = append(baselines, newBaseline(overallBucket, all))
+		for bucket := 0; bucket < 7*24; bucket++ {
+			if values, ok := buckets[bucket]; ok {
+				baselines = append(baselines, newBaseline(bucket, values))
+			}
+		}
+	}
+	return baselines
+}
+
+// meanStdDev returns the mean and population standard deviation of the values
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// baselineIndex indexes baselines by labels and bucket
+type baselineIndex map[string]map[int]model.MetricBaseline
+
+// indexBaselines builds a lookup index over baselines
+func indexBaselines(baselines []model.MetricBaseline) baselineIndex {
+	index := make(baselineIndex)
+	for _, b := range baselines {
+		if index[b.Labels] == nil {
+			index[b.Labels] = make(map[int]model.MetricBaseline)
+		}
+		index[b.Labels][b.Bucket] = b
+	}
+	return index
+}
+
+// lookup returns the baseline of the hour-of-week bucket of t, falling back to the
+// overall baseline when the bucket has too few samples
+func (idx baselineIndex) lookup(labels string, t time.Time) (model.MetricBaseline, bool) {
+	buckets, ok := idx[labels]
+	if !ok {
+		return model.MetricBaseline{}, false
+	}
+	if b, ok := buckets[hourOfWeek(t)]; ok && b.Samples >= minBucketSamples {
+		return b, true
+	}
+	b, ok := buckets[overallBucket]
+	if !ok || b.Samples < minBucketSamples {
+		return model.MetricBaseline{}, false
+	}
+	return b, true
+}
+
+// deviation returns how many standard deviations a value is away from the baseline mean.
+// The standard deviation is floored so that perfectly flat series do not alert on tiny changes.
+func deviation(value float64, baseline model.MetricBaseline) float64 {
+	stdDev := math.Max(baseline.StdDev, math.Max(math.Abs(baseline.Mean)*0.01, 0.1))
+	return math.Abs(value-baseline.Mean) / stdDev
+}
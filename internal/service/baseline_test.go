@@ -0,0 +1,172 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+)
+
+// windowSampleRepo serves stored samples of the requested time range
+type windowSampleRepo struct {
+	repository.SampleRepository
+	samples []model.MetricSample
+	loads   int
+}
+
+func (r *windowSampleRepo) GetSeries(hostname, metric string, start, end time.Time) ([]model.MetricSample, error) {
+	r.loads++
+	var samples []model.MetricSample
+	for _, sample := range r.samples {
+		if sample.Hostname == hostname && sample.Metric == metric && !sample.Timestamp.Before(start) && !sample.Timestamp.After(end) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples, nil
+}
+
+// memoryBaselineRepo keeps baselines in memory, stamping them with a fixed time like the database would
+type memoryBaselineRepo struct {
+	baselines map[string][]model.MetricBaseline
+	now       time.Time
+	writes    int
+}
+
+func (r *memoryBaselineRepo) GetBaselines(hostname, metricType string, lookback int) ([]model.MetricBaseline, error) {
+	return r.baselines[fmt.Sprintf("%s/%s/%d", hostname, metricType, lookback)], nil
+}
+
+func (r *memoryBaselineRepo) ReplaceBaselines(hostname, metricType string, lookback int, baselines []model.MetricBaseline) error {
+	r.writes++
+	for i := range baselines {
+		baselines[i].UpdatedAt = r.now
+	}
+	r.baselines[fmt.Sprintf("%s/%s/%d", hostname, metricType, lookback)] = baselines
+	return nil
+}
+
+func TestLearnBaselines(t *testing.T) {
+	monday := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	series := map[string][]MetricPoint{
+		"mountpoint=/": {
+			{monday, 10}, {monday.Add(10 * time.Minute), 20}, // 周一10点
+			{monday.Add(time.Hour), 60}, // 周一11点
+		},
+	}
+
+	baselines := learnBaselines("web-1", SampleDiskUsagePercent, series, []string{"mountpoint=/"}, 3600)
+	if len(baselines) != 3 {
+		t.Fatalf("got %d baselines, want overall and 2 buckets", len(baselines))
+	}
+	overall, tenOClock := baselines[0], baselines[1]
+	if overall.Bucket != overallBucket || overall.Samples != 3 || overall.Mean != 30 {
+		t.Errorf("overall = %+v, want mean 30 over 3 samples", overall)
+	}
+	if tenOClock.Bucket != 24+10 || tenOClock.Mean != 15 || tenOClock.StdDev != 5 || tenOClock.Lookback != 3600 {
+		t.Errorf("bucket = %+v, want Monday 10:00 with mean 15 and std dev 5", tenOClock)
+	}
+
+	// 分桶样本不足时退回到整体基线
+	index := indexBaselines([]model.MetricBaseline{
+		{Labels: "", Bucket: overallBucket, Mean: 50, Samples: minBucketSamples},
+		{Labels: "", Bucket: 24 + 10, Mean: 20, Samples: minBucketSamples},
+		{Labels: "", Bucket: 24 + 11, Mean: 90, Samples: minBucketSamples - 1},
+	})
+	tests := []struct {
+		at   time.Time
+		mean float64
+	}{
+		{monday, 20},
+		{monday.Add(time.Hour), 50},
+		{monday.Add(24 * time.Hour), 50},
+	}
+	for _, tt := range tests {
+		b, ok := index.lookup("", tt.at)
+		if !ok || b.Mean != tt.mean {
+			t.Errorf("lookup(%v) = %+v, %v, want mean %v", tt.at, b, ok, tt.mean)
+		}
+	}
+	if _, ok := index.lookup("mountpoint=/data", monday); ok {
+		t.Error("lookup of an unknown series should fail")
+	}
+}
+
+func TestBaselineLearnerWindow(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	host := model.Host{BaseModel: model.BaseModel{ID: 1}, Hostname: "web-1"}
+	samples := &windowSampleRepo{}
+	// 最近3天每小时一个样本，越早的值越大
+	for i := 0; i < 72; i++ {
+		samples.samples = append(samples.samples, model.MetricSample{
+			Hostname: "web-1", Metric: SampleMemoryUsagePercent,
+			Timestamp: now.Add(-time.Duration(i) * time.Hour), Value: float64(i),
+		})
+	}
+	repo := &memoryBaselineRepo{baselines: make(map[string][]model.MetricBaseline), now: now}
+	learner := &baselineLearner{series: seriesLoader{sampleRepo: samples}, baselineRepo: repo}
+
+	// 读取不会学习基线
+	if _, err := learner.GetBaseline(host, SampleMemoryUsagePercent, 24*time.Hour); err != gorm.ErrRecordNotFound {
+		t.Fatalf("GetBaseline before learning: err = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+	if samples.loads != 0 || repo.writes != 0 {
+		t.Fatalf("GetBaseline loaded %d series and wrote %d times, want none", samples.loads, repo.writes)
+	}
+
+	tests := []struct {
+		lookback time.Duration
+		samples  int
+	}{
+		{24 * time.Hour, 25}, // 窗口两端都包含
+		{48 * time.Hour, 49},
+		{0, 72}, // 默认窗口覆盖全部样本
+	}
+	for _, tt := range tests {
+		baseline, err := learner.LearnBaseline(host, SampleMemoryUsagePercent, tt.lookback, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := baseline.Baselines[0].Samples; got != tt.samples {
+			t.Errorf("lookback %v: learned from %d samples, want %d", tt.lookback, got, tt.samples)
+		}
+	}
+
+	// 不同学习窗口的基线互不覆盖
+	for _, tt := range tests {
+		baseline, err := learner.GetBaseline(host, SampleMemoryUsagePercent, tt.lookback)
+		if err != nil {
+			t.Fatalf("lookback %v: %v", tt.lookback, err)
+		}
+		if got := baseline.Baselines[0].Samples; got != tt.samples {
+			t.Errorf("lookback %v: stored baseline has %d samples, want %d", tt.lookback, got, tt.samples)
+		}
+		if !baseline.LearnedAt.Equal(now) {
+			t.Errorf("LearnedAt = %v, want %v", baseline.LearnedAt, now)
+		}
+	}
+	if samples.loads != 3 || repo.writes != 3 {
+		t.Errorf("loaded %d series and wrote %d times, want 3 each", samples.loads, repo.writes)
+	}
+
+	// 刷新间隔内复用已学习的基线，超过后重新学习
+	if _, err := learner.LearnBaseline(host, SampleMemoryUsagePercent, 24*time.Hour, now.Add(baselineRefreshInterval/2)); err != nil {
+		t.Fatal(err)
+	}
+	if repo.writes != 3 {
+		t.Errorf("fresh baseline was relearned")
+	}
+	later := now.Add(baselineRefreshInterval)
+	repo.now = later
+	baseline, err := learner.LearnBaseline(host, SampleMemoryUsagePercent, 24*time.Hour, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.writes != 4 || !baseline.LearnedAt.Equal(later) || baseline.Baselines[0].Samples != 24 {
+		t.Errorf("relearned baseline = %d samples learned at %v after %d writes, want 24 samples at %v after 4",
+			baseline.Baselines[0].Samples, baseline.LearnedAt, repo.writes, later)
+	}
+}
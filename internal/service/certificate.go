@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+// 证书探测持久化到 metric_samples 的指标名称，标签: check, type
+const (
+	SampleCertDaysRemaining = "cert_days_remaining" // 距证书过期的天数，已过期为负数
+	SampleCertChainValid    = "cert_chain_valid"    // 证书链校验通过为1，否则为0
+)
+
+// runCertificateCheck fetches the certificate of a check from a TLS endpoint or a PEM file
+// and validates its chain against the system roots
+func runCertificateCheck(ctx context.Context, check model.CertificateCheck) model.ProbeResult {
+	start := time.Now()
+	result := model.ProbeResult{CheckedAt: start}
+
+	var chain []*x509.Certificate
+	var serverName string
+	var err error
+	switch check.Source {
+	case model.CertSourceFile:
+		chain, err = readCertificateFile(check.FilePath)
+	default:
+		serverName = certificateServerName(check)
+		chain, err = fetchCertificateChain(ctx, check.Address, serverName)
+	}
+	result.LatencyMs = durationMs(time.Since(start))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Certificate = inspectCertificateChain(chain, serverName, nil, start)
+	result.Success = true
+	return result
+}
+
+// certificateServerName returns the SNI name of a TLS certificate check
+func certificateServerName(check model.CertificateCheck) string {
+	if check.ServerName != "" {
+		return check.ServerName
+	}
+	host, _, err := net.SplitHostPort(check.Address)
+	if err != nil {
+		return check.Address
+	}
+	return host
+}
+
+// fetchCertificateChain connects to a TLS endpoint and returns the presented certificate chain.
+// Verification is done separately so that invalid or expired certificates can still be inspected.
+func fetchCertificateChain(ctx context.Context, address, serverName string) ([]*x509.Certificate, error) {
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: true,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("tls connect failed: %v", err)
+	}
+	defer conn.Close()
+
+	chain := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("server presented no certificate")
+	}
+	return chain, nil
+}
+
+// readCertificateFile parses all certificates of a PEM file, the first one being the leaf
+func readCertificateFile(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %v", err)
+	}
+
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %v", err)
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificate found in %s", path)
+	}
+	return chain, nil
+}
+
+// inspectCertificateChain describes the leaf certificate and verifies the chain at the given
+// time against roots (the system roots when nil), checking the host name when serverName is not empty
+func inspectCertificateChain(chain []*x509.Certificate, serverName string, roots *x509.CertPool, now time.Time) *model.CertificateInfo {
+	leaf := chain[0]
+	sans := append([]string{}, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	info := &model.CertificateInfo{
+		Subject:       leaf.Subject.String(),
+		Issuer:        leaf.Issuer.String(),
+		SANs:          sans,
+		NotBefore:     leaf.NotBefore,
+		NotAfter:      leaf.NotAfter,
+		DaysRemaining: leaf.NotAfter.Sub(now).Hours() / 24,
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	info.ChainValid = err == nil
+	if err != nil {
+		info.ChainError = err.Error()
+	}
+	return info
+}
+
+// certificateSamples converts the certificate of a probe result to metric samples of the host
+func certificateSamples(hostname, checkName string, result model.ProbeResult) []model.MetricSample {
+	if result.Certificate == nil {
+		return nil
+	}
+	labels := model.FormatLabels(map[string]string{"check": checkName, "type": model.ProbeTypeCert})
+	chainValid := 0.0
+	if result.Certificate.ChainValid {
+		chainValid = 1
+	}
+	return []model.MetricSample{
+		{Hostname: hostname, Metric: SampleCertDaysRemaining, Labels: labels, Value: result.Certificate.DaysRemaining, Timestamp: result.CheckedAt},
+		{Hostname: hostname, Metric: SampleCertChainValid, Labels: labels, Value: chainValid, Timestamp: result.CheckedAt},
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+func TestCertificateCheckFetchesTLSChain(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result := runCertificateCheck(ctx, model.CertificateCheck{
+		Source:     model.CertSourceTLS,
+		Address:    strings.TrimPrefix(server.URL, "https://"),
+		ServerName: "example.com",
+	})
+	if !result.Success || result.Certificate == nil {
+		t.Fatalf("expected certificate, got %+v", result)
+	}
+	if !result.Certificate.NotAfter.Equal(server.Certificate().NotAfter) {
+		t.Errorf("expected not after %v, got %v", server.Certificate().NotAfter, result.Certificate.NotAfter)
+	}
+	// the test certificate is self signed and not trusted by the system roots
+	if result.Certificate.ChainValid || result.Certificate.ChainError == "" {
+		t.Errorf("expected untrusted chain, got %+v", result.Certificate)
+	}
+}
+
+func TestInspectCertificateChain(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	cert := server.Certificate()
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	chain := []*x509.Certificate{cert}
+
+	now := cert.NotAfter.Add(-10 * 24 * time.Hour)
+	info := inspectCertificateChain(chain, "example.com", roots, now)
+	if !info.ChainValid {
+		t.Errorf("expected valid chain, got %s", info.ChainError)
+	}
+	if info.DaysRemaining != 10 {
+		t.Errorf("expected 10 days remaining, got %v", info.DaysRemaining)
+	}
+	if len(info.SANs) == 0 || info.SANs[0] != "example.com" {
+		t.Errorf("unexpected SANs %v", info.SANs)
+	}
+
+	if info := inspectCertificateChain(chain, "other.test", roots, now); info.ChainValid {
+		t.Error("expected host name mismatch to invalidate the chain")
+	}
+	if info := inspectCertificateChain(chain, "example.com", roots, cert.NotAfter.Add(time.Hour)); info.ChainValid || info.DaysRemaining >= 0 {
+		t.Errorf("expected expired certificate, got %+v", info)
+	}
+}
+
+func TestReadCertificateFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("ignored")})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})...)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := readCertificateFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 1 || !chain[0].Equal(server.Certificate()) {
+		t.Errorf("unexpected chain %v", chain)
+	}
+
+	empty := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCertificateFile(empty); err == nil {
+		t.Error("expected error for file without certificates")
+	}
+}
@@ -0,0 +1,425 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// collectorSettingsRefresh is how long the collector host configs of the local host are cached
+const collectorSettingsRefresh = 30 * time.Second
+
+// Collector collects one kind of data of the local machine, such as a metric or the results
+// of the probes, process watches, systemd units, log watches or Nagios checks
+type Collector interface {
+	// Name identifies the collector in configuration and status
+	Name() string
+	// DefaultInterval is the time between collections unless configured otherwise
+	DefaultInterval() time.Duration
+	// Collect returns samples to persist. Hostname and timestamp are filled in when empty.
+	// Collectors that only keep in-memory history return no samples.
+	Collect(ctx context.Context) ([]model.MetricSample, error)
+}
+
+// CollectorRegistry holds collectors by name
+type CollectorRegistry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+// NewCollectorRegistry creates an empty collector registry
+func NewCollectorRegistry() *CollectorRegistry {
+	return &CollectorRegistry{collectors: make(map[string]Collector)}
+}
+
+// Register adds a collector, names must be unique
+func (r *CollectorRegistry) Register(collector Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := collector.Name()
+	if name == "" {
+		return fmt.Errorf("collector name is required")
+	}
+	if _, exists := r.collectors[name]; exists {
+		return fmt.Errorf("collector %s is already registered", name)
+	}
+	if collector.DefaultInterval() <= 0 {
+		return fmt.Errorf("collector %s must have a positive default interval", name)
+	}
+	r.collectors[name] = collector
+	return nil
+}
+
+// Get returns a collector by name
+func (r *CollectorRegistry) Get(name string) (Collector, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	collector, ok := r.collectors[name]
+	return collector, ok
+}
+
+// All returns the registered collectors ordered by name
+func (r *CollectorRegistry) All() []Collector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	collectors := make([]Collector, 0, len(r.collectors))
+	for _, collector := range r.collectors {
+		collectors = append(collectors, collector)
+	}
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].Name() < collectors[j].Name() })
+	return collectors
+}
+
+// ParseCollectorConfig parses and validates the JSON value of a collector host config
+func ParseCollectorConfig(value string) (*model.CollectorSettings, error) {
+	var settings model.CollectorSettings
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&settings); err != nil {
+		return nil, fmt.Errorf("invalid collector config: %w", err)
+	}
+	if settings.Interval < 0 {
+		return nil, fmt.Errorf("collector interval must not be negative")
+	}
+	if settings.Timeout < 0 {
+		return nil, fmt.Errorf("collector timeout must not be negative")
+	}
+	return &settings, nil
+}
+
+// CollectorScheduler runs every registered collector on its own interval and stores the samples
+type CollectorScheduler interface {
+	Start()
+	Stop()
+	RunOnce(now time.Time)
+	Status() []model.CollectorStatus
+}
+
+// collectorState tracks the runs of one collector
+type collectorState struct {
+	running      bool
+	lastRunAt    *time.Time
+	lastDuration time.Duration
+	lastSamples  int
+	lastError    string
+	lastErrorAt  *time.Time
+	runs         int64
+	failures     int64
+}
+
+// collectorSchedule is the effective schedule of a collector
+type collectorSchedule struct {
+	enabled  bool
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// collectorScheduler implements CollectorScheduler interface
+type collectorScheduler struct {
+	registry     *CollectorRegistry
+	sampleRepo   repository.SampleRepository
+	hostRepo     repository.HostRepository
+	configRepo   repository.HostConfigRepository
+	hostname     string
+	global       map[string]config.CollectorSettings
+	timeout      time.Duration
+	tickInterval time.Duration
+	logger       *logger.Logger
+	stop         chan struct{}
+	running      sync.WaitGroup // the background loop and the collections it started, waited for by Stop
+
+	mu                 sync.Mutex
+	states             map[string]*collectorState
+	hostSettings       map[string]model.CollectorSettings
+	hostSettingsLoaded time.Time
+}
+
+// NewCollectorScheduler creates a new collector scheduler for the local host
+func NewCollectorScheduler(db *gorm.DB, registry *CollectorRegistry, cfg config.CollectorConfig, hostname string, logger *logger.Logger) CollectorScheduler {
+	tickInterval := time.Duration(cfg.TickInterval) * time.Second
+	if tickInterval <= 0 {
+		tickInterval = time.Second
+	}
+
+	return &collectorScheduler{
+		registry:     registry,
+		sampleRepo:   repository.NewSampleRepository(db),
+		hostRepo:     repository.NewHostRepository(db),
+		configRepo:   repository.NewHostConfigRepository(db),
+		hostname:     hostname,
+		global:       cfg.Collectors,
+		timeout:      probeTimeout(cfg.Timeout),
+		tickInterval: tickInterval,
+		logger:       logger,
+		stop:         make(chan struct{}),
+		states:       make(map[string]*collectorState),
+	}
+}
+
+// Start starts running due collectors in background
+func (s *collectorScheduler) Start() {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		ticker := time.NewTicker(s.tickInterval)
+		defer ticker.Stop()
+
+		s.dispatch(time.Now())
+		for {
+			select {
+			case now := <-ticker.C:
+				s.dispatch(now)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops running collectors and waits for the running collections to finish
+func (s *collectorScheduler) Stop() {
+	close(s.stop)
+	s.running.Wait()
+}
+
+// RunOnce runs every enabled collector that is due and waits for them to finish
+func (s *collectorScheduler) RunOnce(now time.Time) {
+	s.dispatch(now).Wait()
+}
+
+// dispatch starts every enabled collector that is due and not still running.
+// Collectors run concurrently so that a slow collector does not delay the others.
+func (s *collectorScheduler) dispatch(now time.Time) *sync.WaitGroup {
+	s.refreshHostSettings(now)
+
+	var wg sync.WaitGroup
+	for _, collector := range s.registry.All() {
+		schedule := s.schedule(collector)
+		if !schedule.enabled {
+			continue
+		}
+
+		s.mu.Lock()
+		state := s.state(collector.Name())
+		due := !state.running && (state.lastRunAt == nil || now.Sub(*state.lastRunAt) >= schedule.interval)
+		if due {
+			runAt := now
+			state.running = true
+			state.lastRunAt = &runAt
+		}
+		s.mu.Unlock()
+		if !due {
+			continue
+		}
+
+		wg.Add(1)
+		s.running.Add(1)
+		go func(collector Collector, timeout time.Duration) {
+			defer s.running.Done()
+			defer wg.Done()
+			s.run(collector, timeout, now)
+		}(collector, schedule.timeout)
+	}
+	return &wg
+}
+
+// collectResult is the outcome of a single collection
+type collectResult struct {
+	samples []model.MetricSample
+	err     error
+}
+
+// run collects once within the timeout and stores the samples. A collector that ignores
+// the context deadline is reported as timed out and left behind: run returns so that it
+// does not hold up the scheduler or Stop, and the collector stays marked running, and is
+// not started again, until it returns.
+func (s *collectorScheduler) run(collector Collector, timeout time.Duration, now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan collectResult, 1)
+	go func() {
+		samples, err := collector.Collect(ctx)
+		done <- collectResult{samples: samples, err: err}
+	}()
+
+	var result collectResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		s.finish(collector.Name(), time.Since(start), 0, fmt.Errorf("collector timed out after %s", timeout), false)
+		go func() {
+			// 结果被丢弃，done 有缓冲，采集器返回时不会阻塞
+			<-done
+			s.logger.Warn("Collector returned after its timeout, results discarded", "collector", collector.Name(), "duration", time.Since(start), "timeout", timeout)
+			s.mu.Lock()
+			s.state(collector.Name()).running = false
+			s.mu.Unlock()
+		}()
+		return
+	}
+
+	err := result.err
+	if err == nil && len(result.samples) > 0 {
+		for i := range result.samples {
+			if result.samples[i].Hostname == "" {
+				result.samples[i].Hostname = s.hostname
+			}
+			if result.samples[i].Timestamp.IsZero() {
+				result.samples[i].Timestamp = now
+			}
+		}
+		if storeErr := s.sampleRepo.CreateBatch(result.samples); storeErr != nil {
+			err = fmt.Errorf("failed to store samples: %w", storeErr)
+		}
+	}
+	s.finish(collector.Name(), time.Since(start), len(result.samples), err, true)
+}
+
+// finish records the outcome of a collection
+func (s *collectorScheduler) finish(name string, duration time.Duration, samples int, err error, done bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.state(name)
+	state.running = !done
+	state.lastDuration = duration
+	state.lastSamples = samples
+	state.runs++
+	if err != nil {
+		s.logger.Warn("Collector failed", "collector", name, "error", err)
+		failedAt := time.Now()
+		state.lastError = err.Error()
+		state.lastErrorAt = &failedAt
+		state.failures++
+	} else {
+		state.lastError = ""
+	}
+}
+
+// state returns the state of a collector, the caller must hold the lock
+func (s *collectorScheduler) state(name string) *collectorState {
+	state, ok := s.states[name]
+	if !ok {
+		state = &collectorState{}
+		s.states[name] = state
+	}
+	return state
+}
+
+// schedule resolves the collector defaults overridden by the global and then the host settings
+func (s *collectorScheduler) schedule(collector Collector) collectorSchedule {
+	schedule := collectorSchedule{enabled: true, interval: collector.DefaultInterval(), timeout: s.timeout}
+
+	if global, ok := s.global[collector.Name()]; ok {
+		if global.Enabled != nil {
+			schedule.enabled = *global.Enabled
+		}
+		if global.Interval > 0 {
+			schedule.interval = time.Duration(global.Interval) * time.Second
+		}
+		if global.Timeout > 0 {
+			schedule.timeout = time.Duration(global.Timeout) * time.Second
+		}
+	}
+
+	s.mu.Lock()
+	host, ok := s.hostSettings[collector.Name()]
+	s.mu.Unlock()
+	if ok {
+		if host.Enabled != nil {
+			schedule.enabled = *host.Enabled
+		}
+		if host.Interval > 0 {
+			schedule.interval = time.Duration(host.Interval) * time.Second
+		}
+		if host.Timeout > 0 {
+			schedule.timeout = time.Duration(host.Timeout) * time.Second
+		}
+	}
+	return schedule
+}
+
+// refreshHostSettings reloads the collector host configs of the local host when they are outdated.
+// Lookup failures keep the previous settings, invalid configs are skipped.
+func (s *collectorScheduler) refreshHostSettings(now time.Time) {
+	s.mu.Lock()
+	fresh := !s.hostSettingsLoaded.IsZero() && now.Sub(s.hostSettingsLoaded) < collectorSettingsRefresh
+	if !fresh {
+		s.hostSettingsLoaded = now
+	}
+	s.mu.Unlock()
+	if fresh {
+		return
+	}
+
+	settings := make(map[string]model.CollectorSettings)
+	localHost, err := s.hostRepo.GetByHostname(s.hostname)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			s.logger.Warn("Failed to get local host for collector settings", "error", err)
+			return
+		}
+	} else {
+		configs, err := s.configRepo.GetByCategory(localHost.ID, model.HostConfigCategoryCollector)
+		if err != nil {
+			s.logger.Warn("Failed to get collector settings", "error", err)
+			return
+		}
+		for _, hostConfig := range configs {
+			hostSettings, err := ParseCollectorConfig(hostConfig.Value)
+			if err != nil {
+				s.logger.Warn("Invalid collector settings", "collector", hostConfig.Key, "error", err)
+				continue
+			}
+			settings[hostConfig.Key] = *hostSettings
+		}
+	}
+
+	s.mu.Lock()
+	s.hostSettings = settings
+	s.mu.Unlock()
+}
+
+// Status returns the schedule and last run of every registered collector
+func (s *collectorScheduler) Status() []model.CollectorStatus {
+	collectors := s.registry.All()
+	statuses := make([]model.CollectorStatus, 0, len(collectors))
+	for _, collector := range collectors {
+		schedule := s.schedule(collector)
+
+		s.mu.Lock()
+		state := *s.state(collector.Name())
+		s.mu.Unlock()
+
+		statuses = append(statuses, model.CollectorStatus{
+			Name:           collector.Name(),
+			Enabled:        schedule.enabled,
+			Interval:       schedule.interval.Seconds(),
+			Timeout:        schedule.timeout.Seconds(),
+			Running:        state.running,
+			LastRunAt:      state.lastRunAt,
+			LastDurationMs: durationMs(state.lastDuration),
+			LastSamples:    state.lastSamples,
+			LastError:      state.lastError,
+			LastErrorAt:    state.lastErrorAt,
+			Runs:           state.runs,
+			Failures:       state.failures,
+		})
+	}
+	return statuses
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// memorySampleRepo keeps stored samples in memory
+type memorySampleRepo struct {
+	repository.SampleRepository
+	mu      sync.Mutex
+	samples []model.MetricSample
+}
+
+func (r *memorySampleRepo) CreateBatch(samples []model.MetricSample) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples = append(r.samples, samples...)
+	return nil
+}
+
+// staticHostRepo knows a single local host
+type staticHostRepo struct {
+	repository.HostRepository
+	host *model.Host
+}
+
+func (r *staticHostRepo) GetByHostname(hostname string) (*model.Host, error) {
+	if r.host == nil || r.host.Hostname != hostname {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.host, nil
+}
+
+// staticHostConfigRepo returns fixed host configs
+type staticHostConfigRepo struct {
+	repository.HostConfigRepository
+	configs []model.HostConfig
+}
+
+func (r *staticHostConfigRepo) GetByCategory(hostID uint, category string) ([]model.HostConfig, error) {
+	var configs []model.HostConfig
+	for _, hostConfig := range r.configs {
+		if hostConfig.HostID == hostID && hostConfig.Category == category {
+			configs = append(configs, hostConfig)
+		}
+	}
+	return configs, nil
+}
+
+// countingCollector counts its runs and returns fixed samples or an error
+type countingCollector struct {
+	name     string
+	interval time.Duration
+	samples  []model.MetricSample
+	err      error
+	block    chan struct{} // when set, Collect waits for it regardless of the context
+
+	mu   sync.Mutex
+	runs int
+}
+
+func (c *countingCollector) Name() string                   { return c.name }
+func (c *countingCollector) DefaultInterval() time.Duration { return c.interval }
+
+func (c *countingCollector) Collect(ctx context.Context) ([]model.MetricSample, error) {
+	c.mu.Lock()
+	c.runs++
+	c.mu.Unlock()
+	if c.block != nil {
+		<-c.block
+	}
+	return c.samples, c.err
+}
+
+func (c *countingCollector) runCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runs
+}
+
+func newTestCollectorScheduler(t *testing.T, cfg config.CollectorConfig, hostConfigs []model.HostConfig, collectors ...Collector) (*collectorScheduler, *memorySampleRepo) {
+	t.Helper()
+	registry := NewCollectorRegistry()
+	for _, collector := range collectors {
+		if err := registry.Register(collector); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sampleRepo := &memorySampleRepo{}
+	scheduler := &collectorScheduler{
+		registry:     registry,
+		sampleRepo:   sampleRepo,
+		hostRepo:     &staticHostRepo{host: &model.Host{BaseModel: model.BaseModel{ID: 1}, Hostname: "web-1"}},
+		configRepo:   &staticHostConfigRepo{configs: hostConfigs},
+		hostname:     "web-1",
+		global:       cfg.Collectors,
+		timeout:      probeTimeout(cfg.Timeout),
+		tickInterval: time.Second,
+		logger:       logger.New(config.LogConfig{Level: "error", Format: "text"}),
+		stop:         make(chan struct{}),
+		states:       make(map[string]*collectorState),
+	}
+	return scheduler, sampleRepo
+}
+
+func TestCollectorRegistry(t *testing.T) {
+	registry := NewCollectorRegistry()
+	if err := registry.Register(&countingCollector{name: "b", interval: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(&countingCollector{name: "a", interval: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(&countingCollector{name: "a", interval: time.Second}); err == nil {
+		t.Error("expected error for duplicate collector")
+	}
+	if err := registry.Register(&countingCollector{name: "c"}); err == nil {
+		t.Error("expected error for collector without interval")
+	}
+
+	all := registry.All()
+	if len(all) != 2 || all[0].Name() != "a" || all[1].Name() != "b" {
+		t.Errorf("expected collectors a and b, got %d", len(all))
+	}
+	if _, ok := registry.Get("b"); !ok {
+		t.Error("expected collector b")
+	}
+}
+
+func TestCollectorSchedulerIntervals(t *testing.T) {
+	fast := &countingCollector{name: "fast", interval: 5 * time.Second, samples: []model.MetricSample{{Metric: "fast_metric", Value: 1}}}
+	slow := &countingCollector{name: "slow", interval: time.Minute}
+	scheduler, sampleRepo := newTestCollectorScheduler(t, config.CollectorConfig{Timeout: 5}, nil, fast, slow)
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i <= 12; i++ {
+		scheduler.RunOnce(start.Add(time.Duration(i) * 5 * time.Second))
+	}
+	if fast.runCount() != 13 {
+		t.Errorf("expected fast collector to run 13 times, got %d", fast.runCount())
+	}
+	if slow.runCount() != 2 {
+		t.Errorf("expected slow collector to run twice, got %d", slow.runCount())
+	}
+
+	if len(sampleRepo.samples) != 13 {
+		t.Fatalf("expected 13 samples, got %d", len(sampleRepo.samples))
+	}
+	first := sampleRepo.samples[0]
+	if first.Hostname != "web-1" || !first.Timestamp.Equal(start) || first.Metric != "fast_metric" {
+		t.Errorf("expected hostname and timestamp to be filled in, got %+v", first)
+	}
+}
+
+func TestCollectorSchedulerErrors(t *testing.T) {
+	failing := &countingCollector{name: "failing", interval: time.Second, err: errors.New("permission denied")}
+	scheduler, _ := newTestCollectorScheduler(t, config.CollectorConfig{}, nil, failing)
+
+	now := time.Now()
+	scheduler.RunOnce(now)
+	status := scheduler.Status()[0]
+	if status.LastError != "permission denied" || status.LastErrorAt == nil || status.Runs != 1 || status.Failures != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if status.LastRunAt == nil || !status.LastRunAt.Equal(now) || status.Running {
+		t.Errorf("unexpected last run %+v", status)
+	}
+
+	// a later success clears the error but keeps the failure count
+	failing.err = nil
+	scheduler.RunOnce(now.Add(time.Second))
+	status = scheduler.Status()[0]
+	if status.LastError != "" || status.Runs != 2 || status.Failures != 1 {
+		t.Errorf("unexpected status after recovery %+v", status)
+	}
+}
+
+func TestCollectorSchedulerTimeout(t *testing.T) {
+	stuck := &countingCollector{name: "stuck", interval: time.Second, block: make(chan struct{})}
+	scheduler, _ := newTestCollectorScheduler(t, config.CollectorConfig{}, nil, stuck)
+	scheduler.timeout = 50 * time.Millisecond
+
+	now := time.Now()
+	wg := scheduler.dispatch(now)
+	deadline := time.Now().Add(2 * time.Second)
+	for scheduler.Status()[0].LastError == "" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := scheduler.Status()[0]
+	if status.LastError != "collector timed out after 50ms" || !status.Running {
+		t.Fatalf("expected timed out running collector, got %+v", status)
+	}
+
+	// a collector still running after its timeout is not started again
+	scheduler.dispatch(now.Add(time.Minute))
+	if stuck.runCount() != 1 {
+		t.Errorf("expected no second run while stuck, got %d runs", stuck.runCount())
+	}
+
+	// the round and Stop do not wait for a collector ignoring its timeout
+	returned := make(chan struct{})
+	go func() {
+		wg.Wait()
+		scheduler.Stop()
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the round and Stop to return after the timeout")
+	}
+
+	close(stuck.block)
+	deadline = time.Now().Add(2 * time.Second)
+	for scheduler.Status()[0].Running && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if scheduler.Status()[0].Running {
+		t.Error("expected collector to stop running after it returned")
+	}
+}
+
+func TestCollectorSchedulerStopWaitsForCollections(t *testing.T) {
+	slow := &countingCollector{name: "slow", interval: time.Minute, block: make(chan struct{}),
+		samples: []model.MetricSample{{Metric: "slow_metric", Value: 1}}}
+	scheduler, sampleRepo := newTestCollectorScheduler(t, config.CollectorConfig{}, nil, slow)
+	scheduler.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for slow.runCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("expected Stop to wait for the running collection")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(slow.block)
+	<-stopped
+	sampleRepo.mu.Lock()
+	defer sampleRepo.mu.Unlock()
+	if len(sampleRepo.samples) != 1 {
+		t.Errorf("expected the samples stored before Stop returned, got %d", len(sampleRepo.samples))
+	}
+}
+
+func TestCollectorSchedulerSettings(t *testing.T) {
+	disabled := false
+	collectors := []Collector{
+		&countingCollector{name: "cpu_history", interval: 5 * time.Second},
+		&countingCollector{name: "sensors", interval: time.Minute},
+		&countingCollector{name: "disk", interval: time.Minute},
+	}
+	cfg := config.CollectorConfig{
+		Timeout: 20,
+		Collectors: map[string]config.CollectorSettings{
+			"sensors": {Enabled: &disabled},
+			"disk":    {Interval: 300, Timeout: 60},
+		},
+	}
+	hostConfigs := []model.HostConfig{
+		{HostID: 1, Category: model.HostConfigCategoryCollector, Key: "sensors", Value: `{"enabled": true, "interval": 120}`},
+		{HostID: 1, Category: model.HostConfigCategoryCollector, Key: "cpu_history", Value: `{"enabled": false}`},
+		{HostID: 1, Category: model.HostConfigCategoryCollector, Key: "disk", Value: `{"interval": "fast"}`},
+		{HostID: 2, Category: model.HostConfigCategoryCollector, Key: "disk", Value: `{"enabled": false}`},
+	}
+	scheduler, _ := newTestCollectorScheduler(t, cfg, hostConfigs, collectors...)
+	scheduler.RunOnce(time.Now())
+
+	expected := map[string]model.CollectorStatus{
+		"cpu_history": {Enabled: false, Interval: 5, Timeout: 20},
+		"disk":        {Enabled: true, Interval: 300, Timeout: 60},
+		"sensors":     {Enabled: true, Interval: 120, Timeout: 20},
+	}
+	for _, status := range scheduler.Status() {
+		want := expected[status.Name]
+		if status.Enabled != want.Enabled || status.Interval != want.Interval || status.Timeout != want.Timeout {
+			t.Errorf("unexpected %s status %+v", status.Name, status)
+		}
+		if ran := status.Runs > 0; ran != want.Enabled {
+			t.Errorf("expected %s to run only when enabled, got %d runs", status.Name, status.Runs)
+		}
+	}
+}
+
+func TestRoundCollector(t *testing.T) {
+	var mu sync.Mutex
+	var rounds []string
+	round := func(name string, err error) Collector {
+		return roundCollector(name, time.Minute, func(now time.Time) error {
+			mu.Lock()
+			defer mu.Unlock()
+			rounds = append(rounds, name)
+			return err
+		})
+	}
+	hostConfigs := []model.HostConfig{
+		{HostID: 1, Category: model.HostConfigCategoryCollector, Key: "log_watches", Value: `{"enabled": false}`},
+	}
+	scheduler, sampleRepo := newTestCollectorScheduler(t, config.CollectorConfig{Timeout: 5}, hostConfigs,
+		round("probes", nil), round("log_watches", nil), round("systemd_units", errors.New("systemctl failed")))
+	scheduler.RunOnce(time.Now())
+
+	if len(rounds) != 2 || len(sampleRepo.samples) != 0 {
+		t.Errorf("expected the enabled rounds to run without samples, got %v and %d samples", rounds, len(sampleRepo.samples))
+	}
+	for _, status := range scheduler.Status() {
+		if status.Name == "systemd_units" && status.LastError != "systemctl failed" {
+			t.Errorf("expected the round error in the status, got %+v", status)
+		}
+	}
+}
+
+func TestParseCollectorConfig(t *testing.T) {
+	settings, err := ParseCollectorConfig(`{"enabled": false, "interval": 30, "timeout": 10}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Enabled == nil || *settings.Enabled || settings.Interval != 30 || settings.Timeout != 10 {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+
+	for _, value := range []string{`{"interval": -1}`, `{"timeout": -5}`, `{"intervall": 30}`, `[]`} {
+		if _, err := ParseCollectorConfig(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
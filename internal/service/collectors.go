@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+// 内置采集器的默认采集间隔
+const (
+	historyCollectInterval = 5 * time.Second // 仪表盘使用的内存历史
+	sampleCollectInterval  = time.Minute     // 持久化的指标，未配置 record_interval 时使用
+)
+
+// funcCollector adapts a collection function to the Collector interface
+type funcCollector struct {
+	name     string
+	interval time.Duration
+	collect  func(ctx context.Context) ([]model.MetricSample, error)
+}
+
+// NewCollector creates a collector from a collection function
+func NewCollector(name string, interval time.Duration, collect func(ctx context.Context) ([]model.MetricSample, error)) Collector {
+	return &funcCollector{name: name, interval: interval, collect: collect}
+}
+
+func (c *funcCollector) Name() string                   { return c.name }
+func (c *funcCollector) DefaultInterval() time.Duration { return c.interval }
+
+func (c *funcCollector) Collect(ctx context.Context) ([]model.MetricSample, error) {
+	return c.collect(ctx)
+}
+
+// historyCollector wraps a history update that produces no samples
+func historyCollector(name string, collect func(ctx context.Context) error) Collector {
+	return NewCollector(name, historyCollectInterval, func(ctx context.Context) ([]model.MetricSample, error) {
+		return nil, collect(ctx)
+	})
+}
+
+// roundCollector wraps a round of checks or watches that stores its own results and
+// returns no samples
+func roundCollector(name string, interval time.Duration, run func(now time.Time) error) Collector {
+	return NewCollector(name, interval, func(ctx context.Context) ([]model.MetricSample, error) {
+		return nil, run(time.Now())
+	})
+}
+
+// Collectors returns the built-in collectors of the local machine: the in-memory CPU, memory
+// and network history and the memory, disk, socket and sensor samples used by alert rules.
+// The sample collectors run every sampleInterval by default, normally the record interval.
+func (s *monitorService) Collectors(sampleInterval time.Duration) []Collector {
+	if sampleInterval <= 0 {
+		sampleInterval = sampleCollectInterval
+	}
+	return []Collector{
+		historyCollector("cpu_history", s.collectCPUHistory),
+		historyCollector("memory_history", s.collectMemoryHistory),
+		historyCollector("network_history", s.collectNetworkHistory),
+		NewCollector("memory", sampleInterval, s.collectMemorySamples),
+		NewCollector("disk", sampleInterval, s.collectDiskSamples),
+		NewCollector("connections", sampleInterval, s.collectConnectionSamples),
+		NewCollector("sensors", sampleInterval, s.collectSensorSamples),
+	}
+}
+
+// collectMemorySamples returns the memory usage sample
+func (s *monitorService) collectMemorySamples(ctx context.Context) ([]model.MetricSample, error) {
+	memoryData, err := s.GetMemoryData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []model.MetricSample{{Metric: SampleMemoryUsagePercent, Value: memoryData.UsagePercent}}, nil
+}
+
+// collectDiskSamples returns the usage, inode and read-only samples of every collected mount
+// and the system-wide file handle samples
+func (s *monitorService) collectDiskSamples(ctx context.Context) ([]model.MetricSample, error) {
+	diskData, err := s.GetDiskData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.source.Now()
+	var samples []model.MetricSample
+	for _, disk := range diskData.Disks {
+		samples = append(samples, model.MetricSample{
+			Metric:    SampleDiskUsagePercent,
+			Labels:    model.FormatLabels(map[string]string{"mountpoint": disk.MountPoint}),
+			Value:     disk.UsagePercent,
+			Timestamp: now,
+		})
+	}
+	return append(samples, filesystemSamples("", diskData, now)...), nil
+}
+
+// collectConnectionSamples returns the socket count of every TCP state and the UDP socket count
+func (s *monitorService) collectConnectionSamples(ctx context.Context) ([]model.MetricSample, error) {
+	connectionData, err := s.GetConnectionData(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]model.MetricSample, 0, len(TCPStates)+1)
+	for _, state := range TCPStates {
+		samples = append(samples, model.MetricSample{Metric: TCPStateSample(state), Value: float64(connectionData.TCPStates[state])})
+	}
+	return append(samples, model.MetricSample{Metric: SampleUDPSockets, Value: float64(connectionData.UDPSockets)}), nil
+}
+
+// collectSensorSamples returns the readings and limit flags of every hardware sensor
+func (s *monitorService) collectSensorSamples(ctx context.Context) ([]model.MetricSample, error) {
+	sensorData, err := s.GetSensorData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sensorSamples("", sensorData, sensorData.Timestamp), nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// CommandRunner runs external commands, so that collectors built on command output can be
+// tested with a fake
+type CommandRunner interface {
+	// Run runs a command and returns its standard output, which is also returned when the
+	// command exits with a non-zero status
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ExecOptions restricts the commands run by an exec runner
+type ExecOptions struct {
+	Env       []string // environment of the commands, the server environment when nil
+	Dir       string   // working directory, the server working directory when empty
+	MaxOutput int      // bytes of standard output kept, unlimited when 0
+}
+
+// maxCommandStderr bounds the standard error kept for error messages
+const maxCommandStderr = 4096
+
+type execRunner struct {
+	opts ExecOptions
+}
+
+// NewExecRunner creates a command runner executing commands on the local machine
+func NewExecRunner() CommandRunner {
+	return execRunner{}
+}
+
+// NewExecRunnerWithOptions creates a command runner executing commands on the local machine
+// with a restricted environment and output size
+func NewExecRunnerWithOptions(opts ExecOptions) CommandRunner {
+	return execRunner{opts: opts}
+}
+
+// Run runs the command in its own process group, which is killed as a whole when the
+// context is done so that children of the command cannot outlive it
+func (r execRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	stdout := &limitedBuffer{max: r.opts.MaxOutput}
+	stderr := &limitedBuffer{max: maxCommandStderr}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = r.opts.Env
+	cmd.Dir = r.opts.Dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.Bytes(), fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return stdout.Bytes(), fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer keeps up to max bytes written to it and silently discards the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.max > 0 {
+		if remaining := b.max - b.Len(); remaining < len(p) {
+			if remaining > 0 {
+				b.Buffer.Write(p[:remaining])
+			}
+			return len(p), nil
+		}
+	}
+	return b.Buffer.Write(p)
+}
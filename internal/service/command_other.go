@@ -0,0 +1,13 @@
+//go:build !unix
+
+package service
+
+import "os/exec"
+
+// setProcessGroup is a no-op where process groups are not available
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command itself where process groups are not available
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
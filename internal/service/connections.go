@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v3/net"
+
+	"monitor-server/internal/model"
+)
+
+// TCPStates lists the TCP socket states reported by the kernel
+var TCPStates = []string{
+	"ESTABLISHED", "SYN_SENT", "SYN_RECV", "FIN_WAIT1", "FIN_WAIT2", "TIME_WAIT",
+	"CLOSE", "CLOSE_WAIT", "LAST_ACK", "LISTEN", "CLOSING",
+}
+
+// 连接状态持久化到 metric_samples 的指标名称，无标签。每个 TCP 状态单独一个指标，以便分别设置告警规则
+const (
+	SampleTCPStatePrefix = "tcp_"        // 加小写状态名，如 tcp_close_wait
+	SampleUDPSockets     = "udp_sockets" // UDP 套接字数
+)
+
+// TCPStateSample returns the sample metric name of a TCP state, such as tcp_close_wait
+func TCPStateSample(state string) string {
+	return SampleTCPStatePrefix + strings.ToLower(state)
+}
+
+// GetConnectionData retrieves socket states, listening ports and the remote addresses with
+// the most connections, all of them when top is not positive
+func (s *monitorService) GetConnectionData(ctx context.Context, top int) (*model.ConnectionData, error) {
+	conns, err := s.source.Connections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connections: %w", err)
+	}
+
+	names := make(map[int32]string)
+	processName := func(pid int32) string {
+		if name, ok := names[pid]; ok {
+			return name
+		}
+		name := ""
+		if proc, err := s.source.Process(ctx, pid); err == nil {
+			name, _ = proc.Name(ctx)
+		}
+		names[pid] = name
+		return name
+	}
+
+	return summarizeConnections(conns, processName, top), nil
+}
+
+// summarizeConnections counts sockets by state and remote address and lists the listening
+// sockets. UDP sockets without a remote address are reported as listening.
+func summarizeConnections(conns []net.ConnectionStat, processName func(pid int32) string, top int) *model.ConnectionData {
+	data := &model.ConnectionData{
+		Total:           len(conns),
+		TCPStates:       make(map[string]int, len(TCPStates)),
+		Listening:       []model.ListeningPort{},
+		RemoteAddresses: []model.RemoteAddressCount{},
+	}
+	for _, state := range TCPStates {
+		data.TCPStates[state] = 0
+	}
+
+	type listenKey struct {
+		protocol, address string
+		port              uint32
+		pid               int32
+	}
+	listening := make(map[listenKey]bool)
+	remotes := make(map[string]*model.RemoteAddressCount)
+
+	for _, conn := range conns {
+		tcp := conn.Type == syscall.SOCK_STREAM
+		if tcp {
+			data.TCPStates[conn.Status]++
+		} else {
+			data.UDPSockets++
+		}
+
+		if conn.Status == "LISTEN" || (!tcp && conn.Raddr.IP == "") {
+			key := listenKey{connectionProtocol(conn.Family, conn.Type), conn.Laddr.IP, conn.Laddr.Port, conn.Pid}
+			if listening[key] {
+				continue
+			}
+			listening[key] = true
+			port := model.ListeningPort{Protocol: key.protocol, Address: key.address, Port: key.port, PID: conn.Pid}
+			if conn.Pid > 0 {
+				port.Process = processName(conn.Pid)
+			}
+			data.Listening = append(data.Listening, port)
+			continue
+		}
+
+		if !tcp || conn.Raddr.IP == "" {
+			continue
+		}
+		remote, ok := remotes[conn.Raddr.IP]
+		if !ok {
+			remote = &model.RemoteAddressCount{Address: conn.Raddr.IP, States: make(map[string]int)}
+			remotes[conn.Raddr.IP] = remote
+		}
+		remote.Count++
+		remote.States[conn.Status]++
+	}
+
+	sort.Slice(data.Listening, func(i, j int) bool {
+		a, b := data.Listening[i], data.Listening[j]
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.Address < b.Address
+	})
+
+	for _, remote := range remotes {
+		data.RemoteAddresses = append(data.RemoteAddresses, *remote)
+	}
+	sort.Slice(data.RemoteAddresses, func(i, j int) bool {
+		a, b := data.RemoteAddresses[i], data.RemoteAddresses[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Address < b.Address
+	})
+	if top > 0 && top < len(data.RemoteAddresses) {
+		data.RemoteAddresses = data.RemoteAddresses[:top]
+	}
+	return data
+}
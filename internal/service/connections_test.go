@@ -0,0 +1,47 @@
+package service
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+func TestSummarizeConnections(t *testing.T) {
+	tcp := func(status, laddr string, lport uint32, raddr string, pid int32) net.ConnectionStat {
+		return net.ConnectionStat{
+			Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Status: status, Pid: pid,
+			Laddr: net.Addr{IP: laddr, Port: lport}, Raddr: net.Addr{IP: raddr, Port: 40000},
+		}
+	}
+	conns := []net.ConnectionStat{
+		tcp("LISTEN", "0.0.0.0", 80, "", 100),
+		tcp("ESTABLISHED", "10.0.0.1", 80, "10.0.0.2", 100),
+		tcp("CLOSE_WAIT", "10.0.0.1", 80, "10.0.0.2", 100),
+		tcp("TIME_WAIT", "10.0.0.1", 80, "10.0.0.3", 0),
+		{Family: syscall.AF_INET6, Type: syscall.SOCK_DGRAM, Status: "NONE", Laddr: net.Addr{IP: "::", Port: 53}, Pid: 200},
+		{Family: syscall.AF_INET, Type: syscall.SOCK_DGRAM, Status: "NONE", Laddr: net.Addr{IP: "10.0.0.1", Port: 5000}, Raddr: net.Addr{IP: "10.0.0.9", Port: 53}},
+	}
+	names := map[int32]string{100: "nginx", 200: "dnsmasq"}
+
+	data := summarizeConnections(conns, func(pid int32) string { return names[pid] }, 1)
+	if data.Total != 6 || data.UDPSockets != 2 {
+		t.Errorf("unexpected totals %+v", data)
+	}
+	if data.TCPStates["ESTABLISHED"] != 1 || data.TCPStates["CLOSE_WAIT"] != 1 || data.TCPStates["LISTEN"] != 1 || data.TCPStates["SYN_SENT"] != 0 {
+		t.Errorf("unexpected tcp states %v", data.TCPStates)
+	}
+	if _, ok := data.TCPStates["LAST_ACK"]; !ok {
+		t.Error("expected every tcp state to be reported")
+	}
+
+	if len(data.Listening) != 2 || data.Listening[0].Port != 53 || data.Listening[0].Protocol != "udp6" || data.Listening[1].Process != "nginx" {
+		t.Errorf("unexpected listening ports %+v", data.Listening)
+	}
+	if len(data.RemoteAddresses) != 1 || data.RemoteAddresses[0].Address != "10.0.0.2" || data.RemoteAddresses[0].Count != 2 || data.RemoteAddresses[0].States["CLOSE_WAIT"] != 1 {
+		t.Errorf("unexpected remote addresses %+v", data.RemoteAddresses)
+	}
+	if TCPStateSample("CLOSE_WAIT") != "tcp_close_wait" {
+		t.Errorf("unexpected sample name %s", TCPStateSample("CLOSE_WAIT"))
+	}
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// escalationCheckInterval 检查待升级告警的间隔
+const escalationCheckInterval = 30 * time.Second
+
+// Clock provides the current time and can be replaced by a fake clock in tests
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock implements Clock using the system time
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewRealClock returns a clock backed by the system time
+func NewRealClock() Clock {
+	return realClock{}
+}
+
+// OnCallAt returns who is on call for a schedule at the given time and whether it comes
+// from an override. The most recently created override covering the time wins; otherwise
+// participants take turns of RotationHours starting at StartTime.
+func OnCallAt(schedule model.OnCallSchedule, t time.Time) (string, bool) {
+	var override *model.OnCallOverride
+	for i := range schedule.Overrides {
+		o := &schedule.Overrides[i]
+		if t.Before(o.StartTime) || !t.Before(o.EndTime) {
+			continue
+		}
+		if override == nil || o.CreatedAt.After(override.CreatedAt) || (o.CreatedAt.Equal(override.CreatedAt) && o.ID > override.ID) {
+			override = o
+		}
+	}
+	if override != nil {
+		return override.User, true
+	}
+
+	participants := scheduleParticipants(schedule)
+	if len(participants) == 0 || t.Before(schedule.StartTime) {
+		return "", false
+	}
+	rotation := time.Duration(schedule.RotationHours) * time.Hour
+	if rotation <= 0 {
+		rotation = 7 * 24 * time.Hour
+	}
+	turn := int(t.Sub(schedule.StartTime) / rotation)
+	return participants[turn%len(participants)], false
+}
+
+// scheduleParticipants splits the comma separated participants of a schedule
+func scheduleParticipants(schedule model.OnCallSchedule) []string {
+	var participants []string
+	for _, p := range strings.Split(schedule.Participants, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			participants = append(participants, p)
+		}
+	}
+	return participants
+}
+
+// selectEscalationPolicy picks the enabled policy that applies to an alert. A policy matching
+// both host group and severity wins over one matching only the host group, which wins over
+// one matching only the severity, which wins over a catch-all policy; ties go to the lowest ID.
+func selectEscalationPolicy(policies []model.EscalationPolicy, alert model.Alert, groupIDs []uint) *model.EscalationPolicy {
+	groups := make(map[uint]bool, len(groupIDs))
+	for _, id := range groupIDs {
+		groups[id] = true
+	}
+
+	var best *model.EscalationPolicy
+	bestScore := -1
+	for i := range policies {
+		policy := &policies[i]
+		if !policy.Enabled || len(policy.Steps) == 0 {
+			continue
+		}
+		score := 0
+		if policy.HostGroupID != nil {
+			if !groups[*policy.HostGroupID] {
+				continue
+			}
+			score += 2
+		}
+		if policy.Severity != "" {
+			if policy.Severity != alert.Severity {
+				continue
+			}
+			score++
+		}
+		if score > bestScore || (score == bestScore && policy.ID < best.ID) {
+			best = policy
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// escalationEngine advances the escalation state of alerts. It holds no storage so that
+// the timer logic can be driven by a fake clock.
+type escalationEngine struct {
+	clock    Clock
+	schedule func(id uint) (*model.OnCallSchedule, error)
+	send     func(step model.EscalationStep, notification model.EscalationNotification) error
+}
+
+// sortedSteps returns the steps of a policy ordered by position
+func sortedSteps(policy model.EscalationPolicy) []model.EscalationStep {
+	steps := make([]model.EscalationStep, len(policy.Steps))
+	copy(steps, policy.Steps)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Position < steps[j].Position
+	})
+	return steps
+}
+
+// escalationStart returns when the escalation of an alert starts: when it last started firing,
+// so that a reopened alert escalates from the reopen instead of its original start
+func escalationStart(alert model.Alert) time.Time {
+	if alert.LastStateChangeAt != nil && alert.LastStateChangeAt.After(alert.StartTime) {
+		return *alert.LastStateChangeAt
+	}
+	return alert.StartTime
+}
+
+// stepDueAt returns when a step becomes due: the escalation start plus the delays of all steps up to it
+func stepDueAt(alert model.Alert, steps []model.EscalationStep, index int) time.Time {
+	due := escalationStart(alert)
+	for i := 0; i <= index && i < len(steps); i++ {
+		due = due.Add(time.Duration(steps[i].DelayMinutes) * time.Minute)
+	}
+	return due
+}
+
+// advance sends every step of the policy that has become due for an unacknowledged active
+// alert and records the progress on the alert. It reports whether the alert was changed.
+func (e *escalationEngine) advance(alert *model.Alert, policy model.EscalationPolicy) (bool, error) {
+	if alert.Status != "active" || alert.AcknowledgedAt != nil || alert.Flapping {
+		return false, nil
+	}
+
+	now := e.clock.Now()
+	steps := sortedSteps(policy)
+	changed := false
+	for alert.EscalationStep < len(steps) {
+		index := alert.EscalationStep
+		if now.Before(stepDueAt(*alert, steps, index)) {
+			break
+		}
+
+		step := steps[index]
+		notification := model.EscalationNotification{
+			PolicyID:   policy.ID,
+			PolicyName: policy.Name,
+			Step:       index,
+			Alert:      *alert,
+			SentAt:     now,
+		}
+		if step.ScheduleID != nil && e.schedule != nil {
+			schedule, err := e.schedule(*step.ScheduleID)
+			if err != nil {
+				return changed, fmt.Errorf("failed to get on-call schedule %d: %w", *step.ScheduleID, err)
+			}
+			notification.OnCall, _ = OnCallAt(*schedule, now)
+		}
+		notification.Summary = escalationSummary(notification)
+
+		if err := e.send(step, notification); err != nil {
+			return changed, err
+		}
+
+		policyID := policy.ID
+		alert.EscalationPolicyID = &policyID
+		alert.EscalationStep = index + 1
+		alert.LastEscalatedAt = &now
+		changed = true
+	}
+	return changed, nil
+}
+
+// escalationSummary builds the text of an escalation notification
+func escalationSummary(n model.EscalationNotification) string {
+	target := ""
+	if n.OnCall != "" {
+		target = fmt.Sprintf("，通知值班人员 %s", n.OnCall)
+	}
+	return fmt.Sprintf("[升级 %s 第%d步] 告警 %d 未确认%s: %s", n.PolicyName, n.Step+1, n.Alert.ID, target, n.Alert.Message)
+}
+
+// EscalationManager periodically escalates unacknowledged alerts according to escalation policies
+type EscalationManager interface {
+	Start()
+	Stop()
+	EscalateOnce() error
+}
+
+// escalationManager implements EscalationManager interface
+type escalationManager struct {
+	alertRepo      repository.AlertRepository
+	hostRepo       repository.HostRepository
+	hostGroupRepo  repository.HostGroupRepository
+	escalationRepo repository.EscalationRepository
+	engine         *escalationEngine
+	client         *http.Client
+	logger         *logger.Logger
+	stop           chan struct{}
+	running        sync.WaitGroup // the background loop, waited for by Stop
+}
+
+// NewEscalationManager creates a new escalation manager instance
+func NewEscalationManager(db *gorm.DB, clock Clock, logger *logger.Logger) EscalationManager {
+	m := &escalationManager{
+		alertRepo:      repository.NewAlertRepository(db),
+		hostRepo:       repository.NewHostRepository(db),
+		hostGroupRepo:  repository.NewHostGroupRepository(db),
+		escalationRepo: repository.NewEscalationRepository(db),
+		client:         &http.Client{Timeout: 10 * time.Second},
+		logger:         logger,
+		stop:           make(chan struct{}),
+	}
+	m.engine = &escalationEngine{
+		clock:    clock,
+		schedule: m.escalationRepo.GetScheduleByID,
+		send:     m.send,
+	}
+	return m
+}
+
+// Start starts escalating alerts in background
+func (m *escalationManager) Start() {
+	m.running.Add(1)
+	go func() {
+		defer m.running.Done()
+		ticker := time.NewTicker(escalationCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.EscalateOnce(); err != nil {
+					m.logger.Error("Failed to escalate alerts", "error", err)
+				}
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops escalating alerts and waits for the running round to finish
+func (m *escalationManager) Stop() {
+	close(m.stop)
+	m.running.Wait()
+}
+
+// EscalateOnce advances the escalation of all active unacknowledged alerts once
+func (m *escalationManager) EscalateOnce() error {
+	policies, err := m.escalationRepo.GetEnabledPolicies()
+	if err != nil {
+		return fmt.Errorf("failed to get escalation policies: %w", err)
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	alerts, err := m.alertRepo.GetActiveAlerts()
+	if err != nil {
+		return fmt.Errorf("failed to get active alerts: %w", err)
+	}
+
+	hostGroups := make(map[string][]uint)
+	for i := range alerts {
+		alert := &alerts[i]
+		if alert.AcknowledgedAt != nil {
+			continue
+		}
+
+		groupIDs, ok := hostGroups[alert.Hostname]
+		if !ok {
+			groupIDs = m.hostGroupIDs(alert.Hostname)
+			hostGroups[alert.Hostname] = groupIDs
+		}
+
+		policy := selectEscalationPolicy(policies, *alert, groupIDs)
+		if policy == nil {
+			continue
+		}
+
+		changed, err := m.engine.advance(alert, *policy)
+		if err != nil {
+			m.logger.Warn("Failed to escalate alert", "alert_id", alert.ID, "policy", policy.Name, "error", err)
+		}
+		if changed {
+			if err := m.alertRepo.UpdateEscalationState(alert); err != nil {
+				m.logger.Warn("Failed to update alert", "alert_id", alert.ID, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// hostGroupIDs returns the IDs of the groups a host belongs to
+func (m *escalationManager) hostGroupIDs(hostname string) []uint {
+	host, err := m.hostRepo.GetByHostname(hostname)
+	if err != nil {
+		return nil
+	}
+	groups, err := m.hostGroupRepo.GetHostGroups(host.ID)
+	if err != nil {
+		return nil
+	}
+	ids := make([]uint, 0, len(groups))
+	for _, group := range groups {
+		ids = append(ids, group.ID)
+	}
+	return ids
+}
+
+// send delivers an escalation notification to the step receiver
+func (m *escalationManager) send(step model.EscalationStep, notification model.EscalationNotification) error {
+	switch step.Receiver {
+	case model.ReceiverWebhook:
+		return postWebhook(m.client, step.WebhookURL, notification)
+	default:
+		m.logger.Info("Alert escalation",
+			"policy", notification.PolicyName,
+			"step", notification.Step+1,
+			"alert_id", notification.Alert.ID,
+			"on_call", notification.OnCall,
+			"summary", notification.Summary,
+		)
+		return nil
+	}
+}
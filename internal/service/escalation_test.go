@@ -0,0 +1,259 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+// fakeClock is a manually advanced Clock
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// sentStep records a notification sent by the escalation engine
+type sentStep struct {
+	step   int
+	onCall string
+}
+
+func newTestEngine(clock Clock, schedules map[uint]model.OnCallSchedule) (*escalationEngine, *[]sentStep) {
+	var sent []sentStep
+	engine := &escalationEngine{
+		clock: clock,
+		schedule: func(id uint) (*model.OnCallSchedule, error) {
+			schedule := schedules[id]
+			return &schedule, nil
+		},
+		send: func(step model.EscalationStep, n model.EscalationNotification) error {
+			sent = append(sent, sentStep{step: n.Step, onCall: n.OnCall})
+			return nil
+		},
+	}
+	return engine, &sent
+}
+
+func testPolicy() model.EscalationPolicy {
+	scheduleID := uint(7)
+	policy := model.EscalationPolicy{
+		Name:    "critical",
+		Enabled: true,
+		Steps: []model.EscalationStep{
+			{Position: 2, DelayMinutes: 15, Receiver: model.ReceiverLog},
+			{Position: 0, DelayMinutes: 0, Receiver: model.ReceiverLog},
+			{Position: 1, DelayMinutes: 10, Receiver: model.ReceiverLog, ScheduleID: &scheduleID},
+		},
+	}
+	policy.ID = 1
+	return policy
+}
+
+func TestEscalationStepsFollowDelays(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	schedules := map[uint]model.OnCallSchedule{
+		7: {Participants: "alice,bob", RotationHours: 24, StartTime: start.Add(-time.Hour)},
+	}
+	engine, sent := newTestEngine(clock, schedules)
+	policy := testPolicy()
+	alert := &model.Alert{Status: "active", StartTime: start}
+
+	// 第一步立即发送
+	if changed, err := engine.advance(alert, policy); err != nil || !changed {
+		t.Fatalf("advance at start = %v, %v; want changed", changed, err)
+	}
+	if len(*sent) != 1 || (*sent)[0].step != 0 {
+		t.Fatalf("sent = %+v, want step 0 only", *sent)
+	}
+
+	// 第二步在10分钟后
+	clock.Advance(9 * time.Minute)
+	if changed, _ := engine.advance(alert, policy); changed {
+		t.Fatalf("step 1 sent before its delay: %+v", *sent)
+	}
+	clock.Advance(time.Minute)
+	if changed, _ := engine.advance(alert, policy); !changed {
+		t.Fatalf("step 1 not sent after its delay")
+	}
+	if got := (*sent)[1]; got.step != 1 || got.onCall != "alice" {
+		t.Fatalf("second notification = %+v, want step 1 to alice", got)
+	}
+
+	// 第三步在第二步之后15分钟
+	clock.Advance(14 * time.Minute)
+	if changed, _ := engine.advance(alert, policy); changed {
+		t.Fatalf("step 2 sent before its delay")
+	}
+	clock.Advance(time.Minute)
+	if changed, _ := engine.advance(alert, policy); !changed {
+		t.Fatalf("step 2 not sent after its delay")
+	}
+
+	// 所有步骤执行完毕后不再发送
+	clock.Advance(time.Hour)
+	if changed, _ := engine.advance(alert, policy); changed {
+		t.Fatalf("escalation continued past the last step")
+	}
+	if len(*sent) != 3 || alert.EscalationStep != 3 {
+		t.Fatalf("sent %d notifications, escalation step %d; want 3 and 3", len(*sent), alert.EscalationStep)
+	}
+	if alert.EscalationPolicyID == nil || *alert.EscalationPolicyID != policy.ID {
+		t.Fatalf("escalation policy not recorded on alert")
+	}
+}
+
+func TestEscalationCatchesUpOnOverdueSteps(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start.Add(30 * time.Minute)}
+	engine, sent := newTestEngine(clock, map[uint]model.OnCallSchedule{7: {}})
+	alert := &model.Alert{Status: "active", StartTime: start}
+
+	if _, err := engine.advance(alert, testPolicy()); err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	if len(*sent) != 3 {
+		t.Fatalf("sent %d notifications, want all 3 overdue steps", len(*sent))
+	}
+}
+
+func TestReopenedAlertEscalatesFromReopen(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	engine, sent := newTestEngine(clock, map[uint]model.OnCallSchedule{7: {}})
+	policy := testPolicy()
+	alert := &model.Alert{Status: "active", StartTime: start, LastStateChangeAt: &start}
+	engine.advance(alert, policy)
+
+	// 告警恢复后在两小时后重新打开，保留原开始时间，升级进度被清除
+	clock.Advance(2 * time.Hour)
+	reopenedAt := clock.Now()
+	alert.LastStateChangeAt = &reopenedAt
+	alert.EscalationStep = 0
+	alert.EscalationPolicyID = nil
+	alert.LastEscalatedAt = nil
+
+	if _, err := engine.advance(alert, policy); err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	if len(*sent) != 2 || alert.EscalationStep != 1 {
+		t.Fatalf("sent %d notifications, escalation step %d after reopen; want only the first step again", len(*sent), alert.EscalationStep)
+	}
+	clock.Advance(10 * time.Minute)
+	if changed, _ := engine.advance(alert, policy); !changed || alert.EscalationStep != 2 {
+		t.Fatalf("step 1 not sent 10 minutes after the reopen")
+	}
+}
+
+func TestAcknowledgementStopsEscalation(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	engine, sent := newTestEngine(clock, map[uint]model.OnCallSchedule{7: {}})
+	policy := testPolicy()
+	alert := &model.Alert{Status: "active", StartTime: start}
+
+	engine.advance(alert, policy)
+	clock.Advance(5 * time.Minute)
+	ackAt := clock.Now()
+	alert.AcknowledgedAt = &ackAt
+
+	clock.Advance(time.Hour)
+	if changed, _ := engine.advance(alert, policy); changed {
+		t.Fatalf("acknowledged alert escalated")
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("sent %d notifications, want 1", len(*sent))
+	}
+}
+
+func TestResolvedAndFlappingAlertsDoNotEscalate(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start.Add(time.Hour)}
+	engine, sent := newTestEngine(clock, map[uint]model.OnCallSchedule{7: {}})
+
+	engine.advance(&model.Alert{Status: "resolved", StartTime: start}, testPolicy())
+	engine.advance(&model.Alert{Status: "active", Flapping: true, StartTime: start}, testPolicy())
+	if len(*sent) != 0 {
+		t.Fatalf("sent %d notifications, want none", len(*sent))
+	}
+}
+
+func TestOnCallRotationAndOverrides(t *testing.T) {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	schedule := model.OnCallSchedule{
+		Participants:  "alice, bob,carol",
+		RotationHours: 24,
+		StartTime:     start,
+	}
+
+	cases := []struct {
+		at   time.Time
+		want string
+	}{
+		{start.Add(-time.Minute), ""},
+		{start, "alice"},
+		{start.Add(23 * time.Hour), "alice"},
+		{start.Add(24 * time.Hour), "bob"},
+		{start.Add(48 * time.Hour), "carol"},
+		{start.Add(72 * time.Hour), "alice"},
+	}
+	for _, tc := range cases {
+		if got, override := OnCallAt(schedule, tc.at); got != tc.want || override {
+			t.Errorf("OnCallAt(%s) = %q, %v; want %q from rotation", tc.at, got, override, tc.want)
+		}
+	}
+
+	older := model.OnCallOverride{User: "dave", StartTime: start.Add(24 * time.Hour), EndTime: start.Add(48 * time.Hour)}
+	older.CreatedAt = start
+	newer := model.OnCallOverride{User: "erin", StartTime: start.Add(30 * time.Hour), EndTime: start.Add(36 * time.Hour)}
+	newer.CreatedAt = start.Add(time.Hour)
+	schedule.Overrides = []model.OnCallOverride{older, newer}
+
+	if got, override := OnCallAt(schedule, start.Add(25*time.Hour)); got != "dave" || !override {
+		t.Errorf("OnCallAt during override = %q, %v; want dave", got, override)
+	}
+	if got, _ := OnCallAt(schedule, start.Add(31*time.Hour)); got != "erin" {
+		t.Errorf("OnCallAt during overlapping overrides = %q; want the newer override erin", got)
+	}
+	if got, override := OnCallAt(schedule, start.Add(48*time.Hour)); got != "carol" || override {
+		t.Errorf("OnCallAt at override end = %q, %v; want carol from rotation", got, override)
+	}
+}
+
+func TestSelectEscalationPolicyPrecedence(t *testing.T) {
+	groupID := uint(3)
+	policies := []model.EscalationPolicy{
+		{Name: "catch-all", Enabled: true, Steps: []model.EscalationStep{{}}},
+		{Name: "critical", Severity: "critical", Enabled: true, Steps: []model.EscalationStep{{}}},
+		{Name: "group", HostGroupID: &groupID, Enabled: true, Steps: []model.EscalationStep{{}}},
+		{Name: "group-critical", HostGroupID: &groupID, Severity: "critical", Enabled: true, Steps: []model.EscalationStep{{}}},
+	}
+	for i := range policies {
+		policies[i].ID = uint(i + 1)
+	}
+
+	cases := []struct {
+		severity string
+		groups   []uint
+		want     string
+	}{
+		{"warning", nil, "catch-all"},
+		{"critical", nil, "critical"},
+		{"warning", []uint{groupID}, "group"},
+		{"critical", []uint{groupID}, "group-critical"},
+	}
+	for _, tc := range cases {
+		policy := selectEscalationPolicy(policies, model.Alert{Severity: tc.severity}, tc.groups)
+		if policy == nil || policy.Name != tc.want {
+			t.Errorf("severity %s groups %v: got %v, want %s", tc.severity, tc.groups, policy, tc.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+// 文件系统健康状况持久化到 metric_samples 的指标名称
+const (
+	SampleDiskInodeUsagePercent = "disk_inode_usage_percent" // inode 使用率，标签: mountpoint
+	SampleDiskReadOnly          = "disk_read_only"           // fstab 中为读写挂载但当前只读为1，否则为0，标签: mountpoint
+	SampleFDUsagePercent        = "fd_usage_percent"         // 系统文件句柄使用率，无标签
+	SampleFDUsed                = "fd_used"                  // 使用中的系统文件句柄数，无标签
+)
+
+const (
+	defaultFileNrPath = "/proc/sys/fs/file-nr"
+	defaultFstabPath  = "/etc/fstab"
+)
+
+// FilesystemSample returns the sample metric of a filesystem history metric, empty if unknown
+func FilesystemSample(metric string) string {
+	switch metric {
+	case "inode":
+		return SampleDiskInodeUsagePercent
+	case "read_only":
+		return SampleDiskReadOnly
+	case "fd":
+		return SampleFDUsagePercent
+	}
+	return ""
+}
+
+// readFileNr reads the system-wide file handle usage. file-nr holds the number of
+// allocated handles, the number of allocated but unused handles and the maximum.
+func readFileNr(path string) (*model.FileDescriptorUsage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected file-nr format: %q", strings.TrimSpace(string(data)))
+	}
+	var values [3]uint64
+	for i, field := range fields {
+		values[i], err = strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected file-nr value %q: %w", field, err)
+		}
+	}
+
+	// 2.6 之后的内核不再回收空闲句柄，第二列恒为0
+	usage := &model.FileDescriptorUsage{Used: values[0] - min(values[1], values[0]), Max: values[2]}
+	if usage.Max > 0 {
+		usage.UsagePercent = float64(usage.Used) / float64(usage.Max) * 100
+	}
+	return usage, nil
+}
+
+// readFstabReadOnly reads which mount points fstab declares, mapped to whether they are mounted read-only
+func readFstabReadOnly(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	mounts := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] == "none" || fields[2] == "swap" {
+			continue
+		}
+		var opts []string
+		if len(fields) > 3 {
+			opts = strings.Split(fields[3], ",")
+		}
+		mounts[unescapeFstab(fields[1])] = isReadOnlyMount(opts)
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeFstab decodes the octal escapes fstab uses for blanks in paths, such as \040
+func unescapeFstab(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+4 <= len(value) {
+			if code, err := strconv.ParseUint(value[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
+// isReadOnlyMount reports whether mount options contain ro
+func isReadOnlyMount(opts []string) bool {
+	for _, opt := range opts {
+		if opt == "ro" {
+			return true
+		}
+	}
+	return false
+}
+
+// unexpectedReadOnly reports whether a read-only mount is declared read-write in fstab.
+// Mount points missing from fstab are not reported, they are usually mounted read-only on purpose.
+func unexpectedReadOnly(mountpoint string, readOnly bool, fstab map[string]bool) bool {
+	if !readOnly {
+		return false
+	}
+	fstabReadOnly, ok := fstab[mountpoint]
+	return ok && !fstabReadOnly
+}
+
+// filesystemSamples converts inode, read-only and file handle usage to metric samples
+func filesystemSamples(hostname string, data *model.DiskData, now time.Time) []model.MetricSample {
+	var samples []model.MetricSample
+	for _, disk := range data.Disks {
+		labels := model.FormatLabels(map[string]string{"mountpoint": disk.MountPoint})
+		// btrfs 等动态分配 inode 的文件系统不报告 inode 总数
+		if disk.InodesTotal > 0 {
+			samples = append(samples, model.MetricSample{Hostname: hostname, Metric: SampleDiskInodeUsagePercent, Labels: labels, Value: disk.InodesUsedPercent, Timestamp: now})
+		}
+		readOnly := 0.0
+		if disk.UnexpectedReadOnly {
+			readOnly = 1
+		}
+		samples = append(samples, model.MetricSample{Hostname: hostname, Metric: SampleDiskReadOnly, Labels: labels, Value: readOnly, Timestamp: now})
+	}
+	if fds := data.FileDescriptors; fds != nil {
+		samples = append(samples,
+			model.MetricSample{Hostname: hostname, Metric: SampleFDUsagePercent, Value: fds.UsagePercent, Timestamp: now},
+			model.MetricSample{Hostname: hostname, Metric: SampleFDUsed, Value: float64(fds.Used), Timestamp: now},
+		)
+	}
+	return samples
+}
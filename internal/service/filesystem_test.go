@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadFileNr(t *testing.T) {
+	usage, err := readFileNr(writeTestFile(t, "file-nr", "9728\t0\t197854\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Used != 9728 || usage.Max != 197854 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+	if usage.UsagePercent < 4.91 || usage.UsagePercent > 4.92 {
+		t.Errorf("expected usage percent about 4.92, got %f", usage.UsagePercent)
+	}
+
+	// older kernels report allocated but unused handles in the second column
+	usage, err = readFileNr(writeTestFile(t, "file-nr", "1000 200 2000\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Used != 800 || usage.UsagePercent != 40 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	for _, content := range []string{"", "1 2", "a 0 100"} {
+		if _, err := readFileNr(writeTestFile(t, "file-nr", content)); err == nil {
+			t.Errorf("expected error for file-nr %q", content)
+		}
+	}
+}
+
+func TestReadFstabReadOnly(t *testing.T) {
+	fstab := `# /etc/fstab: static file system information.
+UUID=0a3407de-014b-458b-b5c1-848e92a327a3 /               ext4    errors=remount-ro 0       1
+UUID=4bd2d3f1-3b4e-4f8c-a4a1-5f0c7d8b2e11 /data           xfs     defaults,noatime  0       2
+/dev/sr0                                  /media/cdrom    iso9660 ro,user,noauto    0       0
+/dev/sdc1                                 /mnt/My\040Disk ext4    rw                0       0
+/swapfile                                 none            swap    sw                0       0
+tmpfs                                     /tmp            tmpfs
+`
+	mounts, err := readFstabReadOnly(writeTestFile(t, "fstab", fstab))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{"/": false, "/data": false, "/media/cdrom": true, "/mnt/My Disk": false, "/tmp": false}
+	if len(mounts) != len(expected) {
+		t.Fatalf("expected mounts %v, got %v", expected, mounts)
+	}
+	for mountpoint, readOnly := range expected {
+		if got, ok := mounts[mountpoint]; !ok || got != readOnly {
+			t.Errorf("expected %s read-only %v, got %v (present %v)", mountpoint, readOnly, got, ok)
+		}
+	}
+
+	if _, err := readFstabReadOnly(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing fstab")
+	}
+}
+
+func TestSystemSourceFilesystemHealth(t *testing.T) {
+	ctx := context.Background()
+	source := gopsutilSource{
+		fileNrPath: filepath.Join("testdata", "proc", "sys", "fs", "file-nr"),
+		fstabPath:  filepath.Join("testdata", "etc", "fstab"),
+	}
+	if fds, err := source.FileHandles(ctx); err != nil || fds.Used != 9600 || fds.Max != 100000 {
+		t.Errorf("unexpected file handles %+v %v", fds, err)
+	}
+	fstab, err := source.Fstab(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{"/": false, "/boot/efi": false, "/data": false, "/mnt/iso": true}
+	if !reflect.DeepEqual(fstab, expected) {
+		t.Errorf("expected fstab %v, got %v", expected, fstab)
+	}
+}
+
+func TestUnexpectedReadOnly(t *testing.T) {
+	fstab := map[string]bool{"/": false, "/media/cdrom": true}
+	tests := []struct {
+		mountpoint string
+		readOnly   bool
+		expected   bool
+	}{
+		{"/", true, true},
+		{"/", false, false},
+		{"/media/cdrom", true, false},
+		{"/mnt/usb", true, false},
+	}
+	for _, tt := range tests {
+		if got := unexpectedReadOnly(tt.mountpoint, tt.readOnly, fstab); got != tt.expected {
+			t.Errorf("unexpectedReadOnly(%s, %v) = %v, expected %v", tt.mountpoint, tt.readOnly, got, tt.expected)
+		}
+	}
+	if unexpectedReadOnly("/", true, nil) {
+		t.Error("expected no detection without fstab")
+	}
+	if !isReadOnlyMount([]string{"ro", "relatime"}) || isReadOnlyMount([]string{"rw", "errors=remount-ro"}) {
+		t.Error("unexpected read-only option detection")
+	}
+}
+
+func TestFilesystemSamples(t *testing.T) {
+	now := time.Now()
+	data := &model.DiskData{
+		Disks: []model.DiskInfo{
+			{MountPoint: "/", InodesTotal: 1000, InodesUsed: 950, InodesUsedPercent: 95, ReadOnly: true, UnexpectedReadOnly: true},
+			{MountPoint: "/data"}, // btrfs reports no inodes
+		},
+		FileDescriptors: &model.FileDescriptorUsage{Used: 500, Max: 1000, UsagePercent: 50},
+	}
+
+	values := make(map[string]float64)
+	for _, sample := range filesystemSamples("web-1", data, now) {
+		if sample.Hostname != "web-1" || !sample.Timestamp.Equal(now) {
+			t.Errorf("unexpected sample %+v", sample)
+		}
+		values[sample.Metric+" "+sample.Labels] = sample.Value
+	}
+	expected := map[string]float64{
+		SampleDiskInodeUsagePercent + " mountpoint=/": 95,
+		SampleDiskReadOnly + " mountpoint=/":          1,
+		SampleDiskReadOnly + " mountpoint=/data":      0,
+		SampleFDUsagePercent + " ":                    50,
+		SampleFDUsed + " ":                            500,
+	}
+	if len(values) != len(expected) {
+		t.Fatalf("expected samples %v, got %v", expected, values)
+	}
+	for key, value := range expected {
+		if got, ok := values[key]; !ok || got != value {
+			t.Errorf("expected %s = %f, got %f (present %v)", key, value, got, ok)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// collectFilterRefresh is how long the filter of the local host is cached before host configs are read again
+const collectFilterRefresh = 30 * time.Second
+
+// CollectFilter is a compiled set of include/exclude rules deciding which mounts and
+// interfaces are collected. A nil filter includes everything.
+type CollectFilter struct {
+	rules             model.CollectFilter
+	includeDevices    []*regexp.Regexp
+	excludeDevices    []*regexp.Regexp
+	includeInterfaces []*regexp.Regexp
+	excludeInterfaces []*regexp.Regexp
+}
+
+// NewCollectFilter validates and compiles collection rules
+func NewCollectFilter(rules model.CollectFilter) (*CollectFilter, error) {
+	for _, patterns := range [][]string{rules.IncludeMountpoints, rules.ExcludeMountpoints} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid mountpoint glob %q: %w", pattern, err)
+			}
+		}
+	}
+
+	filter := &CollectFilter{rules: rules}
+	var err error
+	if filter.includeDevices, err = compilePatterns("include_devices", rules.IncludeDevices); err != nil {
+		return nil, err
+	}
+	if filter.excludeDevices, err = compilePatterns("exclude_devices", rules.ExcludeDevices); err != nil {
+		return nil, err
+	}
+	if filter.includeInterfaces, err = compilePatterns("include_interfaces", rules.IncludeInterfaces); err != nil {
+		return nil, err
+	}
+	if filter.excludeInterfaces, err = compilePatterns("exclude_interfaces", rules.ExcludeInterfaces); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// compilePatterns compiles the regular expressions of a filter field
+func compilePatterns(field string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %w", field, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Rules returns the rules the filter was compiled from
+func (f *CollectFilter) Rules() model.CollectFilter {
+	if f == nil {
+		return model.CollectFilter{}
+	}
+	return f.rules
+}
+
+// MatchMount reports whether a mount is collected, and the rule excluding it otherwise
+func (f *CollectFilter) MatchMount(device, mountpoint, fstype string) (bool, string) {
+	if f == nil {
+		return true, ""
+	}
+
+	equal := func(pattern, value string) bool { return pattern == value }
+	if ok, reason := matchRules("fstypes", fstype, f.rules.IncludeFSTypes, f.rules.ExcludeFSTypes, equal); !ok {
+		return false, reason
+	}
+	if ok, reason := matchRules("mountpoints", mountpoint, f.rules.IncludeMountpoints, f.rules.ExcludeMountpoints, matchMountpoint); !ok {
+		return false, reason
+	}
+	return matchRegexps("devices", device, f.includeDevices, f.excludeDevices)
+}
+
+// MatchInterface reports whether a network interface is collected, and the rule excluding it otherwise
+func (f *CollectFilter) MatchInterface(name string) (bool, string) {
+	if f == nil {
+		return true, ""
+	}
+	return matchRegexps("interfaces", name, f.includeInterfaces, f.excludeInterfaces)
+}
+
+// matchRules applies include and exclude patterns to a value
+func matchRules(field, value string, include, exclude []string, match func(pattern, value string) bool) (bool, string) {
+	if len(include) > 0 {
+		included := false
+		for _, pattern := range include {
+			if match(pattern, value) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false, "not in include_" + field
+		}
+	}
+	for _, pattern := range exclude {
+		if match(pattern, value) {
+			return false, fmt.Sprintf("exclude_%s: %s", field, pattern)
+		}
+	}
+	return true, ""
+}
+
+// matchRegexps applies include and exclude regular expressions to a value
+func matchRegexps(field, value string, include, exclude []*regexp.Regexp) (bool, string) {
+	if len(include) > 0 {
+		included := false
+		for _, re := range include {
+			if re.MatchString(value) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false, "not in include_" + field
+		}
+	}
+	for _, re := range exclude {
+		if re.MatchString(value) {
+			return false, fmt.Sprintf("exclude_%s: %s", field, re.String())
+		}
+	}
+	return true, ""
+}
+
+// matchMountpoint matches a mountpoint against a glob. A trailing /** matches the
+// directory itself and every mountpoint below it.
+func matchMountpoint(pattern, mountpoint string) bool {
+	base, recursive := strings.CutSuffix(pattern, "/**")
+	if !recursive {
+		matched, _ := path.Match(pattern, mountpoint)
+		return matched
+	}
+	if base == "" {
+		return true
+	}
+	for dir := mountpoint; ; dir = path.Dir(dir) {
+		if matched, _ := path.Match(base, dir); matched {
+			return true
+		}
+		if dir == "/" || dir == "." {
+			return false
+		}
+	}
+}
+
+// MergeCollectFilter overrides the global rules with the fields a host sets
+func MergeCollectFilter(global, host model.CollectFilter) model.CollectFilter {
+	merged := global
+	override := func(dst *[]string, src []string) {
+		if src != nil {
+			*dst = src
+		}
+	}
+	override(&merged.IncludeFSTypes, host.IncludeFSTypes)
+	override(&merged.ExcludeFSTypes, host.ExcludeFSTypes)
+	override(&merged.IncludeMountpoints, host.IncludeMountpoints)
+	override(&merged.ExcludeMountpoints, host.ExcludeMountpoints)
+	override(&merged.IncludeDevices, host.IncludeDevices)
+	override(&merged.ExcludeDevices, host.ExcludeDevices)
+	override(&merged.IncludeInterfaces, host.IncludeInterfaces)
+	override(&merged.ExcludeInterfaces, host.ExcludeInterfaces)
+	return merged
+}
+
+// ParseCollectFilterConfig parses and validates the JSON value of a collect filter host config
+func ParseCollectFilterConfig(value string) (*model.CollectFilter, error) {
+	var rules model.CollectFilter
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("invalid collect filter config: %w", err)
+	}
+	if _, err := NewCollectFilter(rules); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// CollectFilterFromConfig converts the global filter configuration to collection rules
+func CollectFilterFromConfig(cfg config.FilterConfig) model.CollectFilter {
+	return model.CollectFilter{
+		IncludeFSTypes:     cfg.IncludeFSTypes,
+		ExcludeFSTypes:     cfg.ExcludeFSTypes,
+		IncludeMountpoints: cfg.IncludeMountpoints,
+		ExcludeMountpoints: cfg.ExcludeMountpoints,
+		IncludeDevices:     cfg.IncludeDevices,
+		ExcludeDevices:     cfg.ExcludeDevices,
+		IncludeInterfaces:  cfg.IncludeInterfaces,
+		ExcludeInterfaces:  cfg.ExcludeInterfaces,
+	}
+}
+
+// CollectFilterProvider resolves the collection filter of the local host
+type CollectFilterProvider interface {
+	// Filter returns the global rules overridden by the collect filter configs of the local host
+	Filter() (*CollectFilter, error)
+	// Resolve returns the global rules overridden by the given host rules
+	Resolve(host model.CollectFilter) (*CollectFilter, error)
+}
+
+// hostCollectFilters implements CollectFilterProvider from the configuration and host configs
+type hostCollectFilters struct {
+	hostRepo   repository.HostRepository
+	configRepo repository.HostConfigRepository
+	hostname   string
+	global     model.CollectFilter
+	logger     *logger.Logger
+
+	mu       sync.Mutex
+	cached   *CollectFilter
+	loadedAt time.Time
+}
+
+// NewCollectFilterProvider creates a collection filter provider for the local host
+func NewCollectFilterProvider(db *gorm.DB, cfg config.MonitorConfig, logger *logger.Logger) CollectFilterProvider {
+	return &hostCollectFilters{
+		hostRepo:   repository.NewHostRepository(db),
+		configRepo: repository.NewHostConfigRepository(db),
+		hostname:   cfg.Hostname,
+		global:     CollectFilterFromConfig(cfg.Filters),
+		logger:     logger,
+	}
+}
+
+// Filter returns the cached filter of the local host, reloading host configs when it is outdated
+func (p *hostCollectFilters) Filter() (*CollectFilter, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cached != nil && time.Since(p.loadedAt) < collectFilterRefresh {
+		return p.cached, nil
+	}
+	filter, err := p.Resolve(p.loadHostRules())
+	if err != nil {
+		return nil, err
+	}
+	p.cached, p.loadedAt = filter, time.Now()
+	return filter, nil
+}
+
+// Resolve compiles the global rules overridden by host rules
+func (p *hostCollectFilters) Resolve(host model.CollectFilter) (*CollectFilter, error) {
+	return NewCollectFilter(MergeCollectFilter(p.global, host))
+}
+
+// loadHostRules merges the collect filter configs of the local host in key order.
+// Lookup failures and invalid configs are logged and fall back to the global rules.
+func (p *hostCollectFilters) loadHostRules() model.CollectFilter {
+	var rules model.CollectFilter
+	localHost, err := p.hostRepo.GetByHostname(p.hostname)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			p.logger.Warn("Failed to get local host for collect filters", "error", err)
+		}
+		return rules
+	}
+	configs, err := p.configRepo.GetByCategory(localHost.ID, model.HostConfigCategoryCollectFilter)
+	if err != nil {
+		p.logger.Warn("Failed to get collect filters", "error", err)
+		return rules
+	}
+
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Key < configs[j].Key })
+	for _, hostConfig := range configs {
+		hostRules, err := ParseCollectFilterConfig(hostConfig.Value)
+		if err != nil {
+			p.logger.Warn("Invalid collect filter", "key", hostConfig.Key, "error", err)
+			continue
+		}
+		rules = MergeCollectFilter(rules, *hostRules)
+	}
+	return rules
+}
+
+// collectFilter returns the filter of the local host, nil when no provider is configured
+func (s *monitorService) collectFilter() (*CollectFilter, error) {
+	if s.filters == nil {
+		return nil, nil
+	}
+	filter, err := s.filters.Filter()
+	if err != nil {
+		return nil, fmt.Errorf("invalid collect filter: %w", err)
+	}
+	return filter, nil
+}
+
+// PreviewCollectFilter lists every mount and interface of the local machine and whether it is
+// collected, using the effective filter or the global rules overridden by the given host rules
+func (s *monitorService) PreviewCollectFilter(ctx context.Context, host *model.CollectFilter) (*model.CollectFilterPreview, error) {
+	var filter *CollectFilter
+	var err error
+	switch {
+	case host == nil:
+		filter, err = s.collectFilter()
+	case s.filters == nil:
+		filter, err = NewCollectFilter(*host)
+	default:
+		filter, err = s.filters.Resolve(*host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	partitions, err := s.source.Partitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk partitions: %w", err)
+	}
+	netStats, err := s.source.NetIOCounters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network stats: %w", err)
+	}
+
+	preview := &model.CollectFilterPreview{
+		Filter:     filter.Rules(),
+		Mounts:     make([]model.FilterMatch, 0, len(partitions)),
+		Interfaces: make([]model.FilterMatch, 0, len(netStats)),
+	}
+	for _, partition := range partitions {
+		included, reason := filter.MatchMount(partition.Device, partition.Mountpoint, partition.Fstype)
+		preview.Mounts = append(preview.Mounts, model.FilterMatch{
+			Name:     partition.Mountpoint,
+			Device:   partition.Device,
+			FSType:   partition.Fstype,
+			Included: included,
+			Reason:   reason,
+		})
+	}
+	for _, stat := range netStats {
+		included, reason := filter.MatchInterface(stat.Name)
+		preview.Interfaces = append(preview.Interfaces, model.FilterMatch{Name: stat.Name, Included: included, Reason: reason})
+	}
+	return preview, nil
+}
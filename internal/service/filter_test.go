@@ -0,0 +1,150 @@
+package service
+
+import (
+	"testing"
+
+	"monitor-server/internal/model"
+)
+
+func TestMatchMountpoint(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		mountpoint string
+		expected   bool
+	}{
+		{"/run/**", "/run", true},
+		{"/run/**", "/run/user/1000", true},
+		{"/run/**", "/runner", false},
+		{"/mnt/*", "/mnt/c", true},
+		{"/mnt/*", "/mnt/c/data", false},
+		{"/mnt/?", "/mnt/d", true},
+		{"/mnt/?", "/mnt/data", false},
+		{"/var/lib/*/overlay/**", "/var/lib/docker/overlay/abc/merged", true},
+		{"/**", "/data", true},
+		{"/data", "/data", true},
+	}
+	for _, tt := range tests {
+		if got := matchMountpoint(tt.pattern, tt.mountpoint); got != tt.expected {
+			t.Errorf("matchMountpoint(%q, %q) = %v, expected %v", tt.pattern, tt.mountpoint, got, tt.expected)
+		}
+	}
+}
+
+func TestCollectFilterMatchMount(t *testing.T) {
+	filter, err := NewCollectFilter(model.CollectFilter{
+		ExcludeFSTypes:     []string{"tmpfs", "squashfs"},
+		ExcludeMountpoints: []string{"/run/**", "/mnt/wsl*"},
+		ExcludeDevices:     []string{`^/dev/loop\d+$`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		device, mountpoint, fstype string
+		included                   bool
+		reason                     string
+	}{
+		{"/dev/sda1", "/", "ext4", true, ""},
+		{"/dev/sdb1", "/mnt/c", "ext4", true, ""},
+		{"tmpfs", "/dev/shm", "tmpfs", false, "exclude_fstypes: tmpfs"},
+		{"/dev/sdc1", "/run/media/usb", "vfat", false, "exclude_mountpoints: /run/**"},
+		{"/dev/sdd", "/mnt/wslg", "ext4", false, "exclude_mountpoints: /mnt/wsl*"},
+		{"/dev/loop3", "/media/iso", "iso9660", false, `exclude_devices: ^/dev/loop\d+$`},
+	}
+	for _, tt := range tests {
+		included, reason := filter.MatchMount(tt.device, tt.mountpoint, tt.fstype)
+		if included != tt.included || reason != tt.reason {
+			t.Errorf("MatchMount(%s, %s, %s) = %v %q, expected %v %q", tt.device, tt.mountpoint, tt.fstype, included, reason, tt.included, tt.reason)
+		}
+	}
+
+	filter, err = NewCollectFilter(model.CollectFilter{IncludeFSTypes: []string{"ext4", "xfs"}, IncludeMountpoints: []string{"/data/**"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if included, reason := filter.MatchMount("/dev/sda1", "/", "ext4"); included || reason != "not in include_mountpoints" {
+		t.Errorf("expected / to be outside include_mountpoints, got %v %q", included, reason)
+	}
+	if included, reason := filter.MatchMount("/dev/sdb1", "/data/a", "btrfs"); included || reason != "not in include_fstypes" {
+		t.Errorf("expected btrfs to be outside include_fstypes, got %v %q", included, reason)
+	}
+	if included, _ := filter.MatchMount("/dev/sdb1", "/data/a", "xfs"); !included {
+		t.Error("expected /data/a to be included")
+	}
+}
+
+func TestCollectFilterMatchInterface(t *testing.T) {
+	filter, err := NewCollectFilter(model.CollectFilter{ExcludeInterfaces: []string{"^veth", "^docker"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]bool{"eth0": true, "lo": true, "veth1a2b3c": false, "docker0": false} {
+		if included, _ := filter.MatchInterface(name); included != expected {
+			t.Errorf("MatchInterface(%s) = %v, expected %v", name, included, expected)
+		}
+	}
+
+	filter, err = NewCollectFilter(model.CollectFilter{IncludeInterfaces: []string{"^(eth|en)"}, ExcludeInterfaces: []string{"^eth1$"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]bool{"eth0": true, "enp3s0": true, "eth1": false, "wlan0": false} {
+		if included, _ := filter.MatchInterface(name); included != expected {
+			t.Errorf("MatchInterface(%s) = %v, expected %v", name, included, expected)
+		}
+	}
+
+	var nilFilter *CollectFilter
+	if included, _ := nilFilter.MatchInterface("veth0"); !included {
+		t.Error("expected nil filter to include everything")
+	}
+}
+
+func TestNewCollectFilterInvalid(t *testing.T) {
+	invalid := []model.CollectFilter{
+		{ExcludeMountpoints: []string{"/mnt/["}},
+		{IncludeDevices: []string{"("}},
+		{ExcludeInterfaces: []string{"[a-"}},
+	}
+	for _, rules := range invalid {
+		if _, err := NewCollectFilter(rules); err == nil {
+			t.Errorf("expected error for %+v", rules)
+		}
+	}
+}
+
+func TestMergeCollectFilter(t *testing.T) {
+	global := model.CollectFilter{
+		ExcludeFSTypes:     []string{"tmpfs"},
+		ExcludeMountpoints: []string{"/run/**"},
+		ExcludeInterfaces:  []string{"^veth"},
+	}
+	host, err := ParseCollectFilterConfig(`{"exclude_mountpoints": [], "exclude_interfaces": ["^veth", "^docker"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := MergeCollectFilter(global, *host)
+	if len(merged.ExcludeFSTypes) != 1 || merged.ExcludeFSTypes[0] != "tmpfs" {
+		t.Errorf("expected fstypes to be inherited, got %v", merged.ExcludeFSTypes)
+	}
+	if merged.ExcludeMountpoints == nil || len(merged.ExcludeMountpoints) != 0 {
+		t.Errorf("expected mountpoints to be cleared, got %v", merged.ExcludeMountpoints)
+	}
+	if len(merged.ExcludeInterfaces) != 2 {
+		t.Errorf("expected interfaces to be overridden, got %v", merged.ExcludeInterfaces)
+	}
+}
+
+func TestParseCollectFilterConfig(t *testing.T) {
+	for _, value := range []string{
+		`{"exclude_interface": ["^veth"]}`,
+		`{"include_devices": ["("]}`,
+		`not json`,
+	} {
+		if _, err := ParseCollectFilterConfig(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
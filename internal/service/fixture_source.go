@@ -0,0 +1,468 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+
+	"monitor-server/internal/model"
+)
+
+// SystemSnapshot records the system data at one point in time. It is captured by
+// CaptureSnapshot and replayed by FixtureSource, which fails readings that were not recorded.
+type SystemSnapshot struct {
+	Timestamp     time.Time                  `json:"timestamp"`
+	CPUPercent    float64                    `json:"cpu_percent"`
+	CPUInfo       []cpu.InfoStat             `json:"cpu_info,omitempty"`
+	CPUCount      int                        `json:"cpu_count"`
+	VirtualMemory *mem.VirtualMemoryStat     `json:"virtual_memory,omitempty"`
+	SwapMemory    *mem.SwapMemoryStat        `json:"swap_memory,omitempty"`
+	Partitions    []disk.PartitionStat       `json:"partitions,omitempty"`
+	DiskUsage     map[string]*disk.UsageStat `json:"disk_usage,omitempty"` // by mount point
+	NetIOCounters []net.IOCountersStat       `json:"net_io_counters,omitempty"`
+	NetInterfaces []net.InterfaceStat        `json:"net_interfaces,omitempty"`
+	Connections   []net.ConnectionStat       `json:"connections,omitempty"`
+	HostInfo      *host.InfoStat             `json:"host_info,omitempty"`
+	LoadAvg       *load.AvgStat              `json:"load_avg,omitempty"`
+	Temperatures  []host.TemperatureStat     `json:"temperatures,omitempty"`
+	HwmonSensors  []model.SensorChip         `json:"hwmon_sensors,omitempty"`
+	FileHandles   *model.FileDescriptorUsage `json:"file_handles,omitempty"`
+	Fstab         map[string]bool            `json:"fstab,omitempty"` // mount point to read-only
+	Processes     []ProcessSnapshot          `json:"processes,omitempty"`
+}
+
+// ProcessSnapshot records the attributes of a single process at the time of a snapshot
+type ProcessSnapshot struct {
+	PID           int32                   `json:"pid"`
+	PPID          int32                   `json:"ppid"`
+	Name          string                  `json:"name"`
+	Username      string                  `json:"username,omitempty"`
+	RSS           uint64                  `json:"rss"` // resident memory in bytes
+	MemoryPercent float32                 `json:"memory_percent"`
+	NumThreads    int32                   `json:"num_threads"`
+	Cmdline       string                  `json:"cmdline,omitempty"`
+	Status        string                  `json:"status"`      // running, sleep, idle, zombie and so on
+	CreateTime    int64                   `json:"create_time"` // milliseconds since the epoch
+	CPUUser       float64                 `json:"cpu_user"`    // accumulated user CPU time in seconds
+	CPUSystem     float64                 `json:"cpu_system"`  // accumulated system CPU time in seconds
+	Exe           string                  `json:"exe,omitempty"`
+	Cwd           string                  `json:"cwd,omitempty"`
+	NumFDs        int32                   `json:"num_fds,omitempty"`
+	IOCounters    *process.IOCountersStat `json:"io_counters,omitempty"`
+	Environ       []string                `json:"environ,omitempty"`
+	Connections   []net.ConnectionStat    `json:"connections,omitempty"`
+}
+
+// FixtureSource implements SystemSource by replaying recorded snapshots. Readings come from
+// the current snapshot and the clock reports its timestamp. Sleep moves to the next snapshot,
+// so rates and process CPU usage are computed between consecutive snapshots.
+type FixtureSource struct {
+	mu        sync.Mutex
+	snapshots []SystemSnapshot
+	current   int
+}
+
+// NewFixtureSource creates a source replaying snapshots in order, starting with the first
+func NewFixtureSource(snapshots ...SystemSnapshot) (*FixtureSource, error) {
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("no snapshots to replay")
+	}
+	return &FixtureSource{snapshots: snapshots}, nil
+}
+
+// LoadFixtureSource creates a source replaying the JSON array of snapshots stored in a file
+func LoadFixtureSource(path string) (*FixtureSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshots: %w", err)
+	}
+	var snapshots []SystemSnapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshots in %s: %w", path, err)
+	}
+	return NewFixtureSource(snapshots...)
+}
+
+// Advance moves to the next snapshot, false when the last one is already current
+func (f *FixtureSource) Advance() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.current+1 >= len(f.snapshots) {
+		return false
+	}
+	f.current++
+	return true
+}
+
+// snapshot returns the current snapshot
+func (f *FixtureSource) snapshot() *SystemSnapshot {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &f.snapshots[f.current]
+}
+
+// Now returns the timestamp of the current snapshot
+func (f *FixtureSource) Now() time.Time {
+	return f.snapshot().Timestamp
+}
+
+// Sleep advances to the next snapshot instead of waiting. On the last snapshot time stands still.
+func (f *FixtureSource) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.Advance()
+	return nil
+}
+
+// errNotRecorded is returned for data missing from a snapshot
+func errNotRecorded(field string) error {
+	return fmt.Errorf("%s not recorded in snapshot", field)
+}
+
+func (f *FixtureSource) CPUPercent(ctx context.Context, interval time.Duration) (float64, error) {
+	return f.snapshot().CPUPercent, nil
+}
+
+func (f *FixtureSource) CPUInfo(ctx context.Context) ([]cpu.InfoStat, error) {
+	return f.snapshot().CPUInfo, nil
+}
+
+func (f *FixtureSource) CPUCount(ctx context.Context) (int, error) {
+	return f.snapshot().CPUCount, nil
+}
+
+func (f *FixtureSource) VirtualMemory(ctx context.Context) (*mem.VirtualMemoryStat, error) {
+	if stat := f.snapshot().VirtualMemory; stat != nil {
+		return stat, nil
+	}
+	return nil, errNotRecorded("virtual memory")
+}
+
+func (f *FixtureSource) SwapMemory(ctx context.Context) (*mem.SwapMemoryStat, error) {
+	if stat := f.snapshot().SwapMemory; stat != nil {
+		return stat, nil
+	}
+	return nil, errNotRecorded("swap memory")
+}
+
+func (f *FixtureSource) Partitions(ctx context.Context) ([]disk.PartitionStat, error) {
+	return f.snapshot().Partitions, nil
+}
+
+func (f *FixtureSource) DiskUsage(ctx context.Context, mountpoint string) (*disk.UsageStat, error) {
+	if usage, ok := f.snapshot().DiskUsage[mountpoint]; ok && usage != nil {
+		return usage, nil
+	}
+	return nil, errNotRecorded("usage of " + mountpoint)
+}
+
+func (f *FixtureSource) NetIOCounters(ctx context.Context) ([]net.IOCountersStat, error) {
+	return f.snapshot().NetIOCounters, nil
+}
+
+func (f *FixtureSource) NetInterfaces(ctx context.Context) ([]net.InterfaceStat, error) {
+	return f.snapshot().NetInterfaces, nil
+}
+
+func (f *FixtureSource) Connections(ctx context.Context) ([]net.ConnectionStat, error) {
+	return f.snapshot().Connections, nil
+}
+
+func (f *FixtureSource) HostInfo(ctx context.Context) (*host.InfoStat, error) {
+	if info := f.snapshot().HostInfo; info != nil {
+		return info, nil
+	}
+	return nil, errNotRecorded("host info")
+}
+
+func (f *FixtureSource) LoadAvg(ctx context.Context) (*load.AvgStat, error) {
+	if avg := f.snapshot().LoadAvg; avg != nil {
+		return avg, nil
+	}
+	return nil, errNotRecorded("load average")
+}
+
+func (f *FixtureSource) Temperatures(ctx context.Context) ([]host.TemperatureStat, error) {
+	return f.snapshot().Temperatures, nil
+}
+
+func (f *FixtureSource) HwmonSensors(ctx context.Context) ([]model.SensorChip, error) {
+	if chips := f.snapshot().HwmonSensors; chips != nil {
+		return chips, nil
+	}
+	return nil, errNotRecorded("hwmon sensors")
+}
+
+func (f *FixtureSource) FileHandles(ctx context.Context) (*model.FileDescriptorUsage, error) {
+	if usage := f.snapshot().FileHandles; usage != nil {
+		return usage, nil
+	}
+	return nil, errNotRecorded("file handles")
+}
+
+func (f *FixtureSource) Fstab(ctx context.Context) (map[string]bool, error) {
+	if fstab := f.snapshot().Fstab; fstab != nil {
+		return fstab, nil
+	}
+	return nil, errNotRecorded("fstab")
+}
+
+func (f *FixtureSource) Pids(ctx context.Context) ([]int32, error) {
+	processes := f.snapshot().Processes
+	pids := make([]int32, 0, len(processes))
+	for _, proc := range processes {
+		pids = append(pids, proc.PID)
+	}
+	return pids, nil
+}
+
+func (f *FixtureSource) Process(ctx context.Context, pid int32) (SystemProcess, error) {
+	if _, err := f.process(pid); err != nil {
+		return nil, err
+	}
+	return fixtureProcess{source: f, pid: pid}, nil
+}
+
+// process returns a process of the current snapshot
+func (f *FixtureSource) process(pid int32) (*ProcessSnapshot, error) {
+	snapshot := f.snapshot()
+	for i := range snapshot.Processes {
+		if snapshot.Processes[i].PID == pid {
+			return &snapshot.Processes[i], nil
+		}
+	}
+	return nil, ErrProcessNotFound
+}
+
+// fixtureProcess implements SystemProcess on the current snapshot, so a process missing from
+// a later snapshot has exited
+type fixtureProcess struct {
+	source *FixtureSource
+	pid    int32
+}
+
+func (p fixtureProcess) Pid() int32 { return p.pid }
+
+func (p fixtureProcess) Name(ctx context.Context) (string, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return "", err
+	}
+	return proc.Name, nil
+}
+
+func (p fixtureProcess) Ppid(ctx context.Context) (int32, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return 0, err
+	}
+	return proc.PPID, nil
+}
+
+func (p fixtureProcess) Username(ctx context.Context) (string, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return "", err
+	}
+	return proc.Username, nil
+}
+
+func (p fixtureProcess) MemoryInfo(ctx context.Context) (*process.MemoryInfoStat, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return nil, err
+	}
+	return &process.MemoryInfoStat{RSS: proc.RSS}, nil
+}
+
+func (p fixtureProcess) MemoryPercent(ctx context.Context) (float32, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return 0, err
+	}
+	return proc.MemoryPercent, nil
+}
+
+func (p fixtureProcess) NumThreads(ctx context.Context) (int32, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return 0, err
+	}
+	return proc.NumThreads, nil
+}
+
+func (p fixtureProcess) Cmdline(ctx context.Context) (string, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return "", err
+	}
+	return proc.Cmdline, nil
+}
+
+func (p fixtureProcess) Status(ctx context.Context) ([]string, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return nil, err
+	}
+	return []string{proc.Status}, nil
+}
+
+func (p fixtureProcess) CreateTime(ctx context.Context) (int64, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return 0, err
+	}
+	return proc.CreateTime, nil
+}
+
+func (p fixtureProcess) Times(ctx context.Context) (*cpu.TimesStat, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return nil, err
+	}
+	return &cpu.TimesStat{User: proc.CPUUser, System: proc.CPUSystem}, nil
+}
+
+func (p fixtureProcess) Exe(ctx context.Context) (string, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return "", err
+	}
+	return proc.Exe, nil
+}
+
+func (p fixtureProcess) Cwd(ctx context.Context) (string, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return "", err
+	}
+	return proc.Cwd, nil
+}
+
+func (p fixtureProcess) NumFDs(ctx context.Context) (int32, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return 0, err
+	}
+	return proc.NumFDs, nil
+}
+
+func (p fixtureProcess) IOCounters(ctx context.Context) (*process.IOCountersStat, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return nil, err
+	}
+	if proc.IOCounters == nil {
+		return nil, errNotRecorded("I/O counters")
+	}
+	return proc.IOCounters, nil
+}
+
+func (p fixtureProcess) Environ(ctx context.Context) ([]string, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return nil, err
+	}
+	return proc.Environ, nil
+}
+
+func (p fixtureProcess) Connections(ctx context.Context) ([]net.ConnectionStat, error) {
+	proc, err := p.source.process(p.pid)
+	if err != nil {
+		return nil, err
+	}
+	return proc.Connections, nil
+}
+
+// CaptureSnapshot records the current readings of a source. CPU and memory are required,
+// everything else is recorded when it can be read.
+func CaptureSnapshot(ctx context.Context, source SystemSource) (SystemSnapshot, error) {
+	snapshot := SystemSnapshot{Timestamp: source.Now(), DiskUsage: make(map[string]*disk.UsageStat)}
+
+	var err error
+	if snapshot.CPUPercent, err = source.CPUPercent(ctx, time.Second); err != nil {
+		return snapshot, fmt.Errorf("failed to get CPU usage: %w", err)
+	}
+	if snapshot.CPUCount, err = source.CPUCount(ctx); err != nil {
+		return snapshot, fmt.Errorf("failed to get CPU count: %w", err)
+	}
+	if snapshot.VirtualMemory, err = source.VirtualMemory(ctx); err != nil {
+		return snapshot, fmt.Errorf("failed to get memory stats: %w", err)
+	}
+	snapshot.CPUInfo, _ = source.CPUInfo(ctx)
+	snapshot.SwapMemory, _ = source.SwapMemory(ctx)
+	snapshot.Partitions, _ = source.Partitions(ctx)
+	for _, partition := range snapshot.Partitions {
+		if usage, err := source.DiskUsage(ctx, partition.Mountpoint); err == nil {
+			snapshot.DiskUsage[partition.Mountpoint] = usage
+		}
+	}
+	snapshot.NetIOCounters, _ = source.NetIOCounters(ctx)
+	snapshot.NetInterfaces, _ = source.NetInterfaces(ctx)
+	snapshot.Connections, _ = source.Connections(ctx)
+	snapshot.HostInfo, _ = source.HostInfo(ctx)
+	snapshot.LoadAvg, _ = source.LoadAvg(ctx)
+	snapshot.Temperatures, _ = source.Temperatures(ctx)
+	snapshot.HwmonSensors, _ = source.HwmonSensors(ctx)
+	snapshot.FileHandles, _ = source.FileHandles(ctx)
+	snapshot.Fstab, _ = source.Fstab(ctx)
+
+	pids, err := source.Pids(ctx)
+	if err != nil {
+		return snapshot, nil
+	}
+	for _, pid := range pids {
+		proc, err := source.Process(ctx, pid)
+		if err != nil {
+			continue
+		}
+		if recorded, err := captureProcess(ctx, proc); err == nil {
+			snapshot.Processes = append(snapshot.Processes, recorded)
+		}
+	}
+	return snapshot, nil
+}
+
+// captureProcess records a process, failing when it has exited. Fields that cannot be read,
+// usually for lack of permission, are left empty.
+func captureProcess(ctx context.Context, proc SystemProcess) (ProcessSnapshot, error) {
+	recorded := ProcessSnapshot{PID: proc.Pid()}
+	var err error
+	if recorded.Name, err = proc.Name(ctx); err != nil {
+		return recorded, err
+	}
+	if memoryInfo, err := proc.MemoryInfo(ctx); err == nil {
+		recorded.RSS = memoryInfo.RSS
+	}
+	if status, err := proc.Status(ctx); err == nil && len(status) > 0 {
+		recorded.Status = status[0]
+	}
+	if times, err := proc.Times(ctx); err == nil {
+		recorded.CPUUser, recorded.CPUSystem = times.User, times.System
+	}
+	recorded.PPID, _ = proc.Ppid(ctx)
+	recorded.Username, _ = proc.Username(ctx)
+	recorded.MemoryPercent, _ = proc.MemoryPercent(ctx)
+	recorded.NumThreads, _ = proc.NumThreads(ctx)
+	recorded.Cmdline, _ = proc.Cmdline(ctx)
+	recorded.CreateTime, _ = proc.CreateTime(ctx)
+	recorded.Exe, _ = proc.Exe(ctx)
+	recorded.Cwd, _ = proc.Cwd(ctx)
+	recorded.NumFDs, _ = proc.NumFDs(ctx)
+	recorded.IOCounters, _ = proc.IOCounters(ctx)
+	recorded.Connections, _ = proc.Connections(ctx)
+	// environment variables may hold secrets and are not recorded
+	return recorded, nil
+}
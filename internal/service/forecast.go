@@ -0,0 +1,141 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+// minForecastSamples 进行趋势预测所需的最少样本数
+const minForecastSamples = 3
+
+// linearFit holds the result of a least squares linear regression
+type linearFit struct {
+	Slope     float64 // 每秒变化量
+	Intercept float64 // 相对于 origin 的截距
+	RSquared  float64
+	Origin    time.Time
+}
+
+// fitLinear fits value = intercept + slope * seconds(t - origin) over the points
+func fitLinear(points []MetricPoint) (linearFit, bool) {
+	if len(points) < minForecastSamples {
+		return linearFit{}, false
+	}
+
+	origin := points[0].Timestamp
+	n := float64(len(points))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		x := p.Timestamp.Sub(origin).Seconds()
+		sumX += x
+		sumY += p.Value
+		sumXY += x * p.Value
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return linearFit{}, false // 所有样本时间相同
+	}
+
+	slope := (n*sumXY - sumX*sumY) / denominator
+	intercept := (sumY - slope*sumX) / n
+
+	// 计算拟合优度 R²
+	meanY := sumY / n
+	var ssTot, ssRes float64
+	for _, p := range points {
+		x := p.Timestamp.Sub(origin).Seconds()
+		predicted := intercept + slope*x
+		ssRes += (p.Value - predicted) * (p.Value - predicted)
+		ssTot += (p.Value - meanY) * (p.Value - meanY)
+	}
+	rSquared := 1.0
+	if ssTot > 0 {
+		rSquared = 1 - ssRes/ssTot
+	}
+
+	return linearFit{Slope: slope, Intercept: intercept, RSquared: rSquared, Origin: origin}, true
+}
+
+// ForecastUsage fits a linear trend over a usage percentage series and projects
+// when it reaches capacity. HoursToFull stays nil when usage is not growing.
+func ForecastUsage(resource string, points []MetricPoint, capacity float64, now time.Time) model.ResourceForecast {
+	forecast := model.ResourceForecast{
+		Resource: resource,
+		Samples:  len(points),
+	}
+	if len(points) == 0 {
+		return forecast
+	}
+	forecast.CurrentUsage = points[len(points)-1].Value
+
+	fit, ok := fitLinear(points)
+	if !ok {
+		return forecast
+	}
+	forecast.GrowthPerHour = fit.Slope * 3600
+	forecast.RSquared = fit.RSquared
+
+	if fit.Slope <= 0 {
+		return forecast
+	}
+
+	// 根据拟合直线计算达到容量上限的时间点
+	secondsToFull := (capacity-fit.Intercept)/fit.Slope - now.Sub(fit.Origin).Seconds()
+	hours := math.Max(secondsToFull/3600, 0)
+	fullAt := now.Add(time.Duration(hours * float64(time.Hour)))
+	forecast.HoursToFull = &hours
+	forecast.PredictedFullAt = &fullAt
+
+	return forecast
+}
+
+// samplePoints converts metric samples of a single series into points
+func samplePoints(samples []model.MetricSample) []MetricPoint {
+	points := make([]MetricPoint, 0, len(samples))
+	for _, sample := range samples {
+		points = append(points, MetricPoint{Timestamp: sample.Timestamp, Value: sample.Value})
+	}
+	return points
+}
+
+// groupSamplesByLabels splits samples ordered by labels into one series per label set
+func groupSamplesByLabels(samples []model.MetricSample) (map[string][]MetricPoint, []string) {
+	series := make(map[string][]MetricPoint)
+	var order []string
+	for _, sample := range samples {
+		if _, ok := series[sample.Labels]; !ok {
+			order = append(order, sample.Labels)
+		}
+		series[sample.Labels] = append(series[sample.Labels], MetricPoint{Timestamp: sample.Timestamp, Value: sample.Value})
+	}
+	return series, order
+}
+
+// BuildHostForecast predicts disk exhaustion per mount point and memory growth of a host
+func BuildHostForecast(host model.Host, diskSamples, memorySamples []model.MetricSample, lookback time.Duration, now time.Time) *model.HostForecast {
+	result := &model.HostForecast{
+		HostID:      host.ID,
+		Hostname:    host.Hostname,
+		Lookback:    int(lookback.Seconds()),
+		GeneratedAt: now,
+		Disks:       []model.ResourceForecast{},
+	}
+
+	series, order := groupSamplesByLabels(diskSamples)
+	for _, labels := range order {
+		forecast := ForecastUsage("disk", series[labels], 100, now)
+		forecast.MountPoint = model.ParseLabels(labels)["mountpoint"]
+		result.Disks = append(result.Disks, forecast)
+	}
+
+	if len(memorySamples) > 0 {
+		forecast := ForecastUsage("memory", samplePoints(memorySamples), 100, now)
+		result.Memory = &forecast
+	}
+
+	return result
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFitLinear(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		points    []MetricPoint
+		ok        bool
+		slope     float64 // 每分钟变化量
+		intercept float64
+		rSquared  float64
+	}{
+		{name: "too few samples", points: testSeries(start, 10, 20)},
+		{name: "identical timestamps", points: []MetricPoint{{start, 1}, {start, 2}, {start, 3}}},
+		{name: "perfect line", points: testSeries(start, 10, 11, 12), ok: true, slope: 1, intercept: 10, rSquared: 1},
+		{name: "flat series", points: testSeries(start, 5, 5, 5), ok: true, intercept: 5, rSquared: 1},
+		{name: "declining", points: testSeries(start, 30, 20, 10), ok: true, slope: -10, intercept: 30, rSquared: 1},
+		{name: "noisy", points: testSeries(start, 0, 2, 1, 3), ok: true, slope: 0.8, intercept: 0.3, rSquared: 0.64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fit, ok := fitLinear(tt.points)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !closeTo(fit.Slope*60, tt.slope) || !closeTo(fit.Intercept, tt.intercept) || !closeTo(fit.RSquared, tt.rSquared) {
+				t.Errorf("fit = %+v, want slope %v/min, intercept %v, R² %v", fit, tt.slope, tt.intercept, tt.rSquared)
+			}
+			if !fit.Origin.Equal(start) {
+				t.Errorf("origin = %v, want %v", fit.Origin, start)
+			}
+		})
+	}
+}
+
+func TestForecastUsage(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	now := start.Add(2 * time.Minute)
+
+	// 每分钟增长1%，从12%到100%还需88分钟
+	forecast := ForecastUsage("disk", testSeries(start, 10, 11, 12), 100, now)
+	if forecast.Samples != 3 || forecast.CurrentUsage != 12 || !closeTo(forecast.GrowthPerHour, 60) {
+		t.Errorf("forecast = %+v", forecast)
+	}
+	if forecast.HoursToFull == nil || !closeTo(*forecast.HoursToFull, 88.0/60) {
+		t.Fatalf("HoursToFull = %v, want %v", forecast.HoursToFull, 88.0/60)
+	}
+	if want := now.Add(88 * time.Minute); forecast.PredictedFullAt.Sub(want).Abs() > time.Second {
+		t.Errorf("PredictedFullAt = %v, want %v", forecast.PredictedFullAt, want)
+	}
+
+	// 已超过容量时不会预测过去的时间
+	forecast = ForecastUsage("disk", testSeries(start, 98, 99, 101), 100, now)
+	if forecast.HoursToFull == nil || *forecast.HoursToFull != 0 {
+		t.Errorf("HoursToFull = %v, want 0", forecast.HoursToFull)
+	}
+
+	// 用量没有增长时不预测
+	for _, values := range [][]float64{{30, 20, 10}, {5, 5, 5}, {50, 60}} {
+		forecast := ForecastUsage("memory", testSeries(start, values...), 100, now)
+		if forecast.HoursToFull != nil || forecast.PredictedFullAt != nil {
+			t.Errorf("ForecastUsage(%v) predicted %v, want none", values, *forecast.HoursToFull)
+		}
+	}
+
+	if forecast := ForecastUsage("memory", nil, 100, now); forecast.Samples != 0 || forecast.CurrentUsage != 0 {
+		t.Errorf("empty forecast = %+v", forecast)
+	}
+}
+
+func closeTo(got, want float64) bool {
+	return math.Abs(got-want) < 1e-9
+}
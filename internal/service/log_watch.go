@@ -0,0 +1,398 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// 日志监视持久化到 metric_samples 的指标名称
+const (
+	SampleLogMatches   = "log_matches"    // 本轮匹配的行数，标签: watch
+	SampleLogMatchRate = "log_match_rate" // 每分钟匹配的行数，标签: watch
+)
+
+const (
+	// logEventsPerRound is the number of matched lines stored as events per watch and round
+	logEventsPerRound = 5
+	// logEventLineMax truncates stored lines
+	logEventLineMax = 1024
+	// logReadLimit bounds the bytes read from a file per round, the rest is read in the next round
+	logReadLimit = 16 << 20
+	// logPartialLineMax bounds an incomplete line kept between rounds
+	logPartialLineMax = 64 << 10
+)
+
+// ParseLogWatchConfig parses and validates the JSON value of a log watch host config
+func ParseLogWatchConfig(value string) (*model.LogWatchConfig, *regexp.Regexp, error) {
+	var cfg model.LogWatchConfig
+	if err := json.Unmarshal([]byte(value), &cfg); err != nil {
+		return nil, nil, fmt.Errorf("invalid log watch config: %w", err)
+	}
+	if !filepath.IsAbs(cfg.Path) {
+		return nil, nil, fmt.Errorf("log watch path must be absolute: %q", cfg.Path)
+	}
+	cfg.Path = filepath.Clean(cfg.Path)
+	if cfg.Pattern == "" {
+		return nil, nil, fmt.Errorf("log watch pattern is required")
+	}
+	re, err := regexp.Compile(cfg.Pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid log watch pattern: %w", err)
+	}
+	return &cfg, re, nil
+}
+
+// LogWatchName returns the watch name of a log watch config key, false when the key does not
+// carry the log watch prefix
+func LogWatchName(key string) (string, bool) {
+	name, ok := strings.CutPrefix(key, model.LogWatchKeyPrefix)
+	return name, ok && name != ""
+}
+
+// ValidateHostConfigKey checks that log watch configs, and only they, use prefixed keys, so a
+// watch never shares its key with a collector or any other config of the host
+func ValidateHostConfigKey(category, key string) error {
+	_, isWatchKey := LogWatchName(key)
+	if category == model.HostConfigCategoryLogWatch && !isWatchKey {
+		return fmt.Errorf("log watch key must be %q followed by the watch name: %q", model.LogWatchKeyPrefix, key)
+	}
+	if category != model.HostConfigCategoryLogWatch && strings.HasPrefix(key, model.LogWatchKeyPrefix) {
+		return fmt.Errorf("key prefix %q is reserved for log watches: %q", model.LogWatchKeyPrefix, key)
+	}
+	return nil
+}
+
+// LogWatcher tails the log files configured on the local host and records matching lines
+type LogWatcher interface {
+	RunOnce(now time.Time) error
+	// Collector returns the collector tailing the log files every record interval by default
+	Collector() Collector
+	// Stop closes the tailed log files, call it once the collector scheduler has stopped.
+	// It waits for a round still running after its collector timeout.
+	Stop()
+}
+
+type logWatcher struct {
+	hostRepo      repository.HostRepository
+	configRepo    repository.HostConfigRepository
+	eventRepo     repository.LogEventRepository
+	sampleRepo    repository.SampleRepository
+	hostname      string
+	interval      time.Duration
+	retentionDays int
+	lastRun       time.Time
+	lastCleanup   time.Time
+	tailers       map[string]*logTailer // 按监视名称
+	mu            sync.Mutex            // 保护 tailers，超时后仍在运行的一轮可能与 Stop 并发
+	logger        *logger.Logger
+}
+
+// NewLogWatcher creates a new log watcher polling at the metrics record interval
+func NewLogWatcher(db *gorm.DB, cfg config.MonitorConfig, logger *logger.Logger) LogWatcher {
+	interval := time.Duration(cfg.RecordInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &logWatcher{
+		hostRepo:      repository.NewHostRepository(db),
+		configRepo:    repository.NewHostConfigRepository(db),
+		eventRepo:     repository.NewLogEventRepository(db),
+		sampleRepo:    repository.NewSampleRepository(db),
+		hostname:      cfg.Hostname,
+		interval:      interval,
+		retentionDays: cfg.RetentionDays,
+		tailers:       make(map[string]*logTailer),
+		logger:        logger,
+	}
+}
+
+// Collector returns the collector tailing the log files, run by CollectorScheduler
+func (w *logWatcher) Collector() Collector {
+	return roundCollector("log_watches", w.interval, w.RunOnce)
+}
+
+// Stop closes the tailed log files
+func (w *logWatcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for name, tailer := range w.tailers {
+		tailer.close()
+		delete(w.tailers, name)
+	}
+}
+
+// RunOnce reads the lines written since the last round from every log watch of the local host,
+// records match counts and rates and stores a few matched lines as events
+func (w *logWatcher) RunOnce(now time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	localHost, err := w.hostRepo.GetByHostname(w.hostname)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to get local host: %w", err)
+	}
+	configs, err := w.configRepo.GetByCategory(localHost.ID, model.HostConfigCategoryLogWatch)
+	if err != nil {
+		return fmt.Errorf("failed to get log watches: %w", err)
+	}
+
+	elapsed := w.interval
+	if !w.lastRun.IsZero() && now.After(w.lastRun) {
+		elapsed = now.Sub(w.lastRun)
+	}
+	w.lastRun = now
+
+	var samples []model.MetricSample
+	var events []model.LogEvent
+	configured := make(map[string]bool, len(configs))
+	for _, hostConfig := range configs {
+		name, ok := LogWatchName(hostConfig.Key)
+		if !ok {
+			w.logger.Warn("Invalid log watch key", "key", hostConfig.Key, "prefix", model.LogWatchKeyPrefix)
+			continue
+		}
+		watchCfg, re, err := ParseLogWatchConfig(hostConfig.Value)
+		if err != nil {
+			w.logger.Warn("Invalid log watch", "watch", name, "error", err)
+			continue
+		}
+		configured[name] = true
+
+		tailer := w.tailers[name]
+		if tailer == nil || tailer.path != watchCfg.Path {
+			if tailer != nil {
+				tailer.close()
+			}
+			tailer = newLogTailer(watchCfg.Path, watchCfg.FromStart)
+			w.tailers[name] = tailer
+		}
+
+		lines, err := tailer.poll()
+		if err != nil {
+			w.logger.Warn("Failed to read log file", "watch", name, "path", watchCfg.Path, "error", err)
+		}
+		count, matched := matchLogLines(re, lines, logEventsPerRound)
+		for _, line := range matched {
+			events = append(events, model.LogEvent{
+				HostID:    localHost.ID,
+				Watch:     name,
+				Path:      watchCfg.Path,
+				Line:      sanitizeLogLine(line),
+				MatchedAt: now,
+			})
+		}
+
+		labels := model.FormatLabels(map[string]string{"watch": name})
+		samples = append(samples,
+			model.MetricSample{Hostname: w.hostname, Metric: SampleLogMatches, Labels: labels, Value: float64(count), Timestamp: now},
+			model.MetricSample{Hostname: w.hostname, Metric: SampleLogMatchRate, Labels: labels, Value: float64(count) / elapsed.Minutes(), Timestamp: now},
+		)
+	}
+
+	for name, tailer := range w.tailers {
+		if !configured[name] {
+			tailer.close()
+			delete(w.tailers, name)
+		}
+	}
+
+	if err := w.eventRepo.CreateBatch(events); err != nil {
+		return fmt.Errorf("failed to save log events: %w", err)
+	}
+	if err := w.sampleRepo.CreateBatch(samples); err != nil {
+		return err
+	}
+
+	// 每小时清理一次过期事件
+	if w.retentionDays > 0 && now.Sub(w.lastCleanup) >= time.Hour {
+		w.lastCleanup = now
+		if err := w.eventRepo.DeleteOldEvents(w.retentionDays); err != nil {
+			w.logger.Warn("Failed to delete old log events", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// matchLogLines counts the lines matching re and returns up to max of them
+func matchLogLines(re *regexp.Regexp, lines []string, max int) (int, []string) {
+	count := 0
+	var matched []string
+	for _, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		count++
+		if len(matched) < max {
+			matched = append(matched, line)
+		}
+	}
+	return count, matched
+}
+
+// sanitizeLogLine truncates a line and makes it storable as text
+func sanitizeLogLine(line string) string {
+	if len(line) > logEventLineMax {
+		line = line[:logEventLineMax]
+	}
+	line = strings.ReplaceAll(line, "\x00", "")
+	return strings.ToValidUTF8(line, "�")
+}
+
+// logTailer reads the lines appended to a file. It keeps the file open so that the rest of a
+// file renamed by rotation is read before switching to the new file at the path, and starts
+// over when the file is truncated in place.
+type logTailer struct {
+	path      string
+	fromStart bool
+	opened    bool // 是否打开过文件，之后出现的文件都从开头读取
+	readLimit int
+	file      *os.File
+	offset    int64
+	partial   []byte
+}
+
+func newLogTailer(path string, fromStart bool) *logTailer {
+	return &logTailer{path: path, fromStart: fromStart, readLimit: logReadLimit}
+}
+
+// poll returns the complete lines written since the last poll
+func (t *logTailer) poll() ([]string, error) {
+	if t.file == nil {
+		if err := t.open(); err != nil {
+			return nil, err
+		}
+		if t.file == nil {
+			return nil, nil
+		}
+	}
+
+	lines, eof, err := t.read(nil)
+	if err != nil || !eof {
+		// 旧文件读完之前不检查轮转和截断，剩余内容下一轮继续读取
+		return lines, err
+	}
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// 文件已轮转走，等待新文件出现
+			return lines, nil
+		}
+		return lines, err
+	}
+	current, err := t.file.Stat()
+	if err != nil {
+		return lines, err
+	}
+
+	if !os.SameFile(info, current) {
+		// 文件已轮转，旧文件未换行结束的内容作为一行
+		if len(t.partial) > 0 {
+			lines = append(lines, string(t.partial))
+		}
+		t.close()
+		if err := t.open(); err != nil || t.file == nil {
+			return lines, err
+		}
+		lines, _, err = t.read(lines)
+		return lines, err
+	}
+	if info.Size() < t.offset {
+		// 文件被原地截断
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			return lines, err
+		}
+		t.offset = 0
+		t.partial = nil
+		lines, _, err = t.read(lines)
+		return lines, err
+	}
+	return lines, nil
+}
+
+// open opens the file at the path, leaving file nil when it does not exist. The first file
+// opened is read from its end unless fromStart is set, later files from the start.
+func (t *logTailer) open() error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			t.opened = true
+			return nil
+		}
+		return err
+	}
+
+	t.offset = 0
+	t.partial = nil
+	if !t.opened && !t.fromStart {
+		if t.offset, err = file.Seek(0, io.SeekEnd); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	t.opened = true
+	t.file = file
+	return nil
+}
+
+// read appends the complete lines read from the open file to lines, reporting whether the end
+// of the file was reached before the read limit
+func (t *logTailer) read(lines []string) ([]string, bool, error) {
+	buf := make([]byte, min(64<<10, t.readLimit))
+	for total := 0; total < t.readLimit; {
+		n, err := t.file.Read(buf[:min(len(buf), t.readLimit-total)])
+		if n > 0 {
+			total += n
+			t.offset += int64(n)
+			data := append(t.partial, buf[:n]...)
+			for {
+				i := bytes.IndexByte(data, '\n')
+				if i < 0 {
+					break
+				}
+				lines = append(lines, strings.TrimSuffix(string(data[:i]), "\r"))
+				data = data[i+1:]
+			}
+			if len(data) > logPartialLineMax {
+				lines = append(lines, string(data))
+				data = nil
+			}
+			t.partial = append([]byte(nil), data...)
+		}
+		if err == io.EOF {
+			return lines, true, nil
+		}
+		if err != nil {
+			return lines, false, err
+		}
+	}
+	return lines, false, nil
+}
+
+func (t *logTailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
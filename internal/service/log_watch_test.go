@@ -0,0 +1,205 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"monitor-server/internal/model"
+)
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pollLines(t *testing.T, tailer *logTailer, expected ...string) {
+	t.Helper()
+	lines, err := tailer.poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected) == 0 {
+		expected = nil
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected lines %q, got %q", expected, lines)
+	}
+}
+
+func TestLogTailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "old line\n")
+
+	tailer := newLogTailer(path, false)
+	defer tailer.close()
+
+	// existing content is skipped
+	pollLines(t, tailer)
+
+	appendFile(t, path, "first\nsecond\r\npart")
+	pollLines(t, tailer, "first", "second")
+	appendFile(t, path, "ial\n")
+	pollLines(t, tailer, "partial")
+
+	// rotation by rename: the rest of the old file is read, then the new file from its start
+	appendFile(t, path, "before rotate\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path+".1", "late write\n")
+	appendFile(t, path, "after rotate\n")
+	pollLines(t, tailer, "before rotate", "late write", "after rotate")
+
+	// truncation in place
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "truncated\n")
+	pollLines(t, tailer, "truncated")
+
+	// file removed and created again
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	pollLines(t, tailer)
+	appendFile(t, path, "recreated\n")
+	pollLines(t, tailer, "recreated")
+}
+
+func TestLogTailerRotationAtReadLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	tailer := newLogTailer(path, true)
+	tailer.readLimit = 16
+	defer tailer.close()
+
+	appendFile(t, path, "start\n")
+	pollLines(t, tailer, "start")
+
+	// the rotated file holds more than a round reads, the new file is only read once it is done
+	appendFile(t, path, "line one\nline two\nline three\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "new\n")
+	pollLines(t, tailer, "line one")
+	pollLines(t, tailer, "line two", "line three", "new")
+}
+
+func TestLogTailerMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kern.log")
+	tailer := newLogTailer(path, false)
+	defer tailer.close()
+
+	pollLines(t, tailer)
+	// a file appearing later is read from its start
+	appendFile(t, path, "Out of memory: Killed process 1234\n")
+	pollLines(t, tailer, "Out of memory: Killed process 1234")
+}
+
+func TestLogTailerFromStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "one\ntwo\n")
+
+	tailer := newLogTailer(path, true)
+	defer tailer.close()
+	pollLines(t, tailer, "one", "two")
+}
+
+func TestMatchLogLines(t *testing.T) {
+	re := regexp.MustCompile(`FATAL|Out of memory`)
+	lines := []string{"INFO start", "FATAL one", "FATAL two", "Out of memory", "WARN x", "FATAL three"}
+
+	count, matched := matchLogLines(re, lines, 2)
+	if count != 4 {
+		t.Errorf("expected 4 matches, got %d", count)
+	}
+	if !reflect.DeepEqual(matched, []string{"FATAL one", "FATAL two"}) {
+		t.Errorf("unexpected matched lines: %q", matched)
+	}
+
+	line := sanitizeLogLine("bad\x00\xffbyte" + strings.Repeat("x", 2*logEventLineMax))
+	if len(line) > logEventLineMax+3 || strings.Contains(line, "\x00") || !strings.HasPrefix(line, "bad�byte") {
+		t.Errorf("unexpected sanitized line %q", line[:20])
+	}
+}
+
+func TestLogWatchLabels(t *testing.T) {
+	// watch names come from config keys and may hold the label separators
+	for _, name := range []string{"nginx", "api,errors", "level=error", `C:\logs\`, `a\,b=`} {
+		labels := model.FormatLabels(map[string]string{"watch": name})
+		if parsed := model.ParseLabels(labels); !reflect.DeepEqual(parsed, map[string]string{"watch": name}) {
+			t.Errorf("%q: labels %q parsed as %q", name, labels, parsed)
+		}
+	}
+	// labels written before escaping still parse
+	for labels, expected := range map[string]map[string]string{
+		`mountpoint=C:\`: {"mountpoint": `C:\`},
+		`flag,expr=a=b`:  {"flag": "", "expr": "a=b"},
+	} {
+		if parsed := model.ParseLabels(labels); !reflect.DeepEqual(parsed, expected) {
+			t.Errorf("%q: expected %q, got %q", labels, expected, parsed)
+		}
+	}
+}
+
+func TestParseLogWatchConfig(t *testing.T) {
+	cfg, re, err := ParseLogWatchConfig(`{"path": "/var/log/../log/kern.log", "pattern": "Out of memory"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Path != "/var/log/kern.log" || cfg.FromStart || !re.MatchString("kernel: Out of memory: Kill") {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	for _, value := range []string{
+		`not json`,
+		`{"path": "kern.log", "pattern": "x"}`,
+		`{"path": "/var/log/kern.log"}`,
+		`{"path": "/var/log/kern.log", "pattern": "("}`,
+	} {
+		if _, _, err := ParseLogWatchConfig(value); err == nil {
+			t.Errorf("%s: expected error", value)
+		}
+	}
+}
+
+func TestLogWatchKeys(t *testing.T) {
+	if name, ok := LogWatchName("logwatch:kernel"); !ok || name != "kernel" {
+		t.Errorf("expected watch kernel, got %q %v", name, ok)
+	}
+	for _, key := range []string{"kernel", "logwatch:", "system_metrics"} {
+		if _, ok := LogWatchName(key); ok {
+			t.Errorf("%s: expected no watch name", key)
+		}
+	}
+
+	tests := []struct {
+		category string
+		key      string
+		valid    bool
+	}{
+		{model.HostConfigCategoryLogWatch, "logwatch:system_metrics", true},
+		// 与采集器同名的监视不能使用未加前缀的键
+		{model.HostConfigCategoryLogWatch, "system_metrics", false},
+		{model.HostConfigCategoryLogWatch, "logwatch:", false},
+		{model.HostConfigCategoryCollector, "system_metrics", true},
+		{model.HostConfigCategoryCollector, "logwatch:system_metrics", false},
+		{"monitoring", "cpu_threshold", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateHostConfigKey(tt.category, tt.key); (err == nil) != tt.valid {
+			t.Errorf("%s %s: expected valid %v, got %v", tt.category, tt.key, tt.valid, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// 持久化到 metric_samples 的指标名称
+const (
+	SampleDiskUsagePercent   = "disk_usage_percent"   // 标签: mountpoint
+	SampleMemoryUsagePercent = "memory_usage_percent" // 无标签
+)
+
+// bytesPerGB 与 MonitorService 返回的 GB 数值互相换算
+const bytesPerGB = 1024 * 1024 * 1024
+
+// MetricsRecorder keeps the local host marked as seen and deletes expired metrics and samples.
+// The system metrics themselves are stored by its collector, and labeled samples by the
+// collectors of MonitorService, both run by CollectorScheduler.
+type MetricsRecorder interface {
+	Start()
+	Stop()
+	RecordOnce(ctx context.Context) error
+	Collector() Collector
+}
+
+// metricsRecorder implements MetricsRecorder interface
+type metricsRecorder struct {
+	monitorService MonitorService
+	metricsRepo    repository.MetricsRepository
+	sampleRepo     repository.SampleRepository
+	hostRepo       repository.HostRepository
+	hostname       string
+	interval       time.Duration
+	retentionDays  int
+	lastCleanup    time.Time
+	logger         *logger.Logger
+	stop           chan struct{}
+	running        sync.WaitGroup // the background loop, waited for by Stop
+}
+
+// NewMetricsRecorder creates a new metrics recorder instance
+func NewMetricsRecorder(monitorService MonitorService, db *gorm.DB, cfg config.MonitorConfig, logger *logger.Logger) MetricsRecorder {
+	interval := time.Duration(cfg.RecordInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &metricsRecorder{
+		monitorService: monitorService,
+		metricsRepo:    repository.NewMetricsRepository(db),
+		sampleRepo:     repository.NewSampleRepository(db),
+		hostRepo:       repository.NewHostRepository(db),
+		hostname:       cfg.Hostname,
+		interval:       interval,
+		retentionDays:  cfg.RetentionDays,
+		logger:         logger,
+		stop:           make(chan struct{}),
+	}
+}
+
+// Start starts updating the last seen time and deleting expired history in background
+func (r *metricsRecorder) Start() {
+	r.running.Add(1)
+	go func() {
+		defer r.running.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.RecordOnce(context.Background()); err != nil {
+					r.logger.Error("Failed to record host heartbeat", "error", err)
+				}
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background loop and waits for the running round to finish
+func (r *metricsRecorder) Stop() {
+	close(r.stop)
+	r.running.Wait()
+}
+
+// RecordOnce updates the last seen time of the local host and deletes expired history once an hour
+func (r *metricsRecorder) RecordOnce(ctx context.Context) error {
+	now := time.Now()
+
+	if err := r.hostRepo.UpdateLastSeen(r.hostname); err != nil {
+		r.logger.Warn("Failed to update host last seen", "hostname", r.hostname, "error", err)
+	}
+
+	// 每小时清理一次过期数据
+	if r.retentionDays > 0 && now.Sub(r.lastCleanup) >= time.Hour {
+		r.lastCleanup = now
+		if err := r.metricsRepo.DeleteOldRecords(r.retentionDays); err != nil {
+			r.logger.Warn("Failed to delete old metrics", "error", err)
+		}
+		if err := r.sampleRepo.DeleteOldSamples(r.retentionDays); err != nil {
+			r.logger.Warn("Failed to delete old samples", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// Collector returns the collector that stores the CPU, memory, disk and network usage of the
+// local machine in system_metrics, running every record interval by default
+func (r *metricsRecorder) Collector() Collector {
+	return NewCollector("system_metrics", r.interval, r.collectSystemMetrics)
+}
+
+// collectSystemMetrics stores the current system metrics of the local machine. The row goes to
+// system_metrics rather than metric_samples, so no samples are returned.
+func (r *metricsRecorder) collectSystemMetrics(ctx context.Context) ([]model.MetricSample, error) {
+	now := time.Now()
+
+	cpuData, err := r.monitorService.GetCPUData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	memoryData, err := r.monitorService.GetMemoryData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	diskData, err := r.monitorService.GetDiskData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	networkData, err := r.monitorService.GetNetworkData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var diskUsage float64
+	if diskData.TotalCapacity > 0 {
+		diskUsage = diskData.TotalUsed / diskData.TotalCapacity * 100
+	}
+
+	metric := &model.SystemMetrics{
+		Hostname:    r.hostname,
+		CPUUsage:    cpuData.Usage,
+		MemoryUsage: memoryData.UsagePercent,
+		MemoryTotal: uint64(memoryData.Total * bytesPerGB),
+		MemoryUsed:  uint64(memoryData.Used * bytesPerGB),
+		DiskUsage:   diskUsage,
+		DiskTotal:   uint64(diskData.TotalCapacity * bytesPerGB),
+		DiskUsed:    uint64(diskData.TotalUsed * bytesPerGB),
+		NetworkSent: networkData.TotalBytesSent,
+		NetworkRecv: networkData.TotalBytesRecv,
+		Timestamp:   now,
+	}
+	return nil, r.metricsRepo.Create(metric)
+}
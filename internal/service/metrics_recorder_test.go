@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// memoryMetricsRepo keeps created system metrics in memory
+type memoryMetricsRepo struct {
+	repository.MetricsRepository
+	metrics []model.SystemMetrics
+}
+
+func (r *memoryMetricsRepo) Create(metric *model.SystemMetrics) error {
+	r.metrics = append(r.metrics, *metric)
+	return nil
+}
+
+func TestSystemMetricsCollector(t *testing.T) {
+	s, source := newFixtureMonitorService(t, webServerFilter)
+	metricsRepo := &memoryMetricsRepo{}
+	recorder := &metricsRecorder{
+		monitorService: s,
+		metricsRepo:    metricsRepo,
+		hostname:       "web-1",
+		interval:       30 * time.Second,
+		logger:         logger.New(config.LogConfig{Level: "error", Format: "text"}),
+	}
+
+	scheduler, sampleRepo := newTestCollectorScheduler(t, config.CollectorConfig{}, nil, recorder.Collector())
+	scheduler.RunOnce(source.Now())
+	status := scheduler.Status()[0]
+	if status.Name != "system_metrics" || status.Interval != 30 || status.LastError != "" {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if len(metricsRepo.metrics) != 1 || len(sampleRepo.samples) != 0 {
+		t.Fatalf("expected a single system metrics row and no samples, got %d rows and %d samples", len(metricsRepo.metrics), len(sampleRepo.samples))
+	}
+	if metric := metricsRepo.metrics[0]; metric.Hostname != "web-1" || metric.MemoryUsage != 50 || metric.DiskTotal == 0 {
+		t.Errorf("unexpected system metrics %+v", metric)
+	}
+
+	// 与其他采集器一样可以按主机禁用
+	hostConfigs := []model.HostConfig{
+		{HostID: 1, Category: model.HostConfigCategoryCollector, Key: "system_metrics", Value: `{"enabled": false}`},
+	}
+	scheduler, _ = newTestCollectorScheduler(t, config.CollectorConfig{}, hostConfigs, recorder.Collector())
+	scheduler.RunOnce(source.Now().Add(time.Minute))
+	if len(metricsRepo.metrics) != 1 {
+		t.Errorf("expected no rows from the disabled collector, got %d", len(metricsRepo.metrics)-1)
+	}
+}
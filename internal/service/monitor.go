@@ -3,21 +3,13 @@ package service
 import (
 	"context"
 	"fmt"
-	"runtime"
-	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"monitor-server/internal/model"
 
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/disk"
-	"github.com/shirou/gopsutil/v3/host"
-	"github.com/shirou/gopsutil/v3/load"
-	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/net"
-	"github.com/shirou/gopsutil/v3/process"
 )
 
 // MonitorService defines the interface for system monitoring operations
@@ -27,9 +19,13 @@ type MonitorService interface {
 	GetDiskData(ctx context.Context) (*model.DiskData, error)
 	GetNetworkData(ctx context.Context) (*model.NetworkData, error)
 	GetSystemInfo(ctx context.Context) (*model.SystemInfo, error)
-	GetProcessData(ctx context.Context, limit int, sortBy string) (*model.ProcessData, error)
-	StartHistoryCollection(ctx context.Context)
-	StopHistoryCollection()
+	GetProcessData(ctx context.Context, query model.ProcessQuery) (*model.ProcessData, error)
+	GetProcessDetail(ctx context.Context, pid int32) (*model.ProcessDetail, error)
+	GetProcessTree(ctx context.Context, rootPID int32, collapse bool) (*model.ProcessTree, error)
+	GetConnectionData(ctx context.Context, top int) (*model.ConnectionData, error)
+	GetSensorData(ctx context.Context) (*model.SensorData, error)
+	PreviewCollectFilter(ctx context.Context, host *model.CollectFilter) (*model.CollectFilterPreview, error)
+	Collectors(sampleInterval time.Duration) []Collector
 }
 
 // monitorService implements MonitorService interface
@@ -39,115 +35,142 @@ type monitorService struct {
 	memoryHistory   []model.MemoryUsage
 	networkHistory  []model.NetworkUsage
 	maxHistorySize  int
-	collectTicker   *time.Ticker
-	stopCollection  chan struct{}
+
+	netMu       sync.Mutex
+	lastNetAt   time.Time // time of the previous network counter reading, used for rates
+	lastNetSent uint64
+	lastNetRecv uint64
+
+	procCPU processCPUSampler // CPU times of recent process listings, reused as the sampling baseline
+
+	filters CollectFilterProvider // mounts and interfaces to collect, nil collects everything
+	source  SystemSource          // system data and clock
 }
 
-// NewMonitorService creates a new monitor service instance
-func NewMonitorService() MonitorService {
-	service := &monitorService{
+// NewMonitorService creates a new monitor service instance reading the live system.
+// History is filled by the collectors returned from Collectors.
+func NewMonitorService(filters CollectFilterProvider) MonitorService {
+	return NewMonitorServiceWithSource(NewSystemSource(), filters)
+}
+
+// NewMonitorServiceWithSource creates a monitor service reading system data from source,
+// such as a FixtureSource replaying recorded snapshots
+func NewMonitorServiceWithSource(source SystemSource, filters CollectFilterProvider) MonitorService {
+	return &monitorService{
 		maxHistorySize: 20,
 		cpuHistory:     make([]model.CpuUsage, 0, 20),
 		memoryHistory:  make([]model.MemoryUsage, 0, 20),
 		networkHistory: make([]model.NetworkUsage, 0, 20),
-		stopCollection: make(chan struct{}),
+		filters:        filters,
+		source:         source,
 	}
-	
-	// Start background data collection
-	go service.startBackgroundCollection()
-	
-	return service
 }
 
-// startBackgroundCollection starts collecting historical data in background
-func (s *monitorService) startBackgroundCollection() {
-	s.collectTicker = time.NewTicker(5 * time.Second)
-	
-	go func() {
-		for {
-			select {
-			case <-s.collectTicker.C:
-				s.collectHistoricalData()
-			case <-s.stopCollection:
-				return
-			}
-		}
-	}()
+// collectCPUHistory appends the current CPU usage to the CPU history
+func (s *monitorService) collectCPUHistory(ctx context.Context) error {
+	usage, err := s.source.CPUPercent(ctx, time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to get CPU usage: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cpuHistory = append(s.cpuHistory, model.CpuUsage{
+		Timestamp: s.source.Now(),
+		Usage:     usage,
+	})
+	if len(s.cpuHistory) > s.maxHistorySize {
+		s.cpuHistory = s.cpuHistory[1:]
+	}
+	return nil
 }
 
-// collectHistoricalData collects current metrics for historical tracking
-func (s *monitorService) collectHistoricalData() {
-	now := time.Now()
-	
-	// Collect CPU data
-	cpuPercents, err := cpu.Percent(time.Second, false)
-	if err == nil && len(cpuPercents) > 0 {
-		s.mu.Lock()
-		s.cpuHistory = append(s.cpuHistory, model.CpuUsage{
-			Timestamp: now,
-			Usage:     cpuPercents[0],
-		})
-		if len(s.cpuHistory) > s.maxHistorySize {
-			s.cpuHistory = s.cpuHistory[1:]
-		}
-		s.mu.Unlock()
+// collectMemoryHistory appends the current memory usage to the memory history
+func (s *monitorService) collectMemoryHistory(ctx context.Context) error {
+	vmStat, err := s.source.VirtualMemory(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get memory info: %w", err)
 	}
-	
-	// Collect Memory data
-	vmStat, err := mem.VirtualMemory()
-	if err == nil {
-		used := float64(vmStat.Used) / (1024 * 1024 * 1024)
-		s.mu.Lock()
-		s.memoryHistory = append(s.memoryHistory, model.MemoryUsage{
-			Timestamp:    now,
-			UsagePercent: vmStat.UsedPercent,
-			Used:         used,
-		})
-		if len(s.memoryHistory) > s.maxHistorySize {
-			s.memoryHistory = s.memoryHistory[1:]
-		}
-		s.mu.Unlock()
+
+	used := float64(vmStat.Used) / (1024 * 1024 * 1024)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.memoryHistory = append(s.memoryHistory, model.MemoryUsage{
+		Timestamp:    s.source.Now(),
+		UsagePercent: vmStat.UsedPercent,
+		Used:         used,
+	})
+	if len(s.memoryHistory) > s.maxHistorySize {
+		s.memoryHistory = s.memoryHistory[1:]
 	}
-	
-	// Collect Network data (calculate per-second rates)
-	netStats, err := net.IOCounters(false)
-	if err == nil && len(netStats) > 0 {
-		s.mu.Lock()
-		// For simplicity, we'll use the total network stats
-		// In a real implementation, you'd want to calculate the delta from previous readings
-		s.networkHistory = append(s.networkHistory, model.NetworkUsage{
-			Timestamp:       now,
-			BytesSentPerSec: netStats[0].BytesSent / 5, // Rough approximation
-			BytesRecvPerSec: netStats[0].BytesRecv / 5, // Rough approximation
-		})
-		if len(s.networkHistory) > s.maxHistorySize {
-			s.networkHistory = s.networkHistory[1:]
+	return nil
+}
+
+// collectNetworkHistory appends the per-second traffic of the interfaces included by the
+// collect filter since the previous call to the network history. The first call only
+// records the counters.
+func (s *monitorService) collectNetworkHistory(ctx context.Context) error {
+	netStats, err := s.source.NetIOCounters(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get network stats: %w", err)
+	}
+	filter, err := s.collectFilter()
+	if err != nil {
+		return err
+	}
+	now := s.source.Now()
+
+	var bytesSent, bytesRecv uint64
+	for _, stat := range netStats {
+		if ok, _ := filter.MatchInterface(stat.Name); ok {
+			bytesSent += stat.BytesSent
+			bytesRecv += stat.BytesRecv
 		}
-		s.mu.Unlock()
 	}
+
+	s.netMu.Lock()
+	lastAt, lastSent, lastRecv := s.lastNetAt, s.lastNetSent, s.lastNetRecv
+	s.lastNetAt, s.lastNetSent, s.lastNetRecv = now, bytesSent, bytesRecv
+	s.netMu.Unlock()
+
+	// Counters go backwards when interfaces disappear or the filter changes
+	elapsed := now.Sub(lastAt).Seconds()
+	if lastAt.IsZero() || elapsed <= 0 || bytesSent < lastSent || bytesRecv < lastRecv {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.networkHistory = append(s.networkHistory, model.NetworkUsage{
+		Timestamp:       now,
+		BytesSentPerSec: uint64(float64(bytesSent-lastSent) / elapsed),
+		BytesRecvPerSec: uint64(float64(bytesRecv-lastRecv) / elapsed),
+	})
+	if len(s.networkHistory) > s.maxHistorySize {
+		s.networkHistory = s.networkHistory[1:]
+	}
+	return nil
 }
 
 // GetCPUData retrieves current CPU monitoring data
 func (s *monitorService) GetCPUData(ctx context.Context) (*model.CpuData, error) {
 	// Get CPU usage percentage
-	cpuPercents, err := cpu.Percent(time.Second, false)
+	usage, err := s.source.CPUPercent(ctx, time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CPU usage: %w", err)
 	}
 	
-	var usage float64
-	if len(cpuPercents) > 0 {
-		usage = cpuPercents[0]
-	}
-	
 	// Get CPU info
-	cpuInfos, err := cpu.Info()
+	cpuInfos, err := s.source.CPUInfo(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CPU info: %w", err)
 	}
 	
 	// Get logical CPU count (includes hyperthreading)
-	cores := int32(runtime.NumCPU())
+	cores, err := s.source.CPUCount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CPU count: %w", err)
+	}
 	
 	var frequency float64
 	var cpuModel string
@@ -159,14 +182,8 @@ func (s *monitorService) GetCPUData(ctx context.Context) (*model.CpuData, error)
 	
 	// Get temperature (may not be available on all systems)
 	var temperature *float64
-	temps, err := host.SensorsTemperatures()
-	if err == nil && len(temps) > 0 {
-		for _, temp := range temps {
-			if temp.SensorKey == "coretemp" || temp.SensorKey == "k10temp" {
-				temperature = &temp.Temperature
-				break
-			}
-		}
+	if sensors, err := s.GetSensorData(ctx); err == nil {
+		temperature = cpuTemperature(sensors.Chips)
 	}
 	
 	s.mu.RLock()
@@ -176,7 +193,7 @@ func (s *monitorService) GetCPUData(ctx context.Context) (*model.CpuData, error)
 	
 	return &model.CpuData{
 		Usage:       usage,
-		Cores:       int(cores),
+		Cores:       cores,
 		Frequency:   frequency,
 		Temperature: temperature,
 		Model:       cpuModel,
@@ -187,13 +204,13 @@ func (s *monitorService) GetCPUData(ctx context.Context) (*model.CpuData, error)
 // GetMemoryData retrieves current memory monitoring data
 func (s *monitorService) GetMemoryData(ctx context.Context) (*model.MemoryData, error) {
 	// Get virtual memory stats
-	vmStat, err := mem.VirtualMemory()
+	vmStat, err := s.source.VirtualMemory(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get memory stats: %w", err)
 	}
 	
 	// Get swap memory stats
-	swapStat, err := mem.SwapMemory()
+	swapStat, err := s.source.SwapMemory(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get swap stats: %w", err)
 	}
@@ -226,40 +243,32 @@ func (s *monitorService) GetMemoryData(ctx context.Context) (*model.MemoryData,
 // GetDiskData retrieves current disk monitoring data
 func (s *monitorService) GetDiskData(ctx context.Context) (*model.DiskData, error) {
 	// Get disk partitions
-	partitions, err := disk.Partitions(false)
+	partitions, err := s.source.Partitions(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get disk partitions: %w", err)
 	}
+	filter, err := s.collectFilter()
+	if err != nil {
+		return nil, err
+	}
 	
 	var disks []model.DiskInfo
 	var totalCapacity, totalUsed, totalFree float64
+
+	// Without fstab (e.g. in containers) unexpected read-only mounts are not detected
+	fstab, _ := s.source.Fstab(ctx)
 	
 	for _, partition := range partitions {
-		// Skip special filesystems and virtual mounts
-		if partition.Fstype == "tmpfs" || partition.Fstype == "devtmpfs" || 
-		   partition.Fstype == "sysfs" || partition.Fstype == "proc" ||
-		   partition.Fstype == "squashfs" || partition.Fstype == "overlay" ||
-		   partition.Fstype == "none" || partition.Fstype == "rootfs" ||
-		   partition.Fstype == "9p" { // WSL2 Windows drives
+		// Skip virtual filesystems and mounts excluded by the collect filter
+		if ok, _ := filter.MatchMount(partition.Device, partition.Mountpoint, partition.Fstype); !ok {
 			continue
 		}
 		
-		// Skip WSL2 and other virtual mounts by path
-		if strings.HasPrefix(partition.Mountpoint, "/mnt/wsl") ||
-		   strings.HasPrefix(partition.Mountpoint, "/usr/lib/wsl") ||
-		   strings.HasPrefix(partition.Mountpoint, "/usr/lib/modules") ||
-		   strings.HasPrefix(partition.Mountpoint, "/mnt/c") ||
-		   strings.HasPrefix(partition.Mountpoint, "/mnt/d") ||
-		   strings.HasPrefix(partition.Mountpoint, "/run") ||
-		   strings.HasPrefix(partition.Mountpoint, "/init") ||
-		   strings.HasPrefix(partition.Mountpoint, "/mnt/wslg") {
-			continue
-		}
-		
-		usage, err := disk.Usage(partition.Mountpoint)
+		usage, err := s.source.DiskUsage(ctx, partition.Mountpoint)
 		if err != nil {
 			continue // Skip if we can't get usage stats
 		}
+		readOnly := isReadOnlyMount(partition.Opts)
 		
 		// Convert bytes to GB
 		total := float64(usage.Total) / (1024 * 1024 * 1024)
@@ -274,6 +283,14 @@ func (s *monitorService) GetDiskData(ctx context.Context) (*model.DiskData, erro
 			Used:         used,
 			Free:         free,
 			UsagePercent: usage.UsedPercent,
+
+			InodesTotal:       usage.InodesTotal,
+			InodesUsed:        usage.InodesUsed,
+			InodesFree:        usage.InodesFree,
+			InodesUsedPercent: usage.InodesUsedPercent,
+
+			ReadOnly:           readOnly,
+			UnexpectedReadOnly: unexpectedReadOnly(partition.Mountpoint, readOnly, fstab),
 		}
 		
 		disks = append(disks, diskInfo)
@@ -282,24 +299,29 @@ func (s *monitorService) GetDiskData(ctx context.Context) (*model.DiskData, erro
 		totalFree += free
 	}
 	
-	return &model.DiskData{
+	diskData := &model.DiskData{
 		Disks:         disks,
 		TotalCapacity: totalCapacity,
 		TotalUsed:     totalUsed,
 		TotalFree:     totalFree,
-	}, nil
+	}
+	// file-nr only exists on Linux
+	if fds, err := s.source.FileHandles(ctx); err == nil {
+		diskData.FileDescriptors = fds
+	}
+	return diskData, nil
 }
 
 // GetNetworkData retrieves current network monitoring data
 func (s *monitorService) GetNetworkData(ctx context.Context) (*model.NetworkData, error) {
 	// Get network interface stats
-	netStats, err := net.IOCounters(true)
+	netStats, err := s.source.NetIOCounters(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get network stats: %w", err)
 	}
 	
 	// Get network interface info
-	netInterfaces, err := net.Interfaces()
+	netInterfaces, err := s.source.NetInterfaces(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get network interfaces: %w", err)
 	}
@@ -310,10 +332,19 @@ func (s *monitorService) GetNetworkData(ctx context.Context) (*model.NetworkData
 		interfaceMap[iface.Name] = iface
 	}
 	
+	filter, err := s.collectFilter()
+	if err != nil {
+		return nil, err
+	}
+	
 	var interfaces []model.NetworkInterface
 	var totalBytesSent, totalBytesRecv uint64
 	
 	for _, stat := range netStats {
+		if ok, _ := filter.MatchInterface(stat.Name); !ok {
+			continue
+		}
+		
 		// Skip loopback and down interfaces for main stats
 		ifaceInfo, exists := interfaceMap[stat.Name]
 		isUp := exists && len(ifaceInfo.Flags) > 0 && ifaceInfo.Flags[0] == "up"
@@ -325,9 +356,9 @@ func (s *monitorService) GetNetworkData(ctx context.Context) (*model.NetworkData
 			switch {
 			case stat.Name == "lo":
 				speed = 0 // Loopback
-			case stat.Name[:3] == "eth" || stat.Name[:2] == "en":
+			case strings.HasPrefix(stat.Name, "eth") || strings.HasPrefix(stat.Name, "en"):
 				speed = 1000 // Assume 1Gbps for ethernet
-			case stat.Name[:4] == "wlan" || stat.Name[:2] == "wl":
+			case strings.HasPrefix(stat.Name, "wl"):
 				speed = 300 // Assume 300Mbps for wireless
 			default:
 				speed = 100 // Default speed
@@ -365,19 +396,19 @@ func (s *monitorService) GetNetworkData(ctx context.Context) (*model.NetworkData
 // GetSystemInfo retrieves system information
 func (s *monitorService) GetSystemInfo(ctx context.Context) (*model.SystemInfo, error) {
 	// Get host info
-	hostInfo, err := host.Info()
+	hostInfo, err := s.source.HostInfo(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get host info: %w", err)
 	}
 	
 	// Get load average
-	loadAvg, err := load.Avg()
+	loadAvg, err := s.source.LoadAvg(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get load average: %w", err)
 	}
 	
 	// Get process count
-	processes, err := process.Pids()
+	processes, err := s.source.Pids(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get process count: %w", err)
 	}
@@ -393,121 +424,3 @@ func (s *monitorService) GetSystemInfo(ctx context.Context) (*model.SystemInfo,
 		LoadAverage: []float64{loadAvg.Load1, loadAvg.Load5, loadAvg.Load15},
 	}, nil
 }
-
-// GetProcessData retrieves process monitoring data
-func (s *monitorService) GetProcessData(ctx context.Context, limit int, sortBy string) (*model.ProcessData, error) {
-	// Get all process PIDs
-	pids, err := process.Pids()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get process PIDs: %w", err)
-	}
-	
-	var processes []model.ProcessInfo
-	runningCount := 0
-	sleepingCount := 0
-	
-	// Get process information for each PID
-	for _, pid := range pids {
-		proc, err := process.NewProcess(pid)
-		if err != nil {
-			continue // Process might have terminated
-		}
-		
-		name, err := proc.Name()
-		if err != nil {
-			continue
-		}
-		
-		cpuPercent, err := proc.CPUPercent()
-		if err != nil {
-			cpuPercent = 0
-		}
-		
-		memoryInfo, err := proc.MemoryInfo()
-		if err != nil {
-			continue
-		}
-		
-		memoryPercent, err := proc.MemoryPercent()
-		if err != nil {
-			memoryPercent = 0
-		}
-		
-		statusList, err := proc.Status()
-		var status string
-		if err != nil || len(statusList) == 0 {
-			status = "unknown"
-		} else {
-			status = statusList[0]
-		}
-		
-		createTime, err := proc.CreateTime()
-		if err != nil {
-			createTime = 0
-		}
-		
-		cmdline, err := proc.Cmdline()
-		if err != nil {
-			cmdline = ""
-		}
-		
-		// Count process status
-		switch status {
-		case "R", "running":
-			runningCount++
-		case "S", "sleeping":
-			sleepingCount++
-		}
-		
-		processInfo := model.ProcessInfo{
-			PID:           pid,
-			Name:          name,
-			CPUPercent:    cpuPercent,
-			MemoryPercent: memoryPercent,
-			MemoryMB:      float64(memoryInfo.RSS) / (1024 * 1024), // Convert to MB
-			Status:        status,
-			CreateTime:    time.Unix(createTime/1000, 0), // Convert from milliseconds
-			Cmdline:       cmdline,
-		}
-		
-		processes = append(processes, processInfo)
-	}
-	
-	// Sort processes based on sortBy parameter
-	switch sortBy {
-	case "cpu":
-		sort.Slice(processes, func(i, j int) bool {
-			return processes[i].CPUPercent > processes[j].CPUPercent
-		})
-	case "memory":
-		sort.Slice(processes, func(i, j int) bool {
-			return processes[i].MemoryPercent > processes[j].MemoryPercent
-		})
-	}
-	
-	// Limit the results
-	if limit > 0 && limit < len(processes) {
-		processes = processes[:limit]
-	}
-	
-	return &model.ProcessData{
-		Processes:         processes,
-		TotalProcesses:    len(pids),
-		RunningProcesses:  runningCount,
-		SleepingProcesses: sleepingCount,
-	}, nil
-}
-
-// StartHistoryCollection starts collecting historical data in background
-func (s *monitorService) StartHistoryCollection(ctx context.Context) {
-	// Background collection is already started in NewMonitorService
-	// This method is kept for interface compatibility
-}
-
-// StopHistoryCollection stops the historical data collection
-func (s *monitorService) StopHistoryCollection() {
-	if s.collectTicker != nil {
-		s.collectTicker.Stop()
-	}
-	close(s.stopCollection)
-}
\ No newline at end of file
@@ -0,0 +1,661 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+)
+
+// staticCollectFilters always returns the same filter
+type staticCollectFilters struct {
+	filter *CollectFilter
+}
+
+func (p staticCollectFilters) Filter() (*CollectFilter, error) { return p.filter, nil }
+
+func (p staticCollectFilters) Resolve(host model.CollectFilter) (*CollectFilter, error) {
+	return NewCollectFilter(MergeCollectFilter(p.filter.Rules(), host))
+}
+
+// newFixtureMonitorService creates a monitor service replaying testdata/snapshots/web-01.json,
+// a web server recorded three times 5 seconds apart. The snapshots record no hwmon sensors, so
+// the temperatures come from the recorded gopsutil temperatures.
+func newFixtureMonitorService(t *testing.T, rules *model.CollectFilter) (*monitorService, *FixtureSource) {
+	t.Helper()
+	source, err := LoadFixtureSource(filepath.Join("testdata", "snapshots", "web-01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var filters CollectFilterProvider
+	if rules != nil {
+		filter, err := NewCollectFilter(*rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filters = staticCollectFilters{filter}
+	}
+	return NewMonitorServiceWithSource(source, filters).(*monitorService), source
+}
+
+// webServerFilter excludes the virtual filesystems and interfaces of the fixture host
+var webServerFilter = &model.CollectFilter{
+	ExcludeFSTypes:     []string{"tmpfs", "squashfs", "overlay"},
+	ExcludeMountpoints: []string{"/var/lib/docker/**"},
+	ExcludeInterfaces:  []string{"^veth", "^docker"},
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestFixtureSource(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	source, err := NewFixtureSource(
+		SystemSnapshot{Timestamp: start, Processes: []ProcessSnapshot{{PID: 10, Name: "job", CPUUser: 1}}},
+		SystemSnapshot{Timestamp: start.Add(5 * time.Second)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proc, err := source.Process(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if times, err := proc.Times(ctx); err != nil || times.User != 1 {
+		t.Errorf("expected recorded CPU times, got %+v %v", times, err)
+	}
+	if _, err := source.Process(ctx, 11); err != ErrProcessNotFound {
+		t.Errorf("expected ErrProcessNotFound, got %v", err)
+	}
+	if _, err := source.VirtualMemory(ctx); err == nil {
+		t.Error("expected error for memory missing from snapshot")
+	}
+	if _, err := source.HwmonSensors(ctx); err == nil {
+		t.Error("expected error for hwmon sensors missing from snapshot")
+	}
+	if _, err := source.FileHandles(ctx); err == nil {
+		t.Error("expected error for file handles missing from snapshot")
+	}
+	if _, err := source.Fstab(ctx); err == nil {
+		t.Error("expected error for fstab missing from snapshot")
+	}
+
+	// Sleep replays the next snapshot, where the process has exited
+	if err := source.Sleep(ctx, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !source.Now().Equal(start.Add(5 * time.Second)) {
+		t.Errorf("expected second snapshot, got %v", source.Now())
+	}
+	if _, err := proc.Name(ctx); err != ErrProcessNotFound {
+		t.Errorf("expected exited process, got %v", err)
+	}
+
+	// time stands still on the last snapshot
+	if source.Advance() {
+		t.Error("expected no snapshot after the last one")
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := source.Sleep(canceled, time.Second); err == nil {
+		t.Error("expected error for canceled context")
+	}
+
+	if _, err := NewFixtureSource(); err == nil {
+		t.Error("expected error without snapshots")
+	}
+	if _, err := LoadFixtureSource(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing fixture")
+	}
+}
+
+func TestMonitorServiceCPUData(t *testing.T) {
+	s, _ := newFixtureMonitorService(t, nil)
+	data, err := s.GetCPUData(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Usage != 12.5 || data.Cores != 12 || data.Frequency != 3400 || data.Model != "Intel(R) Xeon(R) E-2236 CPU @ 3.40GHz" {
+		t.Errorf("unexpected CPU data %+v", data)
+	}
+	if data.Temperature == nil || *data.Temperature != 55 {
+		t.Errorf("expected package temperature 55, got %v", data.Temperature)
+	}
+}
+
+func TestMonitorServiceSensorDataFromHwmon(t *testing.T) {
+	ctx := context.Background()
+	s, source := newFixtureMonitorService(t, nil)
+	data, err := s.GetSensorData(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Chips) == 0 || data.Chips[0].Sensors[0].Value != 55 {
+		t.Fatalf("expected chips from the recorded temperatures, got %+v", data.Chips)
+	}
+
+	// recorded hwmon chips take precedence over the temperatures
+	source.snapshot().HwmonSensors = []model.SensorChip{{
+		ID:   "coretemp-coretemp.0",
+		Name: "coretemp",
+		Sensors: []model.SensorReading{
+			{Key: "temp1", Type: model.SensorTypeTemperature, Value: 71, Status: model.SensorStatusNormal},
+			{Key: "temp2", Type: model.SensorTypeTemperature, Value: 68, Status: model.SensorStatusNormal},
+		},
+	}}
+	if data, err = s.GetSensorData(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Chips) != 1 || data.Chips[0].ID != "coretemp-coretemp.0" || len(data.Chips[0].Sensors) != 2 {
+		t.Errorf("expected the recorded hwmon chip, got %+v", data.Chips)
+	}
+	cpu, err := s.GetCPUData(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpu.Temperature == nil || *cpu.Temperature != 71 {
+		t.Errorf("expected CPU temperature 71 from hwmon, got %v", cpu.Temperature)
+	}
+}
+
+func TestMonitorServiceMemoryData(t *testing.T) {
+	s, _ := newFixtureMonitorService(t, nil)
+	data, err := s.GetMemoryData(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Total != 16 || data.Used != 8 || data.Available != 8 || data.UsagePercent != 50 {
+		t.Errorf("unexpected memory data %+v", data)
+	}
+	if data.SwapTotal != 2 || data.SwapUsed != 0.5 {
+		t.Errorf("unexpected swap %v/%v", data.SwapUsed, data.SwapTotal)
+	}
+}
+
+func TestMonitorServiceHistoryTrimming(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var snapshots []SystemSnapshot
+	for i := 0; i < 25; i++ {
+		snapshots = append(snapshots, SystemSnapshot{
+			Timestamp:     start.Add(time.Duration(i) * historyCollectInterval),
+			CPUPercent:    float64(i),
+			VirtualMemory: &mem.VirtualMemoryStat{Used: uint64(i) << 30, UsedPercent: float64(i) * 2},
+		})
+	}
+	source, err := NewFixtureSource(snapshots...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMonitorServiceWithSource(source, nil).(*monitorService)
+
+	for {
+		if err := s.collectCPUHistory(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.collectMemoryHistory(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if !source.Advance() {
+			break
+		}
+	}
+
+	// only the last 20 readings are kept
+	if len(s.cpuHistory) != 20 || len(s.memoryHistory) != 20 {
+		t.Fatalf("expected 20 readings, got %d CPU and %d memory", len(s.cpuHistory), len(s.memoryHistory))
+	}
+	first, last := s.cpuHistory[0], s.cpuHistory[19]
+	if first.Usage != 5 || !first.Timestamp.Equal(snapshots[5].Timestamp) || last.Usage != 24 {
+		t.Errorf("unexpected CPU history from %+v to %+v", first, last)
+	}
+	if memory := s.memoryHistory[0]; memory.Used != 5 || memory.UsagePercent != 10 {
+		t.Errorf("unexpected first memory reading %+v", memory)
+	}
+}
+
+func TestMonitorServiceDiskData(t *testing.T) {
+	s, _ := newFixtureMonitorService(t, webServerFilter)
+	data, err := s.GetDiskData(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disks := make(map[string]model.DiskInfo)
+	var mountpoints []string
+	for _, disk := range data.Disks {
+		disks[disk.MountPoint] = disk
+		mountpoints = append(mountpoints, disk.MountPoint)
+	}
+	if expected := []string{"/", "/boot/efi", "/data", "/mnt/iso"}; !reflect.DeepEqual(mountpoints, expected) {
+		t.Fatalf("expected mounts %v, got %v", expected, mountpoints)
+	}
+	if data.TotalCapacity != 604.5 {
+		t.Errorf("expected capacity 604.5, got %v", data.TotalCapacity)
+	}
+
+	root := disks["/"]
+	if root.Filesystem != "ext4" || root.Total != 100 || root.UsagePercent != 40 || root.ReadOnly {
+		t.Errorf("unexpected root disk %+v", root)
+	}
+	// /data is rw in fstab, /mnt/iso is meant to be read-only
+	if data := disks["/data"]; !data.ReadOnly || !data.UnexpectedReadOnly || data.InodesUsedPercent != 95 {
+		t.Errorf("expected /data to be unexpectedly read-only, got %+v", data)
+	}
+	if iso := disks["/mnt/iso"]; !iso.ReadOnly || iso.UnexpectedReadOnly {
+		t.Errorf("expected /mnt/iso to be read-only as configured, got %+v", iso)
+	}
+
+	if fds := data.FileDescriptors; fds == nil || fds.Used != 9600 || fds.Max != 100000 || !almostEqual(fds.UsagePercent, 9.6) {
+		t.Errorf("unexpected file handles %+v", fds)
+	}
+}
+
+func TestMonitorServiceDiskDataWithoutFilter(t *testing.T) {
+	s, source := newFixtureMonitorService(t, nil)
+	source.snapshot().FileHandles = nil
+	source.snapshot().Fstab = nil
+	data, err := s.GetDiskData(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Disks) != 7 {
+		t.Errorf("expected every mount without a filter, got %d", len(data.Disks))
+	}
+	if data.FileDescriptors != nil {
+		t.Errorf("expected no file handles without file-nr, got %+v", data.FileDescriptors)
+	}
+	for _, disk := range data.Disks {
+		if disk.UnexpectedReadOnly {
+			t.Errorf("expected no unexpected read-only mount without fstab, got %+v", disk)
+		}
+	}
+}
+
+func TestMonitorServiceNetworkData(t *testing.T) {
+	s, _ := newFixtureMonitorService(t, webServerFilter)
+	data, err := s.GetNetworkData(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		name  string
+		speed uint64
+	}{{"lo", 0}, {"eth0", 1000}, {"wg0", 100}}
+	if len(data.Interfaces) != len(expected) {
+		t.Fatalf("expected %d interfaces, got %+v", len(expected), data.Interfaces)
+	}
+	for i, iface := range data.Interfaces {
+		if iface.Name != expected[i].name || iface.Speed != expected[i].speed || !iface.IsUp {
+			t.Errorf("unexpected interface %+v", iface)
+		}
+	}
+	if data.TotalBytesSent != 504000000 || data.TotalBytesRecv != 2005000000 {
+		t.Errorf("unexpected totals sent %d received %d", data.TotalBytesSent, data.TotalBytesRecv)
+	}
+}
+
+func TestMonitorServiceNetworkHistory(t *testing.T) {
+	ctx := context.Background()
+	s, source := newFixtureMonitorService(t, webServerFilter)
+
+	// the first reading only records the counters
+	if err := s.collectNetworkHistory(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.networkHistory) != 0 {
+		t.Fatalf("expected no rate from a single reading, got %+v", s.networkHistory)
+	}
+
+	for source.Advance() {
+		if err := s.collectNetworkHistory(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(s.networkHistory) != 2 {
+		t.Fatalf("expected 2 rates, got %d", len(s.networkHistory))
+	}
+	// lo, eth0 and wg0 over 5 seconds, veth and docker are excluded
+	for _, usage := range s.networkHistory {
+		if usage.BytesSentPerSec != 1110000 || usage.BytesRecvPerSec != 2210000 {
+			t.Errorf("unexpected rate %+v", usage)
+		}
+	}
+	if !s.networkHistory[1].Timestamp.Equal(source.Now()) {
+		t.Errorf("expected rate at %v, got %v", source.Now(), s.networkHistory[1].Timestamp)
+	}
+}
+
+func TestMonitorServiceNetworkHistoryCounterReset(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	counters := func(sent, recv uint64) []net.IOCountersStat {
+		return []net.IOCountersStat{{Name: "eth0", BytesSent: sent, BytesRecv: recv}}
+	}
+	source, err := NewFixtureSource(
+		SystemSnapshot{Timestamp: start, NetIOCounters: counters(1000, 2000)},
+		SystemSnapshot{Timestamp: start.Add(5 * time.Second), NetIOCounters: counters(100, 200)},
+		SystemSnapshot{Timestamp: start.Add(10 * time.Second), NetIOCounters: counters(600, 1200)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMonitorServiceWithSource(source, nil).(*monitorService)
+
+	for ok := true; ok; ok = source.Advance() {
+		if err := s.collectNetworkHistory(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the reading after the reset becomes the new baseline
+	if len(s.networkHistory) != 1 || s.networkHistory[0].BytesSentPerSec != 100 || s.networkHistory[0].BytesRecvPerSec != 200 {
+		t.Errorf("expected a single rate after the reset, got %+v", s.networkHistory)
+	}
+}
+
+func TestMonitorServiceProcessData(t *testing.T) {
+	ctx := context.Background()
+	s, source := newFixtureMonitorService(t, nil)
+
+	// without a baseline the CPU times are sampled across the first two snapshots
+	data, err := s.GetProcessData(ctx, model.ProcessQuery{SortBy: "cpu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.TotalProcesses != 8 || data.Matched != 8 || data.RunningProcesses != 2 || data.SleepingProcesses != 5 {
+		t.Errorf("unexpected counts %+v", data)
+	}
+	var pids []int32
+	percents := make(map[int32]float64)
+	for _, proc := range data.Processes {
+		pids = append(pids, proc.PID)
+		percents[proc.PID] = proc.CPUPercent
+	}
+	if expected := []int32{101, 200, 102, 300, 1, 100, 201, 202}; !reflect.DeepEqual(pids, expected) {
+		t.Errorf("expected order %v, got %v", expected, pids)
+	}
+	if !almostEqual(percents[101], 50) || !almostEqual(percents[200], 20) || !almostEqual(percents[102], 10) {
+		t.Errorf("unexpected CPU usage %v", percents)
+	}
+	if !source.Now().Equal(time.Date(2024, 5, 1, 12, 0, 5, 0, time.UTC)) {
+		t.Errorf("expected sampling to move to the second snapshot, at %v", source.Now())
+	}
+
+	// the listing is the baseline of the next one 5 seconds later, which needs no sampling
+	source.Advance()
+	data, err = s.GetProcessData(ctx, model.ProcessQuery{Name: "NGINX", SortBy: "cpu", Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Matched != 2 || data.Offset != 1 || len(data.Processes) != 1 || data.Processes[0].PID != 100 {
+		t.Errorf("expected the nginx master on the second page, got %+v", data)
+	}
+
+	data, err = s.GetProcessData(ctx, model.ProcessQuery{User: "root", SortBy: "pid", Order: "desc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pids = pids[:0]
+	for _, proc := range data.Processes {
+		pids = append(pids, proc.PID)
+	}
+	if expected := []int32{400, 100, 1}; !reflect.DeepEqual(pids, expected) {
+		t.Errorf("expected root processes %v, got %v", expected, pids)
+	}
+	// backup.sh started after the baseline and is sampled on its own, time stands still on the last snapshot
+	if data.Processes[0].CPUPercent != 0 {
+		t.Errorf("expected no CPU usage for a new process, got %v", data.Processes[0].CPUPercent)
+	}
+}
+
+func TestMonitorServiceProcessCPUBaselineMerge(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	processes := func(nginx, postgres float64) []ProcessSnapshot {
+		return []ProcessSnapshot{
+			{PID: 100, Name: "nginx", Username: "www-data", Status: "sleep", CPUUser: nginx},
+			{PID: 200, Name: "postgres", Username: "postgres", Status: "sleep", CPUUser: postgres},
+		}
+	}
+	source, err := NewFixtureSource(
+		SystemSnapshot{Timestamp: start, Processes: processes(10, 20)},
+		SystemSnapshot{Timestamp: start.Add(time.Second), Processes: processes(11, 21)},
+		SystemSnapshot{Timestamp: start.Add(6 * time.Second), Processes: processes(13, 25)},
+		SystemSnapshot{Timestamp: start.Add(8 * time.Second), Processes: processes(14, 26)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMonitorServiceWithSource(source, nil).(*monitorService)
+
+	if _, err := s.GetProcessData(ctx, model.ProcessQuery{Name: "nginx"}); err != nil {
+		t.Fatal(err)
+	}
+	// a filtered listing does not hide the usage of the other processes from the next listing
+	source.Advance()
+	data, err := s.GetProcessData(ctx, model.ProcessQuery{SortBy: "pid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	percents := make(map[int32]float64)
+	for _, proc := range data.Processes {
+		percents[proc.PID] = proc.CPUPercent
+	}
+	// nginx reuses the baseline of the first listing, postgres is sampled across the last two snapshots
+	if !almostEqual(percents[100], 3.0/7*100) || !almostEqual(percents[200], 50) {
+		t.Errorf("unexpected CPU usage %v", percents)
+	}
+	if len(s.procCPU.times) != 2 {
+		t.Errorf("expected the baseline to cover both processes, got %v", s.procCPU.times)
+	}
+}
+
+func TestMonitorServiceProcessDetail(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newFixtureMonitorService(t, nil)
+
+	detail, err := s.GetProcessDetail(ctx, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Name != "nginx" || detail.User != "root" || detail.Exe != "/usr/sbin/nginx" || detail.Cwd != "/" || detail.OpenFiles != 12 {
+		t.Errorf("unexpected detail %+v", detail)
+	}
+	if detail.CreateTime.UnixMilli() != 1714550460000 || detail.MemoryMB != 8 {
+		t.Errorf("unexpected create time %v or memory %v", detail.CreateTime, detail.MemoryMB)
+	}
+	if detail.IOCounters == nil || detail.IOCounters.ReadBytes != 409600 {
+		t.Errorf("unexpected I/O counters %+v", detail.IOCounters)
+	}
+	if detail.Environment["NGINX_API_TOKEN"] != "[REDACTED]" || detail.Environment["LANG"] != "C.UTF-8" {
+		t.Errorf("unexpected environment %v", detail.Environment)
+	}
+	if len(detail.Connections) != 2 || detail.Connections[0].Protocol != "tcp" || detail.Connections[0].LocalAddress != "0.0.0.0:80" {
+		t.Errorf("unexpected connections %+v", detail.Connections)
+	}
+	if detail.Parent == nil || detail.Parent.PID != 1 || detail.Parent.Name != "systemd" {
+		t.Errorf("unexpected parent %+v", detail.Parent)
+	}
+	expectedChildren := []model.ProcessRef{{PID: 101, Name: "nginx"}, {PID: 102, Name: "nginx"}}
+	if !reflect.DeepEqual(detail.Children, expectedChildren) {
+		t.Errorf("expected children %+v, got %+v", expectedChildren, detail.Children)
+	}
+
+	if _, err := s.GetProcessDetail(ctx, 999); err != ErrProcessNotFound {
+		t.Errorf("expected ErrProcessNotFound, got %v", err)
+	}
+}
+
+func TestMonitorServiceProcessTree(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newFixtureMonitorService(t, nil)
+
+	tree, err := s.GetProcessTree(ctx, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Roots) != 1 || tree.TotalProcesses != 8 || tree.TotalZombies != 1 {
+		t.Fatalf("unexpected tree with %d roots, %d processes and %d zombies", len(tree.Roots), tree.TotalProcesses, tree.TotalZombies)
+	}
+	systemd := tree.Roots[0]
+	if systemd.PID != 1 || len(systemd.Children) != 3 {
+		t.Fatalf("expected systemd with 3 children, got %+v", systemd)
+	}
+
+	nginx := systemd.Children[0]
+	if len(nginx.Children) != 1 {
+		t.Fatalf("expected nginx workers to be collapsed, got %d children", len(nginx.Children))
+	}
+	if workers := nginx.Children[0]; workers.Count != 2 || !reflect.DeepEqual(workers.PIDs, []int32{101, 102}) || !almostEqual(workers.CPUPercent, 60) {
+		t.Errorf("unexpected workers %+v", workers)
+	}
+	if !almostEqual(nginx.SubtreeCPUPercent, 60) || nginx.SubtreeProcesses != 3 {
+		t.Errorf("unexpected nginx subtree %+v", nginx)
+	}
+	if postgres := systemd.Children[1]; postgres.Zombies != 1 || postgres.SubtreeProcesses != 3 {
+		t.Errorf("unexpected postgres subtree %+v", postgres)
+	}
+
+	if _, err := s.GetProcessTree(ctx, 999, false); err != ErrProcessNotFound {
+		t.Errorf("expected ErrProcessNotFound, got %v", err)
+	}
+}
+
+func TestMonitorServiceConnectionData(t *testing.T) {
+	s, _ := newFixtureMonitorService(t, nil)
+	data, err := s.GetConnectionData(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Total != 9 || data.UDPSockets != 1 {
+		t.Errorf("unexpected totals %+v", data)
+	}
+	for state, count := range map[string]int{"LISTEN": 3, "ESTABLISHED": 3, "TIME_WAIT": 1, "CLOSE_WAIT": 1, "SYN_SENT": 0} {
+		if data.TCPStates[state] != count {
+			t.Errorf("expected %d %s sockets, got %d", count, state, data.TCPStates[state])
+		}
+	}
+
+	var listening []string
+	for _, port := range data.Listening {
+		listening = append(listening, port.Protocol+"/"+port.Process)
+	}
+	if expected := []string{"tcp/nginx", "tcp/nginx", "tcp/postgres", "udp/"}; !reflect.DeepEqual(listening, expected) {
+		t.Errorf("expected listening %v, got %v", expected, listening)
+	}
+	if len(data.RemoteAddresses) != 1 || data.RemoteAddresses[0].Address != "203.0.113.7" || data.RemoteAddresses[0].Count != 3 {
+		t.Errorf("expected the busiest remote address only, got %+v", data.RemoteAddresses)
+	}
+}
+
+func TestMonitorServiceSystemInfo(t *testing.T) {
+	s, _ := newFixtureMonitorService(t, nil)
+	info, err := s.GetSystemInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Hostname != "web-01" || info.Platform != "ubuntu" || info.OS != "22.04" || info.Arch != "x86_64" || info.Processes != 8 {
+		t.Errorf("unexpected system info %+v", info)
+	}
+	if !info.BootTime.Equal(time.Unix(1714550400, 0)) || !reflect.DeepEqual(info.LoadAverage, []float64{1.25, 0.98, 0.75}) {
+		t.Errorf("unexpected boot time %v or load %v", info.BootTime, info.LoadAverage)
+	}
+}
+
+func TestMonitorServicePreviewCollectFilter(t *testing.T) {
+	s, _ := newFixtureMonitorService(t, webServerFilter)
+	preview, err := s.PreviewCollectFilter(context.Background(), &model.CollectFilter{ExcludeFSTypes: []string{"tmpfs"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Mounts) != 7 || len(preview.Interfaces) != 5 {
+		t.Fatalf("expected every mount and interface, got %d and %d", len(preview.Mounts), len(preview.Interfaces))
+	}
+	// the host rules replace the global fstypes, the other global rules still apply
+	included := make(map[string]bool)
+	for _, mount := range preview.Mounts {
+		included[mount.Name] = mount.Included
+	}
+	if !included["/snap/core20/2318"] || included["/run"] || included["/var/lib/docker/overlay2/3f1c/merged"] {
+		t.Errorf("unexpected mounts %v", included)
+	}
+}
+
+func TestMonitorServiceCollectors(t *testing.T) {
+	s, source := newFixtureMonitorService(t, webServerFilter)
+	scheduler, sampleRepo := newTestCollectorScheduler(t, config.CollectorConfig{}, nil, s.Collectors(0)...)
+	scheduler.RunOnce(source.Now())
+
+	for _, status := range scheduler.Status() {
+		if status.LastError != "" {
+			t.Errorf("collector %s failed: %s", status.Name, status.LastError)
+		}
+	}
+	if len(s.cpuHistory) != 1 || len(s.memoryHistory) != 1 {
+		t.Errorf("expected history to be collected, got %d CPU and %d memory readings", len(s.cpuHistory), len(s.memoryHistory))
+	}
+
+	values := make(map[string]float64)
+	for _, sample := range sampleRepo.samples {
+		values[sample.Metric+sample.Labels] = sample.Value
+	}
+	data := model.FormatLabels(map[string]string{"mountpoint": "/data"})
+	expected := map[string]float64{
+		SampleMemoryUsagePercent:           50,
+		SampleDiskUsagePercent + data:      81.25,
+		SampleDiskInodeUsagePercent + data: 95,
+		SampleDiskReadOnly + data:          1,
+		TCPStateSample("CLOSE_WAIT"):       1,
+		SampleUDPSockets:                   1,
+	}
+	for key, value := range expected {
+		if got, ok := values[key]; !ok || got != value {
+			t.Errorf("expected sample %s = %v, got %v", key, value, got)
+		}
+	}
+	if _, ok := values[SampleDiskUsagePercent+model.FormatLabels(map[string]string{"mountpoint": "/run"})]; ok {
+		t.Error("expected excluded mount to have no sample")
+	}
+
+	// the sample collectors follow the record interval, the history keeps its own
+	intervals := make(map[string]time.Duration)
+	for _, collector := range s.Collectors(30 * time.Second) {
+		intervals[collector.Name()] = collector.DefaultInterval()
+	}
+	if intervals["memory"] != 30*time.Second || intervals["sensors"] != 30*time.Second || intervals["cpu_history"] != historyCollectInterval {
+		t.Errorf("unexpected collector intervals %v", intervals)
+	}
+	if collector := s.Collectors(0)[3]; collector.DefaultInterval() != time.Minute {
+		t.Errorf("expected %s to default to a minute, got %v", collector.Name(), collector.DefaultInterval())
+	}
+}
+
+func TestNewMonitorServiceWithSourceErrors(t *testing.T) {
+	source, err := NewFixtureSource(SystemSnapshot{Timestamp: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMonitorServiceWithSource(source, nil)
+	if _, err := s.GetMemoryData(context.Background()); err == nil {
+		t.Error("expected error without memory readings")
+	}
+	if _, err := s.GetSystemInfo(context.Background()); err == nil {
+		t.Error("expected error without host info")
+	}
+	if _, err := s.GetProcessDetail(context.Background(), 1); !errors.Is(err, ErrProcessNotFound) {
+		t.Errorf("expected ErrProcessNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,494 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// Nagios 插件检查结果持久化到 metric_samples 的指标名称
+const (
+	SampleNagiosState    = "nagios_check_state"    // 检查状态 0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN，标签: check
+	SampleNagiosWarning  = "nagios_check_warning"  // 状态为 WARNING 为1，否则为0，标签: check
+	SampleNagiosCritical = "nagios_check_critical" // 状态为 CRITICAL 为1，否则为0，标签: check
+	SampleNagiosPerfData = "nagios_perf"           // 性能数据，时间和字节已换算为秒和字节，标签: check, label
+)
+
+// nagiosPath is the PATH of the restricted plugin environment
+const nagiosPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// NagiosExecutor periodically runs Nagios compatible plugins and stores their state and
+// performance data as metric samples of the host each check is attached to
+type NagiosExecutor interface {
+	RunOnce(now time.Time) error
+	// Collector returns the collector running due checks every tick interval by default
+	Collector() Collector
+	// Stop kills the running plugins and starts no further checks, call it before stopping
+	// the collector scheduler so that it does not wait for the plugins to time out
+	Stop()
+	RunCheck(check *model.NagiosCheck) model.NagiosResult
+	// ValidateCommand returns the cleaned plugin path if it is an executable in a plugin directory
+	ValidateCommand(command string) (string, error)
+}
+
+// nagiosExecutor implements NagiosExecutor interface
+type nagiosExecutor struct {
+	checkRepo     repository.NagiosCheckRepository
+	sampleRepo    repository.SampleRepository
+	runner        CommandRunner
+	pluginDirs    []string
+	tickInterval  time.Duration
+	maxConcurrent int
+	logger        *logger.Logger
+
+	// 停止时取消正在运行的插件
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewNagiosExecutor creates a new Nagios plugin executor running plugins with a restricted environment
+func NewNagiosExecutor(db *gorm.DB, cfg config.NagiosConfig, logger *logger.Logger) NagiosExecutor {
+	tickInterval := time.Duration(cfg.TickInterval) * time.Second
+	if tickInterval <= 0 {
+		tickInterval = 5 * time.Second
+	}
+	maxConcurrent := cfg.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = 5
+	}
+	maxOutput := cfg.MaxOutputBytes
+	if maxOutput <= 0 {
+		maxOutput = 65536
+	}
+
+	var pluginDirs []string
+	for _, dir := range cfg.PluginDirs {
+		if filepath.IsAbs(dir) {
+			pluginDirs = append(pluginDirs, filepath.Clean(dir))
+		} else {
+			logger.Warn("Ignoring relative nagios plugin directory", "dir", dir)
+		}
+	}
+
+	env := []string{"PATH=" + nagiosPath, "LANG=C", "LC_ALL=C"}
+	for _, pair := range cfg.Environment {
+		if strings.Contains(pair, "=") {
+			env = append(env, pair)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &nagiosExecutor{
+		checkRepo:     repository.NewNagiosCheckRepository(db),
+		sampleRepo:    repository.NewSampleRepository(db),
+		runner:        NewExecRunnerWithOptions(ExecOptions{Env: env, Dir: "/", MaxOutput: maxOutput}),
+		pluginDirs:    pluginDirs,
+		tickInterval:  tickInterval,
+		maxConcurrent: maxConcurrent,
+		logger:        logger,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// Collector returns the collector running due checks, run by CollectorScheduler
+func (e *nagiosExecutor) Collector() Collector {
+	return roundCollector("nagios_checks", e.tickInterval, e.RunOnce)
+}
+
+// Stop kills the running plugins and starts no further checks
+func (e *nagiosExecutor) Stop() {
+	e.cancel()
+}
+
+// RunOnce runs every enabled check that is due, at most maxConcurrent at a time,
+// and waits for all of them to finish. No further checks are started once stopped.
+func (e *nagiosExecutor) RunOnce(now time.Time) error {
+	checks, err := e.checkRepo.GetEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to get nagios checks: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, e.maxConcurrent)
+	for i := range checks {
+		check := &checks[i]
+		if !probeDue(check.LastCheckedAt, check.Interval, now) {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-e.ctx.Done():
+			wg.Wait()
+			return nil
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			e.RunCheck(check)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// RunCheck runs a single check, records the result and returns it. The result of a plugin
+// killed because the executor stopped is not recorded.
+func (e *nagiosExecutor) RunCheck(check *model.NagiosCheck) model.NagiosResult {
+	timeout := probeTimeout(check.Timeout)
+	ctx, cancel := context.WithTimeout(e.ctx, timeout)
+	defer cancel()
+
+	var result model.NagiosResult
+	command, args, err := e.commandLine(check)
+	if err != nil {
+		result = nagiosErrorResult(model.NagiosStateUnknown, err.Error(), time.Now())
+	} else {
+		result = runNagiosPlugin(ctx, e.runner, command, args, timeout)
+	}
+	if result.State != model.NagiosStateOK {
+		e.logger.Debug("Nagios check not OK", "check", check.Name, "state", result.StateName, "output", result.Output)
+	}
+	if e.ctx.Err() != nil {
+		return result
+	}
+
+	if check.LastCheckedAt == nil || check.LastState != result.State {
+		check.StateChangedAt = &result.CheckedAt
+	}
+	check.LastCheckedAt = &result.CheckedAt
+	check.LastState = result.State
+	check.LastOutput = result.Output
+	check.LastPerfData = result.RawPerf
+	check.LastDurationMs = result.DurationMs
+	if err := e.checkRepo.UpdateResult(check); err != nil {
+		e.logger.Warn("Failed to update nagios check result", "check", check.Name, "error", err)
+	}
+
+	if check.Host == nil {
+		e.logger.Warn("Nagios check is not attached to a host, result not recorded", "check", check.Name)
+		return result
+	}
+	if err := e.sampleRepo.CreateBatch(nagiosSamples(check.Host.Hostname, check.Name, result)); err != nil {
+		e.logger.Warn("Failed to record nagios check result", "check", check.Name, "error", err)
+	}
+	return result
+}
+
+// ValidateCommand returns the cleaned plugin path if it is an executable in a plugin directory
+func (e *nagiosExecutor) ValidateCommand(command string) (string, error) {
+	return validateNagiosCommand(command, e.pluginDirs)
+}
+
+// commandLine validates the plugin of a check and expands the host macros in its arguments
+func (e *nagiosExecutor) commandLine(check *model.NagiosCheck) (string, []string, error) {
+	command, err := e.ValidateCommand(check.Command)
+	if err != nil {
+		return "", nil, err
+	}
+	args, err := ParseNagiosArguments(check.Arguments)
+	if err != nil {
+		return "", nil, err
+	}
+	if check.Host != nil {
+		replacer := strings.NewReplacer("$HOSTNAME$", check.Host.Hostname, "$HOSTADDRESS$", check.Host.IPAddress)
+		for i, arg := range args {
+			args[i] = replacer.Replace(arg)
+		}
+	}
+	return command, args, nil
+}
+
+// validateNagiosCommand checks that a command is an executable file inside one of the plugin
+// directories. Symbolic links are resolved so that a link cannot point outside the directories.
+func validateNagiosCommand(command string, pluginDirs []string) (string, error) {
+	if !filepath.IsAbs(command) {
+		return "", fmt.Errorf("command must be an absolute path: %q", command)
+	}
+	command = filepath.Clean(command)
+	if !inPluginDir(command, pluginDirs) {
+		return "", fmt.Errorf("command must be located in a plugin directory (%s)", strings.Join(pluginDirs, ", "))
+	}
+
+	resolved, err := filepath.EvalSymlinks(command)
+	if err != nil {
+		return "", fmt.Errorf("command not found: %w", err)
+	}
+	var resolvedDirs []string
+	for _, dir := range pluginDirs {
+		if resolvedDir, err := filepath.EvalSymlinks(dir); err == nil {
+			resolvedDirs = append(resolvedDirs, resolvedDir)
+		}
+	}
+	if !inPluginDir(resolved, resolvedDirs) {
+		return "", fmt.Errorf("command links outside the plugin directories: %s", command)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("command not found: %w", err)
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+		return "", fmt.Errorf("command is not an executable file: %s", command)
+	}
+	return command, nil
+}
+
+// inPluginDir reports whether a cleaned absolute path lies below one of the directories
+func inPluginDir(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseNagiosArguments parses the JSON array of arguments of a check
+func ParseNagiosArguments(arguments string) ([]string, error) {
+	if arguments == "" {
+		return nil, nil
+	}
+	var args []string
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	return args, nil
+}
+
+// runNagiosPlugin runs a plugin and converts its exit code and output to a result. Plugins
+// killed on timeout are CRITICAL, plugins that cannot be run or exit with an unknown code UNKNOWN.
+func runNagiosPlugin(ctx context.Context, runner CommandRunner, command string, args []string, timeout time.Duration) model.NagiosResult {
+	start := time.Now()
+	output, err := runner.Run(ctx, command, args...)
+	duration := time.Since(start)
+
+	var result model.NagiosResult
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result = nagiosErrorResult(model.NagiosStateCritical, fmt.Sprintf("CRITICAL - plugin timed out after %s", timeout), start)
+		result.TimedOut = true
+	case ctx.Err() == context.Canceled:
+		result = nagiosErrorResult(model.NagiosStateUnknown, "UNKNOWN - plugin cancelled", start)
+	case err == nil:
+		result = parseNagiosOutput(string(output), model.NagiosStateOK, start)
+		result.ExitCode = 0
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		state := exitErr.ExitCode()
+		if state > model.NagiosStateUnknown {
+			state = model.NagiosStateUnknown
+		}
+		result = parseNagiosOutput(string(output), state, start)
+		result.ExitCode = exitErr.ExitCode()
+		if result.Output == "" {
+			result.Output = err.Error()
+		}
+	default:
+		result = nagiosErrorResult(model.NagiosStateUnknown, err.Error(), start)
+	}
+
+	if result.Output == "" {
+		result.Output = "(No output returned from plugin)"
+	}
+	result.DurationMs = durationMs(duration)
+	return result
+}
+
+// nagiosErrorResult creates the result of a plugin that did not report a state itself
+func nagiosErrorResult(state int, output string, checkedAt time.Time) model.NagiosResult {
+	return model.NagiosResult{
+		State:     state,
+		StateName: model.NagiosStateNames[state],
+		ExitCode:  -1,
+		Output:    output,
+		PerfData:  []model.NagiosPerfData{},
+		CheckedAt: checkedAt,
+	}
+}
+
+// parseNagiosOutput splits plugin output into the first line, the long output and the
+// performance data, which follows a pipe on the first line and on the first long output
+// line containing one, continuing to the end of the output
+func parseNagiosOutput(output string, state int, checkedAt time.Time) model.NagiosResult {
+	lines := strings.Split(strings.TrimRight(output, "\r\n"), "\n")
+	text, perf, _ := strings.Cut(lines[0], "|")
+	perfParts := []string{perf}
+
+	var long []string
+	inPerf := false
+	for _, line := range lines[1:] {
+		line = strings.TrimSuffix(line, "\r")
+		if inPerf {
+			perfParts = append(perfParts, line)
+			continue
+		}
+		if before, after, found := strings.Cut(line, "|"); found {
+			long = append(long, before)
+			perfParts = append(perfParts, after)
+			inPerf = true
+			continue
+		}
+		long = append(long, line)
+	}
+
+	rawPerf := strings.Join(strings.Fields(strings.Join(perfParts, " ")), " ")
+	return model.NagiosResult{
+		State:      state,
+		StateName:  model.NagiosStateNames[state],
+		Output:     strings.TrimSpace(text),
+		LongOutput: strings.TrimSpace(strings.Join(long, "\n")),
+		PerfData:   parseNagiosPerfData(rawPerf),
+		RawPerf:    rawPerf,
+		CheckedAt:  checkedAt,
+	}
+}
+
+// perfValuePattern splits a performance value into the number and the unit of measurement
+var perfValuePattern = regexp.MustCompile(`^([-+]?(?:[0-9]+\.?[0-9]*|\.[0-9]+)(?:[eE][-+]?[0-9]+)?)(.*)$`)
+
+// perfUnitScales converts time and byte units to seconds and bytes
+var perfUnitScales = map[string]struct {
+	unit  string
+	scale float64
+}{
+	"us": {"s", 1e-6},
+	"ms": {"s", 1e-3},
+	"KB": {"B", 1 << 10},
+	"MB": {"B", 1 << 20},
+	"GB": {"B", 1 << 30},
+	"TB": {"B", 1 << 40},
+}
+
+// parseNagiosPerfData parses performance data items of the form
+// 'label'=value[UOM];[warn];[crit];[min];[max]. Items without a numeric value are skipped.
+func parseNagiosPerfData(raw string) []model.NagiosPerfData {
+	items := []model.NagiosPerfData{}
+	for i := 0; i < len(raw); {
+		if raw[i] == ' ' {
+			i++
+			continue
+		}
+
+		// 带引号的标签可以包含空格和等号，两个单引号表示一个单引号
+		var label strings.Builder
+		if raw[i] == '\'' {
+			i++
+			for i < len(raw) {
+				if raw[i] == '\'' {
+					if i+1 < len(raw) && raw[i+1] == '\'' {
+						label.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				label.WriteByte(raw[i])
+				i++
+			}
+		} else {
+			for i < len(raw) && raw[i] != '=' && raw[i] != ' ' {
+				label.WriteByte(raw[i])
+				i++
+			}
+		}
+
+		end := strings.IndexByte(raw[i:], ' ')
+		if end < 0 {
+			end = len(raw) - i
+		}
+		token := raw[i : i+end]
+		i += end
+
+		if !strings.HasPrefix(token, "=") || label.Len() == 0 {
+			continue
+		}
+		if item, ok := parseNagiosPerfValue(label.String(), token[1:]); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseNagiosPerfValue parses value[UOM];[warn];[crit];[min];[max]
+func parseNagiosPerfValue(label, token string) (model.NagiosPerfData, bool) {
+	fields := strings.Split(token, ";")
+	match := perfValuePattern.FindStringSubmatch(fields[0])
+	if match == nil {
+		return model.NagiosPerfData{}, false
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return model.NagiosPerfData{}, false
+	}
+
+	item := model.NagiosPerfData{Label: label, Value: value, UOM: match[2]}
+	scale := 1.0
+	if unit, ok := perfUnitScales[item.UOM]; ok {
+		item.UOM = unit.unit
+		scale = unit.scale
+	}
+	item.Value *= scale
+
+	field := func(n int) string {
+		if n < len(fields) {
+			return fields[n]
+		}
+		return ""
+	}
+	item.Warn = field(1)
+	item.Crit = field(2)
+	if min, err := strconv.ParseFloat(field(3), 64); err == nil {
+		min *= scale
+		item.Min = &min
+	}
+	if max, err := strconv.ParseFloat(field(4), 64); err == nil {
+		max *= scale
+		item.Max = &max
+	}
+	return item, true
+}
+
+// nagiosSamples converts a check result to metric samples of the host
+func nagiosSamples(hostname, checkName string, result model.NagiosResult) []model.MetricSample {
+	labels := model.FormatLabels(map[string]string{"check": checkName})
+	sample := func(metric, labels string, value float64) model.MetricSample {
+		return model.MetricSample{Hostname: hostname, Metric: metric, Labels: labels, Value: value, Timestamp: result.CheckedAt}
+	}
+
+	warning, critical := 0.0, 0.0
+	switch result.State {
+	case model.NagiosStateWarning:
+		warning = 1
+	case model.NagiosStateCritical:
+		critical = 1
+	}
+	samples := []model.MetricSample{
+		sample(SampleNagiosState, labels, float64(result.State)),
+		sample(SampleNagiosWarning, labels, warning),
+		sample(SampleNagiosCritical, labels, critical),
+	}
+	for _, item := range result.PerfData {
+		perfLabels := model.FormatLabels(map[string]string{"check": checkName, "label": item.Label})
+		samples = append(samples, sample(SampleNagiosPerfData, perfLabels, item.Value))
+	}
+	return samples
+}
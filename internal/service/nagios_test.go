@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// recordingNagiosRepo serves fixed checks and counts the recorded results
+type recordingNagiosRepo struct {
+	repository.NagiosCheckRepository
+	checks  []model.NagiosCheck
+	results chan model.NagiosCheck
+}
+
+func (r *recordingNagiosRepo) GetEnabled() ([]model.NagiosCheck, error) {
+	return r.checks, nil
+}
+
+func (r *recordingNagiosRepo) UpdateResult(check *model.NagiosCheck) error {
+	r.results <- *check
+	return nil
+}
+
+func TestParseNagiosOutput(t *testing.T) {
+	output := "DISK WARNING - free space: / 3326 MB (56%); | /=2643MB;5948;5958;0;5968\n" +
+		"/ 15272 MB (77%);\n" +
+		"/boot 68 MB (69%); | /boot=68MB;88;93;0;98\n" +
+		"/home=69357MB;253404;253409;0;253414\n"
+
+	result := parseNagiosOutput(output, model.NagiosStateWarning, time.Now())
+	if result.Output != "DISK WARNING - free space: / 3326 MB (56%);" {
+		t.Errorf("unexpected output %q", result.Output)
+	}
+	if result.LongOutput != "/ 15272 MB (77%);\n/boot 68 MB (69%);" {
+		t.Errorf("unexpected long output %q", result.LongOutput)
+	}
+	if result.RawPerf != "/=2643MB;5948;5958;0;5968 /boot=68MB;88;93;0;98 /home=69357MB;253404;253409;0;253414" {
+		t.Errorf("unexpected perf data %q", result.RawPerf)
+	}
+	if len(result.PerfData) != 3 || result.StateName != "WARNING" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	root := result.PerfData[0]
+	if root.Label != "/" || root.Value != 2643*(1<<20) || root.UOM != "B" || root.Warn != "5948" || root.Crit != "5958" {
+		t.Errorf("unexpected root perf data %+v", root)
+	}
+	if root.Max == nil || *root.Max != 5968*(1<<20) {
+		t.Errorf("unexpected root max %v", root.Max)
+	}
+}
+
+func TestParseNagiosPerfData(t *testing.T) {
+	items := parseNagiosPerfData(`time=0.006s;;;0.000 'in use'=12% 'it''s'=3c;~:10;@20:30 size=U;1;2 load1=0.5;5.000;10.000;0; rta=120ms bad nothing=`)
+
+	min := 0.0
+	expected := []model.NagiosPerfData{
+		{Label: "time", Value: 0.006, UOM: "s", Min: &min},
+		{Label: "in use", Value: 12, UOM: "%"},
+		{Label: "it's", Value: 3, UOM: "c", Warn: "~:10", Crit: "@20:30"},
+		{Label: "load1", Value: 0.5, Warn: "5.000", Crit: "10.000", Min: &min},
+		{Label: "rta", Value: 0.12, UOM: "s"},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("expected %+v, got %+v", expected, items)
+	}
+}
+
+func TestNagiosSamples(t *testing.T) {
+	result := model.NagiosResult{
+		State:     model.NagiosStateCritical,
+		PerfData:  []model.NagiosPerfData{{Label: "load1", Value: 9}},
+		CheckedAt: time.Now(),
+	}
+	values := make(map[string]float64)
+	for _, sample := range nagiosSamples("web-01", "load", result) {
+		values[sample.Metric+" "+sample.Labels] = sample.Value
+	}
+	expected := map[string]float64{
+		"nagios_check_state check=load":      2,
+		"nagios_check_warning check=load":    0,
+		"nagios_check_critical check=load":   1,
+		"nagios_perf check=load,label=load1": 9,
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	// quoted perfdata labels may hold the label separators
+	items := parseNagiosPerfData(`'cpu=user,sys'=12%`)
+	result.PerfData = items
+	samples := nagiosSamples("web-01", "cpu", result)
+	perf := samples[len(samples)-1]
+	if labels := model.ParseLabels(perf.Labels); perf.Metric != "nagios_perf" || labels["label"] != "cpu=user,sys" || labels["check"] != "cpu" {
+		t.Errorf("unexpected perf sample %+v", perf)
+	}
+}
+
+// writePlugin writes an executable shell script to the directory
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunNagiosPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir := t.TempDir()
+	runner := NewExecRunnerWithOptions(ExecOptions{Env: []string{"PATH=" + nagiosPath, "SECRET_CHECK=visible"}, Dir: "/", MaxOutput: 4096})
+
+	tests := []struct {
+		name   string
+		script string
+		state  int
+		exit   int
+		output string
+	}{
+		{"ok", "echo 'OK - all good | users=3;5;10;0'\nexit 0\n", model.NagiosStateOK, 0, "OK - all good"},
+		{"warning", "echo 'WARNING - load high'\nexit 1\n", model.NagiosStateWarning, 1, "WARNING - load high"},
+		{"critical", "echo 'CRITICAL - down'\nexit 2\n", model.NagiosStateCritical, 2, "CRITICAL - down"},
+		{"unknown", "echo 'UNKNOWN - bad args'\nexit 3\n", model.NagiosStateUnknown, 3, "UNKNOWN - bad args"},
+		{"out of range", "echo 'oops'\nexit 7\n", model.NagiosStateUnknown, 7, "oops"},
+		{"environment", "echo \"$SECRET_CHECK ${HOME:-nohome}\"\n", model.NagiosStateOK, 0, "visible nohome"},
+		{"no output", "exit 0\n", model.NagiosStateOK, 0, "(No output returned from plugin)"},
+	}
+	for _, tt := range tests {
+		plugin := writePlugin(t, dir, "check_"+tt.name, tt.script)
+		result := runNagiosPlugin(context.Background(), runner, plugin, nil, time.Second)
+		if result.State != tt.state || result.ExitCode != tt.exit || result.Output != tt.output {
+			t.Errorf("%s: unexpected result %+v", tt.name, result)
+		}
+	}
+
+	result := runNagiosPlugin(context.Background(), runner, filepath.Join(dir, "missing"), nil, time.Second)
+	if result.State != model.NagiosStateUnknown || result.ExitCode != -1 {
+		t.Errorf("missing plugin: unexpected result %+v", result)
+	}
+}
+
+func TestRunNagiosPluginTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir := t.TempDir()
+	// the child keeps the output pipe open, so the plugin only returns when its whole process group is killed
+	plugin := writePlugin(t, dir, "check_hang", "sleep 30 &\nsleep 30\n")
+	runner := NewExecRunnerWithOptions(ExecOptions{Env: []string{"PATH=" + nagiosPath}})
+
+	timeout := 200 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	result := runNagiosPlugin(ctx, runner, plugin, nil, timeout)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("plugin was not killed on timeout, took %s", elapsed)
+	}
+	if !result.TimedOut || result.State != model.NagiosStateCritical || result.ExitCode != -1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestNagiosExecutorStopKillsPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir := t.TempDir()
+	started := filepath.Join(dir, "started")
+	plugin := writePlugin(t, dir, "check_hang", "touch "+started+"\nsleep 30\n")
+	repo := &recordingNagiosRepo{
+		checks:  []model.NagiosCheck{{Name: "hang", Command: plugin, Interval: 60, Timeout: 30}},
+		results: make(chan model.NagiosCheck, 1),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &nagiosExecutor{
+		checkRepo:     repo,
+		runner:        NewExecRunnerWithOptions(ExecOptions{Env: []string{"PATH=" + nagiosPath}}),
+		pluginDirs:    []string{dir},
+		tickInterval:  10 * time.Millisecond,
+		maxConcurrent: 1,
+		logger:        logger.New(config.LogConfig{Level: "error", Format: "text"}),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.RunOnce(time.Now())
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	e.Stop()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("running plugin was not killed on stop")
+	}
+	select {
+	case check := <-repo.results:
+		t.Errorf("expected no result recorded for the killed plugin, got %+v", check)
+	default:
+	}
+}
+
+func TestValidateNagiosCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir := t.TempDir()
+	plugin := writePlugin(t, dir, "check_load", "exit 0\n")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("docs"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pluginDirs := []string{dir}
+
+	if command, err := validateNagiosCommand(filepath.Join(dir, "sub", "..", "check_load"), pluginDirs); err != nil || command != plugin {
+		t.Errorf("expected %s, got %q, %v", plugin, command, err)
+	}
+	for _, command := range []string{
+		"check_load",
+		filepath.Join(dir, "..", "check_load"),
+		"/bin/sh",
+		filepath.Join(dir, "README"),
+		filepath.Join(dir, "check_missing"),
+		dir,
+	} {
+		if _, err := validateNagiosCommand(command, pluginDirs); err == nil {
+			t.Errorf("%s: expected error", command)
+		}
+	}
+}
+
+func TestValidateNagiosCommandSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	outside := t.TempDir()
+	target := writePlugin(t, outside, "check_anything", "exit 0\n")
+
+	// the plugin directory is reached through a link itself, as /usr/lib64 often is
+	realDir := t.TempDir()
+	dir := filepath.Join(t.TempDir(), "plugins")
+	if err := os.Symlink(realDir, dir); err != nil {
+		t.Fatal(err)
+	}
+	writePlugin(t, realDir, "check_icmp", "exit 0\n")
+	if err := os.Symlink(filepath.Join(dir, "check_icmp"), filepath.Join(realDir, "check_host")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(realDir, "check_escape")); err != nil {
+		t.Fatal(err)
+	}
+	pluginDirs := []string{dir}
+
+	for _, name := range []string{"check_icmp", "check_host"} {
+		if _, err := validateNagiosCommand(filepath.Join(dir, name), pluginDirs); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := validateNagiosCommand(filepath.Join(dir, "check_escape"), pluginDirs); err == nil {
+		t.Error("expected error for a link escaping the plugin directory")
+	}
+}
+
+func TestParseNagiosArguments(t *testing.T) {
+	args, err := ParseNagiosArguments(`["-H", "$HOSTADDRESS$", "-w", "5,4,3"]`)
+	if err != nil || !reflect.DeepEqual(args, []string{"-H", "$HOSTADDRESS$", "-w", "5,4,3"}) {
+		t.Errorf("unexpected arguments %q, %v", args, err)
+	}
+	if args, err := ParseNagiosArguments(""); err != nil || args != nil {
+		t.Errorf("expected no arguments, got %q, %v", args, err)
+	}
+	if _, err := ParseNagiosArguments("-H localhost"); err == nil {
+		t.Error("expected error for non-JSON arguments")
+	}
+}
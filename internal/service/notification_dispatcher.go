@@ -0,0 +1,630 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// NotificationDispatcher groups alert notifications by route and sends them in batches.
+// A new group is sent after group_wait, changes are batched at group_interval and groups
+// with firing alerts are re-sent at repeat_interval.
+type NotificationDispatcher interface {
+	AlertNotifier
+	Start()
+	Stop()
+	FlushOnce(now time.Time)
+	Groups(now time.Time) []model.NotificationGroupStatus
+}
+
+// groupedAlert is the latest known state of an alert inside a notification group
+type groupedAlert struct {
+	Alert  model.NotificationAlert
+	Firing bool
+}
+
+// notificationGroup holds the pending alerts of a route and group key
+type notificationGroup struct {
+	Route      model.NotificationRoute
+	Key        string
+	Labels     map[string]string
+	Alerts     map[uint]groupedAlert
+	Changed    bool
+	CreatedAt  time.Time
+	LastSentAt *time.Time
+	Version    int // 每次变化加一，用于识别发送期间的变化
+}
+
+// notificationDispatcher implements NotificationDispatcher interface
+type notificationDispatcher struct {
+	routeRepo     repository.NotificationRouteRepository
+	pendingRepo   repository.PendingNotificationGroupRepository
+	labeler       alertLabeler
+	defaults      config.NotificationConfig
+	flushInterval time.Duration
+	client        *http.Client
+	logger        *logger.Logger
+
+	mu      sync.Mutex
+	groups  map[string]*notificationGroup
+	stop    chan struct{}
+	running sync.WaitGroup // the background loop, waited for by Stop
+}
+
+// NewNotificationDispatcher creates a new notification dispatcher instance
+func NewNotificationDispatcher(db *gorm.DB, cfg config.NotificationConfig, logger *logger.Logger) NotificationDispatcher {
+	flushInterval := time.Duration(cfg.FlushInterval) * time.Second
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+	if cfg.GroupBy == "" {
+		cfg.GroupBy = "alertname"
+	}
+
+	return &notificationDispatcher{
+		routeRepo:     repository.NewNotificationRouteRepository(db),
+		pendingRepo:   repository.NewPendingNotificationGroupRepository(db),
+		labeler:       newAlertLabeler(db),
+		defaults:      cfg,
+		flushInterval: flushInterval,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		logger:        logger,
+		groups:        make(map[string]*notificationGroup),
+		stop:          make(chan struct{}),
+	}
+}
+
+// Start restores the groups pending before a restart and starts flushing due
+// notification groups in background
+func (d *notificationDispatcher) Start() {
+	d.restore()
+	d.running.Add(1)
+	go func() {
+		defer d.running.Done()
+		ticker := time.NewTicker(d.flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				d.FlushOnce(now)
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops flushing notification groups and waits for the running flush to finish
+func (d *notificationDispatcher) Stop() {
+	close(d.stop)
+	d.running.Wait()
+}
+
+// Notify adds an alert state change to the groups of every matching route
+func (d *notificationDispatcher) Notify(alert model.Alert, event string) error {
+	if event == AlertEventSuppressed {
+		d.withdraw(alert.ID)
+		return nil
+	}
+
+	labels := d.labeler.labels(alert)
+	routes, err := d.matchRoutes(labels)
+	if err != nil {
+		return err
+	}
+
+	entry := groupedAlert{
+		Alert: model.NotificationAlert{
+			AlertID:   alert.ID,
+			Status:    event,
+			Hostname:  alert.Hostname,
+			Severity:  alert.Severity,
+			Labels:    labels,
+			Value:     alert.Value,
+			Message:   alert.Message,
+			StartTime: alert.StartTime,
+			EndTime:   alert.EndTime,
+		},
+		Firing: event == AlertEventFiring,
+	}
+
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, route := range routes {
+		groupLabels := d.groupLabels(route, labels)
+		key := fmt.Sprintf("%d|%s", route.ID, model.FormatLabels(groupLabels))
+
+		group, ok := d.groups[key]
+		if !ok {
+			group = &notificationGroup{
+				Route:     route,
+				Key:       key,
+				Labels:    groupLabels,
+				Alerts:    make(map[uint]groupedAlert),
+				CreatedAt: now,
+			}
+			d.groups[key] = group
+		}
+		group.Route = route
+		group.Alerts[alert.ID] = entry
+		group.Changed = true
+		group.Version++
+		d.persist(group)
+	}
+	return nil
+}
+
+// withdraw removes a suppressed alert from all pending groups so that it is no longer sent
+func (d *notificationDispatcher) withdraw(alertID uint) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, group := range d.groups {
+		if _, ok := group.Alerts[alertID]; !ok {
+			continue
+		}
+		delete(group.Alerts, alertID)
+		group.Changed = true
+		group.Version++
+		if len(group.Alerts) == 0 {
+			delete(d.groups, key)
+			d.forget(key)
+			continue
+		}
+		d.persist(group)
+	}
+}
+
+// persist stores a pending group so that it survives a restart. The caller holds d.mu.
+func (d *notificationDispatcher) persist(group *notificationGroup) {
+	alerts := make([]model.NotificationAlert, 0, len(group.Alerts))
+	for _, entry := range group.Alerts {
+		alerts = append(alerts, entry.Alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].AlertID < alerts[j].AlertID })
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		d.logger.Warn("Failed to encode notification group", "group", group.Key, "error", err)
+		return
+	}
+
+	pending := &model.PendingNotificationGroup{
+		GroupKey:   group.Key,
+		RouteID:    group.Route.ID,
+		Labels:     model.FormatLabels(group.Labels),
+		Alerts:     string(data),
+		Changed:    group.Changed,
+		LastSentAt: group.LastSentAt,
+	}
+	pending.CreatedAt = group.CreatedAt
+	if err := d.pendingRepo.Save(pending); err != nil {
+		d.logger.Warn("Failed to store notification group", "group", group.Key, "error", err)
+	}
+}
+
+// forget removes a sent group from storage. The caller holds d.mu.
+func (d *notificationDispatcher) forget(key string) {
+	if err := d.pendingRepo.Delete(key); err != nil {
+		d.logger.Warn("Failed to delete notification group", "group", key, "error", err)
+	}
+}
+
+// restore loads the groups that were still pending when the server stopped. Groups of
+// routes that have since been deleted are sent to the default route.
+func (d *notificationDispatcher) restore() {
+	pending, err := d.pendingRepo.List()
+	if err != nil {
+		d.logger.Warn("Failed to load pending notification groups", "error", err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, p := range pending {
+		var alerts []model.NotificationAlert
+		if err := json.Unmarshal([]byte(p.Alerts), &alerts); err != nil {
+			d.logger.Warn("Invalid pending notification group", "group", p.GroupKey, "error", err)
+			continue
+		}
+
+		route := defaultNotificationRoute()
+		if p.RouteID != 0 {
+			if stored, err := d.routeRepo.GetByID(p.RouteID); err == nil {
+				route = *stored
+			}
+		}
+		group := &notificationGroup{
+			Route:      route,
+			Key:        p.GroupKey,
+			Labels:     model.ParseLabels(p.Labels),
+			Alerts:     make(map[uint]groupedAlert, len(alerts)),
+			Changed:    p.Changed,
+			CreatedAt:  p.CreatedAt,
+			LastSentAt: p.LastSentAt,
+		}
+		for _, alert := range alerts {
+			group.Alerts[alert.AlertID] = groupedAlert{Alert: alert, Firing: alert.Status == AlertEventFiring}
+		}
+		if _, ok := d.groups[p.GroupKey]; !ok {
+			d.groups[p.GroupKey] = group
+		}
+	}
+	if len(pending) > 0 {
+		d.logger.Info("Restored pending notification groups", "count", len(pending))
+	}
+}
+
+// matchRoutes returns the enabled routes matching the alert labels, falling back to the default route
+func (d *notificationDispatcher) matchRoutes(labels map[string]string) ([]model.NotificationRoute, error) {
+	routes, err := d.routeRepo.GetEnabled()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification routes: %w", err)
+	}
+
+	var matched []model.NotificationRoute
+	for _, route := range routes {
+		if route.Matchers != "" {
+			selector, err := ParseSelector(route.Matchers)
+			if err != nil {
+				d.logger.Warn("Invalid notification route matchers", "route", route.Name, "error", err)
+				continue
+			}
+			if !selector.Matches(labels) {
+				continue
+			}
+		}
+		matched = append(matched, route)
+		if !route.Continue {
+			break
+		}
+	}
+
+	if len(matched) == 0 {
+		matched = append(matched, defaultNotificationRoute())
+	}
+	return matched, nil
+}
+
+// defaultNotificationRoute returns the route used when no configured route matches
+func defaultNotificationRoute() model.NotificationRoute {
+	return model.NotificationRoute{Name: "default", Receiver: model.ReceiverLog}
+}
+
+// groupLabels extracts the values of the route's group_by labels
+func (d *notificationDispatcher) groupLabels(route model.NotificationRoute, labels map[string]string) map[string]string {
+	groupBy := route.GroupBy
+	if groupBy == "" {
+		groupBy = d.defaults.GroupBy
+	}
+
+	grouped := make(map[string]string)
+	for _, key := range strings.Split(groupBy, ",") {
+		key = strings.TrimSpace(key)
+		if key != "" {
+			grouped[key] = labels[key]
+		}
+	}
+	return grouped
+}
+
+// timings returns the group_wait, group_interval and repeat_interval of a route
+func (d *notificationDispatcher) timings(route model.NotificationRoute) (time.Duration, time.Duration, time.Duration) {
+	pick := func(value, fallback int) time.Duration {
+		if value > 0 {
+			return time.Duration(value) * time.Second
+		}
+		return time.Duration(fallback) * time.Second
+	}
+	return pick(route.GroupWait, d.defaults.GroupWait),
+		pick(route.GroupInterval, d.defaults.GroupInterval),
+		pick(route.RepeatInterval, d.defaults.RepeatInterval)
+}
+
+// nextFlush returns when a group is due next and whether that flush is a repeat reminder
+func (d *notificationDispatcher) nextFlush(group *notificationGroup) (time.Time, bool) {
+	groupWait, groupInterval, repeatInterval := d.timings(group.Route)
+	switch {
+	case group.LastSentAt == nil:
+		return group.CreatedAt.Add(groupWait), false
+	case group.Changed:
+		return group.LastSentAt.Add(groupInterval), false
+	default:
+		return group.LastSentAt.Add(repeatInterval), true
+	}
+}
+
+// FlushOnce sends every group that is due
+func (d *notificationDispatcher) FlushOnce(now time.Time) {
+	d.mu.Lock()
+	var due []*notificationGroup
+	var repeats []bool
+	for _, group := range d.groups {
+		next, repeat := d.nextFlush(group)
+		if now.Before(next) {
+			continue
+		}
+		if repeat && firingCount(group) == 0 {
+			continue
+		}
+		due = append(due, group)
+		repeats = append(repeats, repeat)
+	}
+
+	messages := make([]model.NotificationMessage, len(due))
+	versions := make([]int, len(due))
+	for i, group := range due {
+		messages[i] = buildNotificationMessage(group, repeats[i], now)
+		versions[i] = group.Version
+	}
+	d.mu.Unlock()
+
+	for i, group := range due {
+		if err := d.send(group.Route, messages[i]); err != nil {
+			// 保留分组，下次检查时重试
+			d.logger.Warn("Failed to send notification", "route", group.Route.Name, "group", group.Key, "error", err)
+			continue
+		}
+
+		d.mu.Lock()
+		sentAt := now
+		group.LastSentAt = &sentAt
+		// 发送期间分组有变化时保留变化标记和全部告警，在下个分组间隔发送
+		if group.Version == versions[i] {
+			group.Changed = false
+			// 已发送的恢复告警从分组中移除，分组为空时删除
+			for _, sent := range messages[i].Alerts {
+				if sent.Status == AlertEventResolved {
+					delete(group.Alerts, sent.AlertID)
+				}
+			}
+		}
+		if len(group.Alerts) == 0 {
+			// 发送期间被撤回的分组可能已被同键的新分组替换
+			if d.groups[group.Key] == group {
+				delete(d.groups, group.Key)
+				d.forget(group.Key)
+			}
+		} else if d.groups[group.Key] == group {
+			d.persist(group)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// Groups returns the current state of all pending notification groups
+func (d *notificationDispatcher) Groups(now time.Time) []model.NotificationGroupStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	statuses := make([]model.NotificationGroupStatus, 0, len(d.groups))
+	for _, group := range d.groups {
+		next, _ := d.nextFlush(group)
+		statuses = append(statuses, model.NotificationGroupStatus{
+			RouteID:     group.Route.ID,
+			RouteName:   group.Route.Name,
+			GroupKey:    group.Key,
+			GroupLabels: group.Labels,
+			Alerts:      len(group.Alerts),
+			Firing:      firingCount(group),
+			Changed:     group.Changed,
+			CreatedAt:   group.CreatedAt,
+			LastSentAt:  group.LastSentAt,
+			NextFlushAt: next,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].GroupKey < statuses[j].GroupKey
+	})
+	return statuses
+}
+
+// firingCount returns the number of firing alerts in a group
+func firingCount(group *notificationGroup) int {
+	count := 0
+	for _, entry := range group.Alerts {
+		if entry.Firing {
+			count++
+		}
+	}
+	return count
+}
+
+// buildNotificationMessage aggregates the alerts of a group into one message
+func buildNotificationMessage(group *notificationGroup, repeat bool, now time.Time) model.NotificationMessage {
+	message := model.NotificationMessage{
+		RouteID:     group.Route.ID,
+		RouteName:   group.Route.Name,
+		GroupKey:    group.Key,
+		GroupLabels: group.Labels,
+		Repeat:      repeat,
+		Alerts:      make([]model.NotificationAlert, 0, len(group.Alerts)),
+		SentAt:      now,
+	}
+
+	for _, entry := range group.Alerts {
+		message.Alerts = append(message.Alerts, entry.Alert)
+		if entry.Firing {
+			message.Firing++
+		} else {
+			message.Resolved++
+		}
+	}
+	sort.Slice(message.Alerts, func(i, j int) bool {
+		a, b := message.Alerts[i], message.Alerts[j]
+		if a.Status != b.Status {
+			return a.Status == AlertEventFiring
+		}
+		return a.AlertID < b.AlertID
+	})
+
+	message.Status = AlertEventResolved
+	if message.Firing > 0 {
+		message.Status = AlertEventFiring
+	}
+
+	var labels []string
+	for key, value := range group.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	message.Summary = fmt.Sprintf("[%s] %s: %d 条告警触发中，%d 条已恢复",
+		strings.ToUpper(message.Status), strings.Join(labels, ", "), message.Firing, message.Resolved)
+	if repeat {
+		message.Summary += "（重复提醒）"
+	}
+	return message
+}
+
+// send delivers a message to the route receiver
+func (d *notificationDispatcher) send(route model.NotificationRoute, message model.NotificationMessage) error {
+	switch route.Receiver {
+	case model.ReceiverWebhook:
+		return postWebhook(d.client, route.WebhookURL, message)
+	default:
+		d.logger.Info("Alert notification",
+			"route", message.RouteName,
+			"group", message.GroupKey,
+			"status", message.Status,
+			"firing", message.Firing,
+			"resolved", message.Resolved,
+			"repeat", message.Repeat,
+			"summary", message.Summary,
+		)
+		return nil
+	}
+}
+
+// alertLabeler builds the label set of an alert used for routing and grouping
+type alertLabeler struct {
+	alertRepo     repository.AlertRepository
+	hostRepo      repository.HostRepository
+	hostGroupRepo repository.HostGroupRepository
+	cache         *labelerCache // nil 表示每次都查询数据库
+}
+
+// labelerCache 缓存一轮计算中查询到的主机标签和规则
+type labelerCache struct {
+	hosts map[string]map[string]string
+	rules map[uint]model.AlertRule
+}
+
+// newAlertLabeler creates a new alert labeler
+func newAlertLabeler(db *gorm.DB) alertLabeler {
+	return alertLabeler{
+		alertRepo:     repository.NewAlertRepository(db),
+		hostRepo:      repository.NewHostRepository(db),
+		hostGroupRepo: repository.NewHostGroupRepository(db),
+	}
+}
+
+// cached returns a labeler that looks up each host and rule at most once. It is meant
+// for a single evaluation round, so that changes to hosts and rules are picked up by the next one.
+func (l alertLabeler) cached() alertLabeler {
+	l.cache = &labelerCache{
+		hosts: make(map[string]map[string]string),
+		rules: make(map[uint]model.AlertRule),
+	}
+	return l
+}
+
+// labels returns the alert labels: alertname, rule_id, severity, metric_type, the host labels,
+// host_group (comma separated group names) and the series labels of the alert
+func (l alertLabeler) labels(alert model.Alert) map[string]string {
+	labels := make(map[string]string)
+	for key, value := range l.hostLabels(alert.Hostname) {
+		labels[key] = value
+	}
+
+	rule := alert.Rule
+	if rule.ID == 0 {
+		rule = l.rule(alert.RuleID)
+	}
+	labels["alertname"] = rule.Name
+	labels["rule_id"] = strconv.FormatUint(uint64(alert.RuleID), 10)
+	labels["rule_type"] = ruleTypeOf(rule)
+	labels["hostname"] = alert.Hostname
+	labels["severity"] = alert.Severity
+	labels["metric_type"] = alert.MetricType
+
+	for key, value := range model.ParseLabels(alert.Labels) {
+		labels[key] = value
+	}
+	return labels
+}
+
+// hostLabels returns the labels of a host including its group names, or nil for an unknown host
+func (l alertLabeler) hostLabels(hostname string) map[string]string {
+	if l.cache != nil {
+		if labels, ok := l.cache.hosts[hostname]; ok {
+			return labels
+		}
+	}
+
+	var labels map[string]string
+	if host, err := l.hostRepo.GetByHostname(hostname); err == nil {
+		labels = HostLabels(*host)
+		if groups, err := l.hostGroupRepo.GetHostGroups(host.ID); err == nil && len(groups) > 0 {
+			names := make([]string, 0, len(groups))
+			for _, group := range groups {
+				names = append(names, group.Name)
+			}
+			sort.Strings(names)
+			labels["host_group"] = strings.Join(names, ",")
+		}
+	}
+
+	if l.cache != nil {
+		l.cache.hosts[hostname] = labels
+	}
+	return labels
+}
+
+// rule loads an alert rule, returning the zero rule when it no longer exists
+func (l alertLabeler) rule(id uint) model.AlertRule {
+	if l.cache != nil {
+		if rule, ok := l.cache.rules[id]; ok {
+			return rule
+		}
+	}
+
+	var rule model.AlertRule
+	if loaded, err := l.alertRepo.GetRuleByID(id); err == nil {
+		rule = *loaded
+	}
+
+	if l.cache != nil {
+		l.cache.rules[id] = rule
+	}
+	return rule
+}
+
+// postWebhook posts a JSON payload to a webhook receiver
+func postWebhook(client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
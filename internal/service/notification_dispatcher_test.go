@@ -0,0 +1,256 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// staticRouteRepo serves fixed notification routes
+type staticRouteRepo struct {
+	repository.NotificationRouteRepository
+	routes []model.NotificationRoute
+}
+
+func (r staticRouteRepo) GetEnabled() ([]model.NotificationRoute, error) {
+	return r.routes, nil
+}
+
+func (r staticRouteRepo) GetByID(id uint) (*model.NotificationRoute, error) {
+	for _, route := range r.routes {
+		if route.ID == id {
+			return &route, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// memoryPendingRepo keeps pending notification groups in memory
+type memoryPendingRepo struct {
+	groups map[string]model.PendingNotificationGroup
+}
+
+func (r *memoryPendingRepo) List() ([]model.PendingNotificationGroup, error) {
+	var groups []model.PendingNotificationGroup
+	for _, group := range r.groups {
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func (r *memoryPendingRepo) Save(group *model.PendingNotificationGroup) error {
+	r.groups[group.GroupKey] = *group
+	return nil
+}
+
+func (r *memoryPendingRepo) Delete(groupKey string) error {
+	delete(r.groups, groupKey)
+	return nil
+}
+
+// webhookReceiver records the notification messages posted to it
+type webhookReceiver struct {
+	mu       sync.Mutex
+	messages []model.NotificationMessage
+	onPost   func()
+}
+
+func (w *webhookReceiver) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var message model.NotificationMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.mu.Lock()
+	w.messages = append(w.messages, message)
+	onPost := w.onPost
+	w.onPost = nil
+	w.mu.Unlock()
+	if onPost != nil {
+		onPost()
+	}
+}
+
+func (w *webhookReceiver) statuses(i int) map[uint]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	statuses := make(map[uint]string)
+	for _, alert := range w.messages[i].Alerts {
+		statuses[alert.AlertID] = alert.Status
+	}
+	return statuses
+}
+
+func newTestDispatcher(t *testing.T, pending *memoryPendingRepo) (*notificationDispatcher, *webhookReceiver) {
+	t.Helper()
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	route := model.NotificationRoute{Name: "ops", Receiver: model.ReceiverWebhook, WebhookURL: server.URL, Enabled: true}
+	route.ID = 1
+	lookups := &labelLookups{}
+	dispatcher := &notificationDispatcher{
+		routeRepo:   staticRouteRepo{routes: []model.NotificationRoute{route}},
+		pendingRepo: pending,
+		labeler: alertLabeler{
+			alertRepo:     countingAlertRepo{lookups: lookups},
+			hostRepo:      countingHostRepo{lookups: lookups},
+			hostGroupRepo: countingHostGroupRepo{lookups: lookups},
+		},
+		defaults:      config.NotificationConfig{GroupBy: "alertname", GroupWait: 30, GroupInterval: 300, RepeatInterval: 3600},
+		flushInterval: time.Second,
+		client:        server.Client(),
+		logger:        logger.New(config.LogConfig{Level: "error", Format: "text"}),
+		groups:        make(map[string]*notificationGroup),
+		stop:          make(chan struct{}),
+	}
+	return dispatcher, receiver
+}
+
+func testNotificationAlert(id uint, status string) model.Alert {
+	alert := model.Alert{RuleID: 7, Hostname: "web-1", Severity: "warning", Status: status, StartTime: time.Now()}
+	alert.ID = id
+	return alert
+}
+
+func TestNotifyDuringSend(t *testing.T) {
+	d, receiver := newTestDispatcher(t, &memoryPendingRepo{groups: make(map[string]model.PendingNotificationGroup)})
+	if err := d.Notify(testNotificationAlert(1, "active"), AlertEventFiring); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一条消息构建之后、发送完成之前又有告警加入分组
+	receiver.onPost = func() {
+		if err := d.Notify(testNotificationAlert(2, "active"), AlertEventFiring); err != nil {
+			t.Error(err)
+		}
+	}
+	first := time.Now().Add(time.Minute)
+	d.FlushOnce(first)
+
+	groups := d.Groups(first)
+	if len(groups) != 1 || !groups[0].Changed || groups[0].Alerts != 2 {
+		t.Fatalf("groups = %+v, want the alert added during the send pending", groups)
+	}
+	if got := receiver.statuses(0); len(got) != 1 {
+		t.Fatalf("first message = %v, want only alert 1", got)
+	}
+
+	// 在下个分组间隔发送新加入的告警
+	d.FlushOnce(first.Add(4 * time.Minute))
+	if len(receiver.messages) != 1 {
+		t.Fatalf("sent %d messages before the group interval, want 1", len(receiver.messages))
+	}
+	d.FlushOnce(first.Add(5 * time.Minute))
+	if got := receiver.statuses(1); got[1] != AlertEventFiring || got[2] != AlertEventFiring {
+		t.Fatalf("second message = %v, want both alerts firing", got)
+	}
+	if groups := d.Groups(first); groups[0].Changed {
+		t.Errorf("group still changed after an undisturbed send")
+	}
+}
+
+func TestResolvedAlertsLeaveGroupAfterSend(t *testing.T) {
+	pending := &memoryPendingRepo{groups: make(map[string]model.PendingNotificationGroup)}
+	d, receiver := newTestDispatcher(t, pending)
+	if err := d.Notify(testNotificationAlert(1, "resolved"), AlertEventResolved); err != nil {
+		t.Fatal(err)
+	}
+	if len(pending.groups) != 1 {
+		t.Fatalf("stored %d groups, want 1", len(pending.groups))
+	}
+
+	// 发送期间同一告警重新触发，恢复状态不能被当作已发送而移除
+	receiver.onPost = func() {
+		if err := d.Notify(testNotificationAlert(1, "active"), AlertEventFiring); err != nil {
+			t.Error(err)
+		}
+	}
+	now := time.Now().Add(time.Minute)
+	d.FlushOnce(now)
+	if groups := d.Groups(now); len(groups) != 1 || groups[0].Firing != 1 {
+		t.Fatalf("groups = %+v, want the refired alert kept", groups)
+	}
+
+	// 没有变化时已发送的恢复告警被移除，空分组被删除
+	if err := d.Notify(testNotificationAlert(1, "resolved"), AlertEventResolved); err != nil {
+		t.Fatal(err)
+	}
+	d.FlushOnce(now.Add(5 * time.Minute))
+	if groups := d.Groups(now); len(groups) != 0 {
+		t.Errorf("groups = %+v, want none", groups)
+	}
+	if len(pending.groups) != 0 {
+		t.Errorf("stored groups = %+v, want none", pending.groups)
+	}
+}
+
+func TestDispatcherRestoresPendingGroups(t *testing.T) {
+	pending := &memoryPendingRepo{groups: make(map[string]model.PendingNotificationGroup)}
+	d, _ := newTestDispatcher(t, pending)
+	if err := d.Notify(testNotificationAlert(1, "active"), AlertEventFiring); err != nil {
+		t.Fatal(err)
+	}
+	sentAt := time.Now().Add(time.Minute)
+	d.FlushOnce(sentAt)
+	// 恢复通知在分组间隔到达前服务重启
+	if err := d.Notify(testNotificationAlert(1, "resolved"), AlertEventResolved); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, receiver := newTestDispatcher(t, pending)
+	restarted.restore()
+	groups := restarted.Groups(sentAt)
+	if len(groups) != 1 || !groups[0].Changed || groups[0].Firing != 0 || groups[0].LastSentAt == nil || !groups[0].LastSentAt.Equal(sentAt) {
+		t.Fatalf("restored groups = %+v, want the pending resolution", groups)
+	}
+
+	restarted.FlushOnce(sentAt.Add(5 * time.Minute))
+	if len(receiver.messages) != 1 || receiver.statuses(0)[1] != AlertEventResolved {
+		t.Fatalf("messages = %+v, want the resolution sent after the restart", receiver.messages)
+	}
+	if len(pending.groups) != 0 {
+		t.Errorf("stored groups = %+v, want none after sending", pending.groups)
+	}
+}
+
+func TestDispatcherStopWaitsForFlush(t *testing.T) {
+	d, receiver := newTestDispatcher(t, &memoryPendingRepo{groups: make(map[string]model.PendingNotificationGroup)})
+	d.defaults.GroupWait = 0
+	d.flushInterval = 10 * time.Millisecond
+	if err := d.Notify(testNotificationAlert(1, "active"), AlertEventFiring); err != nil {
+		t.Fatal(err)
+	}
+
+	// 发送阻塞时停止，Stop 需要等待发送完成
+	posted, release := make(chan struct{}), make(chan struct{})
+	receiver.onPost = func() {
+		close(posted)
+		<-release
+	}
+	d.Start()
+	<-posted
+	stopped := make(chan struct{})
+	go func() {
+		d.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned during a send")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+}
@@ -0,0 +1,678 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// 探测结果持久化到 metric_samples 的指标名称，标签: check, type (http, tcp, dns)
+const (
+	SampleProbeUp             = "probe_up"               // 探测成功为1，失败为0
+	SampleProbeLatencyMs      = "probe_latency_ms"       // 探测总耗时（毫秒）
+	SampleProbeHTTPStatusCode = "probe_http_status_code" // HTTP 响应状态码，请求失败时不记录
+	SampleProbeTLSHandshakeMs = "probe_tls_handshake_ms" // TLS 握手耗时（毫秒），仅 HTTPS
+)
+
+const (
+	// maxProbeBodyBytes 校验响应体正则时最多读取的字节数
+	maxProbeBodyBytes = 1 << 20
+	// maxProbeBannerBytes 校验 TCP 服务横幅时最多读取的字节数
+	maxProbeBannerBytes = 4096
+)
+
+// ProbeScheduler periodically runs synthetic checks and stores their results
+// as metric samples of the host each check is attached to
+type ProbeScheduler interface {
+	RunOnce(now time.Time) error
+	// Collector returns the collector running due checks every tick interval by default
+	Collector() Collector
+	RunHTTPCheck(check *model.HTTPCheck) model.ProbeResult
+	RunTCPCheck(check *model.TCPCheck) model.ProbeResult
+	RunDNSCheck(check *model.DNSCheck) model.ProbeResult
+	RunCertificateCheck(check *model.CertificateCheck) model.ProbeResult
+}
+
+// probeScheduler implements ProbeScheduler interface
+type probeScheduler struct {
+	httpCheckRepo repository.HTTPCheckRepository
+	tcpCheckRepo  repository.TCPCheckRepository
+	dnsCheckRepo  repository.DNSCheckRepository
+	certCheckRepo repository.CertificateCheckRepository
+	hostRepo      repository.HostRepository
+	sampleRepo    repository.SampleRepository
+	tickInterval  time.Duration
+	maxConcurrent int
+	logger        *logger.Logger
+}
+
+// NewProbeScheduler creates a new probe scheduler instance
+func NewProbeScheduler(db *gorm.DB, cfg config.ProbeConfig, logger *logger.Logger) ProbeScheduler {
+	tickInterval := time.Duration(cfg.TickInterval) * time.Second
+	if tickInterval <= 0 {
+		tickInterval = 5 * time.Second
+	}
+	maxConcurrent := cfg.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = 10
+	}
+
+	return &probeScheduler{
+		httpCheckRepo: repository.NewHTTPCheckRepository(db),
+		tcpCheckRepo:  repository.NewTCPCheckRepository(db),
+		dnsCheckRepo:  repository.NewDNSCheckRepository(db),
+		certCheckRepo: repository.NewCertificateCheckRepository(db),
+		hostRepo:      repository.NewHostRepository(db),
+		sampleRepo:    repository.NewSampleRepository(db),
+		tickInterval:  tickInterval,
+		maxConcurrent: maxConcurrent,
+		logger:        logger,
+	}
+}
+
+// Collector returns the collector running due checks, run by CollectorScheduler
+func (s *probeScheduler) Collector() Collector {
+	return roundCollector("probes", s.tickInterval, s.RunOnce)
+}
+
+// probeDue reports whether a check with the given interval and last run time is due
+func probeDue(lastCheckedAt *time.Time, interval int, now time.Time) bool {
+	if lastCheckedAt == nil {
+		return true
+	}
+	return now.Sub(*lastCheckedAt) >= time.Duration(interval)*time.Second
+}
+
+// RunOnce runs every enabled check that is due, at most maxConcurrent at a time,
+// waits for all of them to finish and then updates the status of hosts with status checks
+func (s *probeScheduler) RunOnce(now time.Time) error {
+	httpChecks, err := s.httpCheckRepo.GetEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to get http checks: %w", err)
+	}
+	tcpChecks, err := s.tcpCheckRepo.GetEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to get tcp checks: %w", err)
+	}
+	dnsChecks, err := s.dnsCheckRepo.GetEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to get dns checks: %w", err)
+	}
+	certChecks, err := s.certCheckRepo.GetEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to get certificate checks: %w", err)
+	}
+
+	var jobs []func()
+	for i := range httpChecks {
+		check := &httpChecks[i]
+		if probeDue(check.LastCheckedAt, check.Interval, now) {
+			jobs = append(jobs, func() { s.RunHTTPCheck(check) })
+		}
+	}
+	for i := range tcpChecks {
+		check := &tcpChecks[i]
+		if probeDue(check.LastCheckedAt, check.Interval, now) {
+			jobs = append(jobs, func() { s.RunTCPCheck(check) })
+		}
+	}
+	for i := range dnsChecks {
+		check := &dnsChecks[i]
+		if probeDue(check.LastCheckedAt, check.Interval, now) {
+			jobs = append(jobs, func() { s.RunDNSCheck(check) })
+		}
+	}
+	for i := range certChecks {
+		check := &certChecks[i]
+		if probeDue(check.LastCheckedAt, check.Interval, now) {
+			jobs = append(jobs, func() { s.RunCertificateCheck(check) })
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.maxConcurrent)
+	for _, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(job func()) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			job()
+		}(job)
+	}
+	wg.Wait()
+
+	var statusChecks []hostStatusCheck
+	hosts := make(map[uint]*model.Host)
+	for _, check := range tcpChecks {
+		if check.AffectsHostStatus && check.Host != nil {
+			statusChecks = append(statusChecks, hostStatusCheck{check.HostID, check.LastCheckedAt != nil, check.ConsecutiveFailures, check.FailureThreshold})
+			hosts[check.HostID] = check.Host
+		}
+	}
+	for _, check := range dnsChecks {
+		if check.AffectsHostStatus && check.Host != nil {
+			statusChecks = append(statusChecks, hostStatusCheck{check.HostID, check.LastCheckedAt != nil, check.ConsecutiveFailures, check.FailureThreshold})
+			hosts[check.HostID] = check.Host
+		}
+	}
+	for hostID, status := range probeHostStatuses(statusChecks) {
+		host := hosts[hostID]
+		if host.Status == status || host.Status == "maintenance" {
+			continue
+		}
+		reason := ""
+		if status == "offline" {
+			reason = model.HostReasonProbeFailed
+		}
+		if err := s.hostRepo.UpdateStatusWithReason(hostID, status, reason); err != nil {
+			s.logger.Warn("Failed to update host status", "hostname", host.Hostname, "error", err)
+			continue
+		}
+		s.logger.Info("Host status changed by probes", "hostname", host.Hostname, "from", host.Status, "to", status)
+	}
+	return nil
+}
+
+// hostStatusCheck is the state of a probe that decides the status of its host
+type hostStatusCheck struct {
+	HostID    uint
+	Checked   bool
+	Failures  int // 连续失败次数
+	Threshold int
+}
+
+// probeHostStatuses derives host statuses from status checks: a host is offline once any of
+// its status checks failed Threshold times in a row, and online once all of them have run
+// without reaching the threshold
+func probeHostStatuses(checks []hostStatusCheck) map[uint]string {
+	statuses := make(map[uint]string)
+	pending := make(map[uint]bool)
+	for _, check := range checks {
+		threshold := check.Threshold
+		if threshold <= 0 {
+			threshold = 1
+		}
+		switch {
+		case check.Failures >= threshold:
+			statuses[check.HostID] = "offline"
+		case !check.Checked:
+			pending[check.HostID] = true
+		case statuses[check.HostID] == "":
+			statuses[check.HostID] = "online"
+		}
+	}
+	for hostID := range pending {
+		if statuses[hostID] == "online" {
+			delete(statuses, hostID)
+		}
+	}
+	return statuses
+}
+
+// RunHTTPCheck runs a single HTTP check, records the result and returns it
+func (s *probeScheduler) RunHTTPCheck(check *model.HTTPCheck) model.ProbeResult {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(check.Timeout))
+	defer cancel()
+
+	result := runHTTPCheck(ctx, *check)
+	if !result.Success {
+		s.logger.Debug("HTTP check failed", "check", check.Name, "url", check.URL, "error", result.Error)
+	}
+
+	check.LastCheckedAt = &result.CheckedAt
+	check.LastSuccess = result.Success
+	check.LastStatusCode = result.StatusCode
+	check.LastLatencyMs = result.LatencyMs
+	check.LastError = result.Error
+	if err := s.httpCheckRepo.UpdateResult(check); err != nil {
+		s.logger.Warn("Failed to update http check result", "check", check.Name, "error", err)
+	}
+
+	if check.Host == nil {
+		s.logger.Warn("HTTP check is not attached to a host, result not recorded", "check", check.Name)
+		return result
+	}
+	if err := s.sampleRepo.CreateBatch(probeSamples(check.Host.Hostname, check.Name, model.ProbeTypeHTTP, result)); err != nil {
+		s.logger.Warn("Failed to record http check result", "check", check.Name, "error", err)
+	}
+	return result
+}
+
+// RunTCPCheck runs a single TCP check, records the result and returns it
+func (s *probeScheduler) RunTCPCheck(check *model.TCPCheck) model.ProbeResult {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(check.Timeout))
+	defer cancel()
+
+	result := runTCPCheck(ctx, *check)
+	if !result.Success {
+		s.logger.Debug("TCP check failed", "check", check.Name, "address", check.Address, "error", result.Error)
+	}
+
+	check.LastCheckedAt = &result.CheckedAt
+	check.LastSuccess = result.Success
+	check.LastLatencyMs = result.LatencyMs
+	check.LastError = result.Error
+	check.ConsecutiveFailures = nextFailureCount(check.ConsecutiveFailures, result.Success)
+	if err := s.tcpCheckRepo.UpdateResult(check); err != nil {
+		s.logger.Warn("Failed to update tcp check result", "check", check.Name, "error", err)
+	}
+
+	if check.Host == nil {
+		s.logger.Warn("TCP check is not attached to a host, result not recorded", "check", check.Name)
+		return result
+	}
+	if err := s.sampleRepo.CreateBatch(probeSamples(check.Host.Hostname, check.Name, model.ProbeTypeTCP, result)); err != nil {
+		s.logger.Warn("Failed to record tcp check result", "check", check.Name, "error", err)
+	}
+	return result
+}
+
+// RunDNSCheck runs a single DNS check, records the result and returns it
+func (s *probeScheduler) RunDNSCheck(check *model.DNSCheck) model.ProbeResult {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(check.Timeout))
+	defer cancel()
+
+	result := runDNSCheck(ctx, *check)
+	if !result.Success {
+		s.logger.Debug("DNS check failed", "check", check.Name, "query", check.QueryName, "error", result.Error)
+	}
+
+	check.LastCheckedAt = &result.CheckedAt
+	check.LastSuccess = result.Success
+	check.LastLatencyMs = result.LatencyMs
+	check.LastAnswers = strings.Join(result.Answers, ",")
+	check.LastError = result.Error
+	check.ConsecutiveFailures = nextFailureCount(check.ConsecutiveFailures, result.Success)
+	if err := s.dnsCheckRepo.UpdateResult(check); err != nil {
+		s.logger.Warn("Failed to update dns check result", "check", check.Name, "error", err)
+	}
+
+	if check.Host == nil {
+		s.logger.Warn("DNS check is not attached to a host, result not recorded", "check", check.Name)
+		return result
+	}
+	if err := s.sampleRepo.CreateBatch(probeSamples(check.Host.Hostname, check.Name, model.ProbeTypeDNS, result)); err != nil {
+		s.logger.Warn("Failed to record dns check result", "check", check.Name, "error", err)
+	}
+	return result
+}
+
+// RunCertificateCheck runs a single certificate check, records the result and returns it.
+// The certificate details of the last successful run are kept when a run fails.
+func (s *probeScheduler) RunCertificateCheck(check *model.CertificateCheck) model.ProbeResult {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(check.Timeout))
+	defer cancel()
+
+	result := runCertificateCheck(ctx, *check)
+	if !result.Success {
+		s.logger.Debug("Certificate check failed", "check", check.Name, "error", result.Error)
+	}
+
+	check.LastCheckedAt = &result.CheckedAt
+	check.LastSuccess = result.Success
+	check.LastError = result.Error
+	if cert := result.Certificate; cert != nil {
+		notBefore, notAfter := cert.NotBefore, cert.NotAfter
+		check.NotBefore = &notBefore
+		check.NotAfter = &notAfter
+		check.Subject = cert.Subject
+		check.Issuer = cert.Issuer
+		check.SANs = strings.Join(cert.SANs, ",")
+		check.ChainValid = cert.ChainValid
+		check.ChainError = cert.ChainError
+	}
+	if err := s.certCheckRepo.UpdateResult(check); err != nil {
+		s.logger.Warn("Failed to update certificate check result", "check", check.Name, "error", err)
+	}
+
+	if check.Host == nil {
+		s.logger.Warn("Certificate check is not attached to a host, result not recorded", "check", check.Name)
+		return result
+	}
+	samples := probeSamples(check.Host.Hostname, check.Name, model.ProbeTypeCert, result)
+	samples = append(samples, certificateSamples(check.Host.Hostname, check.Name, result)...)
+	if err := s.sampleRepo.CreateBatch(samples); err != nil {
+		s.logger.Warn("Failed to record certificate check result", "check", check.Name, "error", err)
+	}
+	return result
+}
+
+// nextFailureCount returns the consecutive failure count after a probe run
+func nextFailureCount(failures int, success bool) int {
+	if success {
+		return 0
+	}
+	return failures + 1
+}
+
+// probeTimeout converts a timeout in seconds, defaulting to 10 seconds
+func probeTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// runHTTPCheck performs the request of an HTTP check within the context deadline and
+// validates the status code and body. Redirects are followed unless the check expects a
+// 3xx status, in which case the redirect response itself is validated
+func runHTTPCheck(ctx context.Context, check model.HTTPCheck) model.ProbeResult {
+	start := time.Now()
+	result := model.ProbeResult{CheckedAt: start}
+	fail := func(format string, args ...interface{}) model.ProbeResult {
+		result.LatencyMs = durationMs(time.Since(start))
+		result.Error = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	method := check.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			if !tlsStart.IsZero() {
+				result.TLSHandshakeMs = durationMs(time.Since(tlsStart))
+			}
+		},
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), method, check.URL, nil)
+	if err != nil {
+		return fail("invalid request: %v", err)
+	}
+	headers, err := ParseProbeHeaders(check.Headers)
+	if err != nil {
+		return fail("%v", err)
+	}
+	for key, value := range headers {
+		if strings.EqualFold(key, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+
+	expected := check.ExpectedStatus
+	if expected == 0 {
+		expected = http.StatusOK
+	}
+
+	// 每次探测使用新连接，以便测量连接和 TLS 握手耗时
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			DisableKeepAlives: true,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: check.SkipTLSVerify},
+		},
+	}
+	// 期望 3xx 状态码时不跟随重定向，校验重定向响应本身
+	if expected >= 300 && expected < 400 {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode != expected {
+		return fail("unexpected status code %d, expected %d", resp.StatusCode, expected)
+	}
+
+	if check.BodyRegex != "" {
+		pattern, err := regexp.Compile(check.BodyRegex)
+		if err != nil {
+			return fail("invalid body regex: %v", err)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBodyBytes))
+		if err != nil {
+			return fail("failed to read body: %v", err)
+		}
+		if !pattern.Match(body) {
+			return fail("body does not match %q", check.BodyRegex)
+		}
+	}
+
+	result.LatencyMs = durationMs(time.Since(start))
+	result.Success = true
+	return result
+}
+
+// ParseProbeHeaders decodes the JSON object of request headers stored on a check
+func ParseProbeHeaders(headers string) (map[string]string, error) {
+	if strings.TrimSpace(headers) == "" {
+		return nil, nil
+	}
+	var parsed map[string]string
+	if err := json.Unmarshal([]byte(headers), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid headers: %w", err)
+	}
+	return parsed, nil
+}
+
+// runTCPCheck connects to the address of a TCP check within the context deadline, sends the
+// configured payload and validates the banner returned by the service
+func runTCPCheck(ctx context.Context, check model.TCPCheck) model.ProbeResult {
+	start := time.Now()
+	result := model.ProbeResult{CheckedAt: start}
+	fail := func(format string, args ...interface{}) model.ProbeResult {
+		result.LatencyMs = durationMs(time.Since(start))
+		result.Error = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", check.Address)
+	if err != nil {
+		return fail("connect failed: %v", err)
+	}
+	defer conn.Close()
+	connected := time.Since(start)
+
+	if check.Send == "" && check.BannerRegex == "" {
+		result.LatencyMs = durationMs(connected)
+		result.Success = true
+		return result
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if check.Send != "" {
+		if _, err := conn.Write([]byte(check.Send)); err != nil {
+			return fail("send failed: %v", err)
+		}
+	}
+
+	if check.BannerRegex != "" {
+		pattern, err := regexp.Compile(check.BannerRegex)
+		if err != nil {
+			return fail("invalid banner regex: %v", err)
+		}
+		// 服务横幅可能分多次到达，读到匹配或超过上限为止
+		banner := make([]byte, 0, 256)
+		buf := make([]byte, 256)
+		for !pattern.Match(banner) {
+			if len(banner) >= maxProbeBannerBytes {
+				return fail("banner does not match %q", check.BannerRegex)
+			}
+			n, err := conn.Read(buf)
+			banner = append(banner, buf[:n]...)
+			if err != nil {
+				if pattern.Match(banner) {
+					break
+				}
+				if err == io.EOF {
+					return fail("banner does not match %q", check.BannerRegex)
+				}
+				return fail("read banner failed: %v", err)
+			}
+		}
+	}
+
+	result.LatencyMs = durationMs(time.Since(start))
+	result.Success = true
+	return result
+}
+
+// dnsRecordTypes DNS 探测支持的记录类型
+var dnsRecordTypes = map[string]bool{"A": true, "AAAA": true, "CNAME": true, "MX": true, "TXT": true, "NS": true}
+
+// ValidDNSRecordType reports whether a DNS record type is supported by DNS checks
+func ValidDNSRecordType(recordType string) bool {
+	return dnsRecordTypes[recordType]
+}
+
+// dnsResolver returns the system resolver or one that sends every query to the given address
+func dnsResolver(address string) *net.Resolver {
+	if address == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// lookupDNS resolves a name for a record type and returns the answers
+func lookupDNS(ctx context.Context, resolver *net.Resolver, name, recordType string) ([]string, error) {
+	var answers []string
+	switch recordType {
+	case "", "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, cname)
+	case "MX":
+		records, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range records {
+			answers = append(answers, mx.Host)
+		}
+	case "TXT":
+		records, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, records...)
+	case "NS":
+		records, err := resolver.LookupNS(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range records {
+			answers = append(answers, ns.Host)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported record type: %s", recordType)
+	}
+	return answers, nil
+}
+
+// dnsAnswerMatches reports whether the answers contain the expected answer, ignoring case
+// and the trailing dot of fully qualified names
+func dnsAnswerMatches(answers []string, expected string) bool {
+	normalize := func(s string) string {
+		return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
+	}
+	for _, answer := range answers {
+		if normalize(answer) == normalize(expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// runDNSCheck resolves the query of a DNS check within the context deadline and validates the answers
+func runDNSCheck(ctx context.Context, check model.DNSCheck) model.ProbeResult {
+	start := time.Now()
+	result := model.ProbeResult{CheckedAt: start}
+
+	answers, err := lookupDNS(ctx, dnsResolver(check.Resolver), check.QueryName, check.RecordType)
+	result.LatencyMs = durationMs(time.Since(start))
+	result.Answers = answers
+	switch {
+	case err != nil:
+		result.Error = fmt.Sprintf("lookup failed: %v", err)
+	case len(answers) == 0:
+		result.Error = "no answers"
+	case check.ExpectedAnswer != "" && !dnsAnswerMatches(answers, check.ExpectedAnswer):
+		result.Error = fmt.Sprintf("answers %s do not contain %q", strings.Join(answers, ","), check.ExpectedAnswer)
+	default:
+		result.Success = true
+	}
+	return result
+}
+
+// probeSamples converts a probe result to metric samples of the host
+func probeSamples(hostname, checkName, probeType string, result model.ProbeResult) []model.MetricSample {
+	labels := model.FormatLabels(map[string]string{"check": checkName, "type": probeType})
+	sample := func(metric string, value float64) model.MetricSample {
+		return model.MetricSample{Hostname: hostname, Metric: metric, Labels: labels, Value: value, Timestamp: result.CheckedAt}
+	}
+
+	up := 0.0
+	if result.Success {
+		up = 1
+	}
+	samples := []model.MetricSample{
+		sample(SampleProbeUp, up),
+		sample(SampleProbeLatencyMs, result.LatencyMs),
+	}
+	if result.StatusCode != 0 {
+		samples = append(samples, sample(SampleProbeHTTPStatusCode, float64(result.StatusCode)))
+	}
+	if result.TLSHandshakeMs > 0 {
+		samples = append(samples, sample(SampleProbeTLSHandshakeMs, result.TLSHandshakeMs))
+	}
+	return samples
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
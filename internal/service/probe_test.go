@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"monitor-server/internal/model"
+)
+
+func runTestCheck(t *testing.T, check model.HTTPCheck) model.ProbeResult {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return runHTTPCheck(ctx, check)
+}
+
+func TestHTTPCheckSucceeds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	defer server.Close()
+
+	result := runTestCheck(t, model.HTTPCheck{URL: server.URL, BodyRegex: `"status":"ok"`})
+	if !result.Success {
+		t.Fatalf("check failed: %s", result.Error)
+	}
+	if result.StatusCode != http.StatusOK {
+		t.Errorf("status code = %d, want 200", result.StatusCode)
+	}
+	if result.LatencyMs <= 0 {
+		t.Errorf("latency = %v, want > 0", result.LatencyMs)
+	}
+	if result.TLSHandshakeMs != 0 {
+		t.Errorf("tls handshake = %v for plain http, want 0", result.TLSHandshakeMs)
+	}
+}
+
+func TestHTTPCheckSendsMethodAndHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.Header.Get("Authorization") != "Bearer token" || r.Host != "service.internal" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	result := runTestCheck(t, model.HTTPCheck{
+		URL:            server.URL,
+		Method:         http.MethodHead,
+		Headers:        `{"Authorization":"Bearer token","Host":"service.internal"}`,
+		ExpectedStatus: http.StatusNoContent,
+	})
+	if !result.Success {
+		t.Fatalf("check failed: %s", result.Error)
+	}
+}
+
+func TestHTTPCheckRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/target", http.StatusMovedPermanently)
+		case "/temporary":
+			http.Redirect(w, r, "/target", http.StatusTemporaryRedirect)
+		default:
+			fmt.Fprint(w, "target")
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name     string
+		check    model.HTTPCheck
+		wantCode int
+	}{
+		{"followed by default", model.HTTPCheck{URL: server.URL + "/moved", BodyRegex: "^target$"}, http.StatusOK},
+		{"expected 301", model.HTTPCheck{URL: server.URL + "/moved", ExpectedStatus: http.StatusMovedPermanently}, http.StatusMovedPermanently},
+		{"expected 307", model.HTTPCheck{URL: server.URL + "/temporary", ExpectedStatus: http.StatusTemporaryRedirect}, http.StatusTemporaryRedirect},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := runTestCheck(t, tc.check)
+			if !result.Success {
+				t.Fatalf("check failed: %s", result.Error)
+			}
+			if result.StatusCode != tc.wantCode {
+				t.Errorf("status code = %d, want %d", result.StatusCode, tc.wantCode)
+			}
+		})
+	}
+
+	// 期望重定向但目标未重定向时失败
+	result := runTestCheck(t, model.HTTPCheck{URL: server.URL + "/target", ExpectedStatus: http.StatusFound})
+	if result.Success || result.StatusCode != http.StatusOK {
+		t.Errorf("result = %+v, want failure with status 200", result)
+	}
+}
+
+func TestHTTPCheckFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+		default:
+			fmt.Fprint(w, "maintenance")
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name      string
+		check     model.HTTPCheck
+		timeout   time.Duration
+		wantCode  int
+		wantError string
+	}{
+		{"unexpected status", model.HTTPCheck{URL: server.URL + "/error"}, time.Second, http.StatusServiceUnavailable, "unexpected status code 503"},
+		{"body mismatch", model.HTTPCheck{URL: server.URL, BodyRegex: "^ok$"}, time.Second, http.StatusOK, "body does not match"},
+		{"timeout", model.HTTPCheck{URL: server.URL + "/slow"}, 100 * time.Millisecond, 0, "request failed"},
+		{"connection refused", model.HTTPCheck{URL: "http://127.0.0.1:1"}, time.Second, 0, "request failed"},
+		{"invalid headers", model.HTTPCheck{URL: server.URL, Headers: "not json"}, time.Second, 0, "invalid headers"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+
+			result := runHTTPCheck(ctx, tc.check)
+			if result.Success {
+				t.Fatalf("check succeeded, want failure")
+			}
+			if result.StatusCode != tc.wantCode {
+				t.Errorf("status code = %d, want %d", result.StatusCode, tc.wantCode)
+			}
+			if !strings.Contains(result.Error, tc.wantError) {
+				t.Errorf("error = %q, want it to contain %q", result.Error, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestHTTPCheckRecordsTLSHandshake(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	// 自签名证书在未跳过校验时探测失败
+	if result := runTestCheck(t, model.HTTPCheck{URL: server.URL}); result.Success {
+		t.Fatalf("check against self-signed certificate succeeded without skip_tls_verify")
+	}
+
+	result := runTestCheck(t, model.HTTPCheck{URL: server.URL, SkipTLSVerify: true})
+	if !result.Success {
+		t.Fatalf("check failed: %s", result.Error)
+	}
+	if result.TLSHandshakeMs <= 0 {
+		t.Errorf("tls handshake = %v, want > 0", result.TLSHandshakeMs)
+	}
+}
+
+func TestProbeSamples(t *testing.T) {
+	checkedAt := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	samples := probeSamples("web-01", "api", model.ProbeTypeHTTP, model.ProbeResult{
+		Success:        true,
+		StatusCode:     200,
+		LatencyMs:      42,
+		TLSHandshakeMs: 7,
+		CheckedAt:      checkedAt,
+	})
+	want := map[string]float64{
+		SampleProbeUp:             1,
+		SampleProbeLatencyMs:      42,
+		SampleProbeHTTPStatusCode: 200,
+		SampleProbeTLSHandshakeMs: 7,
+	}
+	if len(samples) != len(want) {
+		t.Fatalf("got %d samples, want %d", len(samples), len(want))
+	}
+	for _, sample := range samples {
+		if sample.Hostname != "web-01" || sample.Labels != "check=api,type=http" || !sample.Timestamp.Equal(checkedAt) {
+			t.Errorf("sample %s has hostname %q labels %q timestamp %s", sample.Metric, sample.Hostname, sample.Labels, sample.Timestamp)
+		}
+		if value, ok := want[sample.Metric]; !ok || value != sample.Value {
+			t.Errorf("sample %s = %v, want %v", sample.Metric, sample.Value, value)
+		}
+	}
+
+	// 请求失败时只记录 up 和耗时
+	samples = probeSamples("web-01", "api", model.ProbeTypeHTTP, model.ProbeResult{LatencyMs: 3, CheckedAt: checkedAt})
+	if len(samples) != 2 || samples[0].Metric != SampleProbeUp || samples[0].Value != 0 {
+		t.Fatalf("failed probe samples = %+v, want probe_up=0 and latency", samples)
+	}
+}
+
+func TestProbeDue(t *testing.T) {
+	now := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	recent := now.Add(-30 * time.Second)
+	old := now.Add(-time.Minute)
+
+	if !probeDue(nil, 60, now) {
+		t.Error("never checked probe is not due")
+	}
+	if probeDue(&recent, 60, now) {
+		t.Error("probe checked 30s ago with 60s interval is due")
+	}
+	if !probeDue(&old, 60, now) {
+		t.Error("probe checked 60s ago with 60s interval is not due")
+	}
+}
+
+// startTCPServer starts a TCP server that handles each connection with handle
+func startTCPServer(t *testing.T, handle func(conn net.Conn)) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestTCPCheck(t *testing.T) {
+	// 模拟 Redis：收到 PING 后返回 +PONG
+	redis := startTCPServer(t, func(conn net.Conn) {
+		buf := make([]byte, 64)
+		n, _ := conn.Read(buf)
+		if strings.HasPrefix(string(buf[:n]), "PING") {
+			conn.Write([]byte("+PO"))
+			time.Sleep(10 * time.Millisecond)
+			conn.Write([]byte("NG\r\n"))
+		}
+	})
+	// 模拟连接后立即发送横幅的服务
+	smtp := startTCPServer(t, func(conn net.Conn) {
+		conn.Write([]byte("220 mail.example.com ESMTP\r\n"))
+	})
+
+	cases := []struct {
+		name      string
+		check     model.TCPCheck
+		wantError string
+	}{
+		{"connect only", model.TCPCheck{Address: redis}, ""},
+		{"send and match split banner", model.TCPCheck{Address: redis, Send: "PING\r\n", BannerRegex: `^\+PONG`}, ""},
+		{"banner", model.TCPCheck{Address: smtp, BannerRegex: `^220 `}, ""},
+		{"banner mismatch", model.TCPCheck{Address: smtp, BannerRegex: `^554 `}, "banner does not match"},
+		{"connection refused", model.TCPCheck{Address: "127.0.0.1:1"}, "connect failed"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			result := runTCPCheck(ctx, tc.check)
+			if tc.wantError == "" {
+				if !result.Success {
+					t.Fatalf("check failed: %s", result.Error)
+				}
+				return
+			}
+			if result.Success || !strings.Contains(result.Error, tc.wantError) {
+				t.Fatalf("result = %v %q, want failure containing %q", result.Success, result.Error, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestTCPCheckBannerTimeout(t *testing.T) {
+	// 服务接受连接但不返回任何内容
+	silent := startTCPServer(t, func(conn net.Conn) {
+		time.Sleep(time.Second)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	result := runTCPCheck(ctx, model.TCPCheck{Address: silent, BannerRegex: "."})
+	if result.Success || !strings.Contains(result.Error, "read banner failed") {
+		t.Fatalf("result = %v %q, want read timeout", result.Success, result.Error)
+	}
+}
+
+// startDNSServer starts a UDP DNS server answering A queries for the given names
+func startDNSServer(t *testing.T, records map[string]string) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) == 0 {
+				continue
+			}
+
+			question := query.Questions[0]
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.Header.ID, Response: true, Authoritative: true, RCode: dnsmessage.RCodeNameError},
+				Questions: query.Questions,
+			}
+			if ip, ok := records[question.Name.String()]; ok {
+				response.Header.RCode = dnsmessage.RCodeSuccess
+				if question.Type == dnsmessage.TypeA {
+					var a [4]byte
+					copy(a[:], net.ParseIP(ip).To4())
+					response.Answers = []dnsmessage.Resource{{
+						Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+						Body:   &dnsmessage.AResource{A: a},
+					}}
+				}
+			}
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(packed, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDNSCheck(t *testing.T) {
+	resolver := startDNSServer(t, map[string]string{"db.internal.": "10.0.0.5"})
+
+	cases := []struct {
+		name      string
+		check     model.DNSCheck
+		wantError string
+	}{
+		{"resolves", model.DNSCheck{QueryName: "db.internal", RecordType: "A"}, ""},
+		{"expected answer", model.DNSCheck{QueryName: "db.internal", RecordType: "A", ExpectedAnswer: "10.0.0.5"}, ""},
+		{"unexpected answer", model.DNSCheck{QueryName: "db.internal", RecordType: "A", ExpectedAnswer: "10.0.0.6"}, "do not contain"},
+		{"nxdomain", model.DNSCheck{QueryName: "missing.internal", RecordType: "A"}, "lookup failed"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			tc.check.Resolver = resolver
+			result := runDNSCheck(ctx, tc.check)
+			if tc.wantError == "" {
+				if !result.Success {
+					t.Fatalf("check failed: %s", result.Error)
+				}
+				if len(result.Answers) != 1 || result.Answers[0] != "10.0.0.5" {
+					t.Errorf("answers = %v, want [10.0.0.5]", result.Answers)
+				}
+				return
+			}
+			if result.Success || !strings.Contains(result.Error, tc.wantError) {
+				t.Fatalf("result = %v %q, want failure containing %q", result.Success, result.Error, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestDNSAnswerMatches(t *testing.T) {
+	answers := []string{"Mail.Example.com.", "10.0.0.5"}
+	for _, expected := range []string{"mail.example.com", "mail.example.com.", " 10.0.0.5 "} {
+		if !dnsAnswerMatches(answers, expected) {
+			t.Errorf("dnsAnswerMatches(%v, %q) = false, want true", answers, expected)
+		}
+	}
+	if dnsAnswerMatches(answers, "example.com") {
+		t.Errorf("dnsAnswerMatches matched a different name")
+	}
+}
+
+func TestProbeHostStatuses(t *testing.T) {
+	statuses := probeHostStatuses([]hostStatusCheck{
+		// 主机1：一个探测连续失败达到阈值
+		{HostID: 1, Checked: true, Failures: 3, Threshold: 3},
+		{HostID: 1, Checked: true, Failures: 0, Threshold: 3},
+		// 主机2：失败次数未达到阈值
+		{HostID: 2, Checked: true, Failures: 2, Threshold: 3},
+		// 主机3：部分探测尚未执行
+		{HostID: 3, Checked: true, Failures: 0, Threshold: 3},
+		{HostID: 3, Checked: false, Failures: 0, Threshold: 3},
+		// 主机4：先出现正常探测，后出现失败探测
+		{HostID: 4, Checked: true, Failures: 0, Threshold: 1},
+		{HostID: 4, Checked: true, Failures: 1, Threshold: 1},
+	})
+
+	want := map[uint]string{1: "offline", 2: "online", 4: "offline"}
+	if len(statuses) != len(want) {
+		t.Fatalf("statuses = %v, want %v", statuses, want)
+	}
+	for hostID, status := range want {
+		if statuses[hostID] != status {
+			t.Errorf("host %d status = %q, want %q", hostID, statuses[hostID], status)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"monitor-server/internal/model"
+)
+
+const (
+	// processCPUSampleInterval is how long process CPU times are sampled when there is no
+	// recent baseline to compare against
+	processCPUSampleInterval = 500 * time.Millisecond
+	// processCPUBaselineMaxAge is how long the CPU times of a listing remain usable as the
+	// baseline of the next one
+	processCPUBaselineMaxAge = 30 * time.Second
+)
+
+// ErrProcessNotFound is returned when a process does not exist
+var ErrProcessNotFound = errors.New("process not found")
+
+// processSortFields compares two processes by a ProcessInfo field, keyed by its JSON name
+var processSortFields = map[string]func(a, b *model.ProcessInfo) bool{
+	"pid":            func(a, b *model.ProcessInfo) bool { return a.PID < b.PID },
+	"ppid":           func(a, b *model.ProcessInfo) bool { return a.PPID < b.PPID },
+	"name":           func(a, b *model.ProcessInfo) bool { return a.Name < b.Name },
+	"user":           func(a, b *model.ProcessInfo) bool { return a.User < b.User },
+	"cpu_percent":    func(a, b *model.ProcessInfo) bool { return a.CPUPercent < b.CPUPercent },
+	"memory_percent": func(a, b *model.ProcessInfo) bool { return a.MemoryPercent < b.MemoryPercent },
+	"memory_mb":      func(a, b *model.ProcessInfo) bool { return a.MemoryMB < b.MemoryMB },
+	"num_threads":    func(a, b *model.ProcessInfo) bool { return a.NumThreads < b.NumThreads },
+	"status":         func(a, b *model.ProcessInfo) bool { return a.Status < b.Status },
+	"create_time":    func(a, b *model.ProcessInfo) bool { return a.CreateTime.Before(b.CreateTime) },
+	"cmdline":        func(a, b *model.ProcessInfo) bool { return a.Cmdline < b.Cmdline },
+}
+
+// processSortAliases maps the historical sort values to field names
+var processSortAliases = map[string]string{
+	"cpu":    "cpu_percent",
+	"memory": "memory_percent",
+}
+
+// visibleEnvKeys are the environment variables whose values are shown, all others are redacted.
+// Secrets hide in too many names (DATABASE_URL, REDIS_URL, ...) to list the sensitive ones instead.
+var visibleEnvKeys = map[string]bool{
+	"PATH": true, "HOME": true, "PWD": true, "USER": true, "LOGNAME": true, "SHELL": true,
+	"LANG": true, "LANGUAGE": true, "TERM": true, "TZ": true, "HOSTNAME": true, "TMPDIR": true,
+	"SHLVL": true, "GOMAXPROCS": true, "GOGC": true,
+}
+
+// visibleEnvPrefixes are name prefixes of environment variables whose values are shown
+var visibleEnvPrefixes = []string{"LC_", "XDG_"}
+
+// ValidProcessSort reports whether processes can be sorted by the given field
+func ValidProcessSort(field string) bool {
+	_, ok := processSortFields[processSortField(field)]
+	return ok
+}
+
+// processSortField resolves sort aliases
+func processSortField(field string) string {
+	if alias, ok := processSortAliases[field]; ok {
+		return alias
+	}
+	return field
+}
+
+// GetProcessData retrieves process monitoring data
+func (s *monitorService) GetProcessData(ctx context.Context, query model.ProcessQuery) (*model.ProcessData, error) {
+	// Get all process PIDs
+	pids, err := s.source.Pids(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process PIDs: %w", err)
+	}
+
+	var procs []SystemProcess
+	var processes []model.ProcessInfo
+	runningCount := 0
+	sleepingCount := 0
+
+	for _, pid := range pids {
+		proc, err := s.source.Process(ctx, pid)
+		if err != nil {
+			continue // Process might have terminated
+		}
+		info, err := processInfo(ctx, proc)
+		if err != nil {
+			continue
+		}
+
+		// Count process status
+		switch info.Status {
+		case process.Running:
+			runningCount++
+		case process.Sleep, process.Idle:
+			sleepingCount++
+		}
+
+		if !matchesProcessQuery(info, query) {
+			continue
+		}
+		procs = append(procs, proc)
+		processes = append(processes, info)
+	}
+
+	// Only the matched processes are sampled, their times join the baseline of the next listing
+	cpuPercents, err := s.procCPU.sample(ctx, s.source, procs, true)
+	if err != nil {
+		return nil, err
+	}
+	for i := range processes {
+		processes[i].CPUPercent = cpuPercents[processes[i].PID]
+	}
+
+	sortProcesses(processes, query.SortBy, query.Order)
+
+	matched := len(processes)
+	offset := query.Offset
+	if offset > matched {
+		offset = matched
+	}
+	processes = processes[offset:]
+	if query.Limit > 0 && query.Limit < len(processes) {
+		processes = processes[:query.Limit]
+	}
+
+	return &model.ProcessData{
+		Processes:         processes,
+		TotalProcesses:    len(pids),
+		RunningProcesses:  runningCount,
+		SleepingProcesses: sleepingCount,
+		Matched:           matched,
+		Offset:            offset,
+		Limit:             query.Limit,
+	}, nil
+}
+
+// GetProcessDetail retrieves detailed information of a single process
+func (s *monitorService) GetProcessDetail(ctx context.Context, pid int32) (*model.ProcessDetail, error) {
+	proc, err := s.source.Process(ctx, pid)
+	if err != nil {
+		if errors.Is(err, ErrProcessNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get process %d: %w", pid, err)
+	}
+	info, err := processInfo(ctx, proc)
+	if err != nil {
+		return nil, ErrProcessNotFound
+	}
+
+	cpuPercents, err := s.procCPU.sample(ctx, s.source, []SystemProcess{proc}, false)
+	if err != nil {
+		return nil, err
+	}
+	info.CPUPercent = cpuPercents[pid]
+
+	// Fields that cannot be read, usually for lack of permission, are left empty
+	detail := &model.ProcessDetail{
+		ProcessInfo: info,
+		Children:    []model.ProcessRef{},
+		Connections: []model.ProcessConnection{},
+		Environment: map[string]string{},
+	}
+	detail.Exe, _ = proc.Exe(ctx)
+	detail.Cwd, _ = proc.Cwd(ctx)
+	if fds, err := proc.NumFDs(ctx); err == nil {
+		detail.OpenFiles = fds
+	}
+	if io, err := proc.IOCounters(ctx); err == nil {
+		detail.IOCounters = &model.ProcessIOCounters{
+			ReadCount:  io.ReadCount,
+			WriteCount: io.WriteCount,
+			ReadBytes:  io.ReadBytes,
+			WriteBytes: io.WriteBytes,
+		}
+	}
+	if environ, err := proc.Environ(ctx); err == nil {
+		detail.Environment = redactEnvironment(environ)
+	}
+	if conns, err := proc.Connections(ctx); err == nil {
+		for _, conn := range conns {
+			detail.Connections = append(detail.Connections, model.ProcessConnection{
+				Protocol:      connectionProtocol(conn.Family, conn.Type),
+				LocalAddress:  connectionAddress(conn.Laddr.IP, conn.Laddr.Port),
+				RemoteAddress: connectionAddress(conn.Raddr.IP, conn.Raddr.Port),
+				Status:        conn.Status,
+			})
+		}
+	}
+
+	if info.PPID > 0 {
+		if parent, err := s.source.Process(ctx, info.PPID); err == nil {
+			name, _ := parent.Name(ctx)
+			detail.Parent = &model.ProcessRef{PID: info.PPID, Name: name}
+		}
+	}
+	// Children are found by scanning parent PIDs, which avoids running pgrep
+	if pids, err := s.source.Pids(ctx); err == nil {
+		for _, childPID := range pids {
+			child, err := s.source.Process(ctx, childPID)
+			if err != nil {
+				continue
+			}
+			if ppid, err := child.Ppid(ctx); err != nil || ppid != pid {
+				continue
+			}
+			name, _ := child.Name(ctx)
+			detail.Children = append(detail.Children, model.ProcessRef{PID: childPID, Name: name})
+		}
+	}
+
+	return detail, nil
+}
+
+// processInfo reads the listing fields of a process except its CPU usage
+func processInfo(ctx context.Context, proc SystemProcess) (model.ProcessInfo, error) {
+	name, err := proc.Name(ctx)
+	if err != nil {
+		return model.ProcessInfo{}, err
+	}
+	memoryInfo, err := proc.MemoryInfo(ctx)
+	if err != nil {
+		return model.ProcessInfo{}, err
+	}
+
+	info := model.ProcessInfo{
+		PID:      proc.Pid(),
+		Name:     name,
+		MemoryMB: float64(memoryInfo.RSS) / (1024 * 1024), // Convert to MB
+		Status:   "unknown",
+	}
+	info.PPID, _ = proc.Ppid(ctx)
+	info.User, _ = proc.Username(ctx)
+	info.MemoryPercent, _ = proc.MemoryPercent(ctx)
+	info.NumThreads, _ = proc.NumThreads(ctx)
+	info.Cmdline, _ = proc.Cmdline(ctx)
+	if statusList, err := proc.Status(ctx); err == nil && len(statusList) > 0 {
+		info.Status = statusList[0]
+	}
+	if createTime, err := proc.CreateTime(ctx); err == nil {
+		info.CreateTime = time.UnixMilli(createTime)
+	}
+	return info, nil
+}
+
+// matchesProcessQuery reports whether a process passes the filters of a query
+func matchesProcessQuery(info model.ProcessInfo, query model.ProcessQuery) bool {
+	if query.Name != "" && !strings.Contains(strings.ToLower(info.Name), strings.ToLower(query.Name)) {
+		return false
+	}
+	if query.User != "" && info.User != query.User {
+		return false
+	}
+	if query.Status != "" && info.Status != query.Status {
+		return false
+	}
+	if query.Cmdline != "" && !strings.Contains(info.Cmdline, query.Cmdline) {
+		return false
+	}
+	return true
+}
+
+// sortProcesses sorts processes by a field, resource usage fields descending by default.
+// Unknown fields leave the order unchanged.
+func sortProcesses(processes []model.ProcessInfo, field, order string) {
+	field = processSortField(field)
+	less, ok := processSortFields[field]
+	if !ok {
+		return
+	}
+	if order == "" {
+		order = "asc"
+		if field == "cpu_percent" || field == "memory_percent" || field == "memory_mb" {
+			order = "desc"
+		}
+	}
+	sort.SliceStable(processes, func(i, j int) bool {
+		if order == "desc" {
+			return less(&processes[j], &processes[i])
+		}
+		return less(&processes[i], &processes[j])
+	})
+}
+
+// processCPUTime is the cumulated CPU time of a process at a point in time
+type processCPUTime struct {
+	Seconds float64
+	At      time.Time
+}
+
+// processCPUSampler measures the CPU usage of processes over an interval, keeping the CPU
+// times of previous samples as the baseline of the next ones
+type processCPUSampler struct {
+	mu    sync.Mutex
+	times map[int32]processCPUTime // CPU times of recent samples by PID
+}
+
+// sample returns the CPU usage of processes over an interval. The CPU times of previous
+// samples are used as the baseline of the processes they cover when recent enough, the other
+// processes are sampled twice processCPUSampleInterval apart. Processes whose times could not
+// be read twice are left out. When keep is set the new times are merged into the baseline.
+func (c *processCPUSampler) sample(ctx context.Context, source SystemSource, procs []SystemProcess, keep bool) (map[int32]float64, error) {
+	now := source.Now()
+	c.mu.Lock()
+	baseline := make(map[int32]processCPUTime, len(procs))
+	var uncovered []SystemProcess
+	for _, proc := range procs {
+		if last, ok := c.times[proc.Pid()]; ok && processCPUBaselineUsable(last, now) {
+			baseline[proc.Pid()] = last
+		} else {
+			uncovered = append(uncovered, proc)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(uncovered) > 0 {
+		for pid, t := range readProcessCPUTimes(ctx, uncovered, now) {
+			baseline[pid] = t
+		}
+		if err := source.Sleep(ctx, processCPUSampleInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	current := readProcessCPUTimes(ctx, procs, source.Now())
+	if keep {
+		c.merge(current, source.Now())
+	}
+	return processCPUPercents(baseline, current), nil
+}
+
+// processCPUBaselineUsable reports whether a previous sample of a process is neither too
+// recent nor too old to compare against
+func processCPUBaselineUsable(last processCPUTime, now time.Time) bool {
+	age := now.Sub(last.At)
+	return age >= processCPUSampleInterval && age <= processCPUBaselineMaxAge
+}
+
+// merge adds new CPU times to the baseline and drops the times that became too old to be
+// used, such as those of exited processes
+func (c *processCPUSampler) merge(times map[int32]processCPUTime, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.times == nil {
+		c.times = make(map[int32]processCPUTime, len(times))
+	}
+	for pid, t := range times {
+		c.times[pid] = t
+	}
+	cutoff := now.Add(-processCPUBaselineMaxAge)
+	for pid, t := range c.times {
+		if t.At.Before(cutoff) {
+			delete(c.times, pid)
+		}
+	}
+}
+
+// readProcessCPUTimes reads the cumulated user and system CPU time of processes at the given time
+func readProcessCPUTimes(ctx context.Context, procs []SystemProcess, at time.Time) map[int32]processCPUTime {
+	times := make(map[int32]processCPUTime, len(procs))
+	for _, proc := range procs {
+		if t, err := proc.Times(ctx); err == nil {
+			times[proc.Pid()] = processCPUTime{Seconds: t.User + t.System, At: at}
+		}
+	}
+	return times
+}
+
+// processCPUPercents computes the CPU usage of each process between two samples. Processes
+// missing from either sample, or whose PID was reused in between, are left out.
+func processCPUPercents(baseline, current map[int32]processCPUTime) map[int32]float64 {
+	percents := make(map[int32]float64, len(current))
+	for pid, cur := range current {
+		last, ok := baseline[pid]
+		elapsed := cur.At.Sub(last.At).Seconds()
+		if !ok || elapsed <= 0 || cur.Seconds < last.Seconds {
+			continue
+		}
+		percents[pid] = (cur.Seconds - last.Seconds) / elapsed * 100
+	}
+	return percents
+}
+
+// redactEnvironment parses KEY=VALUE pairs and hides the values of all variables that are not
+// known to be harmless. Credentials in URLs of visible variables are removed as well.
+func redactEnvironment(environ []string) map[string]string {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			continue
+		}
+		if !visibleEnv(key) {
+			if value != "" {
+				value = "[REDACTED]"
+			}
+		} else {
+			value = stripURLUserinfo(value)
+		}
+		env[key] = value
+	}
+	return env
+}
+
+// visibleEnv reports whether the value of an environment variable may be shown
+func visibleEnv(key string) bool {
+	upper := strings.ToUpper(key)
+	if visibleEnvKeys[upper] {
+		return true
+	}
+	for _, prefix := range visibleEnvPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripURLUserinfo removes the user and password from a URL value
+func stripURLUserinfo(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil || u.Host == "" {
+		return value
+	}
+	u.User = nil
+	return u.String()
+}
+
+// connectionProtocol names the protocol of a socket from its address family and type
+func connectionProtocol(family, sockType uint32) string {
+	switch family {
+	case syscall.AF_UNIX:
+		return "unix"
+	case syscall.AF_INET, syscall.AF_INET6:
+		protocol := "tcp"
+		if sockType == syscall.SOCK_DGRAM {
+			protocol = "udp"
+		}
+		if family == syscall.AF_INET6 {
+			protocol += "6"
+		}
+		return protocol
+	default:
+		return strconv.FormatUint(uint64(family), 10)
+	}
+}
+
+// connectionAddress formats a socket address, empty when the socket has none
+func connectionAddress(ip string, port uint32) string {
+	if ip == "" && port == 0 {
+		return ""
+	}
+	return net.JoinHostPort(ip, strconv.FormatUint(uint64(port), 10))
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"monitor-server/internal/model"
+)
+
+// GetProcessTree builds the process hierarchy, rooted at rootPID when it is not 0. When
+// collapse is set, sibling processes of the same name, roots included, are merged into a single node.
+func (s *monitorService) GetProcessTree(ctx context.Context, rootPID int32, collapse bool) (*model.ProcessTree, error) {
+	pids, err := s.source.Pids(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process PIDs: %w", err)
+	}
+
+	var procs []SystemProcess
+	var infos []model.ProcessInfo
+	for _, pid := range pids {
+		proc, err := s.source.Process(ctx, pid)
+		if err != nil {
+			continue // Process might have terminated
+		}
+		info, err := processInfo(ctx, proc)
+		if err != nil {
+			continue
+		}
+		procs = append(procs, proc)
+		infos = append(infos, info)
+	}
+
+	cpuPercents, err := s.procCPU.sample(ctx, s.source, procs, true)
+	if err != nil {
+		return nil, err
+	}
+	for i := range infos {
+		infos[i].CPUPercent = cpuPercents[infos[i].PID]
+	}
+
+	roots := buildProcessTree(infos)
+	if rootPID != 0 {
+		root := findProcessTreeNode(roots, rootPID)
+		if root == nil {
+			return nil, ErrProcessNotFound
+		}
+		roots = []*model.ProcessTreeNode{root}
+	}
+	if collapse {
+		roots = collapseProcessTreeNodes(roots)
+	}
+
+	tree := &model.ProcessTree{Roots: roots}
+	for _, root := range roots {
+		aggregateProcessTreeNode(root)
+		tree.TotalProcesses += root.SubtreeProcesses
+		tree.TotalZombies += countProcessTreeZombies(root)
+	}
+	return tree, nil
+}
+
+// buildProcessTree links processes to their parents. Processes whose parent is unknown,
+// including PID 1 and kernel threads, become roots. Children are ordered by PID.
+func buildProcessTree(infos []model.ProcessInfo) []*model.ProcessTreeNode {
+	nodes := make(map[int32]*model.ProcessTreeNode, len(infos))
+	for _, info := range infos {
+		nodes[info.PID] = &model.ProcessTreeNode{
+			PID:           info.PID,
+			PPID:          info.PPID,
+			Name:          info.Name,
+			User:          info.User,
+			Status:        info.Status,
+			Count:         1,
+			CPUPercent:    info.CPUPercent,
+			MemoryPercent: info.MemoryPercent,
+			MemoryMB:      info.MemoryMB,
+			Children:      []*model.ProcessTreeNode{},
+		}
+	}
+
+	var roots []*model.ProcessTreeNode
+	for _, info := range infos {
+		node := nodes[info.PID]
+		parent, ok := nodes[info.PPID]
+		if !ok || info.PPID == info.PID {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+		if node.Status == process.Zombie {
+			parent.Zombies++
+		}
+	}
+
+	sortProcessTreeNodes(roots)
+	for _, node := range nodes {
+		sortProcessTreeNodes(node.Children)
+	}
+	return roots
+}
+
+// findProcessTreeNode returns the node of a PID, nil when it is not in the tree
+func findProcessTreeNode(nodes []*model.ProcessTreeNode, pid int32) *model.ProcessTreeNode {
+	for _, node := range nodes {
+		if node.PID == pid {
+			return node
+		}
+		if found := findProcessTreeNode(node.Children, pid); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// collapseProcessTreeNodes merges siblings of the same name, such as worker pools, into the
+// node with the lowest PID and collapses their merged children in turn
+func collapseProcessTreeNodes(nodes []*model.ProcessTreeNode) []*model.ProcessTreeNode {
+	var collapsed []*model.ProcessTreeNode
+	byName := make(map[string]*model.ProcessTreeNode)
+	for _, node := range nodes {
+		merged, ok := byName[node.Name]
+		if !ok {
+			byName[node.Name] = node
+			collapsed = append(collapsed, node)
+			continue
+		}
+		if merged.PIDs == nil {
+			merged.PIDs = []int32{merged.PID}
+		}
+		merged.PIDs = append(merged.PIDs, node.PID)
+		merged.Count += node.Count
+		merged.CPUPercent += node.CPUPercent
+		merged.MemoryPercent += node.MemoryPercent
+		merged.MemoryMB += node.MemoryMB
+		merged.Zombies += node.Zombies
+		merged.Children = append(merged.Children, node.Children...)
+	}
+
+	for _, node := range collapsed {
+		sortProcessTreeNodes(node.Children)
+		node.Children = collapseProcessTreeNodes(node.Children)
+	}
+	return collapsed
+}
+
+// aggregateProcessTreeNode sums the usage of each subtree into its root
+func aggregateProcessTreeNode(node *model.ProcessTreeNode) {
+	node.SubtreeProcesses = node.Count
+	node.SubtreeCPUPercent = node.CPUPercent
+	node.SubtreeMemoryPercent = node.MemoryPercent
+	node.SubtreeMemoryMB = node.MemoryMB
+	for _, child := range node.Children {
+		aggregateProcessTreeNode(child)
+		node.SubtreeProcesses += child.SubtreeProcesses
+		node.SubtreeCPUPercent += child.SubtreeCPUPercent
+		node.SubtreeMemoryPercent += child.SubtreeMemoryPercent
+		node.SubtreeMemoryMB += child.SubtreeMemoryMB
+	}
+}
+
+// countProcessTreeZombies counts the zombies below a node
+func countProcessTreeZombies(node *model.ProcessTreeNode) int {
+	zombies := node.Zombies
+	for _, child := range node.Children {
+		zombies += countProcessTreeZombies(child)
+	}
+	return zombies
+}
+
+// sortProcessTreeNodes orders nodes by PID
+func sortProcessTreeNodes(nodes []*model.ProcessTreeNode) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].PID < nodes[j].PID })
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+
+	"monitor-server/internal/model"
+)
+
+func testProcessTree() []*model.ProcessTreeNode {
+	return buildProcessTree([]model.ProcessInfo{
+		{PID: 1, PPID: 0, Name: "init", CPUPercent: 1, MemoryMB: 10},
+		{PID: 10, PPID: 1, Name: "nginx", CPUPercent: 1, MemoryMB: 20},
+		{PID: 12, PPID: 10, Name: "nginx", CPUPercent: 4, MemoryMB: 30},
+		{PID: 11, PPID: 10, Name: "nginx", CPUPercent: 3, MemoryMB: 30},
+		{PID: 20, PPID: 1, Name: "app", CPUPercent: 2, MemoryMB: 50},
+		{PID: 21, PPID: 20, Name: "sh", Status: "zombie"},
+		{PID: 22, PPID: 20, Name: "sh", Status: "zombie"},
+		{PID: 99, PPID: 98, Name: "orphan"},
+	})
+}
+
+func TestBuildProcessTree(t *testing.T) {
+	roots := testProcessTree()
+	if len(roots) != 2 || roots[0].PID != 1 || roots[1].PID != 99 {
+		t.Fatalf("expected roots 1 and 99, got %+v", roots)
+	}
+
+	nginx := findProcessTreeNode(roots, 10)
+	if nginx == nil || len(nginx.Children) != 2 || nginx.Children[0].PID != 11 {
+		t.Fatalf("expected nginx workers ordered by pid, got %+v", nginx)
+	}
+	if app := findProcessTreeNode(roots, 20); app.Zombies != 2 {
+		t.Errorf("expected 2 zombies under app, got %d", app.Zombies)
+	}
+	if findProcessTreeNode(roots, 1000) != nil {
+		t.Error("expected no node for unknown pid")
+	}
+
+	aggregateProcessTreeNode(roots[0])
+	if roots[0].SubtreeProcesses != 7 || roots[0].SubtreeCPUPercent != 11 || roots[0].SubtreeMemoryMB != 140 {
+		t.Errorf("unexpected subtree aggregates %+v", roots[0])
+	}
+	if nginx.SubtreeCPUPercent != 8 || countProcessTreeZombies(roots[0]) != 2 {
+		t.Errorf("unexpected nginx aggregates %+v", nginx)
+	}
+}
+
+func TestCollapseProcessTree(t *testing.T) {
+	root := testProcessTree()[0]
+	root.Children = collapseProcessTreeNodes(root.Children)
+	aggregateProcessTreeNode(root)
+
+	nginx := findProcessTreeNode([]*model.ProcessTreeNode{root}, 10)
+	if len(nginx.Children) != 1 {
+		t.Fatalf("expected workers collapsed into one node, got %+v", nginx.Children)
+	}
+	workers := nginx.Children[0]
+	if workers.Count != 2 || workers.CPUPercent != 7 || len(workers.PIDs) != 2 || workers.PIDs[0] != 11 {
+		t.Errorf("unexpected collapsed workers %+v", workers)
+	}
+
+	app := findProcessTreeNode([]*model.ProcessTreeNode{root}, 20)
+	if len(app.Children) != 1 || app.Children[0].Count != 2 || app.Zombies != 2 {
+		t.Errorf("unexpected collapsed app children %+v", app)
+	}
+	if root.SubtreeProcesses != 7 {
+		t.Errorf("expected collapsing to keep the process count, got %d", root.SubtreeProcesses)
+	}
+}
+
+func TestCollapseProcessTreeRoots(t *testing.T) {
+	// 父进程未知的同名进程都是根节点，同样需要合并
+	roots := collapseProcessTreeNodes(buildProcessTree([]model.ProcessInfo{
+		{PID: 2, PPID: 0, Name: "kthreadd"},
+		{PID: 99, PPID: 98, Name: "orphan", CPUPercent: 1},
+		{PID: 101, PPID: 100, Name: "orphan", CPUPercent: 2},
+		{PID: 102, PPID: 101, Name: "worker"},
+		{PID: 104, PPID: 103, Name: "worker"},
+	}))
+	if len(roots) != 3 || roots[0].PID != 2 || roots[1].PID != 99 || roots[2].PID != 104 {
+		t.Fatalf("expected roots 2, 99 and 104, got %+v", roots)
+	}
+	orphans := roots[1]
+	if orphans.Count != 2 || orphans.CPUPercent != 3 || len(orphans.PIDs) != 2 || orphans.PIDs[1] != 101 {
+		t.Errorf("unexpected collapsed orphans %+v", orphans)
+	}
+	if len(orphans.Children) != 1 || orphans.Children[0].PID != 102 {
+		t.Errorf("expected the merged children kept, got %+v", orphans.Children)
+	}
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// 进程监视持久化到 metric_samples 的指标名称，标签: watch
+const (
+	SampleProcessCount      = "process_count"       // 匹配进程数
+	SampleProcessCPUPercent = "process_cpu_percent" // 匹配进程 CPU 使用率之和
+	SampleProcessRSSMB      = "process_rss_mb"      // 匹配进程常驻内存之和（MB）
+	SampleProcessOpenFDs    = "process_open_fds"    // 匹配进程打开文件数之和
+	SampleProcessRestarts   = "process_restarts"    // 与上次评估相比新出现的进程数
+	SampleProcessMissing    = "process_missing"     // 匹配进程少于最小数量为1，否则为0
+	SampleProcessOverLimit  = "process_over_limit"  // 超出任一上限为1，否则为0
+)
+
+// ProcessWatcher evaluates the process watches that apply to the local host
+type ProcessWatcher interface {
+	RunOnce(now time.Time) error
+	// Collector returns the collector evaluating the watches every record interval by default
+	Collector() Collector
+	// Evaluate evaluates a single watch without recording it, returning nil when the
+	// watch does not apply to the local host
+	Evaluate(watch *model.ProcessWatch) (*model.ProcessWatchResult, error)
+}
+
+type processWatcher struct {
+	watchRepo  repository.ProcessWatchRepository
+	hostRepo   repository.HostRepository
+	sampleRepo repository.SampleRepository
+	hostname   string
+	interval   time.Duration
+	source     SystemSource
+	logger     *logger.Logger
+
+	cpu      processCPUSampler // 匹配进程上次采集的 CPU 时间，用于计算区间使用率
+	mu       sync.Mutex
+	lastPIDs map[uint]map[int32]bool // 各监视上次匹配到的进程
+}
+
+// processStat describes a running process for watch matching and evaluation
+type processStat struct {
+	PID        int32
+	Name       string
+	Cmdline    string
+	User       string
+	CPUPercent *float64 // nil until the process has been sampled twice
+	RSSMB      float64
+	OpenFDs    int
+}
+
+// processMatcher is a process watch with its command line pattern compiled
+type processMatcher struct {
+	watch   *model.ProcessWatch
+	cmdline *regexp.Regexp
+}
+
+// NewProcessWatcher creates a new process watcher evaluating at the metrics record interval
+func NewProcessWatcher(db *gorm.DB, cfg config.MonitorConfig, logger *logger.Logger) ProcessWatcher {
+	interval := time.Duration(cfg.RecordInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &processWatcher{
+		watchRepo:  repository.NewProcessWatchRepository(db),
+		hostRepo:   repository.NewHostRepository(db),
+		sampleRepo: repository.NewSampleRepository(db),
+		hostname:   cfg.Hostname,
+		interval:   interval,
+		source:     NewSystemSource(),
+		logger:     logger,
+		lastPIDs:   make(map[uint]map[int32]bool),
+	}
+}
+
+// Collector returns the collector evaluating the process watches, run by CollectorScheduler
+func (w *processWatcher) Collector() Collector {
+	return roundCollector("process_watches", w.interval, w.RunOnce)
+}
+
+// RunOnce evaluates every enabled watch of the local host and records the results
+func (w *processWatcher) RunOnce(now time.Time) error {
+	watches, err := w.watchRepo.GetEnabled()
+	if err != nil {
+		return fmt.Errorf("failed to get process watches: %w", err)
+	}
+	matchers, err := w.localMatchers(watches)
+	if err != nil || len(matchers) == 0 {
+		return err
+	}
+
+	matched, err := w.collect(matchers, true)
+	if err != nil {
+		return err
+	}
+
+	var samples []model.MetricSample
+	w.mu.Lock()
+	for i, matcher := range matchers {
+		result := evaluateProcessWatch(*matcher.watch, matched[i], w.lastPIDs[matcher.watch.ID], now)
+		result.Hostname = w.hostname
+		w.lastPIDs[matcher.watch.ID] = pidSet(matched[i])
+		if result.Missing || result.OverLimit {
+			w.logger.Debug("Process watch violated", "watch", result.Name, "violations", result.Violations)
+		}
+		samples = append(samples, processWatchSamples(w.hostname, result)...)
+	}
+	w.mu.Unlock()
+
+	return w.sampleRepo.CreateBatch(samples)
+}
+
+// Evaluate evaluates a single watch against the current processes of the local host
+func (w *processWatcher) Evaluate(watch *model.ProcessWatch) (*model.ProcessWatchResult, error) {
+	matchers, err := w.localMatchers([]model.ProcessWatch{*watch})
+	if err != nil || len(matchers) == 0 {
+		return nil, err
+	}
+
+	now := w.source.Now()
+	matched, err := w.collect(matchers, false)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	result := evaluateProcessWatch(*watch, matched[0], w.lastPIDs[watch.ID], now)
+	w.mu.Unlock()
+	result.Hostname = w.hostname
+	return &result, nil
+}
+
+// localMatchers returns matchers for the watches that target the local host directly or
+// through one of its groups. Watches with an invalid pattern are skipped.
+func (w *processWatcher) localMatchers(watches []model.ProcessWatch) ([]*processMatcher, error) {
+	localHost, err := w.hostRepo.GetByHostname(w.hostname)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			w.logger.Debug("Local host is not registered, process watches skipped", "hostname", w.hostname)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get local host: %w", err)
+	}
+	localHost, err = w.hostRepo.GetWithGroups(localHost.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local host groups: %w", err)
+	}
+	groups := make(map[uint]bool)
+	for _, group := range localHost.Groups {
+		groups[group.ID] = true
+	}
+
+	var matchers []*processMatcher
+	for i := range watches {
+		watch := &watches[i]
+		local := (watch.HostID != nil && *watch.HostID == localHost.ID) ||
+			(watch.HostGroupID != nil && groups[*watch.HostGroupID])
+		if !local {
+			continue
+		}
+		matcher, err := newProcessMatcher(watch)
+		if err != nil {
+			w.logger.Warn("Invalid process watch", "watch", watch.Name, "error", err)
+			continue
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+// newProcessMatcher compiles the command line pattern of a watch
+func newProcessMatcher(watch *model.ProcessWatch) (*processMatcher, error) {
+	matcher := &processMatcher{watch: watch}
+	if watch.CmdlineRegex != "" {
+		re, err := regexp.Compile(watch.CmdlineRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cmdline regex: %v", err)
+		}
+		matcher.cmdline = re
+	}
+	return matcher, nil
+}
+
+// matches reports whether a process satisfies every criterion of the watch
+func (m *processMatcher) matches(proc processStat) bool {
+	if m.watch.ProcessName != "" && proc.Name != m.watch.ProcessName {
+		return false
+	}
+	if m.cmdline != nil && !m.cmdline.MatchString(proc.Cmdline) {
+		return false
+	}
+	if m.watch.User != "" && proc.User != m.watch.User {
+		return false
+	}
+	return true
+}
+
+// collect lists the local processes and returns the ones matched by each matcher. Resource
+// usage is only read for matched processes. CPU usage is measured over an interval through
+// the shared process CPU sampling, and stays unknown for processes that could not be sampled
+// twice; the CPU times are only kept for the next collection when keep is set.
+func (w *processWatcher) collect(matchers []*processMatcher, keep bool) ([][]processStat, error) {
+	ctx := context.Background()
+	pids, err := w.source.Pids(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process PIDs: %w", err)
+	}
+
+	needUser := false
+	for _, matcher := range matchers {
+		needUser = needUser || matcher.watch.User != ""
+	}
+
+	type matchedProcess struct {
+		stat    processStat
+		watches []int // indexes of the matchers
+	}
+	var found []matchedProcess
+	var procs []SystemProcess
+	for _, pid := range pids {
+		proc, err := w.source.Process(ctx, pid)
+		if err != nil {
+			continue // Process might have terminated
+		}
+		name, err := proc.Name(ctx)
+		if err != nil {
+			continue
+		}
+		stat := processStat{PID: pid, Name: name}
+		stat.Cmdline, _ = proc.Cmdline(ctx)
+		if needUser {
+			stat.User, _ = proc.Username(ctx)
+		}
+
+		var watches []int
+		for i, matcher := range matchers {
+			if matcher.matches(stat) {
+				watches = append(watches, i)
+			}
+		}
+		if len(watches) == 0 {
+			continue
+		}
+		if memoryInfo, err := proc.MemoryInfo(ctx); err == nil {
+			stat.RSSMB = float64(memoryInfo.RSS) / (1024 * 1024)
+		}
+		if fds, err := proc.NumFDs(ctx); err == nil {
+			stat.OpenFDs = int(fds)
+		}
+		found = append(found, matchedProcess{stat: stat, watches: watches})
+		procs = append(procs, proc)
+	}
+
+	cpuPercents, err := w.cpu.sample(ctx, w.source, procs, keep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample process CPU usage: %w", err)
+	}
+
+	matched := make([][]processStat, len(matchers))
+	for _, m := range found {
+		if percent, ok := cpuPercents[m.stat.PID]; ok {
+			m.stat.CPUPercent = &percent
+		}
+		for _, i := range m.watches {
+			matched[i] = append(matched[i], m.stat)
+		}
+	}
+	return matched, nil
+}
+
+// evaluateProcessWatch aggregates the matched processes of a watch and checks its limits.
+// Restarts are counted as processes that were not matched by the previous evaluation.
+func evaluateProcessWatch(watch model.ProcessWatch, procs []processStat, lastPIDs map[int32]bool, now time.Time) model.ProcessWatchResult {
+	result := model.ProcessWatchResult{
+		WatchID:   watch.ID,
+		Name:      watch.Name,
+		Count:     len(procs),
+		PIDs:      []int32{},
+		CheckedAt: now,
+	}
+	cpuPercent, cpuKnown := 0.0, true
+	for _, proc := range procs {
+		result.PIDs = append(result.PIDs, proc.PID)
+		if proc.CPUPercent != nil {
+			cpuPercent += *proc.CPUPercent
+		} else {
+			cpuKnown = false
+		}
+		result.RSSMB += proc.RSSMB
+		result.OpenFDs += proc.OpenFDs
+		if lastPIDs != nil && !lastPIDs[proc.PID] {
+			result.Restarts++
+		}
+	}
+	sort.Slice(result.PIDs, func(i, j int) bool { return result.PIDs[i] < result.PIDs[j] })
+	// the CPU limit is only checked once the usage of every matched process is known
+	if cpuKnown {
+		result.CPUPercent = &cpuPercent
+	}
+
+	if result.Count < watch.MinCount {
+		result.Missing = true
+		result.Violations = append(result.Violations, fmt.Sprintf("%d processes running, at least %d required", result.Count, watch.MinCount))
+	}
+	overLimits := []struct {
+		exceeded bool
+		message  string
+	}{
+		{watch.MaxCount > 0 && result.Count > watch.MaxCount, fmt.Sprintf("%d processes running, at most %d allowed", result.Count, watch.MaxCount)},
+		{watch.MaxCPUPercent > 0 && cpuKnown && cpuPercent > watch.MaxCPUPercent, fmt.Sprintf("cpu %.1f%% over limit %.1f%%", cpuPercent, watch.MaxCPUPercent)},
+		{watch.MaxRSSMB > 0 && result.RSSMB > watch.MaxRSSMB, fmt.Sprintf("rss %.1fMB over limit %.1fMB", result.RSSMB, watch.MaxRSSMB)},
+		{watch.MaxOpenFDs > 0 && result.OpenFDs > watch.MaxOpenFDs, fmt.Sprintf("%d open files over limit %d", result.OpenFDs, watch.MaxOpenFDs)},
+	}
+	for _, limit := range overLimits {
+		if limit.exceeded {
+			result.OverLimit = true
+			result.Violations = append(result.Violations, limit.message)
+		}
+	}
+	return result
+}
+
+// pidSet returns the PIDs of the given processes
+func pidSet(procs []processStat) map[int32]bool {
+	pids := make(map[int32]bool, len(procs))
+	for _, proc := range procs {
+		pids[proc.PID] = true
+	}
+	return pids
+}
+
+// processWatchSamples converts a process watch result to metric samples of the host
+func processWatchSamples(hostname string, result model.ProcessWatchResult) []model.MetricSample {
+	labels := model.FormatLabels(map[string]string{"watch": result.Name})
+	flag := func(b bool) float64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	var cpuPercent float64
+	if result.CPUPercent != nil {
+		cpuPercent = *result.CPUPercent
+	}
+	values := []struct {
+		metric string
+		value  float64
+	}{
+		{SampleProcessCount, float64(result.Count)},
+		{SampleProcessCPUPercent, cpuPercent},
+		{SampleProcessRSSMB, result.RSSMB},
+		{SampleProcessOpenFDs, float64(result.OpenFDs)},
+		{SampleProcessRestarts, float64(result.Restarts)},
+		{SampleProcessMissing, flag(result.Missing)},
+		{SampleProcessOverLimit, flag(result.OverLimit)},
+	}
+
+	samples := make([]model.MetricSample, 0, len(values))
+	for _, v := range values {
+		if v.metric == SampleProcessCPUPercent && result.CPUPercent == nil {
+			continue // unknown CPU usage is not recorded
+		}
+		samples = append(samples, model.MetricSample{Hostname: hostname, Metric: v.metric, Labels: labels, Value: v.value, Timestamp: result.CheckedAt})
+	}
+	return samples
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+func TestProcessMatcher(t *testing.T) {
+	procs := []processStat{
+		{PID: 1, Name: "nginx", Cmdline: "nginx: master process /usr/sbin/nginx", User: "root"},
+		{PID: 2, Name: "nginx", Cmdline: "nginx: worker process", User: "www-data"},
+		{PID: 3, Name: "java", Cmdline: "java -jar /opt/app/app.jar", User: "app"},
+	}
+
+	tests := []struct {
+		watch model.ProcessWatch
+		pids  []int32
+	}{
+		{model.ProcessWatch{ProcessName: "nginx"}, []int32{1, 2}},
+		{model.ProcessWatch{ProcessName: "nginx", CmdlineRegex: "worker"}, []int32{2}},
+		{model.ProcessWatch{User: "app"}, []int32{3}},
+		{model.ProcessWatch{CmdlineRegex: `app\.jar$`, User: "root"}, nil},
+	}
+	for _, tt := range tests {
+		matcher, err := newProcessMatcher(&tt.watch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pids []int32
+		for _, proc := range procs {
+			if matcher.matches(proc) {
+				pids = append(pids, proc.PID)
+			}
+		}
+		if !reflect.DeepEqual(pids, tt.pids) {
+			t.Errorf("%+v: expected %v, got %v", tt.watch, tt.pids, pids)
+		}
+	}
+
+	if _, err := newProcessMatcher(&model.ProcessWatch{CmdlineRegex: "("}); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestEvaluateProcessWatch(t *testing.T) {
+	now := time.Now()
+	watch := model.ProcessWatch{Name: "nginx", MinCount: 2, MaxRSSMB: 100, MaxCPUPercent: 50}
+	cpu := func(percent float64) *float64 { return &percent }
+	procs := []processStat{
+		{PID: 20, CPUPercent: cpu(1.5), RSSMB: 40, OpenFDs: 10},
+		{PID: 10, CPUPercent: cpu(0.5), RSSMB: 30, OpenFDs: 5},
+	}
+
+	result := evaluateProcessWatch(watch, procs, nil, now)
+	if result.Count != 2 || result.CPUPercent == nil || *result.CPUPercent != 2 || result.RSSMB != 70 || result.OpenFDs != 15 {
+		t.Errorf("unexpected aggregates %+v", result)
+	}
+	if !reflect.DeepEqual(result.PIDs, []int32{10, 20}) || result.Restarts != 0 {
+		t.Errorf("expected sorted pids without restarts on first evaluation, got %+v", result)
+	}
+	if result.Missing || result.OverLimit {
+		t.Errorf("expected no violations, got %v", result.Violations)
+	}
+
+	// one worker was replaced and memory grew over the limit, the CPU usage of the new worker
+	// is unknown so the CPU limit is not checked
+	procs = []processStat{{PID: 10, CPUPercent: cpu(20), RSSMB: 30}, {PID: 30, RSSMB: 90}}
+	result = evaluateProcessWatch(watch, procs, pidSet([]processStat{{PID: 10}, {PID: 20}}), now)
+	if result.Restarts != 1 || !result.OverLimit || result.Missing || len(result.Violations) != 1 {
+		t.Errorf("expected one restart and the rss limit exceeded, got %+v", result)
+	}
+	if result.CPUPercent != nil {
+		t.Errorf("expected unknown CPU usage, got %v", *result.CPUPercent)
+	}
+
+	procs[1].CPUPercent = cpu(40)
+	result = evaluateProcessWatch(watch, procs, nil, now)
+	if result.CPUPercent == nil || *result.CPUPercent != 60 || len(result.Violations) != 2 {
+		t.Errorf("expected the cpu limit exceeded once known, got %+v", result)
+	}
+
+	result = evaluateProcessWatch(watch, procs[:1], nil, now)
+	if !result.Missing || result.OverLimit {
+		t.Errorf("expected missing processes, got %+v", result)
+	}
+
+	samples := processWatchSamples("web-1", result)
+	if len(samples) != 7 || samples[5].Metric != SampleProcessMissing || samples[5].Value != 1 {
+		t.Errorf("unexpected samples %+v", samples)
+	}
+
+	// unknown CPU usage is not recorded
+	result = evaluateProcessWatch(watch, []processStat{{PID: 30, RSSMB: 90}}, nil, now)
+	for _, sample := range processWatchSamples("web-1", result) {
+		if sample.Metric == SampleProcessCPUPercent {
+			t.Errorf("expected no cpu sample, got %+v", sample)
+		}
+	}
+}
+
+func TestProcessWatcherCollect(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	postgres := ProcessSnapshot{PID: 200, Name: "postgres", Status: "sleep", CPUUser: 50}
+	source, err := NewFixtureSource(
+		SystemSnapshot{Timestamp: start, Processes: []ProcessSnapshot{
+			{PID: 100, Name: "nginx", Status: "sleep", CPUUser: 10, RSS: 64 << 20},
+			{PID: 101, Name: "nginx", Status: "sleep", CPUUser: 5},
+			postgres,
+		}},
+		SystemSnapshot{Timestamp: start.Add(time.Second), Processes: []ProcessSnapshot{
+			{PID: 100, Name: "nginx", Status: "sleep", CPUUser: 11, RSS: 64 << 20},
+			postgres,
+		}},
+		SystemSnapshot{Timestamp: start.Add(11 * time.Second), Processes: []ProcessSnapshot{
+			{PID: 100, Name: "nginx", Status: "sleep", CPUUser: 12},
+			{PID: 102, Name: "nginx", Status: "sleep", CPUUser: 1},
+			postgres,
+		}},
+		SystemSnapshot{Timestamp: start.Add(12 * time.Second), Processes: []ProcessSnapshot{
+			{PID: 100, Name: "nginx", Status: "sleep", CPUUser: 12.1},
+			{PID: 102, Name: "nginx", Status: "sleep", CPUUser: 1.5},
+			postgres,
+		}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &processWatcher{source: source, lastPIDs: make(map[uint]map[int32]bool)}
+	matcher, err := newProcessMatcher(&model.ProcessWatch{ProcessName: "nginx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpuPercents := func(procs []processStat) map[int32]float64 {
+		percents := make(map[int32]float64)
+		for _, proc := range procs {
+			if proc.CPUPercent != nil {
+				percents[proc.PID] = *proc.CPUPercent
+			}
+		}
+		return percents
+	}
+
+	// the first round samples over an interval, 101 exits in between and its usage stays unknown
+	matched, err := w.collect([]*processMatcher{matcher}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matched[0]) != 2 || matched[0][0].RSSMB != 64 {
+		t.Fatalf("expected both nginx processes, got %+v", matched[0])
+	}
+	if percents := cpuPercents(matched[0]); !reflect.DeepEqual(percents, map[int32]float64{100: 100}) {
+		t.Errorf("expected 100%% for 100 and unknown for 101, got %v", percents)
+	}
+
+	// 100 is measured since the previous round, the new worker 102 over a fresh interval
+	source.Advance()
+	matched, err = w.collect([]*processMatcher{matcher}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	percents := cpuPercents(matched[0])
+	if len(percents) != 2 || !almostEqual(percents[100], 1.1/11*100) || !almostEqual(percents[102], 50) {
+		t.Errorf("unexpected CPU usage %v", percents)
+	}
+}
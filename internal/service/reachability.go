@@ -0,0 +1,401 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// 可达性检测持久化到 metric_samples 的指标名称，不带标签，检测方式记录在主机状态原因中
+const (
+	SampleHostReachable  = "host_reachable"           // 主机可达为1，否则为0
+	SampleHostRTTMs      = "host_rtt_ms"              // 应答的平均往返时间
+	SampleHostPacketLoss = "host_packet_loss_percent" // 未应答的连接或 echo 请求百分比
+)
+
+// ReachabilityChecker checks whether the IP address of each host answers and
+// derives the host status from it together with the agent heartbeat
+type ReachabilityChecker interface {
+	Start()
+	Stop()
+	RunOnce(now time.Time) error
+}
+
+type reachabilityChecker struct {
+	hostRepo         repository.HostRepository
+	tcpCheckRepo     repository.TCPCheckRepository
+	dnsCheckRepo     repository.DNSCheckRepository
+	sampleRepo       repository.SampleRepository
+	interval         time.Duration
+	ports            []int
+	timeout          time.Duration
+	attempts         int
+	failureThreshold int
+	agentSilentAfter time.Duration
+	maxConcurrent    int
+	logger           *logger.Logger
+	stop             chan struct{}
+	running          sync.WaitGroup // the background loop, waited for by Stop
+
+	mu       sync.Mutex
+	failures map[uint]int // 各主机连续不可达的轮数
+}
+
+// NewReachabilityChecker creates a new reachability checker
+func NewReachabilityChecker(db *gorm.DB, cfg config.ReachabilityConfig, logger *logger.Logger) ReachabilityChecker {
+	interval := time.Duration(cfg.Interval) * time.Second
+	if interval <= 0 {
+		interval = 60 * time.Second
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	attempts := cfg.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	failureThreshold := cfg.FailureThreshold
+	if failureThreshold <= 0 {
+		failureThreshold = 2
+	}
+	agentSilentAfter := time.Duration(cfg.AgentSilentAfter) * time.Second
+	if agentSilentAfter <= 0 {
+		agentSilentAfter = 300 * time.Second
+	}
+	maxConcurrent := cfg.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = 10
+	}
+
+	return &reachabilityChecker{
+		hostRepo:         repository.NewHostRepository(db),
+		tcpCheckRepo:     repository.NewTCPCheckRepository(db),
+		dnsCheckRepo:     repository.NewDNSCheckRepository(db),
+		sampleRepo:       repository.NewSampleRepository(db),
+		interval:         interval,
+		ports:            cfg.Ports,
+		timeout:          timeout,
+		attempts:         attempts,
+		failureThreshold: failureThreshold,
+		agentSilentAfter: agentSilentAfter,
+		maxConcurrent:    maxConcurrent,
+		logger:           logger,
+		stop:             make(chan struct{}),
+		failures:         make(map[uint]int),
+	}
+}
+
+// Start starts checking hosts in background
+func (c *reachabilityChecker) Start() {
+	c.running.Add(1)
+	go func() {
+		defer c.running.Done()
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				if err := c.RunOnce(now); err != nil {
+					c.logger.Error("Failed to check host reachability", "error", err)
+				}
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops checking hosts and waits for the running round to finish
+func (c *reachabilityChecker) Stop() {
+	close(c.stop)
+	c.running.Wait()
+}
+
+// RunOnce checks every monitored host with an IP address, at most maxConcurrent at a time.
+// Hosts in maintenance and hosts whose status is decided by status probes are left alone.
+func (c *reachabilityChecker) RunOnce(now time.Time) error {
+	hosts, err := c.hostRepo.GetMonitoringEnabledHosts()
+	if err != nil {
+		return fmt.Errorf("failed to get hosts: %w", err)
+	}
+	probed, err := c.probedHosts()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.maxConcurrent)
+	for i := range hosts {
+		host := &hosts[i]
+		if host.IPAddress == "" || host.Status == "maintenance" || probed[host.ID] {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.checkHost(host, now)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// probedHosts returns the hosts that have enabled probes affecting their status
+func (c *reachabilityChecker) probedHosts() (map[uint]bool, error) {
+	tcpChecks, err := c.tcpCheckRepo.GetEnabled()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tcp checks: %w", err)
+	}
+	dnsChecks, err := c.dnsCheckRepo.GetEnabled()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dns checks: %w", err)
+	}
+
+	probed := make(map[uint]bool)
+	for _, check := range tcpChecks {
+		if check.AffectsHostStatus {
+			probed[check.HostID] = true
+		}
+	}
+	for _, check := range dnsChecks {
+		if check.AffectsHostStatus {
+			probed[check.HostID] = true
+		}
+	}
+	return probed, nil
+}
+
+// checkHost checks a single host, records the result and updates the host status
+func (c *reachabilityChecker) checkHost(host *model.Host, now time.Time) {
+	result := checkReachability(host.IPAddress, c.ports, c.timeout, c.attempts)
+	result.HostID = host.ID
+	result.Hostname = host.Hostname
+	if result.Sent == 0 {
+		// 未能检测，不记录样本也不计入连续不可达轮数
+		c.logger.Warn("Host reachability unknown", "hostname", host.Hostname, "ip", host.IPAddress, "error", result.Error)
+		return
+	}
+	if !result.Reachable {
+		c.logger.Debug("Host unreachable", "hostname", host.Hostname, "ip", host.IPAddress, "error", result.Error)
+	}
+
+	if err := c.sampleRepo.CreateBatch(reachabilitySamples(host.Hostname, result)); err != nil {
+		c.logger.Warn("Failed to record host reachability", "hostname", host.Hostname, "error", err)
+	}
+
+	c.mu.Lock()
+	if result.Reachable {
+		delete(c.failures, host.ID)
+	} else {
+		c.failures[host.ID]++
+	}
+	failures := c.failures[host.ID]
+	c.mu.Unlock()
+
+	agentSilent := host.LastSeen == nil || now.Sub(*host.LastSeen) > c.agentSilentAfter
+	status, reason, ok := reachabilityStatus(*host, result.Reachable, result.Method, failures, c.failureThreshold, agentSilent)
+	if !ok || (status == host.Status && reason == host.StatusReason) {
+		return
+	}
+	if err := c.hostRepo.UpdateStatusWithReason(host.ID, status, reason); err != nil {
+		c.logger.Warn("Failed to update host status", "hostname", host.Hostname, "error", err)
+		return
+	}
+	c.logger.Info("Host status changed by reachability check", "hostname", host.Hostname, "from", host.Status, "to", status, "reason", reason)
+}
+
+// reachabilityStatus derives the status and reason of a host. A reporting agent proves the host
+// is up; otherwise the host is online while reachable and offline once it was unreachable for
+// threshold rounds in a row. The reason names the check method that decided the status.
+// ok is false when the status should be left unchanged.
+func reachabilityStatus(host model.Host, reachable bool, method string, failures, threshold int, agentSilent bool) (status, reason string, ok bool) {
+	switch {
+	case host.Agent && !agentSilent:
+		return "online", "", true
+	case reachable && host.Agent:
+		return "online", reachabilityReason(model.HostReasonAgentSilent, method), true
+	case reachable:
+		return "online", "", true
+	case failures >= threshold:
+		return "offline", reachabilityReason(model.HostReasonUnreachable, method), true
+	default:
+		return "", "", false
+	}
+}
+
+// reachabilityReason appends the check method to a host status reason
+func reachabilityReason(reason, method string) string {
+	if method == "" {
+		return reason
+	}
+	return reason + " by " + method
+}
+
+// checkReachability tests an IP address with TCP connects to the given ports in order and
+// repeats the connect to the first port that answers. When no port answers it falls back to
+// an unprivileged ping socket, which requires the kernel to allow it (net.ipv4.ping_group_range).
+// When no ports are configured and ping is unavailable nothing is sent, Sent is 0 and the
+// result has no method.
+func checkReachability(ip string, ports []int, timeout time.Duration, attempts int) model.ReachabilityResult {
+	result := model.ReachabilityResult{IPAddress: ip, CheckedAt: time.Now()}
+
+	var rtts []time.Duration
+	for _, port := range ports {
+		address := net.JoinHostPort(ip, strconv.Itoa(port))
+		rtt, ok := tcpConnect(address, timeout)
+		if !ok {
+			continue
+		}
+		result.Method = model.ReachMethodTCP
+		result.Port = port
+		result.Sent, result.Received = 1, 1
+		rtts = append(rtts, rtt)
+		for ; result.Sent < attempts; result.Sent++ {
+			if rtt, ok := tcpConnect(address, timeout); ok {
+				result.Received++
+				rtts = append(rtts, rtt)
+			}
+		}
+		break
+	}
+
+	if result.Received == 0 {
+		sent, pingRTTs, err := ping(ip, attempts, timeout)
+		switch {
+		case err != nil && len(ports) == 0:
+			// nothing was sent, so reachability is unknown rather than lossless
+			result.Error = fmt.Sprintf("reachability unknown: no tcp ports configured and %v", err)
+		case err != nil:
+			// ping unavailable, report the failed connects instead
+			result.Method = model.ReachMethodTCP
+			result.Sent = len(ports)
+			result.Error = fmt.Sprintf("no tcp port answered and %v", err)
+		default:
+			result.Method = model.ReachMethodICMP
+			result.Sent, result.Received = sent, len(pingRTTs)
+			rtts = pingRTTs
+			if result.Received == 0 {
+				result.Error = "no tcp port or echo request answered"
+			}
+		}
+	}
+
+	result.Reachable = result.Received > 0
+	if result.Sent > 0 {
+		result.PacketLoss = float64(result.Sent-result.Received) / float64(result.Sent) * 100
+	}
+	if len(rtts) > 0 {
+		var total time.Duration
+		for _, rtt := range rtts {
+			total += rtt
+		}
+		result.RTTMs = durationMs(total / time.Duration(len(rtts)))
+	}
+	return result
+}
+
+// tcpConnect connects to an address and reports whether the host answered. A refused
+// connection is an answer too, since the host itself sent the reset.
+func tcpConnect(address string, timeout time.Duration) (time.Duration, bool) {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	rtt := time.Since(start)
+	if err == nil {
+		conn.Close()
+		return rtt, true
+	}
+	return rtt, errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// ping sends echo requests through an unprivileged ICMP datagram socket and returns the
+// number sent and the round trip times of the replies
+func ping(ip string, count int, timeout time.Duration) (int, []time.Duration, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return 0, nil, fmt.Errorf("invalid ip address %q", ip)
+	}
+
+	network, listen, protocol := "udp4", "0.0.0.0", 1
+	var echoType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	if addr.To4() == nil {
+		network, listen, protocol = "udp6", "::", 58
+		echoType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+
+	conn, err := icmp.ListenPacket(network, listen)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unprivileged ping is not permitted: %v", err)
+	}
+	defer conn.Close()
+
+	var rtts []time.Duration
+	buf := make([]byte, 1500)
+	sent := 0
+	for seq := 1; seq <= count; seq++ {
+		// the kernel sets the echo identifier of datagram sockets, replies are matched by sequence
+		msg := icmp.Message{Type: echoType, Body: &icmp.Echo{Seq: seq, Data: []byte("monitor-server")}}
+		data, err := msg.Marshal(nil)
+		if err != nil {
+			return sent, rtts, err
+		}
+
+		start := time.Now()
+		sent++
+		if _, err := conn.WriteTo(data, &net.UDPAddr{IP: addr}); err != nil {
+			continue
+		}
+		if err := conn.SetReadDeadline(start.Add(timeout)); err != nil {
+			return sent, rtts, err
+		}
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				break
+			}
+			reply, err := icmp.ParseMessage(protocol, buf[:n])
+			if err != nil || reply.Type != replyType {
+				continue
+			}
+			if echo, ok := reply.Body.(*icmp.Echo); ok && echo.Seq == seq {
+				rtts = append(rtts, time.Since(start))
+				break
+			}
+		}
+	}
+	return sent, rtts, nil
+}
+
+// reachabilitySamples converts a reachability result to metric samples of the host. The samples
+// carry no method label so that a host falling back from tcp to icmp stays a single series.
+func reachabilitySamples(hostname string, result model.ReachabilityResult) []model.MetricSample {
+	reachable := 0.0
+	if result.Reachable {
+		reachable = 1
+	}
+	samples := []model.MetricSample{
+		{Hostname: hostname, Metric: SampleHostReachable, Value: reachable, Timestamp: result.CheckedAt},
+		{Hostname: hostname, Metric: SampleHostPacketLoss, Value: result.PacketLoss, Timestamp: result.CheckedAt},
+	}
+	if result.Received > 0 {
+		samples = append(samples, model.MetricSample{Hostname: hostname, Metric: SampleHostRTTMs, Value: result.RTTMs, Timestamp: result.CheckedAt})
+	}
+	return samples
+}
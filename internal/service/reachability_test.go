@@ -0,0 +1,111 @@
+package service
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+func TestCheckReachabilityTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	// a closed port answers with a reset and proves the host is reachable as well
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	for _, ports := range [][]int{{port}, {closedPort}} {
+		result := checkReachability("127.0.0.1", ports, time.Second, 3)
+		if !result.Reachable || result.Method != model.ReachMethodTCP || result.Port != ports[0] {
+			t.Errorf("ports %v: expected reachable over tcp, got %+v", ports, result)
+		}
+		if result.Sent != 3 || result.Received != 3 || result.PacketLoss != 0 {
+			t.Errorf("ports %v: expected 3 answered attempts, got %+v", ports, result)
+		}
+	}
+}
+
+func TestCheckReachabilityUnknownWithoutPortsOrPing(t *testing.T) {
+	// ping fails for an invalid address just as it does without ping socket permission
+	result := checkReachability("not-an-ip", nil, time.Second, 3)
+	if result.Reachable || result.Method != "" || result.Sent != 0 {
+		t.Errorf("expected an unknown result, got %+v", result)
+	}
+	if result.PacketLoss != 0 || !strings.Contains(result.Error, "reachability unknown") {
+		t.Errorf("expected an explanatory error and no packet loss, got %+v", result)
+	}
+}
+
+func TestReachabilitySamples(t *testing.T) {
+	now := time.Now()
+	samples := reachabilitySamples("web-1", model.ReachabilityResult{
+		Reachable: true, Method: model.ReachMethodTCP, Sent: 3, Received: 2, PacketLoss: 100.0 / 3, RTTMs: 1.5, CheckedAt: now,
+	})
+	if len(samples) != 3 || samples[0].Metric != SampleHostReachable || samples[0].Value != 1 || samples[2].Metric != SampleHostRTTMs {
+		t.Errorf("unexpected samples %+v", samples)
+	}
+
+	samples = reachabilitySamples("web-1", model.ReachabilityResult{Method: model.ReachMethodICMP, Sent: 3, PacketLoss: 100, CheckedAt: now})
+	if len(samples) != 2 || samples[0].Value != 0 || samples[1].Value != 100 {
+		t.Errorf("expected no rtt sample for an unreachable host, got %+v", samples)
+	}
+	// 检测方式不同的样本属于同一序列
+	for _, sample := range samples {
+		if sample.Labels != "" {
+			t.Errorf("sample %s has labels %q, want none", sample.Metric, sample.Labels)
+		}
+	}
+}
+
+func TestReachabilityStatus(t *testing.T) {
+	agent := model.Host{Agent: true}
+	agentless := model.Host{}
+
+	tests := []struct {
+		host        model.Host
+		reachable   bool
+		failures    int
+		agentSilent bool
+		status      string
+		reason      string
+		ok          bool
+	}{
+		{agent, false, 5, false, "online", "", true},
+		{agent, true, 0, true, "online", model.HostReasonAgentSilent + " by tcp", true},
+		{agentless, true, 0, true, "online", "", true},
+		{agentless, false, 1, true, "", "", false},
+		{agent, false, 2, true, "offline", model.HostReasonUnreachable + " by tcp", true},
+	}
+	for i, tt := range tests {
+		status, reason, ok := reachabilityStatus(tt.host, tt.reachable, model.ReachMethodTCP, tt.failures, 2, tt.agentSilent)
+		if status != tt.status || reason != tt.reason || ok != tt.ok {
+			t.Errorf("case %d: expected %q %q %v, got %q %q %v", i, tt.status, tt.reason, tt.ok, status, reason, ok)
+		}
+	}
+}
+
+func TestPingRejectsInvalidAddress(t *testing.T) {
+	if _, _, err := ping("not-an-ip", 1, time.Second); err == nil {
+		t.Error("expected error for invalid address")
+	}
+}
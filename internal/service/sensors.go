@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+
+	"monitor-server/internal/model"
+)
+
+// 硬件传感器持久化到 metric_samples 的指标名称，标签: chip, sensor
+const (
+	SampleSensorTemperature  = "sensor_temperature_celsius"
+	SampleSensorFan          = "sensor_fan_rpm"
+	SampleSensorPower        = "sensor_power_watts"
+	SampleSensorVoltage      = "sensor_voltage_volts"
+	SampleSensorCurrent      = "sensor_current_amps"
+	SampleSensorOverLimit    = "sensor_over_limit"    // 超出上限、低于下限或芯片报警为1，否则为0
+	SampleSensorOverCritical = "sensor_over_critical" // 达到临界值为1，否则为0
+)
+
+// defaultHwmonRoot is the sysfs directory of hardware monitoring chips
+const defaultHwmonRoot = "/sys/class/hwmon"
+
+// cpuSensorChips are the chips whose temperatures are reported as the CPU temperature
+var cpuSensorChips = map[string]bool{"coretemp": true, "k10temp": true, "zenpower": true, "cpu_thermal": true}
+
+// hwmonSensorType describes how the attributes of a sensor type are read
+type hwmonSensorType struct {
+	sensorType string
+	unit       string
+	scale      float64  // sysfs values are in milli or micro units
+	inputs     []string // value attributes, the first existing is used
+	high       []string
+	critical   []string
+}
+
+// hwmonSensorTypes maps attribute prefixes to sensor types, see the kernel hwmon sysfs interface
+var hwmonSensorTypes = map[string]hwmonSensorType{
+	"temp":  {model.SensorTypeTemperature, "°C", 1e-3, []string{"input"}, []string{"max"}, []string{"crit"}},
+	"fan":   {model.SensorTypeFan, "RPM", 1, []string{"input"}, []string{"max"}, nil},
+	"power": {model.SensorTypePower, "W", 1e-6, []string{"input", "average"}, []string{"cap", "max"}, []string{"crit"}},
+	"in":    {model.SensorTypeVoltage, "V", 1e-3, []string{"input"}, []string{"max"}, []string{"crit"}},
+	"curr":  {model.SensorTypeCurrent, "A", 1e-3, []string{"input"}, []string{"max"}, []string{"crit"}},
+}
+
+// hwmonTypeOrder orders sensors of a chip by type
+var hwmonTypeOrder = map[string]int{"temp": 0, "fan": 1, "power": 2, "in": 3, "curr": 4}
+
+// hwmonAttributePattern matches sensor attribute file names such as temp1_input
+var hwmonAttributePattern = regexp.MustCompile(`^(temp|fan|power|in|curr)([0-9]+)_(input|average)$`)
+
+// SensorSample returns the metric sample name of a sensor type
+func SensorSample(sensorType string) string {
+	switch sensorType {
+	case model.SensorTypeTemperature:
+		return SampleSensorTemperature
+	case model.SensorTypeFan:
+		return SampleSensorFan
+	case model.SensorTypePower:
+		return SampleSensorPower
+	case model.SensorTypeVoltage:
+		return SampleSensorVoltage
+	case model.SensorTypeCurrent:
+		return SampleSensorCurrent
+	}
+	return ""
+}
+
+// GetSensorData retrieves all hardware sensors from the hwmon chips of the source, falling back
+// to its temperatures where hwmon is not available
+func (s *monitorService) GetSensorData(ctx context.Context) (*model.SensorData, error) {
+	chips, err := s.source.HwmonSensors(ctx)
+	if err != nil || len(chips) == 0 {
+		// 部分传感器读取失败时仍返回其余的温度
+		temps, _ := s.source.Temperatures(ctx)
+		chips = temperatureSensorChips(temps)
+	}
+	return &model.SensorData{Chips: chips, Timestamp: s.source.Now()}, nil
+}
+
+// cpuTemperature returns the highest temperature reported by a CPU chip
+func cpuTemperature(chips []model.SensorChip) *float64 {
+	var temperature *float64
+	for _, chip := range chips {
+		if !cpuSensorChips[chip.Name] {
+			continue
+		}
+		for _, sensor := range chip.Sensors {
+			if sensor.Type == model.SensorTypeTemperature && (temperature == nil || sensor.Value > *temperature) {
+				value := sensor.Value
+				temperature = &value
+			}
+		}
+	}
+	return temperature
+}
+
+// readHwmonChips reads every chip below a hwmon class directory
+func readHwmonChips(root string) ([]model.SensorChip, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var chips []model.SensorChip
+	for _, entry := range entries {
+		dir := filepath.Join(root, entry.Name())
+		// 旧内核的属性文件位于 device 目录下
+		attrDir := dir
+		if _, err := os.Stat(filepath.Join(dir, "name")); err != nil {
+			attrDir = filepath.Join(dir, "device")
+		}
+		name, err := readSysfsString(filepath.Join(attrDir, "name"))
+		if err != nil {
+			continue
+		}
+
+		chip := model.SensorChip{ID: name, Name: name, Sensors: readHwmonSensors(attrDir)}
+		if target, err := filepath.EvalSymlinks(filepath.Join(dir, "device")); err == nil {
+			chip.Device = filepath.Base(target)
+			chip.ID = name + "-" + chip.Device
+		}
+		chips = append(chips, chip)
+	}
+
+	sort.Slice(chips, func(i, j int) bool { return chips[i].ID < chips[j].ID })
+	return chips, nil
+}
+
+// readHwmonSensors reads the sensors of a chip. Sensors whose value cannot be read, which
+// some drivers report for unconnected inputs, are skipped.
+func readHwmonSensors(dir string) []model.SensorReading {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	type sensorKey struct {
+		prefix string
+		index  int
+	}
+	seen := make(map[sensorKey]bool)
+	var keys []sensorKey
+	for _, entry := range entries {
+		match := hwmonAttributePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		index, _ := strconv.Atoi(match[2])
+		key := sensorKey{match[1], index}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].prefix != keys[j].prefix {
+			return hwmonTypeOrder[keys[i].prefix] < hwmonTypeOrder[keys[j].prefix]
+		}
+		return keys[i].index < keys[j].index
+	})
+
+	sensors := []model.SensorReading{}
+	for _, key := range keys {
+		if sensor, ok := readHwmonSensor(dir, key.prefix+strconv.Itoa(key.index), hwmonSensorTypes[key.prefix]); ok {
+			sensors = append(sensors, sensor)
+		}
+	}
+	return sensors
+}
+
+// readHwmonSensor reads the value, label, limits and alarm of one sensor
+func readHwmonSensor(dir, key string, sensorType hwmonSensorType) (model.SensorReading, bool) {
+	attribute := func(names []string) *float64 {
+		for _, name := range names {
+			if value, err := readSysfsFloat(filepath.Join(dir, key+"_"+name)); err == nil {
+				value *= sensorType.scale
+				return &value
+			}
+		}
+		return nil
+	}
+
+	value := attribute(sensorType.inputs)
+	if value == nil {
+		return model.SensorReading{}, false
+	}
+	label, err := readSysfsString(filepath.Join(dir, key+"_label"))
+	if err != nil {
+		label = key
+	}
+
+	sensor := model.SensorReading{
+		Key:      key,
+		Label:    label,
+		Type:     sensorType.sensorType,
+		Unit:     sensorType.unit,
+		Value:    *value,
+		Min:      attribute([]string{"min"}),
+		High:     attribute(sensorType.high),
+		Critical: attribute(sensorType.critical),
+	}
+	for _, name := range []string{"alarm", "min_alarm", "max_alarm", "crit_alarm"} {
+		if alarm, err := readSysfsFloat(filepath.Join(dir, key+"_"+name)); err == nil && alarm != 0 {
+			sensor.Alarm = true
+		}
+	}
+	sensor.Status = sensorStatus(sensor)
+	return sensor, true
+}
+
+// sensorStatus classifies a reading against its limits. Limits of zero are treated as unset,
+// as many drivers report them for sensors without limits.
+func sensorStatus(sensor model.SensorReading) string {
+	if sensor.Critical != nil && *sensor.Critical > 0 && sensor.Value >= *sensor.Critical {
+		return model.SensorStatusCritical
+	}
+	if sensor.Alarm ||
+		(sensor.High != nil && *sensor.High > 0 && sensor.Value >= *sensor.High) ||
+		(sensor.Min != nil && *sensor.Min > 0 && sensor.Value < *sensor.Min) {
+		return model.SensorStatusAlarm
+	}
+	return model.SensorStatusNormal
+}
+
+// temperatureSensorChips groups the temperatures reported by gopsutil by the chip name
+// prefixing their keys
+func temperatureSensorChips(temps []host.TemperatureStat) []model.SensorChip {
+	var chips []model.SensorChip
+	index := make(map[string]int)
+	for _, temp := range temps {
+		name, label, _ := strings.Cut(temp.SensorKey, "_")
+		if label == "" {
+			label = temp.SensorKey
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(chips)
+			index[name] = i
+			chips = append(chips, model.SensorChip{ID: name, Name: name})
+		}
+
+		sensor := model.SensorReading{
+			Key:   label,
+			Label: label,
+			Type:  model.SensorTypeTemperature,
+			Unit:  "°C",
+			Value: temp.Temperature,
+		}
+		if temp.High > 0 {
+			high := temp.High
+			sensor.High = &high
+		}
+		if temp.Critical > 0 {
+			critical := temp.Critical
+			sensor.Critical = &critical
+		}
+		sensor.Status = sensorStatus(sensor)
+		chips[i].Sensors = append(chips[i].Sensors, sensor)
+	}
+	return chips
+}
+
+// sensorSamples converts sensor readings to metric samples of the host
+func sensorSamples(hostname string, data *model.SensorData, now time.Time) []model.MetricSample {
+	var samples []model.MetricSample
+	for _, chip := range data.Chips {
+		for _, sensor := range chip.Sensors {
+			metric := SensorSample(sensor.Type)
+			if metric == "" {
+				continue
+			}
+			labels := model.FormatLabels(map[string]string{"chip": chip.ID, "sensor": sensor.Key})
+			overLimit, overCritical := 0.0, 0.0
+			switch sensor.Status {
+			case model.SensorStatusCritical:
+				overLimit, overCritical = 1, 1
+			case model.SensorStatusAlarm:
+				overLimit = 1
+			}
+			samples = append(samples,
+				model.MetricSample{Hostname: hostname, Metric: metric, Labels: labels, Value: sensor.Value, Timestamp: now},
+				model.MetricSample{Hostname: hostname, Metric: SampleSensorOverLimit, Labels: labels, Value: overLimit, Timestamp: now},
+				model.MetricSample{Hostname: hostname, Metric: SampleSensorOverCritical, Labels: labels, Value: overCritical, Timestamp: now},
+			)
+		}
+	}
+	return samples
+}
+
+// readSysfsString reads a sysfs attribute without the trailing newline
+func readSysfsString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readSysfsFloat reads a numeric sysfs attribute
+func readSysfsFloat(path string) (float64, error) {
+	value, err := readSysfsString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(value, 64)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"monitor-server/internal/model"
+)
+
+func TestReadHwmonChips(t *testing.T) {
+	chips, err := readHwmonChips(filepath.Join("testdata", "sysfs", "class", "hwmon"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	byID := make(map[string]model.SensorChip)
+	for _, chip := range chips {
+		ids = append(ids, chip.ID)
+		byID[chip.ID] = chip
+	}
+	expectedIDs := []string{"acpitz", "amdgpu-amdgpu.0", "coretemp-coretemp.0", "k10temp-k10temp.0", "nct6775-nct6775.656"}
+	if len(ids) != len(expectedIDs) {
+		t.Fatalf("expected chips %v, got %v", expectedIDs, ids)
+	}
+	for i := range ids {
+		if ids[i] != expectedIDs[i] {
+			t.Fatalf("expected chips %v, got %v", expectedIDs, ids)
+		}
+	}
+
+	coretemp := byID["coretemp-coretemp.0"]
+	if coretemp.Name != "coretemp" || coretemp.Device != "coretemp.0" || len(coretemp.Sensors) != 3 {
+		t.Fatalf("unexpected coretemp chip %+v", coretemp)
+	}
+	pkg, core0, core8 := coretemp.Sensors[0], coretemp.Sensors[1], coretemp.Sensors[2]
+	if pkg.Key != "temp1" || pkg.Label != "Package id 0" || pkg.Value != 45 || *pkg.High != 80 || *pkg.Critical != 100 || pkg.Status != model.SensorStatusNormal {
+		t.Errorf("unexpected package sensor %+v", pkg)
+	}
+	if core0.Key != "temp2" || !core0.Alarm || core0.Status != model.SensorStatusCritical {
+		t.Errorf("unexpected core 0 sensor %+v", core0)
+	}
+	// sensors are ordered by index, not by name
+	if core8.Key != "temp10" || core8.Label != "Core 8" {
+		t.Errorf("unexpected core 8 sensor %+v", core8)
+	}
+
+	superio := byID["nct6775-nct6775.656"]
+	if len(superio.Sensors) != 3 {
+		t.Fatalf("expected the unreadable temperature to be skipped, got %+v", superio.Sensors)
+	}
+	fan1, fan2, vcore := superio.Sensors[0], superio.Sensors[1], superio.Sensors[2]
+	if fan1.Type != model.SensorTypeFan || fan1.Value != 1200 || fan1.Unit != "RPM" || fan1.Label != "fan1" || fan1.Status != model.SensorStatusNormal {
+		t.Errorf("unexpected fan1 sensor %+v", fan1)
+	}
+	if fan2.Value != 0 || *fan2.Min != 300 || fan2.Status != model.SensorStatusAlarm {
+		t.Errorf("unexpected fan2 sensor %+v", fan2)
+	}
+	if vcore.Type != model.SensorTypeVoltage || vcore.Label != "Vcore" || vcore.Value != 1.032 || *vcore.High != 1.744 || vcore.Status != model.SensorStatusNormal {
+		t.Errorf("unexpected vcore sensor %+v", vcore)
+	}
+
+	gpu := byID["amdgpu-amdgpu.0"]
+	if len(gpu.Sensors) != 2 {
+		t.Fatalf("unexpected gpu sensors %+v", gpu.Sensors)
+	}
+	power, current := gpu.Sensors[0], gpu.Sensors[1]
+	if power.Type != model.SensorTypePower || power.Label != "PPT" || power.Value != 45 || *power.High != 150 {
+		t.Errorf("unexpected power sensor %+v", power)
+	}
+	if current.Type != model.SensorTypeCurrent || current.Value != 2.5 || current.Unit != "A" {
+		t.Errorf("unexpected current sensor %+v", current)
+	}
+
+	// attributes below the device directory
+	k10temp := byID["k10temp-k10temp.0"]
+	if len(k10temp.Sensors) != 1 || k10temp.Sensors[0].Value != 52.125 {
+		t.Errorf("unexpected k10temp chip %+v", k10temp)
+	}
+	acpitz := byID["acpitz"]
+	if acpitz.Device != "" || len(acpitz.Sensors) != 1 || *acpitz.Sensors[0].Critical != 105 {
+		t.Errorf("unexpected acpitz chip %+v", acpitz)
+	}
+
+	if temperature := cpuTemperature(chips); temperature == nil || *temperature != 101 {
+		t.Errorf("expected CPU temperature 101, got %v", temperature)
+	}
+}
+
+func TestReadHwmonChipsMissing(t *testing.T) {
+	if _, err := readHwmonChips(filepath.Join(t.TempDir(), "hwmon")); err == nil {
+		t.Error("expected error for missing hwmon directory")
+	}
+}
+
+func TestGetSensorData(t *testing.T) {
+	s := &monitorService{source: gopsutilSource{hwmonRoot: filepath.Join("testdata", "sysfs", "class", "hwmon")}}
+	data, err := s.GetSensorData(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Chips) != 5 {
+		t.Errorf("expected 5 chips, got %d", len(data.Chips))
+	}
+}
+
+func TestSensorSamples(t *testing.T) {
+	high, critical := 80.0, 100.0
+	data := &model.SensorData{Chips: []model.SensorChip{{
+		ID: "coretemp-coretemp.0",
+		Sensors: []model.SensorReading{
+			{Key: "temp1", Type: model.SensorTypeTemperature, Value: 101, High: &high, Critical: &critical, Status: model.SensorStatusCritical},
+			{Key: "fan1", Type: model.SensorTypeFan, Value: 1200, Status: model.SensorStatusNormal},
+		},
+	}}}
+
+	values := make(map[string]float64)
+	for _, sample := range sensorSamples("web-01", data, time.Now()) {
+		values[sample.Metric+" "+sample.Labels] = sample.Value
+	}
+	expected := map[string]float64{
+		"sensor_temperature_celsius chip=coretemp-coretemp.0,sensor=temp1": 101,
+		"sensor_over_limit chip=coretemp-coretemp.0,sensor=temp1":          1,
+		"sensor_over_critical chip=coretemp-coretemp.0,sensor=temp1":       1,
+		"sensor_fan_rpm chip=coretemp-coretemp.0,sensor=fan1":              1200,
+		"sensor_over_limit chip=coretemp-coretemp.0,sensor=fan1":           0,
+		"sensor_over_critical chip=coretemp-coretemp.0,sensor=fan1":        0,
+	}
+	if len(values) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, values)
+	}
+	for key, value := range expected {
+		if values[key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, values[key])
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+
+	"monitor-server/internal/model"
+)
+
+// SystemSource provides the raw system data monitorService works on, so that filtering,
+// sorting, history and rate calculation can run on recorded data as well as on the live system
+type SystemSource interface {
+	Clock
+	// Sleep waits between two readings that are compared, such as process CPU times
+	Sleep(ctx context.Context, d time.Duration) error
+
+	CPUPercent(ctx context.Context, interval time.Duration) (float64, error) // total usage over the interval
+	CPUInfo(ctx context.Context) ([]cpu.InfoStat, error)
+	CPUCount(ctx context.Context) (int, error) // logical CPUs
+	VirtualMemory(ctx context.Context) (*mem.VirtualMemoryStat, error)
+	SwapMemory(ctx context.Context) (*mem.SwapMemoryStat, error)
+	Partitions(ctx context.Context) ([]disk.PartitionStat, error) // physical partitions only
+	DiskUsage(ctx context.Context, mountpoint string) (*disk.UsageStat, error)
+	NetIOCounters(ctx context.Context) ([]net.IOCountersStat, error) // per interface
+	NetInterfaces(ctx context.Context) ([]net.InterfaceStat, error)
+	Connections(ctx context.Context) ([]net.ConnectionStat, error) // IPv4 and IPv6 sockets
+	HostInfo(ctx context.Context) (*host.InfoStat, error)
+	LoadAvg(ctx context.Context) (*load.AvgStat, error)
+	Temperatures(ctx context.Context) ([]host.TemperatureStat, error)    // may be partial along with an error
+	HwmonSensors(ctx context.Context) ([]model.SensorChip, error)        // hwmon chips, an error where hwmon is not available
+	FileHandles(ctx context.Context) (*model.FileDescriptorUsage, error) // system-wide, Linux only
+	// Fstab returns the mount points declared in the static mount table, mapped to whether
+	// they are mounted read-only
+	Fstab(ctx context.Context) (map[string]bool, error)
+	Pids(ctx context.Context) ([]int32, error)
+	// Process returns a process by PID, ErrProcessNotFound when it does not exist
+	Process(ctx context.Context, pid int32) (SystemProcess, error)
+}
+
+// SystemProcess provides the attributes of a single process. Methods fail once the
+// process has exited.
+type SystemProcess interface {
+	Pid() int32
+	Name(ctx context.Context) (string, error)
+	Ppid(ctx context.Context) (int32, error)
+	Username(ctx context.Context) (string, error)
+	MemoryInfo(ctx context.Context) (*process.MemoryInfoStat, error)
+	MemoryPercent(ctx context.Context) (float32, error)
+	NumThreads(ctx context.Context) (int32, error)
+	Cmdline(ctx context.Context) (string, error)
+	Status(ctx context.Context) ([]string, error)
+	CreateTime(ctx context.Context) (int64, error) // milliseconds since the epoch
+	Times(ctx context.Context) (*cpu.TimesStat, error)
+	Exe(ctx context.Context) (string, error)
+	Cwd(ctx context.Context) (string, error)
+	NumFDs(ctx context.Context) (int32, error)
+	IOCounters(ctx context.Context) (*process.IOCountersStat, error)
+	Environ(ctx context.Context) ([]string, error)
+	Connections(ctx context.Context) ([]net.ConnectionStat, error)
+}
+
+// gopsutilSource implements SystemSource on the live system
+type gopsutilSource struct {
+	realClock
+	hwmonRoot  string // sysfs directory of hardware monitoring chips
+	fileNrPath string // system-wide file handle counters
+	fstabPath  string // static mount table used to detect read-only remounts
+}
+
+// NewSystemSource returns a data source reading the live system through gopsutil
+func NewSystemSource() SystemSource {
+	return gopsutilSource{hwmonRoot: defaultHwmonRoot, fileNrPath: defaultFileNrPath, fstabPath: defaultFstabPath}
+}
+
+func (gopsutilSource) Sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (gopsutilSource) CPUPercent(ctx context.Context, interval time.Duration) (float64, error) {
+	percents, err := cpu.PercentWithContext(ctx, interval, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(percents) == 0 {
+		return 0, fmt.Errorf("no CPU usage reported")
+	}
+	return percents[0], nil
+}
+
+func (gopsutilSource) CPUInfo(ctx context.Context) ([]cpu.InfoStat, error) {
+	return cpu.InfoWithContext(ctx)
+}
+
+func (gopsutilSource) CPUCount(ctx context.Context) (int, error) {
+	return runtime.NumCPU(), nil
+}
+
+func (gopsutilSource) VirtualMemory(ctx context.Context) (*mem.VirtualMemoryStat, error) {
+	return mem.VirtualMemoryWithContext(ctx)
+}
+
+func (gopsutilSource) SwapMemory(ctx context.Context) (*mem.SwapMemoryStat, error) {
+	return mem.SwapMemoryWithContext(ctx)
+}
+
+func (gopsutilSource) Partitions(ctx context.Context) ([]disk.PartitionStat, error) {
+	return disk.PartitionsWithContext(ctx, false)
+}
+
+func (gopsutilSource) DiskUsage(ctx context.Context, mountpoint string) (*disk.UsageStat, error) {
+	return disk.UsageWithContext(ctx, mountpoint)
+}
+
+func (gopsutilSource) NetIOCounters(ctx context.Context) ([]net.IOCountersStat, error) {
+	return net.IOCountersWithContext(ctx, true)
+}
+
+func (gopsutilSource) NetInterfaces(ctx context.Context) ([]net.InterfaceStat, error) {
+	return net.InterfacesWithContext(ctx)
+}
+
+func (gopsutilSource) Connections(ctx context.Context) ([]net.ConnectionStat, error) {
+	return net.ConnectionsWithContext(ctx, "inet")
+}
+
+func (gopsutilSource) HostInfo(ctx context.Context) (*host.InfoStat, error) {
+	return host.InfoWithContext(ctx)
+}
+
+func (gopsutilSource) LoadAvg(ctx context.Context) (*load.AvgStat, error) {
+	return load.AvgWithContext(ctx)
+}
+
+func (gopsutilSource) Temperatures(ctx context.Context) ([]host.TemperatureStat, error) {
+	return host.SensorsTemperaturesWithContext(ctx)
+}
+
+func (s gopsutilSource) HwmonSensors(ctx context.Context) ([]model.SensorChip, error) {
+	return readHwmonChips(s.hwmonRoot)
+}
+
+func (s gopsutilSource) FileHandles(ctx context.Context) (*model.FileDescriptorUsage, error) {
+	return readFileNr(s.fileNrPath)
+}
+
+func (s gopsutilSource) Fstab(ctx context.Context) (map[string]bool, error) {
+	return readFstabReadOnly(s.fstabPath)
+}
+
+func (gopsutilSource) Pids(ctx context.Context) ([]int32, error) {
+	return process.PidsWithContext(ctx)
+}
+
+func (gopsutilSource) Process(ctx context.Context, pid int32) (SystemProcess, error) {
+	proc, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		if errors.Is(err, process.ErrorProcessNotRunning) {
+			return nil, ErrProcessNotFound
+		}
+		return nil, err
+	}
+	return gopsutilProcess{proc}, nil
+}
+
+// gopsutilProcess implements SystemProcess on a live process
+type gopsutilProcess struct {
+	proc *process.Process
+}
+
+func (p gopsutilProcess) Pid() int32 { return p.proc.Pid }
+
+func (p gopsutilProcess) Name(ctx context.Context) (string, error) {
+	return p.proc.NameWithContext(ctx)
+}
+
+func (p gopsutilProcess) Ppid(ctx context.Context) (int32, error) {
+	return p.proc.PpidWithContext(ctx)
+}
+
+func (p gopsutilProcess) Username(ctx context.Context) (string, error) {
+	return p.proc.UsernameWithContext(ctx)
+}
+
+func (p gopsutilProcess) MemoryInfo(ctx context.Context) (*process.MemoryInfoStat, error) {
+	return p.proc.MemoryInfoWithContext(ctx)
+}
+
+func (p gopsutilProcess) MemoryPercent(ctx context.Context) (float32, error) {
+	return p.proc.MemoryPercentWithContext(ctx)
+}
+
+func (p gopsutilProcess) NumThreads(ctx context.Context) (int32, error) {
+	return p.proc.NumThreadsWithContext(ctx)
+}
+
+func (p gopsutilProcess) Cmdline(ctx context.Context) (string, error) {
+	return p.proc.CmdlineWithContext(ctx)
+}
+
+func (p gopsutilProcess) Status(ctx context.Context) ([]string, error) {
+	return p.proc.StatusWithContext(ctx)
+}
+
+func (p gopsutilProcess) CreateTime(ctx context.Context) (int64, error) {
+	return p.proc.CreateTimeWithContext(ctx)
+}
+
+func (p gopsutilProcess) Times(ctx context.Context) (*cpu.TimesStat, error) {
+	return p.proc.TimesWithContext(ctx)
+}
+
+func (p gopsutilProcess) Exe(ctx context.Context) (string, error) {
+	return p.proc.ExeWithContext(ctx)
+}
+
+func (p gopsutilProcess) Cwd(ctx context.Context) (string, error) {
+	return p.proc.CwdWithContext(ctx)
+}
+
+func (p gopsutilProcess) NumFDs(ctx context.Context) (int32, error) {
+	return p.proc.NumFDsWithContext(ctx)
+}
+
+func (p gopsutilProcess) IOCounters(ctx context.Context) (*process.IOCountersStat, error) {
+	return p.proc.IOCountersWithContext(ctx)
+}
+
+func (p gopsutilProcess) Environ(ctx context.Context) ([]string, error) {
+	return p.proc.EnvironWithContext(ctx)
+}
+
+func (p gopsutilProcess) Connections(ctx context.Context) ([]net.ConnectionStat, error) {
+	return p.proc.ConnectionsWithContext(ctx)
+}
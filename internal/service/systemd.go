@@ -0,0 +1,311 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monitor-server/internal/config"
+	"monitor-server/internal/model"
+	"monitor-server/internal/repository"
+	"monitor-server/pkg/logger"
+)
+
+// systemd 单元状态持久化到 metric_samples 的指标名称
+const (
+	SampleSystemdUnitActive   = "systemd_unit_active"   // 单元处于 active 为1，否则为0，标签: unit
+	SampleSystemdUnitFailed   = "systemd_unit_failed"   // 单元处于 failed 为1，否则为0，标签: unit
+	SampleSystemdUnitRestarts = "systemd_unit_restarts" // systemd 自动重启次数，标签: unit
+	SampleSystemdFailedUnits  = "systemd_failed_units"  // 主机上所有失败的单元数，无标签
+)
+
+// systemdShowProperties are the unit properties read with systemctl show
+const systemdShowProperties = "Id,Description,LoadState,ActiveState,SubState,Result,NRestarts,MainPID,ActiveEnterTimestamp"
+
+// systemdCommandTimeout bounds each systemctl call
+const systemdCommandTimeout = 10 * time.Second
+
+// systemdUnitNamePattern matches valid unit names, which also keeps them from being read as options
+var systemdUnitNamePattern = regexp.MustCompile(`^[A-Za-z0-9:_.\\@][A-Za-z0-9:_.\\@-]*$`)
+
+// NormalizeSystemdUnit validates a unit name and appends .service when it has no unit type,
+// as systemctl does
+func NormalizeSystemdUnit(unit string) (string, error) {
+	unit = strings.TrimSpace(unit)
+	if !systemdUnitNamePattern.MatchString(unit) {
+		return "", fmt.Errorf("invalid unit name: %q", unit)
+	}
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+	return unit, nil
+}
+
+// SystemdMonitor queries systemd on the local host for the units watched on it
+type SystemdMonitor interface {
+	RunOnce(now time.Time) error
+	// Collector returns the collector querying systemd every record interval by default
+	Collector() Collector
+	// Status queries the watched units of the local host and all failed units without recording them
+	Status(ctx context.Context) (*model.SystemdStatus, error)
+}
+
+type systemdMonitor struct {
+	watchRepo  repository.SystemdUnitWatchRepository
+	hostRepo   repository.HostRepository
+	sampleRepo repository.SampleRepository
+	runner     CommandRunner
+	hostname   string
+	interval   time.Duration
+	logger     *logger.Logger
+}
+
+// NewSystemdMonitor creates a new systemd monitor querying at the metrics record interval
+func NewSystemdMonitor(db *gorm.DB, cfg config.MonitorConfig, runner CommandRunner, logger *logger.Logger) SystemdMonitor {
+	interval := time.Duration(cfg.RecordInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &systemdMonitor{
+		watchRepo:  repository.NewSystemdUnitWatchRepository(db),
+		hostRepo:   repository.NewHostRepository(db),
+		sampleRepo: repository.NewSampleRepository(db),
+		runner:     runner,
+		hostname:   cfg.Hostname,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// Collector returns the collector querying the systemd units, run by CollectorScheduler
+func (m *systemdMonitor) Collector() Collector {
+	return roundCollector("systemd_units", m.interval, m.RunOnce)
+}
+
+// RunOnce queries the watched units and the failed units of the local host, records them
+// and stores the latest state on each watch. Hosts without systemctl are skipped.
+func (m *systemdMonitor) RunOnce(now time.Time) error {
+	watches, err := m.localWatches()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), systemdCommandTimeout)
+	defer cancel()
+
+	failed, err := failedSystemdUnits(ctx, m.runner)
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			m.logger.Debug("systemctl not available, systemd units skipped", "hostname", m.hostname)
+			return nil
+		}
+		return err
+	}
+	samples := []model.MetricSample{
+		{Hostname: m.hostname, Metric: SampleSystemdFailedUnits, Value: float64(len(failed)), Timestamp: now},
+	}
+
+	if len(watches) > 0 {
+		units := make([]string, len(watches))
+		for i, watch := range watches {
+			units[i] = watch.Unit
+		}
+		statuses, queryErr := querySystemdUnits(ctx, m.runner, units)
+
+		for i := range watches {
+			watch := &watches[i]
+			watch.LastCheckedAt = &now
+			if queryErr != nil {
+				watch.LastError = queryErr.Error()
+			} else {
+				status := statuses[i]
+				watch.LoadState = status.LoadState
+				watch.ActiveState = status.ActiveState
+				watch.SubState = status.SubState
+				watch.Result = status.Result
+				watch.NRestarts = status.NRestarts
+				watch.MainPID = status.MainPID
+				watch.LastError = ""
+				samples = append(samples, systemdUnitSamples(m.hostname, status, now)...)
+			}
+			if err := m.watchRepo.UpdateResult(watch); err != nil {
+				m.logger.Warn("Failed to update systemd unit watch", "unit", watch.Unit, "error", err)
+			}
+		}
+		if queryErr != nil {
+			m.logger.Warn("Failed to query systemd units", "error", queryErr)
+		}
+	}
+
+	return m.sampleRepo.CreateBatch(samples)
+}
+
+// Status queries the watched units and the failed units of the local host
+func (m *systemdMonitor) Status(ctx context.Context) (*model.SystemdStatus, error) {
+	watches, err := m.localWatches()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, systemdCommandTimeout)
+	defer cancel()
+
+	status := &model.SystemdStatus{
+		Hostname:  m.hostname,
+		Units:     []model.SystemdUnitStatus{},
+		CheckedAt: time.Now(),
+	}
+	if status.FailedUnits, err = failedSystemdUnits(ctx, m.runner); err != nil {
+		return nil, err
+	}
+	if len(watches) > 0 {
+		units := make([]string, len(watches))
+		for i, watch := range watches {
+			units[i] = watch.Unit
+		}
+		if status.Units, err = querySystemdUnits(ctx, m.runner, units); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// localWatches returns the enabled unit watches of the local host, none when it is not registered
+func (m *systemdMonitor) localWatches() ([]model.SystemdUnitWatch, error) {
+	localHost, err := m.hostRepo.GetByHostname(m.hostname)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get local host: %w", err)
+	}
+	watches, err := m.watchRepo.GetEnabledByHost(localHost.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get systemd unit watches: %w", err)
+	}
+	return watches, nil
+}
+
+// querySystemdUnits reads the state of units with systemctl show. The output has one block
+// of properties per unit in argument order, separated by blank lines.
+func querySystemdUnits(ctx context.Context, runner CommandRunner, units []string) ([]model.SystemdUnitStatus, error) {
+	args := append([]string{"show", "--property=" + systemdShowProperties, "--"}, units...)
+	output, err := runner.Run(ctx, "systemctl", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []model.SystemdUnitStatus
+	var props map[string]string
+	flush := func() {
+		if props != nil {
+			statuses = append(statuses, parseSystemdUnitProperties(props))
+			props = nil
+		}
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if props == nil {
+			props = make(map[string]string)
+		}
+		props[key] = value
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(statuses) != len(units) {
+		return nil, fmt.Errorf("systemctl show returned %d units, expected %d", len(statuses), len(units))
+	}
+	for i := range statuses {
+		statuses[i].Unit = units[i]
+	}
+	return statuses, nil
+}
+
+// parseSystemdUnitProperties converts systemctl show properties to a unit status
+func parseSystemdUnitProperties(props map[string]string) model.SystemdUnitStatus {
+	status := model.SystemdUnitStatus{
+		Unit:        props["Id"],
+		Description: props["Description"],
+		LoadState:   props["LoadState"],
+		ActiveState: props["ActiveState"],
+		SubState:    props["SubState"],
+		Result:      props["Result"],
+	}
+	// NRestarts is only reported by systemd 235 and later
+	status.NRestarts, _ = strconv.Atoi(props["NRestarts"])
+	if pid, err := strconv.ParseInt(props["MainPID"], 10, 32); err == nil {
+		status.MainPID = int32(pid)
+	}
+	if since, err := parseSystemdTimestamp(props["ActiveEnterTimestamp"], time.Local); err == nil {
+		status.ActiveSince = &since
+	}
+	return status
+}
+
+// parseSystemdTimestamp parses a timestamp printed by systemctl. systemctl prints the zone
+// abbreviation of the local time zone, which only resolves to the right offset in that zone:
+// time.Parse treats unknown abbreviations such as CEST as UTC.
+func parseSystemdTimestamp(value string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation("Mon 2006-01-02 15:04:05 MST", value, loc)
+}
+
+// failedSystemdUnits lists all units in the failed state
+func failedSystemdUnits(ctx context.Context, runner CommandRunner) ([]string, error) {
+	output, err := runner.Run(ctx, "systemctl", "list-units", "--state=failed", "--no-legend", "--plain", "--no-pager")
+	if err != nil {
+		return nil, err
+	}
+
+	failed := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// some versions mark failed units with a bullet even in plain output
+		if len(fields) > 0 && fields[0] == "●" {
+			fields = fields[1:]
+		}
+		if len(fields) > 0 {
+			failed = append(failed, fields[0])
+		}
+	}
+	return failed, scanner.Err()
+}
+
+// systemdUnitSamples converts a unit status to metric samples of the host
+func systemdUnitSamples(hostname string, status model.SystemdUnitStatus, now time.Time) []model.MetricSample {
+	labels := model.FormatLabels(map[string]string{"unit": status.Unit})
+	active, failed := 0.0, 0.0
+	if status.ActiveState == "active" {
+		active = 1
+	}
+	if status.ActiveState == "failed" {
+		failed = 1
+	}
+	return []model.MetricSample{
+		{Hostname: hostname, Metric: SampleSystemdUnitActive, Labels: labels, Value: active, Timestamp: now},
+		{Hostname: hostname, Metric: SampleSystemdUnitFailed, Labels: labels, Value: failed, Timestamp: now},
+		{Hostname: hostname, Metric: SampleSystemdUnitRestarts, Labels: labels, Value: float64(status.NRestarts), Timestamp: now},
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRunner returns canned output per command line and records the calls
+type fakeRunner struct {
+	outputs map[string]string
+	errs    map[string]error
+	calls   []string
+}
+
+func (r *fakeRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	line := strings.Join(append([]string{name}, args...), " ")
+	r.calls = append(r.calls, line)
+	return []byte(r.outputs[line]), r.errs[line]
+}
+
+func TestQuerySystemdUnits(t *testing.T) {
+	show := "systemctl show --property=" + systemdShowProperties + " -- nginx.service sshd.service missing.service"
+	runner := &fakeRunner{outputs: map[string]string{show: `Id=nginx.service
+Description=A high performance web server
+LoadState=loaded
+ActiveState=failed
+SubState=failed
+Result=exit-code
+NRestarts=3
+MainPID=0
+ActiveEnterTimestamp=
+
+MainPID=812
+Id=ssh.service
+Description=OpenBSD Secure Shell server
+LoadState=loaded
+ActiveState=active
+SubState=running
+Result=success
+NRestarts=0
+ActiveEnterTimestamp=Mon 2024-01-15 10:00:00 UTC
+
+Id=missing.service
+Description=missing.service
+LoadState=not-found
+ActiveState=inactive
+SubState=dead
+Result=success
+MainPID=0
+`}}
+
+	statuses, err := querySystemdUnits(context.Background(), runner, []string{"nginx.service", "sshd.service", "missing.service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 3 {
+		t.Fatalf("expected 3 units, got %d", len(statuses))
+	}
+
+	nginx := statuses[0]
+	if nginx.Unit != "nginx.service" || nginx.ActiveState != "failed" || nginx.Result != "exit-code" || nginx.NRestarts != 3 || nginx.ActiveSince != nil {
+		t.Errorf("unexpected nginx status: %+v", nginx)
+	}
+	// aliases are reported under the requested name
+	sshd := statuses[1]
+	if sshd.Unit != "sshd.service" || sshd.ActiveState != "active" || sshd.SubState != "running" || sshd.MainPID != 812 {
+		t.Errorf("unexpected sshd status: %+v", sshd)
+	}
+	if sshd.ActiveSince == nil || sshd.ActiveSince.Year() != 2024 || sshd.ActiveSince.Hour() != 10 {
+		t.Errorf("unexpected sshd active since: %v", sshd.ActiveSince)
+	}
+	if missing := statuses[2]; missing.LoadState != "not-found" || missing.NRestarts != 0 {
+		t.Errorf("unexpected missing status: %+v", missing)
+	}
+
+	samples := systemdUnitSamples("web-01", nginx, *sshd.ActiveSince)
+	values := map[string]float64{}
+	for _, sample := range samples {
+		if sample.Labels != "unit=nginx.service" {
+			t.Errorf("unexpected labels %q", sample.Labels)
+		}
+		values[sample.Metric] = sample.Value
+	}
+	expected := map[string]float64{SampleSystemdUnitActive: 0, SampleSystemdUnitFailed: 1, SampleSystemdUnitRestarts: 3}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected samples %v, got %v", expected, values)
+	}
+}
+
+func TestQuerySystemdUnitsErrors(t *testing.T) {
+	show := "systemctl show --property=" + systemdShowProperties + " -- a.service b.service"
+	runner := &fakeRunner{outputs: map[string]string{show: "Id=a.service\nActiveState=active\n"}}
+	if _, err := querySystemdUnits(context.Background(), runner, []string{"a.service", "b.service"}); err == nil {
+		t.Error("expected error when fewer units are returned than requested")
+	}
+
+	runner = &fakeRunner{errs: map[string]error{show: errors.New("bus connection failed")}}
+	if _, err := querySystemdUnits(context.Background(), runner, []string{"a.service", "b.service"}); err == nil {
+		t.Error("expected error from systemctl")
+	}
+}
+
+func TestFailedSystemdUnits(t *testing.T) {
+	list := "systemctl list-units --state=failed --no-legend --plain --no-pager"
+	runner := &fakeRunner{outputs: map[string]string{list: `nginx.service      loaded failed failed A high performance web server
+● backup.timer     loaded failed failed Nightly backup
+`}}
+
+	failed, err := failedSystemdUnits(context.Background(), runner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(failed, []string{"nginx.service", "backup.timer"}) {
+		t.Errorf("unexpected failed units: %v", failed)
+	}
+
+	runner = &fakeRunner{outputs: map[string]string{list: ""}}
+	failed, err = failedSystemdUnits(context.Background(), runner)
+	if err != nil || failed == nil || len(failed) != 0 {
+		t.Errorf("expected no failed units, got %v, %v", failed, err)
+	}
+}
+
+func TestNormalizeSystemdUnit(t *testing.T) {
+	tests := []struct {
+		unit     string
+		expected string
+		valid    bool
+	}{
+		{"nginx", "nginx.service", true},
+		{" getty@tty1.service ", "getty@tty1.service", true},
+		{"backup.timer", "backup.timer", true},
+		{"system-systemd\\x2dfsck.slice", "system-systemd\\x2dfsck.slice", true},
+		{"--all", "", false},
+		{"nginx; reboot", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		unit, err := NormalizeSystemdUnit(tt.unit)
+		if (err == nil) != tt.valid || unit != tt.expected {
+			t.Errorf("%q: expected %q (valid %v), got %q, %v", tt.unit, tt.expected, tt.valid, unit, err)
+		}
+	}
+}
+
+func TestParseSystemdTimestamp(t *testing.T) {
+	berlin := time.FixedZone("CEST", 2*60*60)
+	tests := []struct {
+		value string
+		loc   *time.Location
+		want  time.Time
+	}{
+		{"Mon 2024-01-15 10:00:00 UTC", time.UTC, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)},
+		{"Mon 2024-07-15 10:00:00 CEST", berlin, time.Date(2024, 7, 15, 8, 0, 0, 0, time.UTC)},
+		{"Mon 2024-07-15 10:00:00 UTC", berlin, time.Date(2024, 7, 15, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseSystemdTimestamp(tt.value, tt.loc)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.value, tt.want, got.UTC())
+		}
+	}
+	if _, err := parseSystemdTimestamp("", time.UTC); err == nil {
+		t.Error("expected error for an empty timestamp")
+	}
+}